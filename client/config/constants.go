@@ -66,6 +66,9 @@ const (
 	DefaultPieceChanSize              = 16
 	DefaultPieceQueueExponent         = 10
 	DefaultPieceDispatcherRandomRatio = 0.1
+	DefaultPieceRetryMaxAttempts      = 3
+	DefaultPieceRetryInitialBackoff   = 200 * time.Millisecond
+	DefaultPieceRetryMaxBackoff       = 3 * time.Second
 	DefaultObjectMaxReplicas          = 3
 )
 
@@ -73,6 +76,9 @@ const (
 const (
 	SimpleLocalTaskStoreStrategy  = StoreStrategy("io.d7y.storage.v2.simple")
 	AdvanceLocalTaskStoreStrategy = StoreStrategy("io.d7y.storage.v2.advance")
+	// DedupLocalTaskStoreStrategy indexes the completed tasks by content digest,
+	// and the tasks with the same content share the data by hard links.
+	DedupLocalTaskStoreStrategy = StoreStrategy("io.d7y.storage.v2.dedup")
 )
 
 // Dfcache subcommand names.
@@ -181,6 +181,18 @@ func (p *DaemonOption) Validate() error {
 		return fmt.Errorf("rate limit must be greater than %s", DefaultMinRate.String())
 	}
 
+	for _, schedules := range [][]BandwidthScheduleOption{p.Download.BandwidthSchedules, p.Upload.BandwidthSchedules} {
+		for _, schedule := range schedules {
+			if int64(schedule.RateLimit.Limit) < DefaultMinRate.ToNumber() {
+				return fmt.Errorf("bandwidth schedule rate limit must be greater than %s", DefaultMinRate.String())
+			}
+		}
+	}
+
+	if p.Download.PieceRetry.MaxAttempts > 0 && p.Download.PieceRetry.InitialBackoff > p.Download.PieceRetry.MaxBackoff {
+		return errors.New("piece retry initialBackoff must be less than or equal to maxBackoff")
+	}
+
 	if p.ObjectStorage.Enable {
 		if p.ObjectStorage.MaxReplicas <= 0 {
 			return errors.New("max replicas must be greater than 0")
@@ -309,6 +321,14 @@ type DownloadOption struct {
 	Concurrent           *ConcurrentOption `mapstructure:"concurrent" yaml:"concurrent"`
 	SyncPieceViaHTTPS    bool              `mapstructure:"syncPieceViaHTTPS" yaml:"syncPieceViaHTTPS"`
 	SplitRunningTasks    bool              `mapstructure:"splitRunningTasks" yaml:"splitRunningTasks"`
+
+	// PriorityTrafficShaper is the option of priority traffic shaper, used when trafficShaperType is priority
+	PriorityTrafficShaper PriorityTrafficShaperOption `mapstructure:"priorityTrafficShaper" yaml:"priorityTrafficShaper"`
+	// BandwidthSchedules overrides totalRateLimit in the periods of the day
+	BandwidthSchedules []BandwidthScheduleOption `mapstructure:"bandwidthSchedules" yaml:"bandwidthSchedules"`
+	// PieceRetry is the option of retrying failed pieces from other parents
+	PieceRetry PieceRetryOption `mapstructure:"pieceRetry" yaml:"pieceRetry"`
+
 	// resource clients option
 	ResourceClients ResourceClientsOption `mapstructure:"resourceClients" yaml:"resourceClients"`
 
@@ -316,6 +336,36 @@ type DownloadOption struct {
 	CacheRecursiveMetadata time.Duration       `mapstructure:"cacheRecursiveMetadata" yaml:"cacheRecursiveMetadata"`
 }
 
+// PriorityTrafficShaperOption is the option of priority traffic shaper. The total rate limit
+// is shared by the classes of tasks grouped by priority and application, the bandwidth
+// guaranteed for a class is in proportion to its weight, and the bandwidth not used by a
+// class is borrowed by the others. Every task is guaranteed the bandwidth of one piece and
+// every class is guaranteed minClassRateLimit before the rest is allocated by weights, they
+// are reduced in proportion when totalRateLimit is not enough, so the limits of tasks never
+// exceed totalRateLimit.
+type PriorityTrafficShaperOption struct {
+	// PriorityWeights is the weights of priorities, the key is the name of priority, like LEVEL6,
+	// the weight of priority not configured is the level plus one, like 7 for LEVEL6, and LEVEL0
+	// is the default priority scheduled as high as LEVEL6, so its weight is 7 too
+	PriorityWeights map[string]int `mapstructure:"priorityWeights" yaml:"priorityWeights"`
+	// ApplicationWeights is the weights of applications, which override the weights of priorities
+	ApplicationWeights map[string]int `mapstructure:"applicationWeights" yaml:"applicationWeights"`
+	// MinClassRateLimit is the least bandwidth guaranteed for every class of tasks
+	MinClassRateLimit util.RateLimit `mapstructure:"minClassRateLimit" yaml:"minClassRateLimit"`
+}
+
+// PieceRetryOption is the option of retrying failed pieces. A failed piece is re-queued
+// with backoff and acquired from the parents which have not failed it yet, when the
+// attempts are exhausted, the piece is acquired from all parents.
+type PieceRetryOption struct {
+	// MaxAttempts is the max retry attempts for every failed piece, 0 disables the retry queue
+	MaxAttempts int `mapstructure:"maxAttempts" yaml:"maxAttempts"`
+	// InitialBackoff is the backoff before the first retry, it doubles for every next retry
+	InitialBackoff time.Duration `mapstructure:"initialBackoff" yaml:"initialBackoff"`
+	// MaxBackoff is the max backoff between retries
+	MaxBackoff time.Duration `mapstructure:"maxBackoff" yaml:"maxBackoff"`
+}
+
 type ResourceClientsOption map[string]interface{}
 
 type TransportOption struct {
@@ -476,6 +526,24 @@ func (p *ProxyOption) unmarshal(unmarshal func(in []byte, out any) (err error),
 type UploadOption struct {
 	ListenOption `yaml:",inline" mapstructure:",squash"`
 	RateLimit    util.RateLimit `mapstructure:"rateLimit" yaml:"rateLimit"`
+
+	// BandwidthSchedules overrides rateLimit in the periods of the day
+	BandwidthSchedules []BandwidthScheduleOption `mapstructure:"bandwidthSchedules" yaml:"bandwidthSchedules"`
+}
+
+// BandwidthScheduleOption is the rate limit applied in a period of the day, like lower
+// upload rate limit during business hours. The first schedule containing the time is applied.
+type BandwidthScheduleOption struct {
+	// Start is the start time of the period in local time zone, the format is 15:04
+	Start string `mapstructure:"start" yaml:"start"`
+	// End is the end time of the period in local time zone, the format is 15:04,
+	// the period crosses midnight when end is before start
+	End string `mapstructure:"end" yaml:"end"`
+	// Weekdays is the days of the week applying the schedule, like Mon and Sat, empty means every day.
+	// The period crossing midnight belongs to the day it starts
+	Weekdays []string `mapstructure:"weekdays" yaml:"weekdays"`
+	// RateLimit is the rate limit in the period
+	RateLimit util.RateLimit `mapstructure:"rateLimit" yaml:"rateLimit"`
 }
 
 type ObjectStorageOption struct {
@@ -936,4 +1004,9 @@ type NetworkOption struct {
 type AnnouncerOption struct {
 	// SchedulerInterval is the interval of announcing scheduler.
 	SchedulerInterval time.Duration `mapstructure:"schedulerInterval" yaml:"schedulerInterval"`
+
+	// UploadBandwidth is the capacity of bytes sent by the network interface per second,
+	// which is announced to scheduler for selecting parents. It is detected by the speed
+	// of network interface if it is zero.
+	UploadBandwidth unit.Bytes `mapstructure:"uploadBandwidth" yaml:"uploadBandwidth"`
 }
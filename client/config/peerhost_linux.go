@@ -89,6 +89,11 @@ var peerHostConfig = func() *DaemonOption {
 				},
 			},
 			SplitRunningTasks: false,
+			PieceRetry: PieceRetryOption{
+				MaxAttempts:    DefaultPieceRetryMaxAttempts,
+				InitialBackoff: DefaultPieceRetryInitialBackoff,
+				MaxBackoff:     DefaultPieceRetryMaxBackoff,
+			},
 		},
 		Upload: UploadOption{
 			RateLimit: util.RateLimit{
@@ -298,6 +298,22 @@ func TestPeerHostOption_Load(t *testing.T) {
 			PerPeerRateLimit: util.RateLimit{
 				Limit: 512 * 1024 * 1024,
 			},
+			PriorityTrafficShaper: PriorityTrafficShaperOption{
+				PriorityWeights: map[string]int{
+					"LEVEL6": 10,
+				},
+				ApplicationWeights: map[string]int{
+					"image": 20,
+				},
+				MinClassRateLimit: util.RateLimit{
+					Limit: 10 * 1024 * 1024,
+				},
+			},
+			PieceRetry: PieceRetryOption{
+				MaxAttempts:    3,
+				InitialBackoff: 100 * time.Millisecond,
+				MaxBackoff:     2 * time.Second,
+			},
 			PieceDownloadTimeout: 30 * time.Second,
 			DownloadGRPC: ListenOption{
 				Security: SecurityOption{
@@ -358,6 +374,16 @@ func TestPeerHostOption_Load(t *testing.T) {
 			RateLimit: util.RateLimit{
 				Limit: 1024 * 1024 * 1024,
 			},
+			BandwidthSchedules: []BandwidthScheduleOption{
+				{
+					Start:    "09:00",
+					End:      "18:00",
+					Weekdays: []string{"Mon", "Tue", "Wed", "Thu", "Fri"},
+					RateLimit: util.RateLimit{
+						Limit: 100 * 1024 * 1024,
+					},
+				},
+			},
 			ListenOption: ListenOption{
 				Security: SecurityOption{
 					Insecure:  true,
@@ -512,6 +538,7 @@ func TestPeerHostOption_Load(t *testing.T) {
 		},
 		Announcer: AnnouncerOption{
 			SchedulerInterval: 1000000000,
+			UploadBandwidth:   unit.GB,
 		},
 	}
 
@@ -624,6 +651,47 @@ func TestPeerHostOption_Validate(t *testing.T) {
 				assert.EqualError(err, msg)
 			},
 		},
+		{
+			name:   "bandwidth schedule rate limit must be greater",
+			config: NewDaemonConfig(),
+			mock: func(cfg *DaemonConfig) {
+				cfg.Scheduler.NetAddrs = []dfnet.NetAddr{
+					{
+						Type: dfnet.TCP,
+						Addr: "127.0.0.1:8002",
+					},
+				}
+				cfg.Upload.BandwidthSchedules = []BandwidthScheduleOption{
+					{
+						Start:     "09:00",
+						End:       "18:00",
+						RateLimit: util.RateLimit{Limit: rate.Limit(10 * unit.MB)},
+					},
+				}
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				msg := fmt.Sprintf("bandwidth schedule rate limit must be greater than %s", DefaultMinRate.String())
+				assert.EqualError(err, msg)
+			},
+		},
+		{
+			name:   "piece retry initial backoff must be less than max backoff",
+			config: NewDaemonConfig(),
+			mock: func(cfg *DaemonConfig) {
+				cfg.Scheduler.NetAddrs = []dfnet.NetAddr{
+					{
+						Type: dfnet.TCP,
+						Addr: "127.0.0.1:8002",
+					},
+				}
+				cfg.Download.PieceRetry.InitialBackoff = 10 * time.Second
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "piece retry initialBackoff must be less than or equal to maxBackoff")
+			},
+		},
 		{
 			name:   "max replicas must be greater than 0",
 			config: NewDaemonConfig(),
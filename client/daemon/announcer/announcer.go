@@ -35,6 +35,7 @@ import (
 
 	"d7y.io/dragonfly/v2/client/config"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/rpc/common"
 	managerclient "d7y.io/dragonfly/v2/pkg/rpc/manager/client"
 	schedulerclient "d7y.io/dragonfly/v2/pkg/rpc/scheduler/client"
 	"d7y.io/dragonfly/v2/pkg/types"
@@ -58,6 +59,7 @@ type announcer struct {
 	daemonDownloadPort int32
 	schedulerClient    schedulerclient.V1
 	managerClient      managerclient.V1
+	bandwidthSampler   *bandwidthSampler
 	done               chan struct{}
 }
 
@@ -114,12 +116,14 @@ func (a *announcer) Stop() error {
 
 // announceToScheduler announces peer information to scheduler.
 func (a *announcer) announceToScheduler() error {
+	a.bandwidthSampler = newBandwidthSampler(a.config.Host.AdvertiseIP, uint64(a.config.Announcer.UploadBandwidth))
+
 	req, err := a.newAnnounceHostRequest()
 	if err != nil {
 		return err
 	}
 
-	if err := a.schedulerClient.AnnounceHost(context.Background(), req); err != nil {
+	if err := a.schedulerClient.AnnounceHost(a.newAnnounceHostContext(), req); err != nil {
 		logger.Errorf("announce for the first time failed: %s", err.Error())
 	}
 
@@ -134,7 +138,7 @@ func (a *announcer) announceToScheduler() error {
 				break
 			}
 
-			if err := a.schedulerClient.AnnounceHost(context.Background(), req); err != nil {
+			if err := a.schedulerClient.AnnounceHost(a.newAnnounceHostContext(), req); err != nil {
 				logger.Error(err)
 				break
 			}
@@ -144,6 +148,12 @@ func (a *announcer) announceToScheduler() error {
 	}
 }
 
+// newAnnounceHostContext returns the context of announcing host, the upload bandwidth
+// of host is announced in grpc metadata, because the network message has no field of it.
+func (a *announcer) newAnnounceHostContext() context.Context {
+	return common.AppendNetworkBandwidthToOutgoingContext(context.Background(), a.bandwidthSampler.Sample())
+}
+
 // newAnnounceHostRequest returns announce host request.
 func (a *announcer) newAnnounceHostRequest() (*schedulerv1.AnnounceHostRequest, error) {
 	hostType := types.HostTypeNormalName
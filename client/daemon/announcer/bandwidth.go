@@ -0,0 +1,155 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package announcer
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	gopsutilnet "github.com/shirou/gopsutil/v3/net"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/rpc/common"
+)
+
+// loopbackInterfaceName is the name of loopback network interface.
+const loopbackInterfaceName = "lo"
+
+// sysClassNetPath is the path of network interfaces in sysfs, the speed of
+// interface is in /sys/class/net/<interface>/speed with Mbit/s.
+var sysClassNetPath = "/sys/class/net"
+
+// bandwidthSampler samples the bytes sent by the network interface of host,
+// and calculates the upload rate between samples.
+type bandwidthSampler struct {
+	// ip is the ip of host, which is used to find the network interface.
+	ip net.IP
+
+	// uploadBandwidth is the configured capacity of bytes sent per second.
+	uploadBandwidth uint64
+
+	// bytesSent is the bytes sent by the network interface in the last sample.
+	bytesSent uint64
+
+	// sampledAt is the time of the last sample.
+	sampledAt time.Time
+
+	// ioCounters returns the io counters of network interfaces.
+	ioCounters func(pernic bool) ([]gopsutilnet.IOCountersStat, error)
+
+	// interfaceName returns the name of network interface with the ip.
+	interfaceName func(ip net.IP) (string, error)
+}
+
+// newBandwidthSampler returns a new bandwidthSampler.
+func newBandwidthSampler(ip net.IP, uploadBandwidth uint64) *bandwidthSampler {
+	return &bandwidthSampler{
+		ip:              ip,
+		uploadBandwidth: uploadBandwidth,
+		ioCounters:      gopsutilnet.IOCounters,
+		interfaceName:   interfaceNameByIP,
+	}
+}
+
+// Sample returns the upload rate since the last sample and the upload bandwidth of host.
+// The upload rate of the first sample is zero, and the upload bandwidth is zero if it
+// is not configured and can not be detected.
+func (s *bandwidthSampler) Sample() common.NetworkBandwidth {
+	name, err := s.interfaceName(s.ip)
+	if err != nil {
+		logger.Debugf("find network interface of %s failed: %s", s.ip, err.Error())
+	}
+
+	bandwidth := common.NetworkBandwidth{
+		UploadBandwidth: s.uploadBandwidth,
+	}
+
+	if bandwidth.UploadBandwidth == 0 && name != "" {
+		bandwidth.UploadBandwidth = interfaceBandwidth(name)
+	}
+
+	counters, err := s.ioCounters(true)
+	if err != nil {
+		logger.Errorf("get io counters of network interfaces failed: %s", err.Error())
+		return bandwidth
+	}
+
+	// If the network interface of host is unknown, the bytes sent by
+	// all of the interfaces except loopback are counted.
+	var bytesSent uint64
+	for _, counter := range counters {
+		if (name != "" && counter.Name == name) || (name == "" && counter.Name != loopbackInterfaceName) {
+			bytesSent += counter.BytesSent
+		}
+	}
+
+	now := time.Now()
+	if !s.sampledAt.IsZero() && bytesSent >= s.bytesSent {
+		if elapsed := now.Sub(s.sampledAt).Seconds(); elapsed > 0 {
+			bandwidth.UploadRate = uint64(float64(bytesSent-s.bytesSent) / elapsed)
+		}
+	}
+
+	s.bytesSent = bytesSent
+	s.sampledAt = now
+	return bandwidth
+}
+
+// interfaceNameByIP returns the name of network interface with the ip.
+func interfaceNameByIP(ip net.IP) (string, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return "", err
+	}
+
+	for _, iface := range interfaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+				return iface.Name, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("network interface of %s not found", ip)
+}
+
+// interfaceBandwidth returns the capacity of bytes sent by the network interface
+// per second, it is zero if the speed of interface is unknown.
+func interfaceBandwidth(name string) uint64 {
+	b, err := os.ReadFile(fmt.Sprintf("%s/%s/speed", sysClassNetPath, name))
+	if err != nil {
+		return 0
+	}
+
+	// The speed of virtual interface is -1.
+	speed, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil || speed <= 0 {
+		return 0
+	}
+
+	// Convert Mbit/s to bytes per second.
+	return uint64(speed) * 1000 * 1000 / 8
+}
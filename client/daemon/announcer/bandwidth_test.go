@@ -0,0 +1,129 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package announcer
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gopsutilnet "github.com/shirou/gopsutil/v3/net"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBandwidthSampler_Sample(t *testing.T) {
+	tests := []struct {
+		name            string
+		uploadBandwidth uint64
+		interfaceName   func(ip net.IP) (string, error)
+		bytesSent       []uint64
+		expect          func(t *testing.T, s *bandwidthSampler)
+	}{
+		{
+			name:            "sample with configured bandwidth",
+			uploadBandwidth: 1000,
+			interfaceName: func(ip net.IP) (string, error) {
+				return "eth0", nil
+			},
+			bytesSent: []uint64{100, 200},
+			expect: func(t *testing.T, s *bandwidthSampler) {
+				assert := assert.New(t)
+				bandwidth := s.Sample()
+				assert.Equal(bandwidth.UploadRate, uint64(0))
+				assert.Equal(bandwidth.UploadBandwidth, uint64(1000))
+
+				s.sampledAt = s.sampledAt.Add(-time.Second)
+				bandwidth = s.Sample()
+				assert.Equal(bandwidth.UploadBandwidth, uint64(1000))
+				assert.Greater(bandwidth.UploadRate, uint64(0))
+				assert.LessOrEqual(bandwidth.UploadRate, uint64(100))
+			},
+		},
+		{
+			name: "sample with counters reset",
+			interfaceName: func(ip net.IP) (string, error) {
+				return "", errors.New("foo")
+			},
+			bytesSent: []uint64{200, 100},
+			expect: func(t *testing.T, s *bandwidthSampler) {
+				assert := assert.New(t)
+				s.Sample()
+				s.sampledAt = s.sampledAt.Add(-time.Second)
+				bandwidth := s.Sample()
+				assert.Equal(bandwidth.UploadRate, uint64(0))
+				assert.Equal(bandwidth.UploadBandwidth, uint64(0))
+				assert.Equal(s.bytesSent, uint64(200))
+			},
+		},
+		{
+			name: "sample without interface counts all except loopback",
+			interfaceName: func(ip net.IP) (string, error) {
+				return "", errors.New("foo")
+			},
+			bytesSent: []uint64{100},
+			expect: func(t *testing.T, s *bandwidthSampler) {
+				assert := assert.New(t)
+				s.Sample()
+				assert.Equal(s.bytesSent, uint64(200))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var i int
+			s := newBandwidthSampler(net.ParseIP("127.0.0.1"), tc.uploadBandwidth)
+			s.interfaceName = tc.interfaceName
+			s.ioCounters = func(pernic bool) ([]gopsutilnet.IOCountersStat, error) {
+				bytesSent := tc.bytesSent[i]
+				i++
+				return []gopsutilnet.IOCountersStat{
+					{Name: "eth0", BytesSent: bytesSent},
+					{Name: "eth1", BytesSent: bytesSent},
+					{Name: loopbackInterfaceName, BytesSent: bytesSent},
+				}, nil
+			}
+
+			tc.expect(t, s)
+		})
+	}
+}
+
+func TestInterfaceBandwidth(t *testing.T) {
+	dir := t.TempDir()
+	oldSysClassNetPath := sysClassNetPath
+	sysClassNetPath = dir
+	defer func() { sysClassNetPath = oldSysClassNetPath }()
+
+	for name, speed := range map[string]string{"eth0": "1000\n", "veth0": "-1\n"} {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, name, "speed"), []byte(speed), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert := assert.New(t)
+	assert.Equal(interfaceBandwidth("eth0"), uint64(125000000))
+	assert.Equal(interfaceBandwidth("veth0"), uint64(0))
+	assert.Equal(interfaceBandwidth("foo"), uint64(0))
+}
@@ -0,0 +1,349 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bandwidth
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"d7y.io/dragonfly/v2/client/config"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+)
+
+const (
+	// defaultInterval is the default interval of applying bandwidth schedules.
+	defaultInterval = 30 * time.Second
+
+	// clockLayout is the layout of start and end time of bandwidth schedule.
+	clockLayout = "15:04"
+)
+
+// Limiter is the rate limiter adjusted by bandwidth schedules.
+type Limiter interface {
+	// SetLimit sets the rate limit.
+	SetLimit(limit rate.Limit)
+}
+
+// LimiterFunc is an adapter to allow the use of ordinary function as Limiter.
+type LimiterFunc func(limit rate.Limit)
+
+// SetLimit calls f(limit).
+func (f LimiterFunc) SetLimit(limit rate.Limit) {
+	f(limit)
+}
+
+// Manager applies the bandwidth schedules of download and upload to the limiters.
+type Manager interface {
+	// Start starts applying the bandwidth schedules.
+	Start()
+
+	// Stop stops applying the bandwidth schedules.
+	Stop()
+
+	// OnNotify reloads the rate limits and bandwidth schedules when the config of daemon changed.
+	OnNotify(cfg *config.DaemonOption)
+}
+
+// manager implements Manager.
+type manager struct {
+	// mu protects budgets.
+	mu sync.Mutex
+
+	// download is the bandwidth budget of download.
+	download *budget
+
+	// upload is the bandwidth budget of upload.
+	upload *budget
+
+	// interval is the interval of applying bandwidth schedules.
+	interval time.Duration
+
+	// now returns the current time.
+	now func() time.Time
+
+	// done is the channel of stopping manager.
+	done chan struct{}
+}
+
+// budget is the rate limit of download or upload applied by bandwidth schedules.
+type budget struct {
+	// name is the name of budget.
+	name string
+
+	// defaultLimit is the rate limit when no schedule contains the time.
+	defaultLimit rate.Limit
+
+	// schedules is the bandwidth schedules of budget.
+	schedules []*schedule
+
+	// limit is the rate limit applied to limiters, zero means not applied.
+	limit rate.Limit
+
+	// limiters is the rate limiters of budget.
+	limiters []Limiter
+}
+
+// schedule is the rate limit applied in a period of the day.
+type schedule struct {
+	// start is the offset of start time from midnight.
+	start time.Duration
+
+	// end is the offset of end time from midnight.
+	end time.Duration
+
+	// weekdays is the days of the week applying the schedule, empty means every day.
+	weekdays map[time.Weekday]struct{}
+
+	// limit is the rate limit in the period.
+	limit rate.Limit
+}
+
+// Option is a functional option for configuring the manager.
+type Option func(m *manager)
+
+// WithDownloadLimiters sets the limiters of download.
+func WithDownloadLimiters(limiters ...Limiter) Option {
+	return func(m *manager) {
+		m.download.limiters = append(m.download.limiters, limiters...)
+	}
+}
+
+// WithUploadLimiters sets the limiters of upload.
+func WithUploadLimiters(limiters ...Limiter) Option {
+	return func(m *manager) {
+		m.upload.limiters = append(m.upload.limiters, limiters...)
+	}
+}
+
+// WithInterval sets the interval of applying bandwidth schedules.
+func WithInterval(interval time.Duration) Option {
+	return func(m *manager) {
+		m.interval = interval
+	}
+}
+
+// New returns a new bandwidth schedule manager.
+func New(cfg *config.DaemonOption, options ...Option) (Manager, error) {
+	m := &manager{
+		download: &budget{name: "download"},
+		upload:   &budget{name: "upload"},
+		interval: defaultInterval,
+		now:      time.Now,
+		done:     make(chan struct{}),
+	}
+
+	for _, opt := range options {
+		opt(m)
+	}
+
+	if err := m.load(cfg); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Start starts applying the bandwidth schedules.
+func (m *manager) Start() {
+	m.apply()
+	go func() {
+		tick := time.NewTicker(m.interval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-tick.C:
+				m.apply()
+			case <-m.done:
+				logger.Infof("bandwidth manager exited")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops applying the bandwidth schedules.
+func (m *manager) Stop() {
+	close(m.done)
+}
+
+// OnNotify reloads the rate limits and bandwidth schedules when the config of daemon changed,
+// the invalid bandwidth schedules are ignored and the previous ones are kept.
+func (m *manager) OnNotify(cfg *config.DaemonOption) {
+	if err := m.load(cfg); err != nil {
+		logger.Errorf("reload bandwidth schedules failed: %s", err.Error())
+		return
+	}
+
+	m.apply()
+}
+
+// load loads the rate limits and bandwidth schedules from the config of daemon.
+func (m *manager) load(cfg *config.DaemonOption) error {
+	downloadSchedules, err := parseSchedules(cfg.Download.BandwidthSchedules)
+	if err != nil {
+		return fmt.Errorf("invalid download bandwidth schedules: %w", err)
+	}
+
+	uploadSchedules, err := parseSchedules(cfg.Upload.BandwidthSchedules)
+	if err != nil {
+		return fmt.Errorf("invalid upload bandwidth schedules: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.download.defaultLimit = cfg.Download.TotalRateLimit.Limit
+	m.download.schedules = downloadSchedules
+	m.upload.defaultLimit = cfg.Upload.RateLimit.Limit
+	m.upload.schedules = uploadSchedules
+	return nil
+}
+
+// apply applies the rate limits of the current time to the limiters.
+func (m *manager) apply() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for _, b := range []*budget{m.download, m.upload} {
+		limit := b.limitAt(now)
+		if limit == b.limit {
+			continue
+		}
+
+		for _, limiter := range b.limiters {
+			limiter.SetLimit(limit)
+		}
+
+		logger.Infof("apply %s rate limit %s", b.name, formatLimit(limit))
+		b.limit = limit
+	}
+}
+
+// limitAt returns the rate limit of the first schedule containing the time,
+// or the default rate limit if no schedule contains it.
+func (b *budget) limitAt(t time.Time) rate.Limit {
+	for _, s := range b.schedules {
+		if s.contains(t) {
+			return s.limit
+		}
+	}
+
+	return b.defaultLimit
+}
+
+// contains returns whether the period of the schedule contains the time.
+func (s *schedule) contains(t time.Time) bool {
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if s.start < s.end {
+		return offset >= s.start && offset < s.end && s.containsWeekday(t.Weekday())
+	}
+
+	// The period crosses midnight, the time after midnight belongs to the previous day.
+	if offset >= s.start {
+		return s.containsWeekday(t.Weekday())
+	}
+
+	if offset < s.end {
+		return s.containsWeekday(t.AddDate(0, 0, -1).Weekday())
+	}
+
+	return false
+}
+
+// containsWeekday returns whether the schedule applies on the weekday.
+func (s *schedule) containsWeekday(weekday time.Weekday) bool {
+	if len(s.weekdays) == 0 {
+		return true
+	}
+
+	_, ok := s.weekdays[weekday]
+	return ok
+}
+
+// parseSchedules parses the bandwidth schedules of config.
+func parseSchedules(options []config.BandwidthScheduleOption) ([]*schedule, error) {
+	var schedules []*schedule
+	for _, option := range options {
+		start, err := parseClock(option.Start)
+		if err != nil {
+			return nil, err
+		}
+
+		end, err := parseClock(option.End)
+		if err != nil {
+			return nil, err
+		}
+
+		if start == end {
+			return nil, fmt.Errorf("start %s is equal to end %s", option.Start, option.End)
+		}
+
+		weekdays := make(map[time.Weekday]struct{}, len(option.Weekdays))
+		for _, name := range option.Weekdays {
+			weekday, err := parseWeekday(name)
+			if err != nil {
+				return nil, err
+			}
+
+			weekdays[weekday] = struct{}{}
+		}
+
+		schedules = append(schedules, &schedule{
+			start:    start,
+			end:      end,
+			weekdays: weekdays,
+			limit:    option.RateLimit.Limit,
+		})
+	}
+
+	return schedules, nil
+}
+
+// parseClock parses the time of the day as the offset from midnight.
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse(clockLayout, s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, the format is %s", s, clockLayout)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// parseWeekday parses the weekday by its full or abbreviated name, like Monday or Mon.
+func parseWeekday(s string) (time.Weekday, error) {
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		name := weekday.String()
+		if strings.EqualFold(s, name) || strings.EqualFold(s, name[:3]) {
+			return weekday, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid weekday %q", s)
+}
+
+// formatLimit formats the rate limit for logging.
+func formatLimit(limit rate.Limit) string {
+	if limit == rate.Inf {
+		return "unlimited"
+	}
+
+	return fmt.Sprintf("%.0f bytes/s", float64(limit))
+}
@@ -0,0 +1,211 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bandwidth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/util"
+)
+
+func newConfig(downloadSchedules, uploadSchedules []config.BandwidthScheduleOption) *config.DaemonOption {
+	cfg := &config.DaemonOption{}
+	cfg.Download.TotalRateLimit = util.RateLimit{Limit: 1000}
+	cfg.Download.BandwidthSchedules = downloadSchedules
+	cfg.Upload.RateLimit = util.RateLimit{Limit: 500}
+	cfg.Upload.BandwidthSchedules = uploadSchedules
+	return cfg
+}
+
+func TestSchedule_contains(t *testing.T) {
+	// 2023-06-05 is Monday.
+	monday := func(hour, min int) time.Time {
+		return time.Date(2023, 6, 5, hour, min, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name   string
+		option config.BandwidthScheduleOption
+		time   time.Time
+		expect bool
+	}{
+		{
+			name:   "time in period",
+			option: config.BandwidthScheduleOption{Start: "09:00", End: "18:00"},
+			time:   monday(9, 0),
+			expect: true,
+		},
+		{
+			name:   "time at the end of period",
+			option: config.BandwidthScheduleOption{Start: "09:00", End: "18:00"},
+			time:   monday(18, 0),
+			expect: false,
+		},
+		{
+			name:   "weekday not applied",
+			option: config.BandwidthScheduleOption{Start: "09:00", End: "18:00", Weekdays: []string{"Sat", "sunday"}},
+			time:   monday(10, 0),
+			expect: false,
+		},
+		{
+			name:   "period crosses midnight before midnight",
+			option: config.BandwidthScheduleOption{Start: "22:00", End: "06:00", Weekdays: []string{"Mon"}},
+			time:   monday(23, 0),
+			expect: true,
+		},
+		{
+			name:   "period crosses midnight belongs to the previous day",
+			option: config.BandwidthScheduleOption{Start: "22:00", End: "06:00", Weekdays: []string{"Sun"}},
+			time:   monday(5, 0),
+			expect: true,
+		},
+		{
+			name:   "time out of period crossing midnight",
+			option: config.BandwidthScheduleOption{Start: "22:00", End: "06:00"},
+			time:   monday(12, 0),
+			expect: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			schedules, err := parseSchedules([]config.BandwidthScheduleOption{tc.option})
+			assert.NoError(err)
+			assert.Equal(schedules[0].contains(tc.time), tc.expect)
+		})
+	}
+}
+
+func TestParseSchedules(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []config.BandwidthScheduleOption
+		expect  func(t *testing.T, schedules []*schedule, err error)
+	}{
+		{
+			name:    "empty schedules",
+			options: nil,
+			expect: func(t *testing.T, schedules []*schedule, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(len(schedules), 0)
+			},
+		},
+		{
+			name: "parse schedules",
+			options: []config.BandwidthScheduleOption{
+				{Start: "09:30", End: "18:00", Weekdays: []string{"Mon", "friday"}, RateLimit: util.RateLimit{Limit: 100}},
+			},
+			expect: func(t *testing.T, schedules []*schedule, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(len(schedules), 1)
+				assert.Equal(schedules[0].start, 9*time.Hour+30*time.Minute)
+				assert.Equal(schedules[0].end, 18*time.Hour)
+				assert.Equal(len(schedules[0].weekdays), 2)
+				assert.Equal(schedules[0].limit, rate.Limit(100))
+			},
+		},
+		{
+			name:    "invalid start",
+			options: []config.BandwidthScheduleOption{{Start: "9", End: "18:00"}},
+			expect: func(t *testing.T, schedules []*schedule, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "invalid time \"9\", the format is 15:04")
+			},
+		},
+		{
+			name:    "start is equal to end",
+			options: []config.BandwidthScheduleOption{{Start: "09:00", End: "09:00"}},
+			expect: func(t *testing.T, schedules []*schedule, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "start 09:00 is equal to end 09:00")
+			},
+		},
+		{
+			name:    "invalid weekday",
+			options: []config.BandwidthScheduleOption{{Start: "09:00", End: "18:00", Weekdays: []string{"foo"}}},
+			expect: func(t *testing.T, schedules []*schedule, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "invalid weekday \"foo\"")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			schedules, err := parseSchedules(tc.options)
+			tc.expect(t, schedules, err)
+		})
+	}
+}
+
+func TestManager_apply(t *testing.T) {
+	assert := assert.New(t)
+	var (
+		downloadLimiter = rate.NewLimiter(rate.Inf, 0)
+		uploadLimiter   = rate.NewLimiter(rate.Inf, 0)
+		shaperLimit     rate.Limit
+	)
+
+	_, err := New(newConfig([]config.BandwidthScheduleOption{{Start: "09:00", End: "25:00"}}, nil))
+	assert.Error(err)
+
+	m, err := New(newConfig(nil, []config.BandwidthScheduleOption{
+		{Start: "09:00", End: "18:00", RateLimit: util.RateLimit{Limit: 100}},
+	}), WithDownloadLimiters(downloadLimiter, LimiterFunc(func(limit rate.Limit) {
+		shaperLimit = limit
+	})), WithUploadLimiters(uploadLimiter))
+	assert.NoError(err)
+
+	instance := m.(*manager)
+	instance.now = func() time.Time {
+		return time.Date(2023, 6, 5, 10, 0, 0, 0, time.Local)
+	}
+
+	// the upload schedule is applied in the period.
+	instance.apply()
+	assert.Equal(downloadLimiter.Limit(), rate.Limit(1000))
+	assert.Equal(shaperLimit, rate.Limit(1000))
+	assert.Equal(uploadLimiter.Limit(), rate.Limit(100))
+
+	// the upload rate limit is restored out of the period.
+	instance.now = func() time.Time {
+		return time.Date(2023, 6, 5, 19, 0, 0, 0, time.Local)
+	}
+	instance.apply()
+	assert.Equal(uploadLimiter.Limit(), rate.Limit(500))
+
+	// the download schedule is reloaded.
+	m.OnNotify(newConfig([]config.BandwidthScheduleOption{
+		{Start: "18:00", End: "09:00", RateLimit: util.RateLimit{Limit: 2000}},
+	}, nil))
+	assert.Equal(downloadLimiter.Limit(), rate.Limit(2000))
+	assert.Equal(shaperLimit, rate.Limit(2000))
+	assert.Equal(uploadLimiter.Limit(), rate.Limit(500))
+
+	// the invalid schedules are ignored and the previous ones are kept.
+	m.OnNotify(newConfig([]config.BandwidthScheduleOption{{Start: "foo", End: "09:00"}}, nil))
+	assert.Equal(downloadLimiter.Limit(), rate.Limit(2000))
+	assert.Equal(len(instance.download.schedules), 1)
+}
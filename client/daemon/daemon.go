@@ -45,6 +45,7 @@ import (
 
 	"d7y.io/dragonfly/v2/client/config"
 	"d7y.io/dragonfly/v2/client/daemon/announcer"
+	"d7y.io/dragonfly/v2/client/daemon/bandwidth"
 	"d7y.io/dragonfly/v2/client/daemon/gc"
 	"d7y.io/dragonfly/v2/client/daemon/metrics"
 	"d7y.io/dragonfly/v2/client/daemon/objectstorage"
@@ -90,12 +91,13 @@ type clientDaemon struct {
 
 	Option config.DaemonOption
 
-	RPCManager     rpcserver.Server
-	UploadManager  upload.Manager
-	ObjectStorage  objectstorage.ObjectStorage
-	ProxyManager   proxy.Manager
-	StorageManager storage.Manager
-	GCManager      gc.Manager
+	RPCManager       rpcserver.Server
+	UploadManager    upload.Manager
+	ObjectStorage    objectstorage.ObjectStorage
+	ProxyManager     proxy.Manager
+	StorageManager   storage.Manager
+	GCManager        gc.Manager
+	BandwidthManager bandwidth.Manager
 
 	PeerTaskManager peer.TaskManager
 	PieceManager    peer.PieceManager
@@ -246,8 +248,9 @@ func New(opt *config.DaemonOption, d dfpath.Dfpath) (Daemon, error) {
 		return nil, err
 	}
 
+	downloadLimiter := rate.NewLimiter(opt.Download.TotalRateLimit.Limit, int(opt.Download.TotalRateLimit.Limit))
 	pmOpts := []peer.PieceManagerOption{
-		peer.WithLimiter(rate.NewLimiter(opt.Download.TotalRateLimit.Limit, int(opt.Download.TotalRateLimit.Limit))),
+		peer.WithLimiter(downloadLimiter),
 		peer.WithCalculateDigest(opt.Download.CalculateDigest),
 		peer.WithTransportOption(opt.Download.Transport),
 		peer.WithConcurrentOption(opt.Download.Concurrent),
@@ -269,18 +272,20 @@ func New(opt *config.DaemonOption, d dfpath.Dfpath) (Daemon, error) {
 			PieceManager:    pieceManager,
 			StorageManager:  storageManager,
 			WatchdogTimeout: opt.Download.WatchdogTimeout,
+			PieceRetry:      opt.Download.PieceRetry,
 			CalculateDigest: opt.Download.CalculateDigest,
 			GRPCCredentials: grpcCredentials,
 			GRPCDialTimeout: opt.Download.GRPCDialTimeout,
 		},
-		SchedulerClient:   schedulerClient,
-		PerPeerRateLimit:  opt.Download.PerPeerRateLimit.Limit,
-		TotalRateLimit:    opt.Download.TotalRateLimit.Limit,
-		TrafficShaperType: opt.Download.TrafficShaperType,
-		Multiplex:         opt.Storage.Multiplex,
-		Prefetch:          opt.Download.Prefetch,
-		GetPiecesMaxRetry: opt.Download.GetPiecesMaxRetry,
-		SplitRunningTasks: opt.Download.SplitRunningTasks,
+		SchedulerClient:       schedulerClient,
+		PerPeerRateLimit:      opt.Download.PerPeerRateLimit.Limit,
+		TotalRateLimit:        opt.Download.TotalRateLimit.Limit,
+		TrafficShaperType:     opt.Download.TrafficShaperType,
+		PriorityTrafficShaper: opt.Download.PriorityTrafficShaper,
+		Multiplex:             opt.Storage.Multiplex,
+		Prefetch:              opt.Download.Prefetch,
+		GetPiecesMaxRetry:     opt.Download.GetPiecesMaxRetry,
+		SplitRunningTasks:     opt.Download.SplitRunningTasks,
 	}
 	peerTaskManager, err := peer.NewPeerTaskManager(peerTaskManagerOption)
 	if err != nil {
@@ -318,8 +323,9 @@ func New(opt *config.DaemonOption, d dfpath.Dfpath) (Daemon, error) {
 		return nil, err
 	}
 
+	uploadLimiter := rate.NewLimiter(opt.Upload.RateLimit.Limit, int(opt.Upload.RateLimit.Limit))
 	uploadOpts := []upload.Option{
-		upload.WithLimiter(rate.NewLimiter(opt.Upload.RateLimit.Limit, int(opt.Upload.RateLimit.Limit))),
+		upload.WithLimiter(uploadLimiter),
 	}
 
 	if opt.Security.AutoIssueCert && opt.Scheduler.Manager.Enable {
@@ -331,6 +337,14 @@ func New(opt *config.DaemonOption, d dfpath.Dfpath) (Daemon, error) {
 		return nil, err
 	}
 
+	// Apply the bandwidth schedules to the download limiter, the traffic shaper of running tasks and the upload limiter.
+	bandwidthManager, err := bandwidth.New(opt,
+		bandwidth.WithDownloadLimiters(downloadLimiter, bandwidth.LimiterFunc(peerTaskManager.SetTotalRateLimit)),
+		bandwidth.WithUploadLimiters(uploadLimiter))
+	if err != nil {
+		return nil, err
+	}
+
 	var objectStorage objectstorage.ObjectStorage
 	if opt.ObjectStorage.Enable {
 		objectStorage, err = objectstorage.New(opt, dynconfig, peerTaskManager, storageManager, d.LogDir())
@@ -340,24 +354,25 @@ func New(opt *config.DaemonOption, d dfpath.Dfpath) (Daemon, error) {
 	}
 
 	return &clientDaemon{
-		once:            &sync.Once{},
-		done:            make(chan bool),
-		schedPeerHost:   host,
-		Option:          *opt,
-		RPCManager:      rpcManager,
-		PeerTaskManager: peerTaskManager,
-		PieceManager:    pieceManager,
-		ProxyManager:    proxyManager,
-		UploadManager:   uploadManager,
-		ObjectStorage:   objectStorage,
-		StorageManager:  storageManager,
-		GCManager:       gc.NewManager(opt.GCInterval.Duration),
-		dynconfig:       dynconfig,
-		dfpath:          d,
-		managerClient:   managerClient,
-		securityClient:  securityClient,
-		schedulerClient: schedulerClient,
-		certifyClient:   certifyClient,
+		once:             &sync.Once{},
+		done:             make(chan bool),
+		schedPeerHost:    host,
+		Option:           *opt,
+		RPCManager:       rpcManager,
+		PeerTaskManager:  peerTaskManager,
+		PieceManager:     pieceManager,
+		ProxyManager:     proxyManager,
+		UploadManager:    uploadManager,
+		ObjectStorage:    objectStorage,
+		StorageManager:   storageManager,
+		GCManager:        gc.NewManager(opt.GCInterval.Duration),
+		BandwidthManager: bandwidthManager,
+		dynconfig:        dynconfig,
+		dfpath:           d,
+		managerClient:    managerClient,
+		securityClient:   securityClient,
+		schedulerClient:  schedulerClient,
+		certifyClient:    certifyClient,
 	}, nil
 }
 
@@ -522,6 +537,7 @@ func (cd *clientDaemon) Serve() error {
 		interval = cd.Option.Reload.Interval.Duration
 	)
 	cd.GCManager.Start()
+	cd.BandwidthManager.Start()
 	// prepare download service listen
 	if cd.Option.Download.DownloadGRPC.UnixListen == nil {
 		return errors.New("download grpc unix listen option is empty")
@@ -724,6 +740,9 @@ func (cd *clientDaemon) Serve() error {
 		return nil
 	})
 
+	// watch rate limits and bandwidth schedules in local config
+	watchers = append(watchers, cd.BandwidthManager.OnNotify)
+
 	// when there is no manager configured, watch schedulers in local config
 	if cd.managerClient == nil {
 		watchers = append(watchers, cd.dynconfig.OnNotify)
@@ -805,6 +824,7 @@ func (cd *clientDaemon) Stop() {
 		}
 
 		cd.GCManager.Stop()
+		cd.BandwidthManager.Stop()
 		cd.RPCManager.Stop()
 		if err := cd.UploadManager.Stop(); err != nil {
 			logger.Errorf("upload manager stop failed %s", err)
@@ -109,6 +109,34 @@ var (
 		Help:      "Counter of the total failed piece tasks.",
 	})
 
+	PieceTaskDigestMismatchCount = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: types.MetricsNamespace,
+		Subsystem: types.DfdaemonMetricsName,
+		Name:      "piece_task_digest_mismatch_total",
+		Help:      "Counter of the total pieces downloaded from other peers mismatching the digest.",
+	})
+
+	PieceTaskRetryCount = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: types.MetricsNamespace,
+		Subsystem: types.DfdaemonMetricsName,
+		Name:      "piece_task_retry_total",
+		Help:      "Counter of the total retries of the failed pieces.",
+	})
+
+	PieceTaskRetrySuccessCount = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: types.MetricsNamespace,
+		Subsystem: types.DfdaemonMetricsName,
+		Name:      "piece_task_retry_success_total",
+		Help:      "Counter of the total failed pieces downloaded successfully after retries.",
+	})
+
+	PieceTaskRetryExhaustedCount = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: types.MetricsNamespace,
+		Subsystem: types.DfdaemonMetricsName,
+		Name:      "piece_task_retry_exhausted_total",
+		Help:      "Counter of the total failed pieces exhausting the retry attempts.",
+	})
+
 	FileTaskCount = promauto.NewCounter(prometheus.CounterOpts{
 		Namespace: types.MetricsNamespace,
 		Subsystem: types.DfdaemonMetricsName,
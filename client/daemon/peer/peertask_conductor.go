@@ -169,6 +169,8 @@ type TaskOption struct {
 	GRPCDialTimeout time.Duration
 	// WatchdogTimeout > 0 indicates to start watch dog for every single peer task
 	WatchdogTimeout time.Duration
+	// PieceRetry is the option of retrying failed pieces from other parents
+	PieceRetry config.PieceRetryOption
 }
 
 func (ptm *peerTaskManager) newPeerTaskConductor(
@@ -554,6 +556,7 @@ func (pt *peerTaskConductor) pullPiecesWithP2P() {
 		peerTaskConductor: pt,
 		pieceRequestQueue: pieceRequestQueue,
 		workers:           map[string]*pieceTaskSynchronizer{},
+		retryQueue:        newPieceRetryQueue(pt.PieceRetry, pt.retryPiece),
 	}
 	pt.receivePeerPacket(pieceRequestQueue)
 }
@@ -595,6 +598,12 @@ func (pt *peerTaskConductor) storeTinyPeerTask() {
 	pt.SetTotalPieces(1)
 	ctx := pt.ctx
 	var err error
+	if err = pt.verifyTinyData(); err != nil {
+		pt.Errorf("verify tiny data failed: %s", err)
+		pt.cancel(commonv1.Code_ClientError, err.Error())
+		return
+	}
+
 	storageDriver, err := pt.StorageManager.RegisterTask(ctx,
 		&storage.RegisterTaskRequest{
 			PeerTaskMetadata: storage.PeerTaskMetadata{
@@ -604,7 +613,6 @@ func (pt *peerTaskConductor) storeTinyPeerTask() {
 			DesiredLocation: "",
 			ContentLength:   contentLength,
 			TotalPieces:     1,
-			// TODO check digest
 		})
 	pt.storage = storageDriver
 	if err != nil {
@@ -656,6 +664,33 @@ func (pt *peerTaskConductor) storeTinyPeerTask() {
 	pt.PublishPieceInfo(0, uint32(contentLength))
 }
 
+// verifyTinyData verifies the tiny data with the digest of request, the tiny data is the whole content
+// of task, so it is skipped for ranged request.
+func (pt *peerTaskConductor) verifyTinyData() error {
+	if !pt.CalculateDigest || pt.request.UrlMeta.GetDigest() == "" || pt.request.UrlMeta.GetRange() != "" {
+		return nil
+	}
+
+	d, err := digest.Parse(pt.request.UrlMeta.Digest)
+	if err != nil {
+		return err
+	}
+
+	reader, err := digest.NewReader(d.Algorithm, bytes.NewReader(pt.tinyData.Content), digest.WithLogger(pt.Log()))
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return err
+	}
+
+	if encoded := reader.Encoded(); encoded != d.Encoded {
+		return fmt.Errorf("tiny data digest mismatch, desired: %s, actual: %s", d.Encoded, encoded)
+	}
+	return nil
+}
+
 func (pt *peerTaskConductor) receivePeerPacket(pieceRequestQueue PieceDispatcher) {
 	var (
 		lastNotReadyPiece   int32 = 0
@@ -1024,13 +1059,42 @@ func (pt *peerTaskConductor) downloadPieceWorker(id int32, requests PieceDispatc
 	}
 }
 
+// retryPiece acquires the failed piece again from the parents which have not failed it
+func (pt *peerTaskConductor) retryPiece(pieceNum int32, excludedParents map[string]struct{}) {
+	select {
+	case <-pt.successCh:
+		return
+	case <-pt.failCh:
+		return
+	default:
+	}
+	if pt.needBackSource.Load() {
+		return
+	}
+	pt.readyPiecesLock.RLock()
+	ready := pt.readyPieces.IsSet(pieceNum)
+	pt.readyPiecesLock.RUnlock()
+	if ready {
+		return
+	}
+	attempt, success := pt.pieceTaskSyncManager.acquireExcluding(
+		&commonv1.PieceTaskRequest{
+			Limit:    1,
+			TaskId:   pt.taskID,
+			SrcPid:   pt.peerID,
+			StartNum: uint32(pieceNum),
+		}, excludedParents)
+	pt.Infof("retry failed piece %d from remote, excluded: %d, attempt: %d, success: %d",
+		pieceNum, len(excludedParents), attempt, success)
+}
+
 func (pt *peerTaskConductor) downloadPiece(workerID int32, request *DownloadPieceRequest) *DownloadPieceResult {
 	// only downloading piece in one worker at same time
 	pt.runningPiecesLock.Lock()
 	if pt.runningPieces.IsSet(request.piece.PieceNum) {
 		pt.runningPiecesLock.Unlock()
+		// when the running piece fails, it will be retried by the retry queue
 		pt.Log().Debugf("piece %d is downloading, skip", request.piece.PieceNum)
-		// TODO save to queue for failed pieces
 		return nil
 	}
 	pt.runningPieces.Set(request.piece.PieceNum)
@@ -1067,6 +1131,10 @@ func (pt *peerTaskConductor) downloadPiece(workerID int32, request *DownloadPiec
 			pt.Infof("switch to back source, skip send failed piece")
 			return result
 		}
+		if pt.pieceTaskSyncManager.retryQueue.fail(request.piece.PieceNum, request.DstPid) {
+			pt.Infof("failed piece %d from peer %s is queued to retry", request.piece.PieceNum, request.DstPid)
+			return result
+		}
 		attempt, success := pt.pieceTaskSyncManager.acquire(
 			&commonv1.PieceTaskRequest{
 				Limit:    1,
@@ -1078,6 +1146,7 @@ func (pt *peerTaskConductor) downloadPiece(workerID int32, request *DownloadPiec
 			request.piece.PieceNum, attempt, success)
 		return result
 	}
+	pt.pieceTaskSyncManager.retryQueue.succeed(request.piece.PieceNum)
 	// broadcast success piece
 	pt.reportSuccessResult(request, result)
 	pt.PublishPieceInfo(request.piece.PieceNum, request.piece.RangeSize)
@@ -1194,6 +1263,13 @@ func (pt *peerTaskConductor) ReportPieceResult(request *DownloadPieceRequest, re
 		pt.reportSuccessResult(request, result)
 		return
 	}
+	if isPieceDigestMismatch(err) {
+		// the piece data of parent is corrupted, report it to scheduler to stop scheduling the parent
+		metrics.PieceTaskDigestMismatchCount.Add(1)
+		pt.reportFailResult(request, result, commonv1.Code_ClientPieceDownloadFail, common.NewDigestMismatchExtendAttribute())
+		return
+	}
+
 	code := commonv1.Code_ClientPieceDownloadFail
 	if isConnectionError(err) {
 		code = commonv1.Code_ClientConnectionError
@@ -1202,7 +1278,7 @@ func (pt *peerTaskConductor) ReportPieceResult(request *DownloadPieceRequest, re
 	} else if isBackSourceError(err) {
 		code = commonv1.Code_ClientBackSourceError
 	}
-	pt.reportFailResult(request, result, code)
+	pt.reportFailResult(request, result, code, nil)
 }
 
 func (pt *peerTaskConductor) reportSuccessResult(request *DownloadPieceRequest, result *DownloadPieceResult) {
@@ -1231,21 +1307,23 @@ func (pt *peerTaskConductor) reportSuccessResult(request *DownloadPieceRequest,
 	span.End()
 }
 
-func (pt *peerTaskConductor) reportFailResult(request *DownloadPieceRequest, result *DownloadPieceResult, code commonv1.Code) {
+func (pt *peerTaskConductor) reportFailResult(request *DownloadPieceRequest, result *DownloadPieceResult, code commonv1.Code,
+	extendAttribute *commonv1.ExtendAttribute) {
 	metrics.PieceTaskFailedCount.Add(1)
 	_, span := tracer.Start(pt.ctx, config.SpanReportPieceResult)
 	span.SetAttributes(config.AttributeWritePieceSuccess.Bool(false))
 
 	err := pt.sendPieceResult(&schedulerv1.PieceResult{
-		TaskId:        pt.GetTaskID(),
-		SrcPid:        pt.GetPeerID(),
-		DstPid:        request.DstPid,
-		PieceInfo:     request.piece,
-		BeginTime:     uint64(result.BeginTime),
-		EndTime:       uint64(result.FinishTime),
-		Success:       false,
-		Code:          code,
-		FinishedCount: pt.readyPieces.Settled(),
+		TaskId:          pt.GetTaskID(),
+		SrcPid:          pt.GetPeerID(),
+		DstPid:          request.DstPid,
+		PieceInfo:       request.piece,
+		BeginTime:       uint64(result.BeginTime),
+		EndTime:         uint64(result.FinishTime),
+		Success:         false,
+		Code:            code,
+		FinishedCount:   pt.readyPieces.Settled(),
+		ExtendAttribute: extendAttribute,
 	})
 	if err != nil {
 		pt.Errorf("report piece task error: %v", err)
@@ -1266,6 +1344,7 @@ func (pt *peerTaskConductor) initStorage(desiredLocation string) (err error) {
 				ContentLength:   pt.GetContentLength(),
 				TotalPieces:     pt.GetTotalPieces(),
 				PieceMd5Sign:    pt.GetPieceMd5Sign(),
+				Digest:          pt.request.UrlMeta.GetDigest(),
 			})
 	} else {
 		pt.storage, err = pt.StorageManager.RegisterSubTask(pt.ctx,
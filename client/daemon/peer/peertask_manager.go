@@ -34,6 +34,7 @@ import (
 	commonv1 "d7y.io/api/pkg/apis/common/v1"
 	schedulerv1 "d7y.io/api/pkg/apis/scheduler/v1"
 
+	"d7y.io/dragonfly/v2/client/config"
 	"d7y.io/dragonfly/v2/client/daemon/metrics"
 	"d7y.io/dragonfly/v2/client/daemon/storage"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
@@ -69,6 +70,9 @@ type TaskManager interface {
 
 	GetPieceManager() PieceManager
 
+	// SetTotalRateLimit updates the total download rate limit shared by running tasks
+	SetTotalRateLimit(totalRateLimit rate.Limit)
+
 	// Stop stops the PeerTaskManager
 	Stop(ctx context.Context) error
 }
@@ -131,6 +135,8 @@ type TaskManagerOption struct {
 	PerPeerRateLimit  rate.Limit
 	TotalRateLimit    rate.Limit
 	TrafficShaperType string
+	// PriorityTrafficShaper is the option of priority traffic shaper
+	PriorityTrafficShaper config.PriorityTrafficShaperOption
 	// Multiplex indicates to reuse the data of completed peer tasks
 	Multiplex bool
 	// Prefetch indicates to prefetch the whole files of ranged requests
@@ -144,7 +150,7 @@ func NewPeerTaskManager(opt *TaskManagerOption) (TaskManager, error) {
 		TaskManagerOption: *opt,
 		runningPeerTasks:  sync.Map{},
 		conductorLock:     &sync.Mutex{},
-		trafficShaper:     NewTrafficShaper(opt.TrafficShaperType, opt.TotalRateLimit, util.ComputePieceSize),
+		trafficShaper:     NewTrafficShaper(opt.TrafficShaperType, opt.TotalRateLimit, util.ComputePieceSize, opt.PriorityTrafficShaper),
 	}
 	ptm.trafficShaper.Start()
 	return ptm, nil
@@ -450,6 +456,10 @@ func (ptm *peerTaskManager) GetPieceManager() PieceManager {
 	return ptm.PieceManager
 }
 
+func (ptm *peerTaskManager) SetTotalRateLimit(totalRateLimit rate.Limit) {
+	ptm.trafficShaper.SetTotalRateLimit(totalRateLimit)
+}
+
 func (ptm *peerTaskManager) AnnouncePeerTask(ctx context.Context, meta storage.PeerTaskMetadata, url string, taskType commonv1.TaskType, urlMeta *commonv1.UrlMeta) error {
 	// Check if the given task is completed in local StorageManager.
 	if ptm.StorageManager.FindCompletedTask(meta.TaskID) == nil {
@@ -14,6 +14,7 @@ import (
 	storage "d7y.io/dragonfly/v2/client/daemon/storage"
 	dflog "d7y.io/dragonfly/v2/internal/dflog"
 	gomock "github.com/golang/mock/gomock"
+	rate "golang.org/x/time/rate"
 	status "google.golang.org/grpc/status"
 )
 
@@ -83,6 +84,18 @@ func (mr *MockTaskManagerMockRecorder) IsPeerTaskRunning(taskID, peerID interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsPeerTaskRunning", reflect.TypeOf((*MockTaskManager)(nil).IsPeerTaskRunning), taskID, peerID)
 }
 
+// SetTotalRateLimit mocks base method.
+func (m *MockTaskManager) SetTotalRateLimit(totalRateLimit rate.Limit) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetTotalRateLimit", totalRateLimit)
+}
+
+// SetTotalRateLimit indicates an expected call of SetTotalRateLimit.
+func (mr *MockTaskManagerMockRecorder) SetTotalRateLimit(totalRateLimit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTotalRateLimit", reflect.TypeOf((*MockTaskManager)(nil).SetTotalRateLimit), totalRateLimit)
+}
+
 // StartFileTask mocks base method.
 func (m *MockTaskManager) StartFileTask(ctx context.Context, req *FileTaskRequest) (chan *FileTaskProgress, error) {
 	m.ctrl.T.Helper()
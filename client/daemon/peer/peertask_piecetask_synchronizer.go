@@ -49,6 +49,8 @@ type pieceTaskSyncManager struct {
 	pieceRequestQueue PieceDispatcher
 	workers           map[string]*pieceTaskSynchronizer
 	watchdog          *synchronizerWatchdog
+	// retryQueue re-queues the failed pieces to other parents
+	retryQueue *pieceRetryQueue
 }
 
 type pieceTaskSynchronizer struct {
@@ -212,20 +214,39 @@ func (s *pieceTaskSyncManager) reportInvalidPeer(destPeer *schedulerv1.PeerPacke
 
 // acquire send the target piece to other peers
 func (s *pieceTaskSyncManager) acquire(request *commonv1.PieceTaskRequest) (attempt int, success int) {
+	return s.acquireExcluding(request, nil)
+}
+
+// acquireExcluding send the target piece to other peers except excludedParents,
+// when all peers are excluded, send to all peers instead
+func (s *pieceTaskSyncManager) acquireExcluding(request *commonv1.PieceTaskRequest, excludedParents map[string]struct{}) (attempt int, success int) {
 	s.RLock()
-	for _, p := range s.workers {
+	defer s.RUnlock()
+	var available bool
+	for peerID := range s.workers {
+		if _, ok := excludedParents[peerID]; !ok {
+			available = true
+			break
+		}
+	}
+	for peerID, p := range s.workers {
+		if _, ok := excludedParents[peerID]; ok && available {
+			continue
+		}
 		attempt++
 		if p.grpcInitialized.Load() && p.acquire(request) == nil {
 			success++
 		}
 	}
-	s.RUnlock()
 	return
 }
 
 func (s *pieceTaskSyncManager) cancel() {
 	s.ctxCancel()
 	s.pieceRequestQueue.Close()
+	if s.retryQueue != nil {
+		s.retryQueue.close()
+	}
 	s.Lock()
 	for _, p := range s.workers {
 		p.close()
@@ -25,6 +25,7 @@ import (
 	testifyassert "github.com/stretchr/testify/assert"
 	"go.uber.org/atomic"
 
+	commonv1 "d7y.io/api/pkg/apis/common/v1"
 	schedulerv1 "d7y.io/api/pkg/apis/scheduler/v1"
 	"d7y.io/api/pkg/apis/scheduler/v1/mocks"
 
@@ -292,3 +293,53 @@ func Test_diffPeers(t *testing.T) {
 		})
 	}
 }
+
+func TestPieceTaskSyncManager_AcquireExcluding(t *testing.T) {
+	testCases := []struct {
+		name            string
+		workers         map[string]*pieceTaskSynchronizer
+		excludedParents map[string]struct{}
+		attempt         int
+	}{
+		{
+			name: "no excluded parents",
+			workers: map[string]*pieceTaskSynchronizer{
+				"peer-0": {grpcInitialized: atomic.NewBool(false)},
+				"peer-1": {grpcInitialized: atomic.NewBool(false)},
+			},
+			excludedParents: nil,
+			attempt:         2,
+		},
+		{
+			name: "skip excluded parents",
+			workers: map[string]*pieceTaskSynchronizer{
+				"peer-0": {grpcInitialized: atomic.NewBool(false)},
+				"peer-1": {grpcInitialized: atomic.NewBool(false)},
+				"peer-2": {grpcInitialized: atomic.NewBool(false)},
+			},
+			excludedParents: map[string]struct{}{"peer-0": {}},
+			attempt:         2,
+		},
+		{
+			name: "all parents excluded",
+			workers: map[string]*pieceTaskSynchronizer{
+				"peer-0": {grpcInitialized: atomic.NewBool(false)},
+				"peer-1": {grpcInitialized: atomic.NewBool(false)},
+			},
+			excludedParents: map[string]struct{}{"peer-0": {}, "peer-1": {}},
+			attempt:         2,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			s := &pieceTaskSyncManager{
+				workers: tt.workers,
+			}
+			attempt, success := s.acquireExcluding(&commonv1.PieceTaskRequest{}, tt.excludedParents)
+			assert.Equal(attempt, tt.attempt)
+			assert.Equal(success, 0)
+		})
+	}
+}
@@ -45,6 +45,8 @@ var _ *logger.SugaredLoggerOnWith // pin this package for no log code generation
 // B. prefetch feature disabled
 //    for ranged request, 1, find completed normal task, 2, find partial completed parent task
 //    for non-ranged request, just find completed task
+// C. for non-ranged request, find completed task with the same content digest when no completed task found,
+//    it only works with dedup store strategy
 
 func (ptm *peerTaskManager) tryReuseFilePeerTask(ctx context.Context,
 	request *FileTaskRequest) (chan *FileTaskProgress, bool) {
@@ -63,6 +65,11 @@ func (ptm *peerTaskManager) tryReuseFilePeerTask(ctx context.Context,
 		reuse = ptm.StorageManager.FindCompletedTask(taskID)
 	}
 
+	// for non-ranged request, check the task with the same content digest
+	if reuse == nil && request.Range == nil {
+		reuse = ptm.StorageManager.FindCompletedTaskByDigest(taskID, request.UrlMeta.GetDigest())
+	}
+
 	if reuse == nil {
 		if request.Range == nil {
 			return nil, false
@@ -241,6 +248,11 @@ func (ptm *peerTaskManager) tryReuseStreamPeerTask(ctx context.Context,
 		reuse = ptm.StorageManager.FindCompletedTask(taskID)
 	}
 
+	// for non-ranged request, check the task with the same content digest
+	if reuse == nil && request.Range == nil {
+		reuse = ptm.StorageManager.FindCompletedTaskByDigest(taskID, request.URLMeta.GetDigest())
+	}
+
 	if reuse == nil {
 		if request.Range == nil {
 			return nil, nil, false
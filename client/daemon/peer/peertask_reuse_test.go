@@ -100,6 +100,57 @@ func TestReuseFilePeerTask(t *testing.T) {
 				assert.Equal(testBytes[0:10], data)
 			},
 		},
+		{
+			name: "completed task with the same digest found",
+			request: &FileTaskRequest{
+				PeerTaskRequest: schedulerv1.PeerTaskRequest{
+					PeerId: "",
+					Url:    "http://example.com/2",
+					UrlMeta: &commonv1.UrlMeta{
+						Digest: "sha256:foo",
+						Tag:    "",
+						Range:  "",
+						Filter: "",
+						Header: nil,
+					},
+				},
+				Output: testOutput,
+				Range:  nil,
+			},
+			enablePrefetch: false,
+			storageManager: func(sm *mocks.MockManager) {
+				var taskID string
+				sm.EXPECT().FindCompletedTask(gomock.Any()).DoAndReturn(
+					func(id string) *storage.ReusePeerTask {
+						return nil
+					})
+				sm.EXPECT().FindCompletedTaskByDigest(gomock.Any(), "sha256:foo").DoAndReturn(
+					func(id, digest string) *storage.ReusePeerTask {
+						taskID = id
+						return &storage.ReusePeerTask{
+							PeerTaskMetadata: storage.PeerTaskMetadata{
+								PeerID: "peer-1",
+								TaskID: taskID,
+							},
+							ContentLength: 10,
+							TotalPieces:   0,
+							PieceMd5Sign:  "",
+						}
+					})
+				sm.EXPECT().Store(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, req *storage.StoreRequest) error {
+						assert.Equal(taskID, req.TaskID)
+						assert.Equal("peer-1", req.PeerID)
+						return os.WriteFile(req.Destination, testBytes[0:10], 0644)
+					})
+			},
+			verify: func(pg chan *FileTaskProgress, ok bool) {
+				assert.True(ok)
+				data, err := os.ReadFile(testOutput)
+				assert.Nil(err)
+				assert.Equal(testBytes[0:10], data)
+			},
+		},
 		{
 			name: "normal completed task not found",
 			request: &FileTaskRequest{
@@ -131,6 +182,10 @@ func TestReuseFilePeerTask(t *testing.T) {
 					func(taskID string) *storage.ReusePeerTask {
 						return nil
 					})
+				sm.EXPECT().FindCompletedTaskByDigest(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(taskID, digest string) *storage.ReusePeerTask {
+						return nil
+					})
 			},
 			verify: func(pg chan *FileTaskProgress, ok bool) {
 				assert.False(ok)
@@ -402,6 +457,59 @@ func TestReuseStreamPeerTask(t *testing.T) {
 				rc.Close()
 			},
 		},
+		{
+			name: "completed task with the same digest found",
+			request: &StreamTaskRequest{
+				URL: "http://example.com/2",
+				URLMeta: &commonv1.UrlMeta{
+					Digest: "sha256:foo",
+					Tag:    "",
+					Range:  "",
+					Filter: "",
+					Header: nil,
+				},
+				Range:  nil,
+				PeerID: "",
+			},
+			enablePrefetch: false,
+			storageManager: func(sm *mocks.MockManager) {
+				var taskID string
+				sm.EXPECT().FindCompletedTask(gomock.Any()).DoAndReturn(
+					func(id string) *storage.ReusePeerTask {
+						return nil
+					})
+				sm.EXPECT().FindCompletedTaskByDigest(gomock.Any(), "sha256:foo").DoAndReturn(
+					func(id, digest string) *storage.ReusePeerTask {
+						taskID = id
+						return &storage.ReusePeerTask{
+							PeerTaskMetadata: storage.PeerTaskMetadata{
+								PeerID: "peer-1",
+								TaskID: taskID,
+							},
+							ContentLength: 10,
+							TotalPieces:   0,
+							PieceMd5Sign:  "",
+						}
+					})
+				sm.EXPECT().ReadAllPieces(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, req *storage.ReadAllPiecesRequest) (io.ReadCloser, error) {
+						assert.Equal(taskID, req.TaskID)
+						assert.Equal("peer-1", req.PeerID)
+						return io.NopCloser(bytes.NewBuffer([]byte("1111111111"))), nil
+					})
+				sm.EXPECT().GetExtendAttribute(gomock.Any(),
+					gomock.Any()).AnyTimes().DoAndReturn(
+					func(ctx context.Context, req *storage.PeerTaskMetadata) (*commonv1.ExtendAttribute, error) {
+						return &commonv1.ExtendAttribute{}, nil
+					})
+			},
+			verify: func(rc io.ReadCloser, attr map[string]string, ok bool) {
+				assert.True(ok)
+				assert.NotNil(rc)
+				assert.Equal("10", attr[headers.ContentLength])
+				rc.Close()
+			},
+		},
 		{
 			name: "normal completed task not found",
 			request: &StreamTaskRequest{
@@ -430,6 +538,10 @@ func TestReuseStreamPeerTask(t *testing.T) {
 					func(taskID string) *storage.ReusePeerTask {
 						return nil
 					})
+				sm.EXPECT().FindCompletedTaskByDigest(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(taskID, digest string) *storage.ReusePeerTask {
+						return nil
+					})
 			},
 			verify: func(rc io.ReadCloser, attr map[string]string, ok bool) {
 				assert.False(ok)
@@ -22,6 +22,7 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -102,6 +103,10 @@ func isPieceNotFound(err error) bool {
 	return false
 }
 
+func isPieceDigestMismatch(err error) bool {
+	return errors.Is(err, storage.ErrPieceDigestMismatch)
+}
+
 func isBackSourceError(err error) bool {
 	if _, ok := err.(*backSourceError); ok {
 		return true
@@ -185,7 +190,13 @@ func (p *pieceDownloader) DownloadPiece(ctx context.Context, req *DownloadPieceR
 	reader, closer := resp.Body.(io.Reader), resp.Body.(io.Closer)
 	if req.CalcDigest {
 		req.log.Debugf("calculate digest for piece %d, digest: %s", req.piece.PieceNum, req.piece.PieceMd5)
-		reader, err = digest.NewReader(digest.AlgorithmMD5, io.LimitReader(resp.Body, int64(req.piece.RangeSize)), digest.WithEncoded(req.piece.PieceMd5), digest.WithLogger(req.log))
+		// the piece digest is md5 encoded or in the form of algorithm:encoded, it is verified when writing piece to storage
+		algorithm := digest.AlgorithmMD5
+		if d, err := digest.Parse(req.piece.PieceMd5); err == nil {
+			algorithm = d.Algorithm
+		}
+
+		reader, err = digest.NewReader(algorithm, io.LimitReader(resp.Body, int64(req.piece.RangeSize)), digest.WithLogger(req.log))
 		if err != nil {
 			_ = closer.Close()
 			req.log.Errorf("init digest reader error: %s", err.Error())
@@ -0,0 +1,153 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"sync"
+	"time"
+
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/daemon/metrics"
+)
+
+// pieceRetryFunc acquires the piece again from the parents not in excludedParents
+type pieceRetryFunc func(pieceNum int32, excludedParents map[string]struct{})
+
+// pieceRetryQueue re-queues the failed pieces with backoff, every retry of a piece excludes
+// the parents which failed it before, so transient parent failures resolve in other parents.
+type pieceRetryQueue struct {
+	sync.Mutex
+	option config.PieceRetryOption
+	retry  pieceRetryFunc
+	pieces map[int32]*pieceRetry
+	closed bool
+}
+
+type pieceRetry struct {
+	attempts        int
+	exhausted       bool
+	excludedParents map[string]struct{}
+	// timer is not nil when a retry is pending
+	timer *time.Timer
+}
+
+func newPieceRetryQueue(option config.PieceRetryOption, retry pieceRetryFunc) *pieceRetryQueue {
+	return &pieceRetryQueue{
+		option: option,
+		retry:  retry,
+		pieces: map[int32]*pieceRetry{},
+	}
+}
+
+// fail records the failed parent of the piece and schedules a retry after backoff,
+// it returns false when the retry queue is disabled or the attempts of the piece are exhausted.
+func (q *pieceRetryQueue) fail(pieceNum int32, parent string) bool {
+	q.Lock()
+	defer q.Unlock()
+	if q.closed || q.option.MaxAttempts <= 0 {
+		return false
+	}
+
+	r, ok := q.pieces[pieceNum]
+	if !ok {
+		r = &pieceRetry{excludedParents: map[string]struct{}{}}
+		q.pieces[pieceNum] = r
+	}
+	r.excludedParents[parent] = struct{}{}
+
+	// a retry is pending, the new failed parent will be excluded in it
+	if r.timer != nil {
+		return true
+	}
+
+	if r.attempts >= q.option.MaxAttempts {
+		if !r.exhausted {
+			r.exhausted = true
+			metrics.PieceTaskRetryExhaustedCount.Add(1)
+		}
+		return false
+	}
+
+	backoff := q.backoff(r.attempts)
+	r.attempts++
+	r.timer = time.AfterFunc(backoff, func() {
+		q.fire(pieceNum)
+	})
+	return true
+}
+
+// succeed removes the piece from the retry queue
+func (q *pieceRetryQueue) succeed(pieceNum int32) {
+	q.Lock()
+	defer q.Unlock()
+	r, ok := q.pieces[pieceNum]
+	if !ok {
+		return
+	}
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	delete(q.pieces, pieceNum)
+	metrics.PieceTaskRetrySuccessCount.Add(1)
+}
+
+// close stops all pending retries
+func (q *pieceRetryQueue) close() {
+	q.Lock()
+	defer q.Unlock()
+	q.closed = true
+	for _, r := range q.pieces {
+		if r.timer != nil {
+			r.timer.Stop()
+		}
+	}
+	q.pieces = map[int32]*pieceRetry{}
+}
+
+func (q *pieceRetryQueue) fire(pieceNum int32) {
+	q.Lock()
+	r, ok := q.pieces[pieceNum]
+	if q.closed || !ok {
+		q.Unlock()
+		return
+	}
+	r.timer = nil
+	// count the retry when it is fired, the pending retries cancelled by close are not counted
+	metrics.PieceTaskRetryCount.Add(1)
+	excludedParents := make(map[string]struct{}, len(r.excludedParents))
+	for parent := range r.excludedParents {
+		excludedParents[parent] = struct{}{}
+	}
+	q.Unlock()
+
+	q.retry(pieceNum, excludedParents)
+}
+
+// backoff returns the initial backoff doubled for every attempt, and not exceeds the max backoff
+func (q *pieceRetryQueue) backoff(attempts int) time.Duration {
+	backoff := q.option.InitialBackoff
+	for i := 0; i < attempts; i++ {
+		if q.option.MaxBackoff > 0 && backoff >= q.option.MaxBackoff {
+			break
+		}
+		backoff *= 2
+	}
+	if q.option.MaxBackoff > 0 && backoff > q.option.MaxBackoff {
+		backoff = q.option.MaxBackoff
+	}
+	return backoff
+}
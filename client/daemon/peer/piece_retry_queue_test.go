@@ -0,0 +1,163 @@
+/*
+ *     Copyright 2022 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/daemon/metrics"
+)
+
+type pieceRetryCall struct {
+	pieceNum        int32
+	excludedParents map[string]struct{}
+}
+
+func newTestPieceRetryQueue(option config.PieceRetryOption) (*pieceRetryQueue, chan pieceRetryCall) {
+	calls := make(chan pieceRetryCall, 16)
+	q := newPieceRetryQueue(option, func(pieceNum int32, excludedParents map[string]struct{}) {
+		calls <- pieceRetryCall{pieceNum: pieceNum, excludedParents: excludedParents}
+	})
+	return q, calls
+}
+
+func TestPieceRetryQueue_Fail(t *testing.T) {
+	assert := assert.New(t)
+	q, calls := newTestPieceRetryQueue(config.PieceRetryOption{
+		MaxAttempts:    2,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     20 * time.Millisecond,
+	})
+	defer q.close()
+
+	// first attempt
+	retryCount := testutil.ToFloat64(metrics.PieceTaskRetryCount)
+	assert.True(q.fail(1, "peer-0"))
+	call := <-calls
+	assert.Equal(testutil.ToFloat64(metrics.PieceTaskRetryCount), retryCount+1)
+	assert.Equal(call.pieceNum, int32(1))
+	assert.Equal(call.excludedParents, map[string]struct{}{"peer-0": {}})
+
+	// second attempt excludes all failed parents
+	assert.True(q.fail(1, "peer-1"))
+	call = <-calls
+	assert.Equal(call.pieceNum, int32(1))
+	assert.Equal(call.excludedParents, map[string]struct{}{"peer-0": {}, "peer-1": {}})
+
+	// attempts exhausted
+	assert.False(q.fail(1, "peer-2"))
+	assert.False(q.fail(1, "peer-3"))
+
+	// other pieces are not affected
+	assert.True(q.fail(2, "peer-0"))
+	call = <-calls
+	assert.Equal(call.pieceNum, int32(2))
+	assert.Equal(call.excludedParents, map[string]struct{}{"peer-0": {}})
+}
+
+func TestPieceRetryQueue_FailPending(t *testing.T) {
+	assert := assert.New(t)
+	q, calls := newTestPieceRetryQueue(config.PieceRetryOption{
+		MaxAttempts:    1,
+		InitialBackoff: 50 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+	})
+	defer q.close()
+
+	assert.True(q.fail(1, "peer-0"))
+	// the pending retry does not consume attempts, and excludes the new failed parent
+	assert.True(q.fail(1, "peer-1"))
+	call := <-calls
+	assert.Equal(call.excludedParents, map[string]struct{}{"peer-0": {}, "peer-1": {}})
+	assert.Equal(len(calls), 0)
+}
+
+func TestPieceRetryQueue_Disabled(t *testing.T) {
+	assert := assert.New(t)
+	q, _ := newTestPieceRetryQueue(config.PieceRetryOption{})
+	defer q.close()
+
+	assert.False(q.fail(1, "peer-0"))
+}
+
+func TestPieceRetryQueue_SucceedAndClose(t *testing.T) {
+	assert := assert.New(t)
+	q, calls := newTestPieceRetryQueue(config.PieceRetryOption{
+		MaxAttempts:    3,
+		InitialBackoff: 50 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+	})
+
+	retryCount := testutil.ToFloat64(metrics.PieceTaskRetryCount)
+	assert.True(q.fail(1, "peer-0"))
+	q.succeed(1)
+	assert.True(q.fail(2, "peer-0"))
+	q.close()
+	assert.False(q.fail(3, "peer-0"))
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(len(calls), 0)
+	// the cancelled retries are not counted
+	assert.Equal(testutil.ToFloat64(metrics.PieceTaskRetryCount), retryCount)
+}
+
+func TestPieceRetryQueue_Backoff(t *testing.T) {
+	testCases := []struct {
+		name     string
+		option   config.PieceRetryOption
+		attempts int
+		expect   time.Duration
+	}{
+		{
+			name:     "first attempt",
+			option:   config.PieceRetryOption{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second},
+			attempts: 0,
+			expect:   100 * time.Millisecond,
+		},
+		{
+			name:     "doubled backoff",
+			option:   config.PieceRetryOption{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second},
+			attempts: 2,
+			expect:   400 * time.Millisecond,
+		},
+		{
+			name:     "max backoff",
+			option:   config.PieceRetryOption{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second},
+			attempts: 10,
+			expect:   time.Second,
+		},
+		{
+			name:     "without max backoff",
+			option:   config.PieceRetryOption{InitialBackoff: 100 * time.Millisecond},
+			attempts: 3,
+			expect:   800 * time.Millisecond,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			q := newPieceRetryQueue(tc.option, nil)
+			assert.Equal(q.backoff(tc.attempts), tc.expect)
+		})
+	}
+}
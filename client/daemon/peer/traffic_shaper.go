@@ -23,6 +23,7 @@ import (
 	"go.uber.org/atomic"
 	"golang.org/x/time/rate"
 
+	"d7y.io/dragonfly/v2/client/config"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/pkg/math"
 )
@@ -30,6 +31,7 @@ import (
 const (
 	TypePlainTrafficShaper    = "plain"
 	TypeSamplingTrafficShaper = "sampling"
+	TypePriorityTrafficShaper = "priority"
 )
 
 // TrafficShaper allocates bandwidth for running tasks dynamically
@@ -46,13 +48,18 @@ type TrafficShaper interface {
 	Record(taskID string, n int)
 	// GetBandwidth gets the total download bandwidth in the past second
 	GetBandwidth() int64
+	// SetTotalRateLimit updates the total rate limit allocated to running tasks
+	SetTotalRateLimit(totalRateLimit rate.Limit)
 }
 
-func NewTrafficShaper(trafficShaperType string, totalRateLimit rate.Limit, computePieceSize func(int64) uint32) TrafficShaper {
+func NewTrafficShaper(trafficShaperType string, totalRateLimit rate.Limit, computePieceSize func(int64) uint32,
+	priorityOption config.PriorityTrafficShaperOption) TrafficShaper {
 	var ts TrafficShaper
 	switch trafficShaperType {
 	case TypeSamplingTrafficShaper:
 		ts = NewSamplingTrafficShaper(totalRateLimit, computePieceSize)
+	case TypePriorityTrafficShaper:
+		ts = NewPriorityTrafficShaper(totalRateLimit, computePieceSize, priorityOption)
 	case TypePlainTrafficShaper:
 		ts = NewPlainTrafficShaper()
 	default:
@@ -112,6 +119,9 @@ func (ts *plainTrafficShaper) GetBandwidth() int64 {
 	return ts.lastSecondBandwidth.Load()
 }
 
+func (ts *plainTrafficShaper) SetTotalRateLimit(_ rate.Limit) {
+}
+
 type taskEntry struct {
 	ptc       *peerTaskConductor
 	pieceSize uint32
@@ -271,3 +281,9 @@ func (ts *samplingTrafficShaper) Record(taskID string, n int) {
 func (ts *samplingTrafficShaper) GetBandwidth() int64 {
 	return ts.lastSecondBandwidth.Load()
 }
+
+func (ts *samplingTrafficShaper) SetTotalRateLimit(totalRateLimit rate.Limit) {
+	ts.Lock()
+	defer ts.Unlock()
+	ts.totalRateLimit = totalRateLimit
+}
@@ -0,0 +1,369 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+	"golang.org/x/time/rate"
+
+	commonv1 "d7y.io/api/pkg/apis/common/v1"
+
+	"d7y.io/dragonfly/v2/client/config"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/math"
+)
+
+// priorityTrafficShaperBusyRatio is the ratio of used bandwidth to the limit of task,
+// the task used more than it in the past second needs more bandwidth.
+const priorityTrafficShaperBusyRatio = 0.9
+
+type priorityTaskEntry struct {
+	ptc       *peerTaskConductor
+	pieceSize uint32
+	// class is the class of task grouped by priority and application
+	class string
+	// weight is the weight of class
+	weight float64
+	// used bandwidth in the past second
+	lastSecondBandwidth *atomic.Int64
+	// need bandwidth in the next second
+	needBandwidth float64
+	// indicates if the bandwidth need to be updated, tasks added within one second don't need to be updated
+	needUpdate bool
+}
+
+type priorityTrafficShaper struct {
+	*logger.SugaredLoggerOnWith
+	sync.RWMutex
+	computePieceSize func(int64) uint32
+	totalRateLimit   rate.Limit
+	// the least bandwidth of every class of tasks
+	minClassRateLimit rate.Limit
+	// weights of priorities
+	priorityWeights map[commonv1.Priority]float64
+	// weights of applications, which override the weights of priorities
+	applicationWeights map[string]float64
+	// total used bandwidth in the past second
+	lastSecondBandwidth *atomic.Int64
+	// total used bandwidth in the current second
+	usingBandWidth *atomic.Int64
+	tasks          map[string]*priorityTaskEntry
+	stopCh         chan struct{}
+}
+
+// NewPriorityTrafficShaper returns a traffic shaper allocating bandwidth by the priority and application of tasks,
+// the bandwidth guaranteed for every class of tasks is in proportion to its weight, and the bandwidth not used
+// by a class is borrowed by the others, so the tasks with low priority can not starve the tasks with high priority.
+func NewPriorityTrafficShaper(totalRateLimit rate.Limit, computePieceSize func(int64) uint32, option config.PriorityTrafficShaperOption) TrafficShaper {
+	log := logger.With("component", "TrafficShaper")
+	priorityWeights := make(map[commonv1.Priority]float64, len(commonv1.Priority_value))
+	for _, level := range commonv1.Priority_value {
+		priorityWeights[commonv1.Priority(level)] = defaultPriorityWeight(commonv1.Priority(level))
+	}
+
+	for name, weight := range option.PriorityWeights {
+		level, ok := commonv1.Priority_value[name]
+		if !ok || weight <= 0 {
+			log.Warnf("invalid weight %d of priority %s, ignore it", weight, name)
+			continue
+		}
+
+		priorityWeights[commonv1.Priority(level)] = float64(weight)
+	}
+
+	applicationWeights := make(map[string]float64, len(option.ApplicationWeights))
+	for application, weight := range option.ApplicationWeights {
+		if weight <= 0 {
+			log.Warnf("invalid weight %d of application %s, ignore it", weight, application)
+			continue
+		}
+
+		applicationWeights[application] = float64(weight)
+	}
+
+	return &priorityTrafficShaper{
+		SugaredLoggerOnWith: log,
+		computePieceSize:    computePieceSize,
+		totalRateLimit:      totalRateLimit,
+		minClassRateLimit:   option.MinClassRateLimit.Limit,
+		priorityWeights:     priorityWeights,
+		applicationWeights:  applicationWeights,
+		lastSecondBandwidth: atomic.NewInt64(0),
+		usingBandWidth:      atomic.NewInt64(0),
+		tasks:               make(map[string]*priorityTaskEntry),
+		stopCh:              make(chan struct{}),
+	}
+}
+
+// defaultPriorityWeight returns the weight of priority not configured, which is the level plus one.
+// LEVEL0 is the default priority and is scheduled as high as LEVEL6, so it has the weight of LEVEL6.
+func defaultPriorityWeight(priority commonv1.Priority) float64 {
+	if priority == commonv1.Priority_LEVEL0 {
+		priority = commonv1.Priority_LEVEL6
+	}
+
+	return float64(priority + 1)
+}
+
+func (ts *priorityTrafficShaper) Start() {
+	go func() {
+		// update bandwidth of all running tasks every second
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				ts.lastSecondBandwidth.Store(ts.usingBandWidth.Load())
+				ts.usingBandWidth.Store(0)
+				ts.updateLimit()
+			case <-ts.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+func (ts *priorityTrafficShaper) Stop() {
+	close(ts.stopCh)
+}
+
+// updateLimit samples every task's need bandwidth and reallocates limits every second
+func (ts *priorityTrafficShaper) updateLimit() {
+	ts.Lock()
+	defer ts.Unlock()
+	for _, te := range ts.tasks {
+		usedBandwidth := float64(te.lastSecondBandwidth.Swap(0))
+		if !te.needUpdate {
+			// if this task is added within 1 second, keep its need bandwidth this time
+			te.needUpdate = true
+			continue
+		}
+
+		// the task used up its limit may need more bandwidth, so it needs all of the bandwidth
+		// and borrows from the others, otherwise it needs the used bandwidth with one more piece
+		if usedBandwidth >= float64(te.ptc.limiter.Limit())*priorityTrafficShaperBusyRatio {
+			te.needBandwidth = float64(ts.totalRateLimit)
+		} else {
+			te.needBandwidth = usedBandwidth + float64(te.pieceSize)
+		}
+
+		if contentLength := te.ptc.contentLength.Load(); contentLength > 0 {
+			remainingLength := contentLength - te.ptc.completedLength.Load()
+			te.needBandwidth = math.Min(float64(remainingLength), te.needBandwidth)
+		}
+	}
+
+	ts.allocate()
+}
+
+// allocate allocates the total rate limit to the classes of tasks, then allocates the bandwidth of every class
+// to its tasks equally. The least bandwidth of a class, one piece for every task and not less than the min
+// class rate limit, is reserved first, and the rest is allocated by weights. When the total rate limit is
+// less than the least bandwidth of all classes, the least bandwidth is reduced in proportion, so the sum of
+// limits never exceeds the total rate limit.
+func (ts *priorityTrafficShaper) allocate() {
+	classes := make(map[string][]*priorityTaskEntry)
+	for _, te := range ts.tasks {
+		classes[te.class] = append(classes[te.class], te)
+	}
+
+	var (
+		names        = make([]string, 0, len(classes))
+		classLeasts  = make([]float64, 0, len(classes))
+		classNeeds   = make([]float64, 0, len(classes))
+		classWeights = make([]float64, 0, len(classes))
+		totalLeast   float64
+	)
+	for name, entries := range classes {
+		var needBandwidth, pieceBandwidth float64
+		for _, te := range entries {
+			needBandwidth += te.needBandwidth
+			pieceBandwidth += float64(te.pieceSize)
+		}
+
+		least := math.Max(pieceBandwidth, float64(ts.minClassRateLimit))
+		names = append(names, name)
+		classLeasts = append(classLeasts, least)
+		classNeeds = append(classNeeds, needBandwidth)
+		classWeights = append(classWeights, entries[0].weight)
+		totalLeast += least
+	}
+
+	total := float64(ts.totalRateLimit)
+	if totalLeast > total {
+		for i := range classLeasts {
+			classLeasts[i] *= total / totalLeast
+		}
+		totalLeast = total
+	}
+
+	for i := range classNeeds {
+		classNeeds[i] = math.Max(classNeeds[i]-classLeasts[i], 0)
+	}
+
+	classBandwidths := allocateBandwidth(total-totalLeast, classNeeds, classWeights)
+	for i, name := range names {
+		var (
+			entries        = classes[name]
+			leasts         = make([]float64, len(entries))
+			needs          = make([]float64, len(entries))
+			weights        = make([]float64, len(entries))
+			pieceBandwidth float64
+		)
+		for _, te := range entries {
+			pieceBandwidth += float64(te.pieceSize)
+		}
+
+		// one piece for every task is reserved in the least bandwidth of class,
+		// it is reduced in proportion when the least bandwidth of class is reduced.
+		pieceRatio := 1.0
+		if classLeasts[i] < pieceBandwidth {
+			pieceRatio = classLeasts[i] / pieceBandwidth
+		}
+
+		bandwidth := classLeasts[i] + classBandwidths[i]
+		for j, te := range entries {
+			leasts[j] = float64(te.pieceSize) * pieceRatio
+			needs[j] = math.Max(te.needBandwidth-leasts[j], 0)
+			weights[j] = 1
+			bandwidth -= leasts[j]
+		}
+
+		for j, bandwidth := range allocateBandwidth(bandwidth, needs, weights) {
+			te := entries[j]
+			limit := rate.Limit(leasts[j] + bandwidth)
+			te.ptc.limiter.SetLimit(limit)
+			ts.Debugf("update limit, task %s, class %s, need bandwidth %f, rate limit %f", te.ptc.taskID, name, te.needBandwidth, limit)
+		}
+	}
+}
+
+// allocateBandwidth allocates the total bandwidth by weighted max-min fairness. Every need is guaranteed
+// the share in proportion to its weight, the share not needed is borrowed by the others, and the bandwidth
+// left after all needs are satisfied is shared by weights, so the needs are able to grow.
+func allocateBandwidth(total float64, needs, weights []float64) []float64 {
+	bandwidths := make([]float64, len(needs))
+	unsatisfied := make([]int, 0, len(needs))
+	for i := range needs {
+		unsatisfied = append(unsatisfied, i)
+	}
+
+	remaining := total
+	for len(unsatisfied) > 0 {
+		var totalWeight float64
+		for _, i := range unsatisfied {
+			totalWeight += weights[i]
+		}
+
+		var (
+			next      []int
+			satisfied float64
+		)
+		for _, i := range unsatisfied {
+			if needs[i] <= remaining*weights[i]/totalWeight {
+				bandwidths[i] = needs[i]
+				satisfied += needs[i]
+				continue
+			}
+
+			next = append(next, i)
+		}
+
+		// none of the needs is satisfied by its share, allocate the shares.
+		if len(next) == len(unsatisfied) {
+			for _, i := range next {
+				bandwidths[i] = remaining * weights[i] / totalWeight
+			}
+
+			return bandwidths
+		}
+
+		remaining -= satisfied
+		unsatisfied = next
+	}
+
+	var totalWeight float64
+	for _, weight := range weights {
+		totalWeight += weight
+	}
+
+	for i := range bandwidths {
+		bandwidths[i] += remaining * weights[i] / totalWeight
+	}
+
+	return bandwidths
+}
+
+func (ts *priorityTrafficShaper) AddTask(taskID string, ptc *peerTaskConductor) {
+	ts.Lock()
+	defer ts.Unlock()
+	priority := ptc.request.UrlMeta.GetPriority()
+	application := ptc.request.UrlMeta.GetApplication()
+	weight, ok := ts.applicationWeights[application]
+	if !ok {
+		weight = ts.priorityWeights[priority]
+	}
+
+	// the new task needs all of the bandwidth until its bandwidth is sampled
+	ts.tasks[taskID] = &priorityTaskEntry{
+		ptc:                 ptc,
+		pieceSize:           ts.computePieceSize(ptc.contentLength.Load()),
+		class:               fmt.Sprintf("%s/%s", priority, application),
+		weight:              math.Max(weight, 1),
+		lastSecondBandwidth: atomic.NewInt64(0),
+		needBandwidth:       float64(ts.totalRateLimit),
+	}
+	ts.allocate()
+}
+
+func (ts *priorityTrafficShaper) RemoveTask(taskID string) {
+	ts.Lock()
+	defer ts.Unlock()
+	if _, ok := ts.tasks[taskID]; !ok {
+		ts.Debugf("the task %s is already removed", taskID)
+		return
+	}
+
+	delete(ts.tasks, taskID)
+	ts.allocate()
+}
+
+func (ts *priorityTrafficShaper) Record(taskID string, n int) {
+	ts.usingBandWidth.Add(int64(n))
+	ts.RLock()
+	if task, ok := ts.tasks[taskID]; ok {
+		task.lastSecondBandwidth.Add(int64(n))
+	} else {
+		ts.Warnf("the task %s is not found when record it", taskID)
+	}
+	ts.RUnlock()
+}
+
+func (ts *priorityTrafficShaper) GetBandwidth() int64 {
+	return ts.lastSecondBandwidth.Load()
+}
+
+func (ts *priorityTrafficShaper) SetTotalRateLimit(totalRateLimit rate.Limit) {
+	ts.Lock()
+	defer ts.Unlock()
+	ts.totalRateLimit = totalRateLimit
+	ts.allocate()
+}
@@ -0,0 +1,223 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"fmt"
+	"testing"
+
+	testifyassert "github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+	"golang.org/x/time/rate"
+
+	commonv1 "d7y.io/api/pkg/apis/common/v1"
+	schedulerv1 "d7y.io/api/pkg/apis/scheduler/v1"
+
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/util"
+)
+
+func TestAllocateBandwidth(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   float64
+		needs   []float64
+		weights []float64
+		expect  []float64
+	}{
+		{
+			name:    "empty needs",
+			total:   100,
+			needs:   []float64{},
+			weights: []float64{},
+			expect:  []float64{},
+		},
+		{
+			name:    "allocate by weights",
+			total:   100,
+			needs:   []float64{100, 100},
+			weights: []float64{3, 1},
+			expect:  []float64{75, 25},
+		},
+		{
+			name:    "borrow the share not needed",
+			total:   100,
+			needs:   []float64{10, 100},
+			weights: []float64{3, 1},
+			expect:  []float64{10, 90},
+		},
+		{
+			name:    "borrow the share not needed by multiple levels",
+			total:   120,
+			needs:   []float64{10, 30, 100},
+			weights: []float64{1, 1, 1},
+			expect:  []float64{10, 30, 80},
+		},
+		{
+			name:    "share the bandwidth left",
+			total:   100,
+			needs:   []float64{10, 30},
+			weights: []float64{1, 2},
+			expect:  []float64{30, 70},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			bandwidths := allocateBandwidth(tc.total, tc.needs, tc.weights)
+			assert.Equal(len(bandwidths), len(tc.expect))
+			for i := range tc.expect {
+				assert.InDelta(bandwidths[i], tc.expect[i], 0.001)
+			}
+		})
+	}
+}
+
+func newPriorityPeerTaskConductor(taskID string, priority commonv1.Priority, application string) *peerTaskConductor {
+	return &peerTaskConductor{
+		taskID: taskID,
+		request: &schedulerv1.PeerTaskRequest{
+			UrlMeta: &commonv1.UrlMeta{
+				Priority:    priority,
+				Application: application,
+			},
+		},
+		limiter:         rate.NewLimiter(rate.Inf, 0),
+		contentLength:   atomic.NewInt64(-1),
+		completedLength: atomic.NewInt64(0),
+	}
+}
+
+func TestDefaultPriorityWeight(t *testing.T) {
+	assert := testifyassert.New(t)
+	assert.Equal(defaultPriorityWeight(commonv1.Priority_LEVEL0), float64(7))
+	assert.Equal(defaultPriorityWeight(commonv1.Priority_LEVEL1), float64(2))
+	assert.Equal(defaultPriorityWeight(commonv1.Priority_LEVEL6), float64(7))
+}
+
+func TestPriorityTrafficShaper(t *testing.T) {
+	assert := testifyassert.New(t)
+	ts := NewPriorityTrafficShaper(1000, func(int64) uint32 { return 10 }, config.PriorityTrafficShaperOption{
+		PriorityWeights:    map[string]int{"LEVEL0": 1, "foo": 2},
+		ApplicationWeights: map[string]int{"image": 3},
+	}).(*priorityTrafficShaper)
+
+	// batch tasks of the same class share the bandwidth of class.
+	batch0 := newPriorityPeerTaskConductor("batch-0", commonv1.Priority_LEVEL0, "dataset")
+	batch1 := newPriorityPeerTaskConductor("batch-1", commonv1.Priority_LEVEL0, "dataset")
+	ts.AddTask("batch-0", batch0)
+	ts.AddTask("batch-1", batch1)
+	assert.InDelta(float64(batch0.limiter.Limit()), 500, 0.001)
+	assert.InDelta(float64(batch1.limiter.Limit()), 500, 0.001)
+
+	// image pull is guaranteed the share by weight of application,
+	// after one piece is reserved for every task.
+	image := newPriorityPeerTaskConductor("image", commonv1.Priority_LEVEL0, "image")
+	ts.AddTask("image", image)
+	assert.InDelta(float64(image.limiter.Limit()), 737.5, 0.001)
+	assert.InDelta(float64(batch0.limiter.Limit()), 131.25, 0.001)
+	assert.InDelta(float64(batch1.limiter.Limit()), 131.25, 0.001)
+
+	// the bandwidth not used by image pull is borrowed by batch tasks.
+	ts.updateLimit()
+	ts.Record("image", 90)
+	ts.Record("batch-0", 125)
+	ts.Record("batch-1", 125)
+	ts.updateLimit()
+	assert.InDelta(float64(image.limiter.Limit()), 100, 0.001)
+	assert.InDelta(float64(batch0.limiter.Limit()), 450, 0.001)
+	assert.InDelta(float64(batch1.limiter.Limit()), 450, 0.001)
+
+	// the bandwidth is reallocated after the task is removed.
+	ts.RemoveTask("image")
+	ts.RemoveTask("image")
+	assert.InDelta(float64(batch0.limiter.Limit()), 500, 0.001)
+	assert.InDelta(float64(batch1.limiter.Limit()), 500, 0.001)
+
+	// the bandwidth is reallocated after the total rate limit is updated.
+	ts.SetTotalRateLimit(2000)
+	assert.InDelta(float64(batch0.limiter.Limit()), 1000, 0.001)
+	assert.InDelta(float64(batch1.limiter.Limit()), 1000, 0.001)
+}
+
+func TestPriorityTrafficShaper_allocate(t *testing.T) {
+	tests := []struct {
+		name           string
+		totalRateLimit rate.Limit
+		option         config.PriorityTrafficShaperOption
+		priorities     []commonv1.Priority
+		expect         []float64
+	}{
+		{
+			name:           "default priority is as high as LEVEL6",
+			totalRateLimit: 1000,
+			priorities:     []commonv1.Priority{commonv1.Priority_LEVEL0, commonv1.Priority_LEVEL6},
+			expect:         []float64{500, 500},
+		},
+		{
+			name:           "allocate by weights",
+			totalRateLimit: 1000,
+			priorities:     []commonv1.Priority{commonv1.Priority_LEVEL6, commonv1.Priority_LEVEL2},
+			expect:         []float64{696, 304},
+		},
+		{
+			name:           "class is guaranteed the min class rate limit",
+			totalRateLimit: 1000,
+			option: config.PriorityTrafficShaperOption{
+				MinClassRateLimit: util.RateLimit{Limit: 300},
+			},
+			priorities: []commonv1.Priority{commonv1.Priority_LEVEL6, commonv1.Priority_LEVEL2},
+			expect:     []float64{580, 420},
+		},
+		{
+			name:           "min class rate limit is reduced when the total rate limit is not enough",
+			totalRateLimit: 1000,
+			option: config.PriorityTrafficShaperOption{
+				MinClassRateLimit: util.RateLimit{Limit: 800},
+			},
+			priorities: []commonv1.Priority{commonv1.Priority_LEVEL6, commonv1.Priority_LEVEL2},
+			expect:     []float64{500, 500},
+		},
+		{
+			name:           "pieces are reduced when the total rate limit is not enough",
+			totalRateLimit: 15,
+			priorities:     []commonv1.Priority{commonv1.Priority_LEVEL6, commonv1.Priority_LEVEL6, commonv1.Priority_LEVEL2},
+			expect:         []float64{5, 5, 5},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			ts := NewPriorityTrafficShaper(tc.totalRateLimit, func(int64) uint32 { return 10 }, tc.option).(*priorityTrafficShaper)
+			var ptcs []*peerTaskConductor
+			for i, priority := range tc.priorities {
+				ptc := newPriorityPeerTaskConductor(fmt.Sprintf("task-%d", i), priority, "")
+				ts.AddTask(ptc.taskID, ptc)
+				ptcs = append(ptcs, ptc)
+			}
+
+			var total float64
+			for i, ptc := range ptcs {
+				assert.InDelta(float64(ptc.limiter.Limit()), tc.expect[i], 0.001)
+				total += float64(ptc.limiter.Limit())
+			}
+			assert.LessOrEqual(total, float64(tc.totalRateLimit)+0.001)
+		})
+	}
+}
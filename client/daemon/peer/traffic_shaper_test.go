@@ -257,7 +257,7 @@ func trafficShaperSetupMockManager(ctrl *gomock.Controller, ts *trafficShaperTes
 		runningPeerTasks: sync.Map{},
 		trafficShaper: NewTrafficShaper(opt.trafficShaperType, opt.totalRateLimit, func(contentLength int64) uint32 {
 			return opt.pieceSize
-		}),
+		}, config.PriorityTrafficShaperOption{}),
 		TaskManagerOption: TaskManagerOption{
 			SchedulerClient:  schedulerClient,
 			PerPeerRateLimit: opt.perPeerRateLimit,
@@ -491,7 +491,7 @@ func TestTrafficShaper_TaskSuite(t *testing.T) {
 		t.Run(_tc.name, func(t *testing.T) {
 			assert := testifyassert.New(t)
 			require := testifyrequire.New(t)
-			for _, trafficShaperType := range []string{"plain", "sampling"} {
+			for _, trafficShaperType := range []string{"plain", "sampling", "priority"} {
 				// dup a new test case with the task type
 				logger.Infof("-------------------- test %s, %s traffic shaper started --------------------",
 					_tc.name, trafficShaperType)
@@ -894,6 +894,7 @@ func (s *server) ImportTask(ctx context.Context, req *dfdaemonv1.ImportTaskReque
 			PeerID: peerID,
 			TaskID: taskID,
 		},
+		Digest: req.UrlMeta.GetDigest(),
 	})
 	if err != nil {
 		msg := fmt.Sprintf("register task to storage manager failed: %v", err)
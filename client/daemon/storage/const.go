@@ -30,5 +30,6 @@ const (
 )
 
 var (
-	ErrShortRead = errors.New("short read")
+	ErrShortRead           = errors.New("short read")
+	ErrPieceDigestMismatch = errors.New("piece digest mismatch")
 )
@@ -53,6 +53,8 @@ type localTaskStore struct {
 	lastAccess    atomic.Int64
 	reclaimMarked atomic.Bool
 	gcCallback    func(CommonTaskRequest)
+	// storeCallback is invoked after the task is stored, used by dedup store strategy
+	storeCallback func(*localTaskStore)
 
 	// when digest not match, invalid will be set
 	invalid atomic.Bool
@@ -159,6 +161,12 @@ func (t *localTaskStore) WritePiece(ctx context.Context, req *WritePieceRequest)
 		}
 	}
 
+	// verify the digest calculated by reader, the corrupted piece is discarded without metadata
+	if err := verifyPieceDigest(req.Reader, req.PieceMetadata.Md5); err != nil {
+		t.Errorf("piece %d is corrupted: %s", req.Num, err)
+		return n, err
+	}
+
 	// when Md5 is empty, try to get md5 from reader, it's useful for back source
 	if req.PieceMetadata.Md5 == "" {
 		t.Debugf("piece %d md5 not found in metadata, read from reader", req.PieceMetadata.Num)
@@ -184,6 +192,30 @@ func (t *localTaskStore) WritePiece(ctx context.Context, req *WritePieceRequest)
 	return n, nil
 }
 
+// verifyPieceDigest verifies the digest calculated by the reader of piece, the expected digest
+// is md5 encoded or in the form of algorithm:encoded, like sha256:xxx. It is skipped when the
+// expected digest is empty or the reader does not calculate digest.
+func verifyPieceDigest(reader io.Reader, expected string) error {
+	if expected == "" {
+		return nil
+	}
+
+	get, ok := reader.(digest.Reader)
+	if !ok {
+		return nil
+	}
+
+	encoded := expected
+	if d, err := digest.Parse(expected); err == nil {
+		encoded = d.Encoded
+	}
+
+	if actual := get.Encoded(); actual != encoded {
+		return fmt.Errorf("%w, desired: %s, actual: %s", ErrPieceDigestMismatch, encoded, actual)
+	}
+	return nil
+}
+
 func (t *localTaskStore) genMetadata(n int64, req *WritePieceRequest) {
 	if req.GenMetadata == nil {
 		return
@@ -359,6 +391,10 @@ func (t *localTaskStore) Store(ctx context.Context, req *StoreRequest) error {
 		}
 	}
 
+	if t.storeCallback != nil {
+		t.storeCallback(t)
+	}
+
 	if req.MetadataOnly {
 		return nil
 	}
@@ -107,6 +107,12 @@ func (t *localSubTaskStore) WritePiece(ctx context.Context, req *WritePieceReque
 		}
 	}
 
+	// verify the digest calculated by reader, the corrupted piece is discarded without metadata
+	if err := verifyPieceDigest(req.Reader, req.PieceMetadata.Md5); err != nil {
+		t.Errorf("piece %d is corrupted: %s", req.Num, err)
+		return n, err
+	}
+
 	// when Md5 is empty, try to get md5 from reader, it's useful for back source
 	if req.PieceMetadata.Md5 == "" {
 		t.Debugf("piece %d md5 not found in metadata, read from reader", req.PieceMetadata.Num)
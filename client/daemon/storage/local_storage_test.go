@@ -582,3 +582,197 @@ func TestLocalTaskStore_CanReclaim(t *testing.T) {
 		})
 	}
 }
+
+func TestStorageManager_DedupStoreStrategy(t *testing.T) {
+	assert := testifyassert.New(t)
+	testBytes := []byte("dedup test data")
+	contentDigest := digest.New(digest.AlgorithmSHA256, digest.SHA256FromStrings(string(testBytes))).String()
+
+	sm, err := NewStorageManager(config.DedupLocalTaskStoreStrategy,
+		&config.StorageOption{
+			DataPath: t.TempDir(),
+			TaskExpireTime: clientutil.Duration{
+				Duration: time.Minute,
+			},
+		}, func(request CommonTaskRequest) {
+		})
+	assert.Nil(err)
+
+	storeTask := func(taskID, peerID, contentDigest string) *localTaskStore {
+		ts, err := sm.RegisterTask(context.Background(), &RegisterTaskRequest{
+			PeerTaskMetadata: PeerTaskMetadata{
+				PeerID: peerID,
+				TaskID: taskID,
+			},
+			ContentLength: int64(len(testBytes)),
+			TotalPieces:   1,
+			Digest:        contentDigest,
+		})
+		assert.Nil(err)
+
+		_, err = ts.WritePiece(context.Background(), &WritePieceRequest{
+			PeerTaskMetadata: PeerTaskMetadata{
+				PeerID: peerID,
+				TaskID: taskID,
+			},
+			PieceMetadata: PieceMetadata{
+				Num:   0,
+				Md5:   calcPieceMd5(testBytes),
+				Range: http.Range{Start: 0, Length: int64(len(testBytes))},
+				Style: commonv1.PieceStyle_PLAIN,
+			},
+			Reader: bytes.NewBuffer(testBytes),
+		})
+		assert.Nil(err)
+
+		assert.Nil(ts.Store(context.Background(), &StoreRequest{
+			CommonTaskRequest: CommonTaskRequest{
+				PeerID: peerID,
+				TaskID: taskID,
+			},
+			MetadataOnly: true,
+			TotalPieces:  1,
+		}))
+		return ts.(*localTaskStore)
+	}
+
+	sameFile := func(a, b string) bool {
+		aStat, err := os.Stat(a)
+		assert.Nil(err)
+		bStat, err := os.Stat(b)
+		assert.Nil(err)
+		return os.SameFile(aStat, bStat)
+	}
+
+	// the content digest is verified and indexed asynchronously.
+	waitIndexed := func() {
+		sm.(*storageManager).indexDigestWaitGroup.Wait()
+	}
+
+	// the tasks with the same content share the data.
+	taskA := storeTask("task-a", "peer-a", contentDigest)
+	waitIndexed()
+	taskB := storeTask("task-b", "peer-b", contentDigest)
+	waitIndexed()
+	assert.True(sameFile(taskA.DataFilePath, taskB.DataFilePath))
+
+	// the task with mismatched digest is not indexed.
+	storeTask("task-c", "peer-c", digest.New(digest.AlgorithmSHA256, digest.SHA256FromStrings("foo")).String())
+	waitIndexed()
+	assert.Nil(sm.FindCompletedTaskByDigest("task-d", digest.New(digest.AlgorithmSHA256, digest.SHA256FromStrings("foo")).String()))
+	assert.Nil(sm.FindCompletedTaskByDigest("task-d", ""))
+
+	// the task with the same digest is linked to the completed task, and it is found by task id later.
+	assert.Nil(sm.FindCompletedTask("task-d"))
+	reuse := sm.FindCompletedTaskByDigest("task-d", contentDigest)
+	assert.NotNil(reuse)
+	assert.Equal(reuse.TaskID, "task-d")
+	assert.Equal(reuse.ContentLength, int64(len(testBytes)))
+	assert.Equal(reuse.TotalPieces, int32(1))
+	assert.NotNil(sm.FindCompletedTask("task-d"))
+
+	taskD := reuse.Storage.(*localTaskStore)
+	assert.True(sameFile(taskA.DataFilePath, taskD.DataFilePath))
+	assert.Equal(len(taskD.Pieces), 1)
+
+	// the data is kept when the origin tasks are reclaimed.
+	assert.Nil(sm.UnregisterTask(context.Background(), CommonTaskRequest{PeerID: "peer-a", TaskID: "task-a"}))
+	assert.Nil(sm.UnregisterTask(context.Background(), CommonTaskRequest{PeerID: "peer-b", TaskID: "task-b"}))
+	data, err := os.ReadFile(taskD.DataFilePath)
+	assert.Nil(err)
+	assert.Equal(data, testBytes)
+
+	reuse = sm.FindCompletedTaskByDigest("task-e", contentDigest)
+	assert.NotNil(reuse)
+	assert.Equal(reuse.PeerID, taskD.PeerID)
+	sm.CleanUp()
+}
+
+func TestLocalTaskStore_WritePiece_VerifyDigest(t *testing.T) {
+	testBytes := []byte("piece test data")
+	md5Encoded := calcPieceMd5(testBytes)
+	sha256Digest := digest.New(digest.AlgorithmSHA256, digest.SHA256FromStrings(string(testBytes))).String()
+
+	tests := []struct {
+		name      string
+		algorithm string
+		expected  string
+		expect    func(t *testing.T, ts *localTaskStore, n int64, err error)
+	}{
+		{
+			name:      "md5 matched",
+			algorithm: digest.AlgorithmMD5,
+			expected:  md5Encoded,
+			expect: func(t *testing.T, ts *localTaskStore, n int64, err error) {
+				assert := testifyassert.New(t)
+				assert.Nil(err)
+				assert.Equal(n, int64(len(testBytes)))
+				assert.Equal(len(ts.Pieces), 1)
+			},
+		},
+		{
+			name:      "sha256 matched",
+			algorithm: digest.AlgorithmSHA256,
+			expected:  sha256Digest,
+			expect: func(t *testing.T, ts *localTaskStore, n int64, err error) {
+				assert := testifyassert.New(t)
+				assert.Nil(err)
+				assert.Equal(len(ts.Pieces), 1)
+			},
+		},
+		{
+			name:      "md5 mismatched",
+			algorithm: digest.AlgorithmMD5,
+			expected:  calcPieceMd5([]byte("foo")),
+			expect: func(t *testing.T, ts *localTaskStore, n int64, err error) {
+				assert := testifyassert.New(t)
+				assert.ErrorIs(err, ErrPieceDigestMismatch)
+				assert.Equal(len(ts.Pieces), 0)
+			},
+		},
+		{
+			name:      "sha256 mismatched",
+			algorithm: digest.AlgorithmSHA256,
+			expected:  digest.New(digest.AlgorithmSHA256, digest.SHA256FromStrings("foo")).String(),
+			expect: func(t *testing.T, ts *localTaskStore, n int64, err error) {
+				assert := testifyassert.New(t)
+				assert.ErrorIs(err, ErrPieceDigestMismatch)
+				assert.Equal(len(ts.Pieces), 0)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			dataFile := path.Join(t.TempDir(), taskData)
+			assert.Nil(os.WriteFile(dataFile, nil, defaultFileMode))
+
+			ts := &localTaskStore{
+				SugaredLoggerOnWith: logger.With("test", "localTaskStore"),
+				persistentMetadata: persistentMetadata{
+					TaskID:       "test",
+					DataFilePath: dataFile,
+					Pieces:       map[int32]PieceMetadata{},
+				},
+			}
+
+			reader, err := digest.NewReader(tc.algorithm, bytes.NewBuffer(testBytes))
+			assert.Nil(err)
+
+			n, err := ts.WritePiece(context.Background(), &WritePieceRequest{
+				PeerTaskMetadata: PeerTaskMetadata{
+					TaskID: ts.TaskID,
+				},
+				PieceMetadata: PieceMetadata{
+					Num:   0,
+					Md5:   tc.expected,
+					Range: http.Range{Start: 0, Length: int64(len(testBytes))},
+					Style: commonv1.PieceStyle_PLAIN,
+				},
+				Reader: reader,
+			})
+			tc.expect(t, ts, n, err)
+		})
+	}
+}
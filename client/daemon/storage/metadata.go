@@ -37,6 +37,7 @@ type persistentMetadata struct {
 	DataFilePath  string                  `json:"dataFilePath"`
 	Done          bool                    `json:"done"`
 	Header        *source.Header          `json:"header"`
+	Digest        string                  `json:"digest,omitempty"`
 }
 
 type PeerTaskMetadata struct {
@@ -66,6 +67,8 @@ type RegisterTaskRequest struct {
 	ContentLength   int64
 	TotalPieces     int32
 	PieceMd5Sign    string
+	// Digest is the content digest of task, like sha256:xxx, used by dedup store strategy
+	Digest string
 }
 
 type WritePieceRequest struct {
@@ -327,6 +327,20 @@ func (mr *MockManagerMockRecorder) FindCompletedTask(taskID interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCompletedTask", reflect.TypeOf((*MockManager)(nil).FindCompletedTask), taskID)
 }
 
+// FindCompletedTaskByDigest mocks base method.
+func (m *MockManager) FindCompletedTaskByDigest(taskID, digest string) *storage.ReusePeerTask {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindCompletedTaskByDigest", taskID, digest)
+	ret0, _ := ret[0].(*storage.ReusePeerTask)
+	return ret0
+}
+
+// FindCompletedTaskByDigest indicates an expected call of FindCompletedTaskByDigest.
+func (mr *MockManagerMockRecorder) FindCompletedTaskByDigest(taskID, digest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCompletedTaskByDigest", reflect.TypeOf((*MockManager)(nil).FindCompletedTaskByDigest), taskID, digest)
+}
+
 // FindPartialCompletedTask mocks base method.
 func (m *MockManager) FindPartialCompletedTask(taskID string, rg *http.Range) *storage.ReusePeerTask {
 	m.ctrl.T.Helper()
@@ -45,6 +45,7 @@ import (
 	"d7y.io/dragonfly/v2/client/daemon/gc"
 	"d7y.io/dragonfly/v2/client/util"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/digest"
 	nethttp "d7y.io/dragonfly/v2/pkg/net/http"
 )
 
@@ -99,6 +100,9 @@ type Manager interface {
 	UnregisterTask(ctx context.Context, req CommonTaskRequest) error
 	// FindCompletedTask try to find a completed task for fast path
 	FindCompletedTask(taskID string) *ReusePeerTask
+	// FindCompletedTaskByDigest try to find a completed task with the same content digest,
+	// and links its data to the given task, only works with dedup store strategy
+	FindCompletedTaskByDigest(taskID, digest string) *ReusePeerTask
 	// FindCompletedSubTask try to find a completed subtask for fast path
 	FindCompletedSubTask(taskID string) *ReusePeerTask
 	// FindPartialCompletedTask try to find a partial completed task for fast path
@@ -139,6 +143,11 @@ type storageManager struct {
 
 	indexRWMutex       sync.RWMutex
 	indexTask2PeerTask map[string][]*localTaskStore // key: task id, value: slice of localTaskStore
+	// indexDigest2PeerTask is protected by indexRWMutex too
+	indexDigest2PeerTask map[string][]*localTaskStore // key: content digest, value: slice of completed localTaskStore
+	// indexingDigestTasks is the tasks verifying content digest before indexing, indexDigestWaitGroup waits them
+	indexingDigestTasks  sync.Map // key: *localTaskStore, value: struct{}
+	indexDigestWaitGroup sync.WaitGroup
 
 	subIndexRWMutex       sync.RWMutex
 	subIndexTask2PeerTask map[string][]*localSubTaskStore // key: task id, value: slice of localSubTaskStore
@@ -168,7 +177,7 @@ func NewStorageManager(storeStrategy config.StoreStrategy, opt *config.StorageOp
 		return nil, err
 	}
 	switch storeStrategy {
-	case config.SimpleLocalTaskStoreStrategy, config.AdvanceLocalTaskStoreStrategy:
+	case config.SimpleLocalTaskStoreStrategy, config.AdvanceLocalTaskStoreStrategy, config.DedupLocalTaskStoreStrategy:
 	case config.StoreStrategy(""):
 		storeStrategy = config.SimpleLocalTaskStoreStrategy
 	default:
@@ -183,6 +192,7 @@ func NewStorageManager(storeStrategy config.StoreStrategy, opt *config.StorageOp
 		gcCallback:            gcCallback,
 		gcInterval:            time.Minute,
 		indexTask2PeerTask:    map[string][]*localTaskStore{},
+		indexDigest2PeerTask:  map[string][]*localTaskStore{},
 		subIndexTask2PeerTask: map[string][]*localSubTaskStore{},
 	}
 
@@ -400,8 +410,10 @@ func (s *storageManager) CreateTask(req *RegisterTaskRequest) (TaskStorageDriver
 			PieceMd5Sign:  req.PieceMd5Sign,
 			PeerID:        req.PeerID,
 			Pieces:        map[int32]PieceMetadata{},
+			Digest:        req.Digest,
 		},
 		gcCallback:       s.gcCallback,
+		storeCallback:    s.indexDigest,
 		dataDir:          dataDir,
 		metadataFilePath: path.Join(dataDir, taskMetadata),
 		expireTime:       s.storeOption.TaskExpireTime.Duration,
@@ -414,13 +426,13 @@ func (s *storageManager) CreateTask(req *RegisterTaskRequest) (TaskStorageDriver
 	}
 	t.touch()
 
-	// fallback to simple strategy for proxy
-	if req.DesiredLocation == "" {
+	// fallback to simple strategy for proxy, dedup strategy always stores data in data directory
+	if req.DesiredLocation == "" && t.StoreStrategy != string(config.DedupLocalTaskStoreStrategy) {
 		t.StoreStrategy = string(config.SimpleLocalTaskStoreStrategy)
 	}
 	data := path.Join(dataDir, taskData)
 	switch t.StoreStrategy {
-	case string(config.SimpleLocalTaskStoreStrategy):
+	case string(config.SimpleLocalTaskStoreStrategy), string(config.DedupLocalTaskStoreStrategy):
 		t.DataFilePath = data
 		f, err := os.OpenFile(t.DataFilePath, os.O_CREATE|os.O_RDWR, defaultFileMode)
 		if err != nil {
@@ -513,6 +525,226 @@ func (s *storageManager) FindCompletedTask(taskID string) *ReusePeerTask {
 	return nil
 }
 
+func (s *storageManager) FindCompletedTaskByDigest(taskID, digest string) *ReusePeerTask {
+	if digest == "" || s.storeStrategy != config.DedupLocalTaskStoreStrategy {
+		return nil
+	}
+
+	s.indexRWMutex.RLock()
+	origin := findCompletedTask(s.indexDigest2PeerTask[digest])
+	s.indexRWMutex.RUnlock()
+	if origin == nil {
+		return nil
+	}
+
+	t, err := s.linkTask(taskID, origin)
+	if err != nil {
+		logger.Warnf("link task %s to task %s/%s with digest %s error: %s", taskID, origin.TaskID, origin.PeerID, digest, err)
+		return nil
+	}
+
+	logger.Infof("link task %s to task %s/%s with digest %s", taskID, origin.TaskID, origin.PeerID, digest)
+	return &ReusePeerTask{
+		Storage: t,
+		PeerTaskMetadata: PeerTaskMetadata{
+			PeerID: t.PeerID,
+			TaskID: taskID,
+		},
+		ContentLength: t.ContentLength,
+		TotalPieces:   t.TotalPieces,
+		Header:        t.Header,
+	}
+}
+
+// linkTask registers a completed task with the metadata of origin task, and its data is a hard link to the origin data.
+func (s *storageManager) linkTask(taskID string, origin *localTaskStore) (*localTaskStore, error) {
+	origin.RLock()
+	req := &RegisterTaskRequest{
+		PeerTaskMetadata: PeerTaskMetadata{
+			PeerID: origin.PeerID,
+			TaskID: taskID,
+		},
+		ContentLength: origin.ContentLength,
+		TotalPieces:   origin.TotalPieces,
+		PieceMd5Sign:  origin.PieceMd5Sign,
+		Digest:        origin.Digest,
+	}
+	pieces := make(map[int32]PieceMetadata, len(origin.Pieces))
+	for num, piece := range origin.Pieces {
+		pieces[num] = piece
+	}
+	header := origin.Header
+	origin.RUnlock()
+
+	ts, err := s.RegisterTask(context.Background(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	t := ts.(*localTaskStore)
+	if t.Done {
+		return t, nil
+	}
+
+	t.Lock()
+	err = relink(origin.DataFilePath, t.DataFilePath)
+	if err == nil {
+		t.Pieces = pieces
+		t.Header = header
+	}
+	t.Unlock()
+	if err != nil {
+		_ = s.deleteTask(req.PeerTaskMetadata)
+		return nil, err
+	}
+
+	// the data is linked to the verified data of origin task, index it before storing to skip verifying
+	s.indexRWMutex.Lock()
+	if ts := s.indexDigest2PeerTask[t.Digest]; !containsTask(ts, t) {
+		s.indexDigest2PeerTask[t.Digest] = append(ts, t)
+	}
+	s.indexRWMutex.Unlock()
+
+	if err := t.Store(context.Background(), &StoreRequest{
+		CommonTaskRequest: CommonTaskRequest{
+			PeerID: req.PeerID,
+			TaskID: req.TaskID,
+		},
+		MetadataOnly: true,
+		TotalPieces:  req.TotalPieces,
+	}); err != nil {
+		_ = s.deleteTask(req.PeerTaskMetadata)
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// indexDigest indexes the completed task of dedup store strategy by its content digest asynchronously,
+// the content is hashed in background, so storing task is not blocked by reading the whole file.
+func (s *storageManager) indexDigest(t *localTaskStore) {
+	if t.StoreStrategy != string(config.DedupLocalTaskStoreStrategy) || t.Digest == "" || !t.Done {
+		return
+	}
+
+	s.indexRWMutex.RLock()
+	indexed := containsTask(s.indexDigest2PeerTask[t.Digest], t)
+	s.indexRWMutex.RUnlock()
+	if indexed {
+		return
+	}
+
+	// the task is being verified
+	if _, loaded := s.indexingDigestTasks.LoadOrStore(t, struct{}{}); loaded {
+		return
+	}
+
+	s.indexDigestWaitGroup.Add(1)
+	go func() {
+		defer s.indexDigestWaitGroup.Done()
+		defer s.indexingDigestTasks.Delete(t)
+		s.indexVerifiedDigest(t)
+	}()
+}
+
+// indexVerifiedDigest verifies the content digest of task and indexes it, when there is a completed
+// task with the same content, the data of task is replaced with a hard link to the data of that task.
+func (s *storageManager) indexVerifiedDigest(t *localTaskStore) {
+	// the digest of request may not match the content, like ranged request, verify it before indexing
+	if err := verifyContentDigest(t.DataFilePath, t.Digest); err != nil {
+		t.Warnf("skip indexing task by digest: %s", err)
+		return
+	}
+
+	// the task may be reclaimed during verifying
+	if _, ok := s.tasks.Load(PeerTaskMetadata{PeerID: t.PeerID, TaskID: t.TaskID}); !ok || t.invalid.Load() {
+		t.Warnf("skip indexing task by digest: task is reclaimed or invalid")
+		return
+	}
+
+	s.indexRWMutex.Lock()
+	defer s.indexRWMutex.Unlock()
+	ts := s.indexDigest2PeerTask[t.Digest]
+	if containsTask(ts, t) {
+		return
+	}
+
+	if origin := findCompletedTask(ts); origin != nil && origin.DataFilePath != t.DataFilePath {
+		if err := relink(origin.DataFilePath, t.DataFilePath); err != nil {
+			t.Warnf("hard link data to task %s/%s error: %s", origin.TaskID, origin.PeerID, err)
+		} else {
+			t.Infof("dedup data with task %s/%s", origin.TaskID, origin.PeerID)
+		}
+	}
+
+	s.indexDigest2PeerTask[t.Digest] = append(ts, t)
+}
+
+// findCompletedTask returns the first valid and completed task.
+func findCompletedTask(ts []*localTaskStore) *localTaskStore {
+	for _, t := range ts {
+		if t.invalid.Load() {
+			continue
+		}
+		// touch it before marking reclaim
+		t.touch()
+		// already marked, skip
+		if t.reclaimMarked.Load() {
+			continue
+		}
+
+		if t.Done {
+			return t
+		}
+	}
+	return nil
+}
+
+func containsTask(ts []*localTaskStore, t *localTaskStore) bool {
+	for _, e := range ts {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyContentDigest verifies the content of file with the expected digest, like sha256:xxx.
+func verifyContentDigest(path, expected string) error {
+	d, err := digest.Parse(expected)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := digest.HashFile(path, d.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	if encoded != d.Encoded {
+		return fmt.Errorf("content digest %s does not match %s", digest.New(d.Algorithm, encoded), expected)
+	}
+	return nil
+}
+
+// relink replaces the target file with a hard link to the source file atomically.
+func relink(source, target string) error {
+	tmp := target + ".link"
+	if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := os.Link(source, tmp); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
 func (s *storageManager) FindPartialCompletedTask(taskID string, rg *nethttp.Range) *ReusePeerTask {
 	s.indexRWMutex.RLock()
 	defer s.indexRWMutex.RUnlock()
@@ -592,6 +824,7 @@ func (s *storageManager) cleanIndex(taskID, peerID string) {
 	for _, t := range ts {
 		if t.PeerID == peerID {
 			logger.Debugf("clean index for %s/%s", taskID, peerID)
+			s.cleanDigestIndex(t)
 			continue
 		}
 		remain = append(remain, t)
@@ -599,6 +832,27 @@ func (s *storageManager) cleanIndex(taskID, peerID string) {
 	s.indexTask2PeerTask[taskID] = remain
 }
 
+// cleanDigestIndex removes the task from digest index, the caller must hold indexRWMutex.
+func (s *storageManager) cleanDigestIndex(t *localTaskStore) {
+	ts, ok := s.indexDigest2PeerTask[t.Digest]
+	if !ok {
+		return
+	}
+
+	var remain []*localTaskStore
+	for _, e := range ts {
+		if e != t {
+			remain = append(remain, e)
+		}
+	}
+
+	if len(remain) == 0 {
+		delete(s.indexDigest2PeerTask, t.Digest)
+		return
+	}
+	s.indexDigest2PeerTask[t.Digest] = remain
+}
+
 func (s *storageManager) cleanSubIndex(taskID, peerID string) {
 	s.subIndexRWMutex.Lock()
 	defer s.subIndexRWMutex.Unlock()
@@ -682,6 +936,7 @@ func (s *storageManager) ReloadPersistentTask(gcCallback GCCallback) error {
 				metadataFilePath:    path.Join(dataDir, taskMetadata),
 				expireTime:          s.storeOption.TaskExpireTime.Duration,
 				gcCallback:          gcCallback,
+				storeCallback:       s.indexDigest,
 				SugaredLoggerOnWith: logger.With("task", taskID, "peer", peerID, "component", s.storeStrategy),
 			}
 			t.touch()
@@ -726,6 +981,10 @@ func (s *storageManager) ReloadPersistentTask(gcCallback GCCallback) error {
 			} else {
 				s.indexTask2PeerTask[taskID] = []*localTaskStore{t}
 			}
+
+			if t.StoreStrategy == string(config.DedupLocalTaskStoreStrategy) && t.Digest != "" && t.Done {
+				s.indexDigest2PeerTask[t.Digest] = append(s.indexDigest2PeerTask[t.Digest], t)
+			}
 		}
 	}
 	// remove load error peer tasks
@@ -910,6 +1169,7 @@ func (s *storageManager) UnregisterTask(ctx context.Context, req CommonTaskReque
 }
 
 func (s *storageManager) CleanUp() {
+	s.indexDigestWaitGroup.Wait()
 	_, _ = s.forceGC()
 }
 
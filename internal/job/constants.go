@@ -24,7 +24,25 @@ const (
 
 // Job Name.
 const (
-	PreheatJob = "preheat"
+	PreheatJob    = "preheat"
+	DeleteTaskJob = "delete_task"
+	GetTaskJob    = "get_task"
+	DrainHostJob  = "drain_host"
+)
+
+// Preheat scope.
+const (
+	// PreheatScopeSeedPeer preheats the seed peers, it is the default scope.
+	PreheatScopeSeedPeer = "seed_peer"
+
+	// PreheatScopeNormalPeer preheats the normal peers.
+	PreheatScopeNormalPeer = "normal_peer"
+)
+
+// Job progress configuration.
+const (
+	// JobProgressNamespace is the prefix of keys of job progresses in result backend.
+	JobProgressNamespace = "job:progresses"
 )
 
 // Machinery server configuration.
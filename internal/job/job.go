@@ -47,6 +47,9 @@ type Job struct {
 	Server *machinery.Server
 	Worker *machinery.Worker
 	Queue  Queue
+
+	// backend is the redis client of result backend, which stores the progresses of jobs.
+	backend redis.UniversalClient
 }
 
 func New(cfg *Config, queue Queue) (*Job, error) {
@@ -63,13 +66,14 @@ func New(cfg *Config, queue Queue) (*Job, error) {
 		return nil, err
 	}
 
-	if err := ping(&redis.UniversalOptions{
+	backend := redis.NewUniversalClient(&redis.UniversalOptions{
 		Addrs:      cfg.Addrs,
 		MasterName: cfg.MasterName,
 		Username:   cfg.Username,
 		Password:   cfg.Password,
 		DB:         cfg.BackendDB,
-	}); err != nil {
+	})
+	if err := backend.Ping(context.Background()).Err(); err != nil {
 		return nil, err
 	}
 
@@ -92,8 +96,9 @@ func New(cfg *Config, queue Queue) (*Job, error) {
 	}
 
 	return &Job{
-		Server: server,
-		Queue:  queue,
+		Server:  server,
+		Queue:   queue,
+		backend: backend,
 	}, nil
 }
 
@@ -116,6 +121,51 @@ type GroupJobState struct {
 	State     string
 	CreatedAt time.Time
 	JobStates []*machineryv1tasks.TaskState
+
+	// JobProgresses are the progresses reported by the jobs in group, the key is the job uuid.
+	JobProgresses map[string]json.RawMessage `json:",omitempty"`
+}
+
+// SetJobProgress stores the progress of the job, it is overwritten by the next report
+// and expires with the results of jobs.
+func (t *Job) SetJobProgress(ctx context.Context, jobID string, progress any) error {
+	b, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+
+	return t.backend.Set(ctx, jobProgressKey(jobID), b, DefaultResultsExpireIn*time.Second).Err()
+}
+
+// GetJobProgresses returns the progresses of the jobs, the jobs without progress are ignored.
+func (t *Job) GetJobProgresses(ctx context.Context, jobIDs ...string) (map[string]json.RawMessage, error) {
+	progresses := make(map[string]json.RawMessage)
+	if len(jobIDs) == 0 {
+		return progresses, nil
+	}
+
+	keys := make([]string, 0, len(jobIDs))
+	for _, jobID := range jobIDs {
+		keys = append(keys, jobProgressKey(jobID))
+	}
+
+	values, err := t.backend.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, value := range values {
+		if progress, ok := value.(string); ok {
+			progresses[jobIDs[i]] = json.RawMessage(progress)
+		}
+	}
+
+	return progresses, nil
+}
+
+// jobProgressKey returns the key of job progress in result backend.
+func jobProgressKey(jobID string) string {
+	return fmt.Sprintf("%s:%s", JobProgressNamespace, jobID)
 }
 
 func (t *Job) GetGroupJobState(groupID string) (*GroupJobState, error) {
@@ -128,6 +178,17 @@ func (t *Job) GetGroupJobState(groupID string) (*GroupJobState, error) {
 		return nil, errors.New("empty group")
 	}
 
+	var jobIDs []string
+	for _, taskState := range taskStates {
+		jobIDs = append(jobIDs, taskState.TaskUUID)
+	}
+
+	// Progresses are informative, the state of group is returned without them.
+	jobProgresses, err := t.GetJobProgresses(context.Background(), jobIDs...)
+	if err != nil {
+		logger.Warnf("get job progresses of group %s failed: %s", groupID, err.Error())
+	}
+
 	for _, taskState := range taskStates {
 		if taskState.IsFailure() {
 			logger.WithGroupAndTaskID(groupID, taskState.TaskUUID).Errorf("task is failed: %#v", taskState)
@@ -136,6 +197,8 @@ func (t *Job) GetGroupJobState(groupID string) (*GroupJobState, error) {
 				State:     machineryv1tasks.StateFailure,
 				CreatedAt: taskState.CreatedAt,
 				JobStates: taskStates,
+
+				JobProgresses: jobProgresses,
 			}, nil
 		}
 	}
@@ -148,6 +211,8 @@ func (t *Job) GetGroupJobState(groupID string) (*GroupJobState, error) {
 				State:     machineryv1tasks.StatePending,
 				CreatedAt: taskState.CreatedAt,
 				JobStates: taskStates,
+
+				JobProgresses: jobProgresses,
 			}, nil
 		}
 	}
@@ -157,6 +222,8 @@ func (t *Job) GetGroupJobState(groupID string) (*GroupJobState, error) {
 		State:     machineryv1tasks.StateSuccess,
 		CreatedAt: taskStates[0].CreatedAt,
 		JobStates: taskStates,
+
+		JobProgresses: jobProgresses,
 	}, nil
 }
 
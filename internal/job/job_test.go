@@ -17,10 +17,14 @@
 package job
 
 import (
+	"context"
+	"encoding/json"
 	"reflect"
 	"testing"
+	"time"
 
 	machineryv1tasks "github.com/RichardKnop/machinery/v1/tasks"
+	"github.com/alicebob/miniredis/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -293,3 +297,234 @@ func TestUnmarshalRequest(t *testing.T) {
 		})
 	}
 }
+
+func newTestJob(t *testing.T) (*Job, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	job, err := New(&Config{Addrs: []string{mr.Addr()}}, GlobalQueue)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return job, mr
+}
+
+func TestJob_SetJobProgress(t *testing.T) {
+	tests := []struct {
+		name   string
+		run    func(job *Job, mr *miniredis.Miniredis) error
+		expect func(t *testing.T, progresses map[string]json.RawMessage, err error)
+	}{
+		{
+			name: "set job progress",
+			run: func(job *Job, mr *miniredis.Miniredis) error {
+				return job.SetJobProgress(context.Background(), "foo", map[string]int{"count": 1})
+			},
+			expect: func(t *testing.T, progresses map[string]json.RawMessage, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(progresses, map[string]json.RawMessage{"foo": json.RawMessage(`{"count":1}`)})
+			},
+		},
+		{
+			name: "job progress is overwritten by the next report",
+			run: func(job *Job, mr *miniredis.Miniredis) error {
+				if err := job.SetJobProgress(context.Background(), "foo", map[string]int{"count": 1}); err != nil {
+					return err
+				}
+
+				return job.SetJobProgress(context.Background(), "foo", map[string]int{"count": 2})
+			},
+			expect: func(t *testing.T, progresses map[string]json.RawMessage, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(progresses, map[string]json.RawMessage{"foo": json.RawMessage(`{"count":2}`)})
+			},
+		},
+		{
+			name: "job progress expires with the results of jobs",
+			run: func(job *Job, mr *miniredis.Miniredis) error {
+				if err := job.SetJobProgress(context.Background(), "foo", map[string]int{"count": 1}); err != nil {
+					return err
+				}
+
+				mr.FastForward(DefaultResultsExpireIn*time.Second + time.Second)
+				return nil
+			},
+			expect: func(t *testing.T, progresses map[string]json.RawMessage, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(len(progresses), 0)
+			},
+		},
+		{
+			name: "marshal job progress failed",
+			run: func(job *Job, mr *miniredis.Miniredis) error {
+				return job.SetJobProgress(context.Background(), "foo", make(chan int))
+			},
+			expect: func(t *testing.T, progresses map[string]json.RawMessage, err error) {
+				assert := assert.New(t)
+				assert.Error(err)
+				assert.Equal(len(progresses), 0)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			job, mr := newTestJob(t)
+			err := tc.run(job, mr)
+			progresses, getErr := job.GetJobProgresses(context.Background(), "foo")
+			if getErr != nil {
+				t.Fatal(getErr)
+			}
+
+			tc.expect(t, progresses, err)
+		})
+	}
+}
+
+func TestJob_GetJobProgresses(t *testing.T) {
+	tests := []struct {
+		name   string
+		jobIDs []string
+		expect func(t *testing.T, progresses map[string]json.RawMessage, err error)
+	}{
+		{
+			name:   "get job progresses",
+			jobIDs: []string{"foo", "bar"},
+			expect: func(t *testing.T, progresses map[string]json.RawMessage, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(progresses, map[string]json.RawMessage{
+					"foo": json.RawMessage(`"foo"`),
+					"bar": json.RawMessage(`"bar"`),
+				})
+			},
+		},
+		{
+			name:   "jobs without progress are ignored",
+			jobIDs: []string{"foo", "baz"},
+			expect: func(t *testing.T, progresses map[string]json.RawMessage, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(progresses, map[string]json.RawMessage{"foo": json.RawMessage(`"foo"`)})
+			},
+		},
+		{
+			name:   "get job progresses without jobs",
+			jobIDs: []string{},
+			expect: func(t *testing.T, progresses map[string]json.RawMessage, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(len(progresses), 0)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			job, _ := newTestJob(t)
+			for _, jobID := range []string{"foo", "bar"} {
+				if err := job.SetJobProgress(context.Background(), jobID, jobID); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			progresses, err := job.GetJobProgresses(context.Background(), tc.jobIDs...)
+			tc.expect(t, progresses, err)
+		})
+	}
+}
+
+func TestJob_GetGroupJobState(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(t *testing.T, job *Job, signatures []*machineryv1tasks.Signature)
+		expect func(t *testing.T, state *GroupJobState, err error)
+	}{
+		{
+			name: "group is pending with progresses",
+			mock: func(t *testing.T, job *Job, signatures []*machineryv1tasks.Signature) {
+				if err := job.Server.GetBackend().SetStateSuccess(signatures[0], nil); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := job.Server.GetBackend().SetStateStarted(signatures[1]); err != nil {
+					t.Fatal(err)
+				}
+
+				for _, signature := range signatures {
+					if err := job.SetJobProgress(context.Background(), signature.UUID, signature.UUID); err != nil {
+						t.Fatal(err)
+					}
+				}
+			},
+			expect: func(t *testing.T, state *GroupJobState, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(state.GroupUUID, "group")
+				assert.Equal(state.State, machineryv1tasks.StatePending)
+				assert.Equal(len(state.JobStates), 2)
+				assert.Equal(state.JobProgresses, map[string]json.RawMessage{
+					"foo": json.RawMessage(`"foo"`),
+					"bar": json.RawMessage(`"bar"`),
+				})
+			},
+		},
+		{
+			name: "group is failed with progresses",
+			mock: func(t *testing.T, job *Job, signatures []*machineryv1tasks.Signature) {
+				if err := job.Server.GetBackend().SetStateSuccess(signatures[0], nil); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := job.Server.GetBackend().SetStateFailure(signatures[1], "baz"); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := job.SetJobProgress(context.Background(), "bar", "bar"); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expect: func(t *testing.T, state *GroupJobState, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(state.State, machineryv1tasks.StateFailure)
+				assert.Equal(state.JobProgresses, map[string]json.RawMessage{"bar": json.RawMessage(`"bar"`)})
+			},
+		},
+		{
+			name: "group is succeeded without progresses",
+			mock: func(t *testing.T, job *Job, signatures []*machineryv1tasks.Signature) {
+				for _, signature := range signatures {
+					if err := job.Server.GetBackend().SetStateSuccess(signature, nil); err != nil {
+						t.Fatal(err)
+					}
+				}
+			},
+			expect: func(t *testing.T, state *GroupJobState, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(state.State, machineryv1tasks.StateSuccess)
+				assert.Equal(len(state.JobProgresses), 0)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			job, _ := newTestJob(t)
+			signatures := []*machineryv1tasks.Signature{
+				{UUID: "foo", GroupUUID: "group", Name: PreheatJob},
+				{UUID: "bar", GroupUUID: "group", Name: PreheatJob},
+			}
+			if err := job.Server.GetBackend().InitGroup("group", []string{"foo", "bar"}); err != nil {
+				t.Fatal(err)
+			}
+
+			tc.mock(t, job, signatures)
+			state, err := job.GetGroupJobState("group")
+			tc.expect(t, state, err)
+		})
+	}
+}
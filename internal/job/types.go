@@ -16,15 +16,261 @@
 
 package job
 
+import "time"
+
 type PreheatRequest struct {
-	URL         string            `json:"url" validate:"required,url"`
+	URL             string            `json:"url" validate:"required,url"`
+	Tag             string            `json:"tag" validate:"omitempty"`
+	Digest          string            `json:"digest" validate:"omitempty"`
+	Filter          string            `json:"filter" validate:"omitempty"`
+	Headers         map[string]string `json:"headers" validate:"omitempty"`
+	Application     string            `json:"application" validate:"omitempty"`
+	Priority        int32             `json:"priority" validate:"omitempty"`
+	Scope           string            `json:"scope" validate:"omitempty,oneof=seed_peer normal_peer"`
+	PeerCount       uint32            `json:"peer_count" validate:"omitempty"`
+	IDC             string            `json:"idc" validate:"omitempty"`
+	Location        string            `json:"location" validate:"omitempty"`
+	HostnamePattern string            `json:"hostname_pattern" validate:"omitempty"`
+}
+
+// PreheatResponse is the progress of preheating a url, it is reported
+// by the scheduler during preheating and returned as the result of job.
+type PreheatResponse struct {
+	// URL is the preheated url.
+	URL string `json:"url"`
+
+	// TaskID is the id of task.
+	TaskID string `json:"task_id"`
+
+	// SchedulerHostname is the hostname of scheduler handling the job.
+	SchedulerHostname string `json:"scheduler_hostname"`
+
+	// SchedulerIP is the ip of scheduler handling the job.
+	SchedulerIP string `json:"scheduler_ip"`
+
+	// Peers are the progresses of peers preheating the url.
+	Peers []*PreheatPeerResponse `json:"peers"`
+
+	// Elapsed is the elapsed time of preheating.
+	Elapsed time.Duration `json:"elapsed"`
+
+	// Done indicates whether preheating is finished.
+	Done bool `json:"done"`
+
+	// Error is the error message of failed preheating.
+	Error string `json:"error,omitempty"`
+
+	// StartedAt is the time of preheating started.
+	StartedAt time.Time `json:"started_at"`
+
+	// UpdatedAt is the time of progress reported.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PreheatPeerResponse is the progress of a peer preheating the url.
+type PreheatPeerResponse struct {
+	// ID is the id of peer downloading the task.
+	ID string `json:"id,omitempty"`
+
+	// HostID is the id of host.
+	HostID string `json:"host_id,omitempty"`
+
+	// Hostname is the hostname of host.
+	Hostname string `json:"hostname,omitempty"`
+
+	// IP is the ip of host.
+	IP string `json:"ip,omitempty"`
+
+	// FinishedPieceCount is the count of pieces downloaded by peer.
+	FinishedPieceCount int32 `json:"finished_piece_count"`
+
+	// TotalPieceCount is the total piece count of task, it is known after preheating succeeded.
+	TotalPieceCount int32 `json:"total_piece_count"`
+
+	// CompletedLength is the length of pieces downloaded by peer.
+	CompletedLength int64 `json:"completed_length"`
+
+	// ContentLength is the content length of task, it is known after preheating succeeded.
+	ContentLength int64 `json:"content_length"`
+
+	// Done indicates whether the peer finished preheating.
+	Done bool `json:"done"`
+
+	// Error is the error message of failed peer.
+	Error string `json:"error,omitempty"`
+}
+
+// DeleteTaskRequest evicts the task from the scheduler and the hosts holding it,
+// the task is found by the task id, or by the url and the metadata generating task id.
+type DeleteTaskRequest struct {
+	TaskID      string            `json:"task_id" validate:"required_without=URL"`
+	URL         string            `json:"url" validate:"omitempty,url"`
 	Tag         string            `json:"tag" validate:"omitempty"`
 	Digest      string            `json:"digest" validate:"omitempty"`
 	Filter      string            `json:"filter" validate:"omitempty"`
 	Headers     map[string]string `json:"headers" validate:"omitempty"`
 	Application string            `json:"application" validate:"omitempty"`
-	Priority    int32             `json:"priority" validate:"omitempty"`
 }
 
-type PreheatResponse struct {
+// DeleteTaskResponse is the result of evicting the task in a scheduler.
+type DeleteTaskResponse struct {
+	// TaskID is the id of task.
+	TaskID string `json:"task_id"`
+
+	// URL is the url of task.
+	URL string `json:"url,omitempty"`
+
+	// SchedulerHostname is the hostname of scheduler handling the job.
+	SchedulerHostname string `json:"scheduler_hostname"`
+
+	// SchedulerIP is the ip of scheduler handling the job.
+	SchedulerIP string `json:"scheduler_ip"`
+
+	// SucceededHosts are the hosts deleted the task.
+	SucceededHosts []*DeleteTaskHostResponse `json:"succeeded_hosts"`
+
+	// FailedHosts are the hosts failed to delete the task.
+	FailedHosts []*DeleteTaskHostResponse `json:"failed_hosts"`
+}
+
+// DeleteTaskHostResponse is the result of deleting the task in a host.
+type DeleteTaskHostResponse struct {
+	// ID is the id of host.
+	ID string `json:"id"`
+
+	// Hostname is the hostname of host.
+	Hostname string `json:"hostname"`
+
+	// IP is the ip of host.
+	IP string `json:"ip"`
+
+	// Error is the error message of failed deletion.
+	Error string `json:"error,omitempty"`
+}
+
+// GetTaskRequest finds the peers holding the task in the scheduler, the task is found
+// by the task id, or by the url and the metadata generating task id.
+type GetTaskRequest struct {
+	TaskID      string            `json:"task_id" validate:"required_without=URL"`
+	URL         string            `json:"url" validate:"omitempty,url"`
+	Tag         string            `json:"tag" validate:"omitempty"`
+	Digest      string            `json:"digest" validate:"omitempty"`
+	Filter      string            `json:"filter" validate:"omitempty"`
+	Headers     map[string]string `json:"headers" validate:"omitempty"`
+	Application string            `json:"application" validate:"omitempty"`
+}
+
+// GetTaskResponse is the result of finding the task in a scheduler.
+type GetTaskResponse struct {
+	// TaskID is the id of task.
+	TaskID string `json:"task_id"`
+
+	// URL is the url of task.
+	URL string `json:"url,omitempty"`
+
+	// SchedulerHostname is the hostname of scheduler handling the job.
+	SchedulerHostname string `json:"scheduler_hostname"`
+
+	// SchedulerIP is the ip of scheduler handling the job.
+	SchedulerIP string `json:"scheduler_ip"`
+
+	// State is the state of task, it is empty if the task is not found.
+	State string `json:"state,omitempty"`
+
+	// ContentLength is the content length of task, it is -1 if the length is unknown.
+	ContentLength int64 `json:"content_length"`
+
+	// TotalPieceCount is the total piece count of task.
+	TotalPieceCount int32 `json:"total_piece_count"`
+
+	// Peers are the peers holding the task.
+	Peers []*GetTaskPeerResponse `json:"peers"`
+}
+
+// GetTaskPeerResponse is the copy of the task held by a peer.
+type GetTaskPeerResponse struct {
+	// ID is the id of peer.
+	ID string `json:"id"`
+
+	// State is the state of peer.
+	State string `json:"state"`
+
+	// HostID is the id of host.
+	HostID string `json:"host_id"`
+
+	// HostType is the type of host.
+	HostType string `json:"host_type"`
+
+	// Hostname is the hostname of host.
+	Hostname string `json:"hostname"`
+
+	// IP is the ip of host.
+	IP string `json:"ip"`
+
+	// Port is the grpc port of host.
+	Port int32 `json:"port"`
+
+	// FinishedPieceCount is the count of pieces finished by peer.
+	FinishedPieceCount uint `json:"finished_piece_count"`
+
+	// CompletedLength is the length of pieces finished by peer.
+	CompletedLength int64 `json:"completed_length"`
+
+	// Percentage is the percentage of pieces finished by peer, it is zero
+	// if the total piece count of task is unknown.
+	Percentage float64 `json:"percentage"`
+
+	// CreatedAt is the time of peer created.
+	CreatedAt time.Time `json:"created_at"`
+
+	// UpdatedAt is the time of peer updated.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DrainHostRequest marks the hosts as draining in the scheduler, the draining hosts
+// are not selected as the parents of new children, and the children downloading
+// from them are migrated to other parents. The hosts are found by the host id,
+// or by the hostname.
+type DrainHostRequest struct {
+	HostID   string `json:"host_id" validate:"required_without=Hostname"`
+	Hostname string `json:"hostname" validate:"omitempty"`
+
+	// Cancel cancels draining of the hosts, the hosts can be selected as parents again.
+	Cancel bool `json:"cancel" validate:"omitempty"`
+}
+
+// DrainHostResponse is the result of draining the hosts in a scheduler.
+type DrainHostResponse struct {
+	// SchedulerHostname is the hostname of scheduler handling the job.
+	SchedulerHostname string `json:"scheduler_hostname"`
+
+	// SchedulerIP is the ip of scheduler handling the job.
+	SchedulerIP string `json:"scheduler_ip"`
+
+	// Hosts are the hosts found in scheduler.
+	Hosts []*DrainHostHostResponse `json:"hosts"`
+}
+
+// DrainHostHostResponse is the state of draining a host.
+type DrainHostHostResponse struct {
+	// ID is the id of host.
+	ID string `json:"id"`
+
+	// Hostname is the hostname of host.
+	Hostname string `json:"hostname"`
+
+	// IP is the ip of host.
+	IP string `json:"ip"`
+
+	// Draining is true if the host is draining.
+	Draining bool `json:"draining"`
+
+	// Drained is true if the host is draining and has no children downloading from it.
+	Drained bool `json:"drained"`
+
+	// MigratedChildCount is the count of children migrated to other parents.
+	MigratedChildCount int `json:"migrated_child_count"`
+
+	// ConcurrentUploadCount is the count of children downloading from the host.
+	ConcurrentUploadCount int32 `json:"concurrent_upload_count"`
 }
@@ -105,6 +105,11 @@ func MakeApplicationsCacheKey() string {
 	return MakeNamespaceCacheKey(ApplicationsNamespace)
 }
 
+// Make back-to-source quotas of applications cache key.
+func MakeApplicationBackToSourceQuotasCacheKey() string {
+	return MakeCacheKey(ApplicationsNamespace, "back-to-source-quotas")
+}
+
 // Make cache key for bucket.
 func MakeBucketCacheKey(name string) string {
 	return MakeCacheKey(BucketsNamespace, name)
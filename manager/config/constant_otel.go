@@ -22,10 +22,17 @@ const (
 	AttributeID          = attribute.Key("d7y.manager.id")
 	AttributePreheatType = attribute.Key("d7y.manager.preheat.type")
 	AttributePreheatURL  = attribute.Key("d7y.manager.preheat.url")
+	AttributeTaskID      = attribute.Key("d7y.manager.task.id")
+	AttributeTaskURL     = attribute.Key("d7y.manager.task.url")
+	AttributeHostID      = attribute.Key("d7y.manager.host.id")
+	AttributeHostname    = attribute.Key("d7y.manager.host.hostname")
 )
 
 const (
 	SpanPreheat          = "preheat"
 	SpanGetLayers        = "get-layers"
 	SpanAuthWithRegistry = "auth-with-registry"
+	SpanDeleteTask       = "delete-task"
+	SpanGetTask          = "get-task"
+	SpanDrainHost        = "drain-host"
 )
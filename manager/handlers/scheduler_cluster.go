@@ -17,6 +17,7 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -44,6 +45,11 @@ func (h *Handlers) CreateSchedulerCluster(ctx *gin.Context) {
 		return
 	}
 
+	if err := validateSchedulerClusterConfig(json.Config); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": err.Error()})
+		return
+	}
+
 	schedulerCluster, err := h.service.CreateSchedulerCluster(ctx.Request.Context(), json)
 	if err != nil {
 		ctx.Error(err) // nolint: errcheck
@@ -104,6 +110,11 @@ func (h *Handlers) UpdateSchedulerCluster(ctx *gin.Context) {
 		return
 	}
 
+	if err := validateSchedulerClusterConfig(json.Config); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": err.Error()})
+		return
+	}
+
 	schedulerCluster, err := h.service.UpdateSchedulerCluster(ctx.Request.Context(), params.ID, json)
 	if err != nil {
 		ctx.Error(err) // nolint: errcheck
@@ -197,3 +208,17 @@ func (h *Handlers) AddSchedulerToSchedulerCluster(ctx *gin.Context) {
 
 	ctx.Status(http.StatusOK)
 }
+
+// validateSchedulerClusterConfig validates the config of scheduler cluster
+// which can not be validated by binding.
+func validateSchedulerClusterConfig(config *types.SchedulerClusterConfig) error {
+	if config == nil || config.EvaluatorWeights == nil {
+		return nil
+	}
+
+	if err := config.EvaluatorWeights.Validate(); err != nil {
+		return fmt.Errorf("invalid evaluator weights: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+//go:generate mockgen -destination mocks/delete_task_mock.go -source delete_task.go -package mocks
+
+package job
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+
+	internaljob "d7y.io/dragonfly/v2/internal/job"
+	"d7y.io/dragonfly/v2/manager/config"
+	"d7y.io/dragonfly/v2/manager/models"
+	"d7y.io/dragonfly/v2/manager/types"
+)
+
+// DeleteTask is the interface used for deleting task in scheduler clusters.
+type DeleteTask interface {
+	// CreateDeleteTask creates a group job which evicts the task in schedulers,
+	// and deletes the task in the hosts of peers holding the task.
+	CreateDeleteTask(context.Context, []models.Scheduler, types.DeleteTaskArgs) (*internaljob.GroupJobState, error)
+}
+
+// deleteTask implements DeleteTask.
+type deleteTask struct {
+	job *internaljob.Job
+}
+
+// newDeleteTask returns a new DeleteTask interface.
+func newDeleteTask(job *internaljob.Job) DeleteTask {
+	return &deleteTask{
+		job: job,
+	}
+}
+
+// CreateDeleteTask creates a group job which evicts the task in schedulers,
+// and deletes the task in the hosts of peers holding the task.
+func (d *deleteTask) CreateDeleteTask(ctx context.Context, schedulers []models.Scheduler, json types.DeleteTaskArgs) (*internaljob.GroupJobState, error) {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, config.SpanDeleteTask, trace.WithSpanKind(trace.SpanKindProducer))
+	span.SetAttributes(config.AttributeTaskID.String(json.TaskID))
+	span.SetAttributes(config.AttributeTaskURL.String(json.URL))
+	defer span.End()
+
+	args, err := internaljob.MarshalRequest(internaljob.DeleteTaskRequest{
+		TaskID:      json.TaskID,
+		URL:         json.URL,
+		Tag:         json.Tag,
+		Digest:      json.Digest,
+		Filter:      json.Filter,
+		Headers:     json.Headers,
+		Application: json.Application,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Every scheduler manages the tasks of its own, the task is evicted in all of the schedulers.
+	return sendGroupJob(ctx, d.job, internaljob.DeleteTaskJob, args, getSchedulerQueues(schedulers))
+}
@@ -0,0 +1,71 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+//go:generate mockgen -destination mocks/drain_host_mock.go -source drain_host.go -package mocks
+
+package job
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+
+	internaljob "d7y.io/dragonfly/v2/internal/job"
+	"d7y.io/dragonfly/v2/manager/config"
+	"d7y.io/dragonfly/v2/manager/models"
+	"d7y.io/dragonfly/v2/manager/types"
+)
+
+// DrainHost is the interface used for draining hosts in scheduler clusters.
+type DrainHost interface {
+	// CreateDrainHost creates a group job which marks the hosts as draining in schedulers,
+	// and waits until the children downloading from the hosts are migrated.
+	CreateDrainHost(context.Context, []models.Scheduler, types.DrainHostArgs) (*internaljob.GroupJobState, error)
+}
+
+// drainHost implements DrainHost.
+type drainHost struct {
+	job *internaljob.Job
+}
+
+// newDrainHost returns a new DrainHost interface.
+func newDrainHost(job *internaljob.Job) DrainHost {
+	return &drainHost{
+		job: job,
+	}
+}
+
+// CreateDrainHost creates a group job which marks the hosts as draining in schedulers,
+// and waits until the children downloading from the hosts are migrated.
+func (d *drainHost) CreateDrainHost(ctx context.Context, schedulers []models.Scheduler, json types.DrainHostArgs) (*internaljob.GroupJobState, error) {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, config.SpanDrainHost, trace.WithSpanKind(trace.SpanKindProducer))
+	span.SetAttributes(config.AttributeHostID.String(json.HostID))
+	span.SetAttributes(config.AttributeHostname.String(json.Hostname))
+	defer span.End()
+
+	args, err := internaljob.MarshalRequest(internaljob.DrainHostRequest{
+		HostID:   json.HostID,
+		Hostname: json.Hostname,
+		Cancel:   json.Cancel,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Every scheduler manages the hosts of its own, the hosts are drained in all of the schedulers.
+	return sendGroupJob(ctx, d.job, internaljob.DrainHostJob, args, getSchedulerQueues(schedulers))
+}
@@ -0,0 +1,75 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+//go:generate mockgen -destination mocks/get_task_mock.go -source get_task.go -package mocks
+
+package job
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+
+	internaljob "d7y.io/dragonfly/v2/internal/job"
+	"d7y.io/dragonfly/v2/manager/config"
+	"d7y.io/dragonfly/v2/manager/models"
+	"d7y.io/dragonfly/v2/manager/types"
+)
+
+// GetTask is the interface used for finding the peers holding the task in scheduler clusters.
+type GetTask interface {
+	// CreateGetTask creates a group job which finds the peers holding the task in schedulers,
+	// and reports how complete the copy of each peer is.
+	CreateGetTask(context.Context, []models.Scheduler, types.GetTaskArgs) (*internaljob.GroupJobState, error)
+}
+
+// getTask implements GetTask.
+type getTask struct {
+	job *internaljob.Job
+}
+
+// newGetTask returns a new GetTask interface.
+func newGetTask(job *internaljob.Job) GetTask {
+	return &getTask{
+		job: job,
+	}
+}
+
+// CreateGetTask creates a group job which finds the peers holding the task in schedulers,
+// and reports how complete the copy of each peer is.
+func (g *getTask) CreateGetTask(ctx context.Context, schedulers []models.Scheduler, json types.GetTaskArgs) (*internaljob.GroupJobState, error) {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, config.SpanGetTask, trace.WithSpanKind(trace.SpanKindProducer))
+	span.SetAttributes(config.AttributeTaskID.String(json.TaskID))
+	span.SetAttributes(config.AttributeTaskURL.String(json.URL))
+	defer span.End()
+
+	args, err := internaljob.MarshalRequest(internaljob.GetTaskRequest{
+		TaskID:      json.TaskID,
+		URL:         json.URL,
+		Tag:         json.Tag,
+		Digest:      json.Digest,
+		Filter:      json.Filter,
+		Headers:     json.Headers,
+		Application: json.Application,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Every scheduler manages the tasks of its own, the task is found in all of the schedulers.
+	return sendGroupJob(ctx, g.job, internaljob.GetTaskJob, args, getSchedulerQueues(schedulers))
+}
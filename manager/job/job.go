@@ -17,6 +17,14 @@
 package job
 
 import (
+	"context"
+	"fmt"
+	"time"
+
+	machineryv1tasks "github.com/RichardKnop/machinery/v1/tasks"
+	"github.com/google/uuid"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
 	internaljob "d7y.io/dragonfly/v2/internal/job"
 	"d7y.io/dragonfly/v2/manager/config"
 )
@@ -24,6 +32,9 @@ import (
 type Job struct {
 	*internaljob.Job
 	Preheat
+	DeleteTask
+	GetTask
+	DrainHost
 }
 
 func New(cfg *config.Config) (*Job, error) {
@@ -45,7 +56,40 @@ func New(cfg *config.Config) (*Job, error) {
 	}
 
 	return &Job{
-		Job:     j,
-		Preheat: p,
+		Job:        j,
+		Preheat:    p,
+		DeleteTask: newDeleteTask(j),
+		GetTask:    newGetTask(j),
+		DrainHost:  newDrainHost(j),
+	}, nil
+}
+
+// sendGroupJob sends a group of jobs with the same args to the queues, one job per queue.
+func sendGroupJob(ctx context.Context, job *internaljob.Job, name string, args []machineryv1tasks.Arg, queues []internaljob.Queue) (*internaljob.GroupJobState, error) {
+	var signatures []*machineryv1tasks.Signature
+	for _, queue := range queues {
+		signatures = append(signatures, &machineryv1tasks.Signature{
+			UUID:       fmt.Sprintf("task_%s", uuid.New().String()),
+			Name:       name,
+			RoutingKey: queue.String(),
+			Args:       args,
+		})
+	}
+
+	group, err := machineryv1tasks.NewGroup(signatures...)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("create %s group %s in queues %v", name, group.GroupUUID, queues)
+	if _, err := job.Server.SendGroupWithContext(ctx, group, 0); err != nil {
+		logger.Errorf("create %s group %s failed: %s", name, group.GroupUUID, err.Error())
+		return nil, err
+	}
+
+	return &internaljob.GroupJobState{
+		GroupUUID: group.GroupUUID,
+		State:     machineryv1tasks.StatePending,
+		CreatedAt: time.Now(),
 	}, nil
 }
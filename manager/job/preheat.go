@@ -128,6 +128,15 @@ func (p *preheat) CreatePreheat(ctx context.Context, schedulers []models.Schedul
 		return nil, errors.New("unknow preheat type")
 	}
 
+	// Set the peers to preheat for download files.
+	for i := range files {
+		files[i].Scope = json.Scope
+		files[i].PeerCount = json.PeerCount
+		files[i].IDC = json.IDC
+		files[i].Location = json.Location
+		files[i].HostnamePattern = json.HostnamePattern
+	}
+
 	return p.createGroupJob(ctx, files, queues)
 }
 
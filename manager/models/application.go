@@ -18,10 +18,11 @@ package models
 
 type Application struct {
 	BaseModel
-	Name     string  `gorm:"column:name;type:varchar(256);index:uk_application_name,unique;not null;comment:name" json:"name"`
-	URL      string  `gorm:"column:url;not null;comment:url" json:"url"`
-	BIO      string  `gorm:"column:bio;type:varchar(1024);comment:biography" json:"bio"`
-	Priority JSONMap `gorm:"column:priority;not null;comment:download priority" json:"priority"`
-	UserID   uint    `gorm:"comment:user id" json:"user_id"`
-	User     User    `json:"user"`
+	Name              string  `gorm:"column:name;type:varchar(256);index:uk_application_name,unique;not null;comment:name" json:"name"`
+	URL               string  `gorm:"column:url;not null;comment:url" json:"url"`
+	BIO               string  `gorm:"column:bio;type:varchar(1024);comment:biography" json:"bio"`
+	Priority          JSONMap `gorm:"column:priority;not null;comment:download priority" json:"priority"`
+	BackToSourceQuota JSONMap `gorm:"column:back_to_source_quota;comment:back-to-source quota" json:"back_to_source_quota"`
+	UserID            uint    `gorm:"comment:user id" json:"user_id"`
+	User              User    `json:"user"`
 }
@@ -287,8 +287,8 @@ func (s *managerServerV1) GetScheduler(ctx context.Context, req *managerv1.GetSc
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
-	// Marshal config of scheduler.
-	schedulerClusterConfig, err := scheduler.SchedulerCluster.Config.MarshalJSON()
+	// Marshal config of scheduler with the back-to-source quotas of applications.
+	schedulerClusterConfig, err := marshalSchedulerClusterConfig(ctx, s.db, s.cache, scheduler.SchedulerCluster.Config)
 	if err != nil {
 		return nil, status.Error(codes.DataLoss, err.Error())
 	}
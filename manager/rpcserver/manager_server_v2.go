@@ -287,8 +287,8 @@ func (s *managerServerV2) GetScheduler(ctx context.Context, req *managerv2.GetSc
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
-	// Marshal config of scheduler.
-	schedulerClusterConfig, err := scheduler.SchedulerCluster.Config.MarshalJSON()
+	// Marshal config of scheduler with the back-to-source quotas of applications.
+	schedulerClusterConfig, err := marshalSchedulerClusterConfig(ctx, s.db, s.cache, scheduler.SchedulerCluster.Config)
 	if err != nil {
 		return nil, status.Error(codes.DataLoss, err.Error())
 	}
@@ -736,7 +736,9 @@ func (s *managerServerV2) ListApplications(ctx context.Context, req *managerv2.L
 }
 
 // TODO(MinH-09) Implement function.
-// CreateModel creates model and update data of model to object storage.
+// CreateModel creates model and update data of model to object storage,
+// the active model of scheduler is stored in the object of
+// types.MakeObjectKeyOfActiveModel in the bucket of types.ModelBucketName.
 func (s *managerServerV2) CreateModel(ctx context.Context, req *managerv2.CreateModelRequest) (*emptypb.Empty, error) {
 	return new(emptypb.Empty), nil
 }
@@ -0,0 +1,93 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rpcserver
+
+import (
+	"context"
+	"encoding/json"
+
+	cachev8 "github.com/go-redis/cache/v8"
+	"gorm.io/gorm"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/manager/cache"
+	"d7y.io/dragonfly/v2/manager/models"
+	"d7y.io/dragonfly/v2/manager/types"
+)
+
+// marshalSchedulerClusterConfig marshals the config of scheduler cluster, and fills the back-to-source
+// quotas of applications in the config. The quotas are cached, so the applications are not scanned on
+// every cache miss of scheduler.
+func marshalSchedulerClusterConfig(ctx context.Context, db *gorm.DB, c *cache.Cache, config models.JSONMap) ([]byte, error) {
+	b, err := config.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var quotas map[string]*types.BackToSourceQuotaConfig
+	if err := c.Once(&cachev8.Item{
+		Ctx:   ctx,
+		Key:   cache.MakeApplicationBackToSourceQuotasCacheKey(),
+		Value: &quotas,
+		TTL:   c.TTL,
+		Do: func(*cachev8.Item) (any, error) {
+			return findApplicationBackToSourceQuotas(ctx, db)
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	if len(quotas) == 0 {
+		return b, nil
+	}
+
+	var schedulerClusterConfig types.SchedulerClusterConfig
+	if err := json.Unmarshal(b, &schedulerClusterConfig); err != nil {
+		return nil, err
+	}
+
+	schedulerClusterConfig.ApplicationBackToSourceQuotas = quotas
+	return json.Marshal(schedulerClusterConfig)
+}
+
+// findApplicationBackToSourceQuotas finds the back-to-source quotas of applications by name,
+// only the applications with back-to-source quota are scanned.
+func findApplicationBackToSourceQuotas(ctx context.Context, db *gorm.DB) (map[string]*types.BackToSourceQuotaConfig, error) {
+	var applications []models.Application
+	if err := db.WithContext(ctx).Select("name", "back_to_source_quota").Find(&applications, "back_to_source_quota IS NOT NULL").Error; err != nil {
+		return nil, err
+	}
+
+	quotas := make(map[string]*types.BackToSourceQuotaConfig, len(applications))
+	for _, application := range applications {
+		b, err := application.BackToSourceQuota.MarshalJSON()
+		if err != nil {
+			logger.Warn(err)
+			continue
+		}
+
+		var quota types.BackToSourceQuotaConfig
+		if err := json.Unmarshal(b, &quota); err != nil {
+			logger.Warn(err)
+			continue
+		}
+
+		quotas[application.Name] = &quota
+	}
+
+	return quotas, nil
+}
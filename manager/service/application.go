@@ -30,12 +30,18 @@ func (s *service) CreateApplication(ctx context.Context, json types.CreateApplic
 		return nil, err
 	}
 
+	backToSourceQuota, err := structure.StructToMap(json.BackToSourceQuota)
+	if err != nil {
+		return nil, err
+	}
+
 	application := models.Application{
-		Name:     json.Name,
-		URL:      json.URL,
-		BIO:      json.BIO,
-		Priority: priority,
-		UserID:   json.UserID,
+		Name:              json.Name,
+		URL:               json.URL,
+		BIO:               json.BIO,
+		Priority:          priority,
+		BackToSourceQuota: backToSourceQuota,
+		UserID:            json.UserID,
 	}
 
 	if err := s.db.WithContext(ctx).Preload("User").Create(&application).Error; err != nil {
@@ -60,8 +66,9 @@ func (s *service) DestroyApplication(ctx context.Context, id uint) error {
 
 func (s *service) UpdateApplication(ctx context.Context, id uint, json types.UpdateApplicationRequest) (*models.Application, error) {
 	var (
-		priority map[string]any
-		err      error
+		priority          map[string]any
+		backToSourceQuota map[string]any
+		err               error
 	)
 	if json.Priority != nil {
 		priority, err = structure.StructToMap(json.Priority)
@@ -70,13 +77,21 @@ func (s *service) UpdateApplication(ctx context.Context, id uint, json types.Upd
 		}
 	}
 
+	if json.BackToSourceQuota != nil {
+		backToSourceQuota, err = structure.StructToMap(json.BackToSourceQuota)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	application := models.Application{}
 	if err := s.db.WithContext(ctx).Preload("User").First(&application, id).Updates(models.Application{
-		Name:     json.Name,
-		URL:      json.URL,
-		BIO:      json.BIO,
-		Priority: priority,
-		UserID:   json.UserID,
+		Name:              json.Name,
+		URL:               json.URL,
+		BIO:               json.BIO,
+		Priority:          priority,
+		BackToSourceQuota: backToSourceQuota,
+		UserID:            json.UserID,
 	}).Error; err != nil {
 		return nil, err
 	}
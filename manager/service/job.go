@@ -24,6 +24,7 @@ import (
 	machineryv1tasks "github.com/RichardKnop/machinery/v1/tasks"
 
 	logger "d7y.io/dragonfly/v2/internal/dflog"
+	internaljob "d7y.io/dragonfly/v2/internal/job"
 	"d7y.io/dragonfly/v2/manager/models"
 	"d7y.io/dragonfly/v2/manager/types"
 	"d7y.io/dragonfly/v2/pkg/retry"
@@ -71,6 +72,124 @@ func (s *service) CreatePreheatJob(ctx context.Context, json types.CreatePreheat
 	return &job, nil
 }
 
+func (s *service) CreateDeleteTaskJob(ctx context.Context, json types.CreateDeleteTaskJobRequest) (*models.Job, error) {
+	schedulers, err := s.findActiveSchedulers(ctx, json.SchedulerClusterIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	groupJobState, err := s.job.CreateDeleteTask(ctx, schedulers, json.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createJobInSchedulers(ctx, groupJobState, schedulers, json.BIO, json.Type, json.Args, json.UserID)
+}
+
+func (s *service) CreateGetTaskJob(ctx context.Context, json types.CreateGetTaskJobRequest) (*models.Job, error) {
+	schedulers, err := s.findActiveSchedulers(ctx, json.SchedulerClusterIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	groupJobState, err := s.job.CreateGetTask(ctx, schedulers, json.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createJobInSchedulers(ctx, groupJobState, schedulers, json.BIO, json.Type, json.Args, json.UserID)
+}
+
+func (s *service) CreateDrainHostJob(ctx context.Context, json types.CreateDrainHostJobRequest) (*models.Job, error) {
+	schedulers, err := s.findActiveSchedulers(ctx, json.SchedulerClusterIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	groupJobState, err := s.job.CreateDrainHost(ctx, schedulers, json.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createJobInSchedulers(ctx, groupJobState, schedulers, json.BIO, json.Type, json.Args, json.UserID)
+}
+
+// createJobInSchedulers creates the job of group sent to the schedulers, and polls the state of group.
+func (s *service) createJobInSchedulers(ctx context.Context, groupJobState *internaljob.GroupJobState, schedulers []models.Scheduler, bio, jobType string, jobArgs any, userID uint) (*models.Job, error) {
+	var schedulerClusters []models.SchedulerCluster
+	visited := make(map[uint]struct{})
+	for _, scheduler := range schedulers {
+		if _, ok := visited[scheduler.SchedulerClusterID]; ok {
+			continue
+		}
+
+		visited[scheduler.SchedulerClusterID] = struct{}{}
+		schedulerClusters = append(schedulerClusters, scheduler.SchedulerCluster)
+	}
+
+	args, err := structure.StructToMap(jobArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	job := models.Job{
+		TaskID:            groupJobState.GroupUUID,
+		BIO:               bio,
+		Type:              jobType,
+		State:             groupJobState.State,
+		Args:              args,
+		UserID:            userID,
+		SchedulerClusters: schedulerClusters,
+	}
+
+	if err := s.db.WithContext(ctx).Create(&job).Error; err != nil {
+		return nil, err
+	}
+
+	go s.pollingJob(context.Background(), job.ID, job.TaskID)
+
+	return &job, nil
+}
+
+// findActiveSchedulers finds all of the active schedulers in the scheduler clusters,
+// the schedulers in all of the scheduler clusters are found if the ids are empty.
+func (s *service) findActiveSchedulers(ctx context.Context, schedulerClusterIDs []uint) ([]models.Scheduler, error) {
+	var schedulerClusters []models.SchedulerCluster
+	if len(schedulerClusterIDs) != 0 {
+		for _, schedulerClusterID := range schedulerClusterIDs {
+			schedulerCluster := models.SchedulerCluster{}
+			if err := s.db.WithContext(ctx).First(&schedulerCluster, schedulerClusterID).Error; err != nil {
+				return nil, err
+			}
+
+			schedulerClusters = append(schedulerClusters, schedulerCluster)
+		}
+	} else {
+		if err := s.db.WithContext(ctx).Find(&schedulerClusters).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var activeSchedulers []models.Scheduler
+	for _, schedulerCluster := range schedulerClusters {
+		var schedulers []models.Scheduler
+		if err := s.db.WithContext(ctx).Preload("SchedulerCluster").Find(&schedulers, models.Scheduler{
+			SchedulerClusterID: schedulerCluster.ID,
+			State:              models.SchedulerStateActive,
+		}).Error; err != nil {
+			return nil, err
+		}
+
+		activeSchedulers = append(activeSchedulers, schedulers...)
+	}
+
+	if len(activeSchedulers) == 0 {
+		return nil, errors.New("active schedulers not found")
+	}
+
+	return activeSchedulers, nil
+}
+
 func (s *service) findCandidateSchedulers(ctx context.Context, schedulerClusterIDs []uint) ([]models.Scheduler, error) {
 	var candidateSchedulers []models.Scheduler
 	if len(schedulerClusterIDs) != 0 {
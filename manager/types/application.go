@@ -21,19 +21,21 @@ type ApplicationParams struct {
 }
 
 type CreateApplicationRequest struct {
-	Name     string          `json:"name" binding:"required"`
-	URL      string          `json:"url" binding:"required"`
-	BIO      string          `json:"bio" binding:"omitempty"`
-	Priority *PriorityConfig `json:"priority" binding:"required"`
-	UserID   uint            `json:"user_id" binding:"required"`
+	Name              string                   `json:"name" binding:"required"`
+	URL               string                   `json:"url" binding:"required"`
+	BIO               string                   `json:"bio" binding:"omitempty"`
+	Priority          *PriorityConfig          `json:"priority" binding:"required"`
+	BackToSourceQuota *BackToSourceQuotaConfig `json:"back_to_source_quota" binding:"omitempty"`
+	UserID            uint                     `json:"user_id" binding:"required"`
 }
 
 type UpdateApplicationRequest struct {
-	Name     string          `json:"name" binding:"omitempty"`
-	URL      string          `json:"url" binding:"omitempty"`
-	BIO      string          `json:"bio" binding:"omitempty"`
-	Priority *PriorityConfig `json:"priority" binding:"omitempty"`
-	UserID   uint            `json:"user_id" binding:"required"`
+	Name              string                   `json:"name" binding:"omitempty"`
+	URL               string                   `json:"url" binding:"omitempty"`
+	BIO               string                   `json:"bio" binding:"omitempty"`
+	Priority          *PriorityConfig          `json:"priority" binding:"omitempty"`
+	BackToSourceQuota *BackToSourceQuotaConfig `json:"back_to_source_quota" binding:"omitempty"`
+	UserID            uint                     `json:"user_id" binding:"required"`
 }
 
 type GetApplicationsQuery struct {
@@ -51,3 +53,13 @@ type URLPriorityConfig struct {
 	Regex string `yaml:"regex" mapstructure:"regex" json:"regex" binding:"required"`
 	Value int    `yaml:"value" mapstructure:"value" json:"value" binding:"required,gte=0,lte=20"`
 }
+
+type BackToSourceQuotaConfig struct {
+	// Limit is the maximum number of concurrent back-to-source downloads of the application
+	// in a scheduler cluster, zero means no limit.
+	Limit uint32 `yaml:"limit" mapstructure:"limit" json:"limit" binding:"omitempty"`
+
+	// Domains are the maximum number of concurrent back-to-source downloads of the application
+	// by the domain of source url in a scheduler cluster, e.g. {"registry.example.com": 10}.
+	Domains map[string]uint32 `yaml:"domains" mapstructure:"domains" json:"domains" binding:"omitempty"`
+}
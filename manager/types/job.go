@@ -58,4 +58,87 @@ type PreheatArgs struct {
 	Tag     string            `json:"tag" binding:"omitempty"`
 	Filter  string            `json:"filter" binding:"omitempty"`
 	Headers map[string]string `json:"headers" binding:"omitempty"`
+
+	// Scope is the scope of peers to preheat, the seed peer selected by
+	// consistent hashing is preheated by default.
+	Scope string `json:"scope" binding:"omitempty,oneof=seed_peer normal_peer"`
+
+	// PeerCount is the number of peers in different hosts to preheat,
+	// all of the peers matched by scope and filters are preheated if it is zero.
+	PeerCount uint32 `json:"peer_count" binding:"omitempty"`
+
+	// IDC is the idc of peers to preheat.
+	IDC string `json:"idc" binding:"omitempty"`
+
+	// Location is the location prefix of peers to preheat.
+	Location string `json:"location" binding:"omitempty"`
+
+	// HostnamePattern is the regular expression of hostnames of peers to preheat.
+	HostnamePattern string `json:"hostname_pattern" binding:"omitempty"`
+}
+
+type CreateDeleteTaskJobRequest struct {
+	BIO                 string         `json:"bio" binding:"omitempty"`
+	Type                string         `json:"type" binding:"required"`
+	Args                DeleteTaskArgs `json:"args" binding:"omitempty"`
+	Result              map[string]any `json:"result" binding:"omitempty"`
+	UserID              uint           `json:"user_id" binding:"omitempty"`
+	SchedulerClusterIDs []uint         `json:"scheduler_cluster_ids" binding:"omitempty"`
+}
+
+type DeleteTaskArgs struct {
+	// TaskID is the id of task to delete, it is required if url is empty.
+	TaskID string `json:"task_id" binding:"required_without=URL"`
+
+	// URL is the url of task to delete, the task id is generated by url and
+	// the url metadata if task id is empty.
+	URL         string            `json:"url" binding:"omitempty,url"`
+	Tag         string            `json:"tag" binding:"omitempty"`
+	Digest      string            `json:"digest" binding:"omitempty"`
+	Filter      string            `json:"filter" binding:"omitempty"`
+	Headers     map[string]string `json:"headers" binding:"omitempty"`
+	Application string            `json:"application" binding:"omitempty"`
+}
+
+type CreateGetTaskJobRequest struct {
+	BIO                 string         `json:"bio" binding:"omitempty"`
+	Type                string         `json:"type" binding:"required"`
+	Args                GetTaskArgs    `json:"args" binding:"omitempty"`
+	Result              map[string]any `json:"result" binding:"omitempty"`
+	UserID              uint           `json:"user_id" binding:"omitempty"`
+	SchedulerClusterIDs []uint         `json:"scheduler_cluster_ids" binding:"omitempty"`
+}
+
+type GetTaskArgs struct {
+	// TaskID is the id of task to find, it is required if url is empty.
+	TaskID string `json:"task_id" binding:"required_without=URL"`
+
+	// URL is the url of task to find, the task id is generated by url and
+	// the url metadata if task id is empty.
+	URL         string            `json:"url" binding:"omitempty,url"`
+	Tag         string            `json:"tag" binding:"omitempty"`
+	Digest      string            `json:"digest" binding:"omitempty"`
+	Filter      string            `json:"filter" binding:"omitempty"`
+	Headers     map[string]string `json:"headers" binding:"omitempty"`
+	Application string            `json:"application" binding:"omitempty"`
+}
+
+type CreateDrainHostJobRequest struct {
+	BIO                 string         `json:"bio" binding:"omitempty"`
+	Type                string         `json:"type" binding:"required"`
+	Args                DrainHostArgs  `json:"args" binding:"omitempty"`
+	Result              map[string]any `json:"result" binding:"omitempty"`
+	UserID              uint           `json:"user_id" binding:"omitempty"`
+	SchedulerClusterIDs []uint         `json:"scheduler_cluster_ids" binding:"omitempty"`
+}
+
+type DrainHostArgs struct {
+	// HostID is the id of host to drain, it is required if hostname is empty.
+	HostID string `json:"host_id" binding:"required_without=Hostname"`
+
+	// Hostname is the hostname of hosts to drain.
+	Hostname string `json:"hostname" binding:"omitempty"`
+
+	// Cancel cancels draining of the hosts.
+	Cancel bool `json:"cancel" binding:"omitempty"`
 }
@@ -0,0 +1,30 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import "fmt"
+
+const (
+	// ModelBucketName is the bucket name of models in object storage.
+	ModelBucketName = "models"
+)
+
+// MakeObjectKeyOfActiveModel returns the object key of the active model of the scheduler,
+// the object is the json of the model metadata and its layers.
+func MakeObjectKeyOfActiveModel(schedulerID uint64) string {
+	return fmt.Sprintf("schedulers/%d/active.json", schedulerID)
+}
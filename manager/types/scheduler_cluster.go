@@ -16,6 +16,14 @@
 
 package types
 
+import (
+	"fmt"
+	"math"
+)
+
+// evaluatorWeightsTolerance is the tolerance of the sum of evaluator weights.
+const evaluatorWeightsTolerance = 1e-6
+
 type SchedulerClusterParams struct {
 	ID uint `uri:"id" binding:"required"`
 }
@@ -54,8 +62,43 @@ type GetSchedulerClustersQuery struct {
 }
 
 type SchedulerClusterConfig struct {
-	FilterParentLimit      uint32 `yaml:"filterParentLimit" mapstructure:"filterParentLimit" json:"filter_parent_limit" binding:"omitempty,gte=1,lte=20"`
-	FilterParentRangeLimit uint32 `yaml:"filterParentRangeLimit" mapstructure:"filterParentRangeLimit" json:"filter_parent_range_limit" binding:"omitempty,gte=10,lte=1000"`
+	FilterParentLimit      uint32                            `yaml:"filterParentLimit" mapstructure:"filterParentLimit" json:"filter_parent_limit" binding:"omitempty,gte=1,lte=20"`
+	FilterParentRangeLimit uint32                            `yaml:"filterParentRangeLimit" mapstructure:"filterParentRangeLimit" json:"filter_parent_range_limit" binding:"omitempty,gte=10,lte=1000"`
+	EvaluatorWeights       *SchedulerClusterEvaluatorWeights `yaml:"evaluatorWeights" mapstructure:"evaluatorWeights" json:"evaluator_weights,omitempty" binding:"omitempty"`
+
+	// ApplicationBackToSourceQuotas are the back-to-source quotas of applications by name,
+	// which are filled by manager from the applications when scheduler gets the config.
+	ApplicationBackToSourceQuotas map[string]*BackToSourceQuotaConfig `yaml:"applicationBackToSourceQuotas" mapstructure:"applicationBackToSourceQuotas" json:"application_back_to_source_quotas,omitempty" binding:"-"`
+}
+
+type SchedulerClusterEvaluatorWeights struct {
+	FinishedPiece           float64 `yaml:"finishedPiece" mapstructure:"finishedPiece" json:"finished_piece" binding:"gte=0,lte=1"`
+	ParentHostUploadSuccess float64 `yaml:"parentHostUploadSuccess" mapstructure:"parentHostUploadSuccess" json:"parent_host_upload_success" binding:"gte=0,lte=1"`
+	FreeUpload              float64 `yaml:"freeUpload" mapstructure:"freeUpload" json:"free_upload" binding:"gte=0,lte=1"`
+	HostType                float64 `yaml:"hostType" mapstructure:"hostType" json:"host_type" binding:"gte=0,lte=1"`
+	IDCAffinity             float64 `yaml:"idcAffinity" mapstructure:"idcAffinity" json:"idc_affinity" binding:"gte=0,lte=1"`
+	LocationAffinity        float64 `yaml:"locationAffinity" mapstructure:"locationAffinity" json:"location_affinity" binding:"gte=0,lte=1"`
+	RTT                     float64 `yaml:"rtt" mapstructure:"rtt" json:"rtt" binding:"gte=0,lte=1"`
+}
+
+// Validate checks that every weight is between 0 and 1, and the sum of weights is 1.
+func (w SchedulerClusterEvaluatorWeights) Validate() error {
+	weights := []float64{w.FinishedPiece, w.ParentHostUploadSuccess, w.FreeUpload, w.HostType, w.IDCAffinity, w.LocationAffinity, w.RTT}
+
+	var sum float64
+	for _, weight := range weights {
+		if weight < 0 || weight > 1 {
+			return fmt.Errorf("invalid weight %.2f", weight)
+		}
+
+		sum += weight
+	}
+
+	if math.Abs(sum-1) > evaluatorWeightsTolerance {
+		return fmt.Errorf("sum of weights is %.2f, not 1", sum)
+	}
+
+	return nil
 }
 
 type SchedulerClusterClientConfig struct {
@@ -0,0 +1,87 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package common
+
+import (
+	"context"
+	"strconv"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// MetadataUploadRate is the metadata key of the bytes sent by
+	// the network interface of host per second.
+	MetadataUploadRate = "d7y-host-upload-rate"
+
+	// MetadataUploadBandwidth is the metadata key of the capacity of bytes
+	// sent by the network interface of host per second.
+	MetadataUploadBandwidth = "d7y-host-upload-bandwidth"
+)
+
+// NetworkBandwidth is the upload bandwidth of host announced in grpc metadata,
+// because the network message of host has no field of bandwidth.
+type NetworkBandwidth struct {
+	// UploadRate is the bytes sent by the network interface of host per second.
+	UploadRate uint64
+
+	// UploadBandwidth is the capacity of bytes sent by the network interface
+	// of host per second, it is zero if the capacity is unknown.
+	UploadBandwidth uint64
+}
+
+// AppendNetworkBandwidthToOutgoingContext returns a new context with the network bandwidth in metadata.
+func AppendNetworkBandwidthToOutgoingContext(ctx context.Context, bandwidth NetworkBandwidth) context.Context {
+	return metadata.AppendToOutgoingContext(ctx,
+		MetadataUploadRate, strconv.FormatUint(bandwidth.UploadRate, 10),
+		MetadataUploadBandwidth, strconv.FormatUint(bandwidth.UploadBandwidth, 10),
+	)
+}
+
+// NetworkBandwidthFromIncomingContext returns the network bandwidth in metadata of the context,
+// it returns false if the bandwidth is not announced.
+func NetworkBandwidthFromIncomingContext(ctx context.Context) (NetworkBandwidth, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return NetworkBandwidth{}, false
+	}
+
+	uploadRate, err := parseUintMetadata(md, MetadataUploadRate)
+	if err != nil {
+		return NetworkBandwidth{}, false
+	}
+
+	uploadBandwidth, err := parseUintMetadata(md, MetadataUploadBandwidth)
+	if err != nil {
+		return NetworkBandwidth{}, false
+	}
+
+	return NetworkBandwidth{
+		UploadRate:      uploadRate,
+		UploadBandwidth: uploadBandwidth,
+	}, true
+}
+
+// parseUintMetadata parses the first value of the key in metadata as uint64.
+func parseUintMetadata(md metadata.MD, key string) (uint64, error) {
+	values := md.Get(key)
+	if len(values) == 0 {
+		return 0, strconv.ErrSyntax
+	}
+
+	return strconv.ParseUint(values[0], 10, 64)
+}
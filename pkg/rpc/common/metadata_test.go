@@ -0,0 +1,83 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestNetworkBandwidthFromIncomingContext(t *testing.T) {
+	tests := []struct {
+		name   string
+		ctx    func() context.Context
+		expect func(t *testing.T, bandwidth NetworkBandwidth, ok bool)
+	}{
+		{
+			name: "context without metadata",
+			ctx:  context.Background,
+			expect: func(t *testing.T, bandwidth NetworkBandwidth, ok bool) {
+				assert := assert.New(t)
+				assert.False(ok)
+			},
+		},
+		{
+			name: "metadata without bandwidth",
+			ctx: func() context.Context {
+				return metadata.NewIncomingContext(context.Background(), metadata.Pairs("foo", "bar"))
+			},
+			expect: func(t *testing.T, bandwidth NetworkBandwidth, ok bool) {
+				assert := assert.New(t)
+				assert.False(ok)
+			},
+		},
+		{
+			name: "invalid upload rate",
+			ctx: func() context.Context {
+				return metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataUploadRate, "foo", MetadataUploadBandwidth, "100"))
+			},
+			expect: func(t *testing.T, bandwidth NetworkBandwidth, ok bool) {
+				assert := assert.New(t)
+				assert.False(ok)
+			},
+		},
+		{
+			name: "bandwidth is appended to outgoing context",
+			ctx: func() context.Context {
+				ctx := AppendNetworkBandwidthToOutgoingContext(context.Background(), NetworkBandwidth{UploadRate: 10, UploadBandwidth: 100})
+				md, _ := metadata.FromOutgoingContext(ctx)
+				return metadata.NewIncomingContext(context.Background(), md)
+			},
+			expect: func(t *testing.T, bandwidth NetworkBandwidth, ok bool) {
+				assert := assert.New(t)
+				assert.True(ok)
+				assert.Equal(bandwidth.UploadRate, uint64(10))
+				assert.Equal(bandwidth.UploadBandwidth, uint64(100))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			bandwidth, ok := NetworkBandwidthFromIncomingContext(tc.ctx())
+			tc.expect(t, bandwidth, ok)
+		})
+	}
+}
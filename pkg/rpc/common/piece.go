@@ -0,0 +1,44 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package common
+
+import (
+	commonv1 "d7y.io/api/pkg/apis/common/v1"
+	schedulerv1 "d7y.io/api/pkg/apis/scheduler/v1"
+)
+
+const (
+	// PieceResultHeaderDigestMismatch is the header key in the extend attribute of piece result,
+	// it is set when the piece downloaded from the parent does not match its digest.
+	PieceResultHeaderDigestMismatch = "d7y-piece-digest-mismatch"
+)
+
+// NewDigestMismatchExtendAttribute returns the extend attribute of piece result marking the piece
+// downloaded from the parent is corrupted, because the code of piece result has no value for it.
+func NewDigestMismatchExtendAttribute() *commonv1.ExtendAttribute {
+	return &commonv1.ExtendAttribute{
+		Header: map[string]string{
+			PieceResultHeaderDigestMismatch: "true",
+		},
+	}
+}
+
+// IsPieceDigestMismatch returns whether the piece result reports the piece downloaded
+// from the parent does not match its digest.
+func IsPieceDigestMismatch(piece *schedulerv1.PieceResult) bool {
+	return piece.GetExtendAttribute().GetHeader()[PieceResultHeaderDigestMismatch] == "true"
+}
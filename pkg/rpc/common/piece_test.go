@@ -0,0 +1,63 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	commonv1 "d7y.io/api/pkg/apis/common/v1"
+	schedulerv1 "d7y.io/api/pkg/apis/scheduler/v1"
+)
+
+func TestIsPieceDigestMismatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		piece  *schedulerv1.PieceResult
+		expect bool
+	}{
+		{
+			name:   "piece result without extend attribute",
+			piece:  &schedulerv1.PieceResult{},
+			expect: false,
+		},
+		{
+			name: "piece result with other headers",
+			piece: &schedulerv1.PieceResult{
+				ExtendAttribute: &commonv1.ExtendAttribute{
+					Header: map[string]string{"foo": "bar"},
+				},
+			},
+			expect: false,
+		},
+		{
+			name: "piece result with digest mismatch",
+			piece: &schedulerv1.PieceResult{
+				ExtendAttribute: NewDigestMismatchExtendAttribute(),
+			},
+			expect: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(IsPieceDigestMismatch(tc.piece), tc.expect)
+		})
+	}
+}
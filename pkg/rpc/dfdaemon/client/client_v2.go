@@ -101,11 +101,9 @@ func (v *v2) SyncPieces(ctx context.Context, opts ...grpc.CallOption) (dfdaemonv
 	)
 }
 
-// DownloadTask downloads task back-to-source.
+// DownloadTask downloads task back-to-source, it returns when the task is downloaded,
+// so the grpc invoke has no timeout and the download is cancelled by the context.
 func (v *v2) DownloadTask(ctx context.Context, req *dfdaemonv2.DownloadTaskRequest, opts ...grpc.CallOption) error {
-	ctx, cancel := context.WithTimeout(ctx, contextTimeout)
-	defer cancel()
-
 	_, err := v.DfdaemonClient.DownloadTask(
 		ctx,
 		req,
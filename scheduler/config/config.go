@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"d7y.io/dragonfly/v2/cmd/dependency/base"
+	managertypes "d7y.io/dragonfly/v2/manager/types"
 	"d7y.io/dragonfly/v2/pkg/net/fqdn"
 	"d7y.io/dragonfly/v2/pkg/net/ip"
 	"d7y.io/dragonfly/v2/pkg/rpc"
@@ -72,6 +73,12 @@ type Config struct {
 
 	// Trainer configuration.
 	Trainer TrainerConfig `yaml:"trainer" mapstructure:"trainer"`
+
+	// Replication configuration.
+	Replication ReplicationConfig `yaml:"replication" mapstructure:"replication"`
+
+	// BackToSourceQuota configuration.
+	BackToSourceQuota BackToSourceQuotaConfig `yaml:"backToSourceQuota" mapstructure:"backToSourceQuota"`
 }
 
 type ServerConfig struct {
@@ -136,6 +143,85 @@ type SchedulerConfig struct {
 
 	// GC configuration.
 	GC GCConfig `yaml:"gc" mapstructure:"gc"`
+
+	// EvaluatorWeights is the weights of scores used by evaluator,
+	// it can be overridden by the config of scheduler cluster in manager.
+	EvaluatorWeights EvaluatorWeightsConfig `yaml:"evaluatorWeights" mapstructure:"evaluatorWeights"`
+
+	// DecisionLog configuration.
+	DecisionLog DecisionLogConfig `yaml:"decisionLog" mapstructure:"decisionLog"`
+
+	// Snapshot configuration.
+	Snapshot SnapshotConfig `yaml:"snapshot" mapstructure:"snapshot"`
+
+	// Preemption configuration.
+	Preemption PreemptionConfig `yaml:"preemption" mapstructure:"preemption"`
+}
+
+type EvaluatorWeightsConfig struct {
+	// FinishedPiece is the weight of finished piece score.
+	FinishedPiece float64 `yaml:"finishedPiece" mapstructure:"finishedPiece"`
+
+	// ParentHostUploadSuccess is the weight of parent's host upload success score.
+	ParentHostUploadSuccess float64 `yaml:"parentHostUploadSuccess" mapstructure:"parentHostUploadSuccess"`
+
+	// FreeUpload is the weight of free upload score.
+	FreeUpload float64 `yaml:"freeUpload" mapstructure:"freeUpload"`
+
+	// HostType is the weight of host type score.
+	HostType float64 `yaml:"hostType" mapstructure:"hostType"`
+
+	// IDCAffinity is the weight of IDC affinity score.
+	IDCAffinity float64 `yaml:"idcAffinity" mapstructure:"idcAffinity"`
+
+	// LocationAffinity is the weight of location affinity score.
+	LocationAffinity float64 `yaml:"locationAffinity" mapstructure:"locationAffinity"`
+
+	// RTT is the weight of round-trip time score, it is transferred to IDC affinity
+	// and location affinity when the round-trip time between hosts is not measured.
+	RTT float64 `yaml:"rtt" mapstructure:"rtt"`
+}
+
+// Validate checks that every weight is between 0 and 1, and the sum of weights is 1,
+// the weights are validated as the evaluator weights of scheduler cluster config.
+func (w EvaluatorWeightsConfig) Validate() error {
+	return managertypes.SchedulerClusterEvaluatorWeights(w).Validate()
+}
+
+type DecisionLogConfig struct {
+	// Enable records the scheduling decisions of peers, including the filtered parents
+	// with reasons, the scores of candidate parents and the blocklist.
+	Enable bool `yaml:"enable" mapstructure:"enable"`
+
+	// TTL is time to live of the decisions of a peer.
+	TTL time.Duration `yaml:"ttl" mapstructure:"ttl"`
+
+	// Limit is the maximum number of decisions recorded for a peer.
+	Limit int `yaml:"limit" mapstructure:"limit"`
+}
+
+type SnapshotConfig struct {
+	// Enable snapshots hosts, tasks and peers to the data directory periodically,
+	// and restores them when the scheduler starts.
+	Enable bool `yaml:"enable" mapstructure:"enable"`
+
+	// Interval is the interval of snapshot.
+	Interval time.Duration `yaml:"interval" mapstructure:"interval"`
+
+	// TTL is time to live of snapshot, the snapshot is discarded
+	// when the scheduler starts if it is older than TTL.
+	TTL time.Duration `yaml:"ttl" mapstructure:"ttl"`
+}
+
+type PreemptionConfig struct {
+	// Enable allows the peer with higher priority to preempt the upload slot of parent's host,
+	// which is occupied by the child with lower priority, when the host has no free upload.
+	// The preempted child is rescheduled to other parents.
+	Enable bool `yaml:"enable" mapstructure:"enable"`
+
+	// Interval is the minimum interval between two preemptions in the same host,
+	// it throttles evicting the children with lower priority from the host.
+	Interval time.Duration `yaml:"interval" mapstructure:"interval"`
 }
 
 type GCConfig struct {
@@ -208,6 +294,10 @@ type JobConfig struct {
 	// Number of workers in local queue.
 	LocalWorkerNum uint `yaml:"localWorkerNum" mapstructure:"localWorkerNum"`
 
+	// PreheatConcurrency is the max number of peers triggered to download seeds
+	// concurrently by a preheat job.
+	PreheatConcurrency uint `yaml:"preheatConcurrency" mapstructure:"preheatConcurrency"`
+
 	// Redis configuration.
 	Redis RedisConfig `yaml:"redis" mapstructure:"redis"`
 }
@@ -222,6 +312,18 @@ type StorageConfig struct {
 	// BufferSize sets the size of buffer container,
 	// if the buffer is full, write all the records in the buffer to the file.
 	BufferSize int `yaml:"bufferSize" mapstructure:"bufferSize"`
+
+	// Format is the format of storage file, supports csv and jsonl.
+	Format string `yaml:"format" mapstructure:"format"`
+
+	// Export configuration.
+	Export StorageExportConfig `yaml:"export" mapstructure:"export"`
+}
+
+type StorageExportConfig struct {
+	// Enable exports the downloads by the metrics server without authentication,
+	// the downloads include the urls of tasks and the addresses of hosts.
+	Enable bool `yaml:"enable" mapstructure:"enable"`
 }
 
 type RedisConfig struct {
@@ -308,6 +410,11 @@ type NetworkTopologyConfig struct {
 	// CollectInterval is the interval of collecting network topology.
 	CollectInterval time.Duration `mapstructure:"collectInterval" yaml:"collectInterval"`
 
+	// HostTTL is the time to live of the hosts which are not managed by the scheduler,
+	// such as the hosts restored from the snapshot or synchronized by other schedulers,
+	// the host is evicted from network topology if it is not updated within the TTL.
+	HostTTL time.Duration `mapstructure:"hostTTL" yaml:"hostTTL"`
+
 	// Probe is the configuration of probe.
 	Probe ProbeConfig `yaml:"probe" mapstructure:"probe"`
 }
@@ -334,6 +441,57 @@ type TrainerConfig struct {
 	Interval time.Duration `yaml:"interval" mapstructure:"interval"`
 }
 
+type ReplicationConfig struct {
+	// Enable replicates the tasks to the schedulers in the same cluster,
+	// so that a surviving scheduler can take over the task whose owner died.
+	Enable bool `yaml:"enable" mapstructure:"enable"`
+
+	// Interval is the interval of replicating tasks.
+	Interval time.Duration `yaml:"interval" mapstructure:"interval"`
+
+	// TTL is time to live of the replicas and the liveness of scheduler,
+	// it should be greater than the interval.
+	TTL time.Duration `yaml:"ttl" mapstructure:"ttl"`
+
+	// Redis configuration, which stores the replicas shared by schedulers.
+	Redis SharedRedisConfig `yaml:"redis" mapstructure:"redis"`
+}
+
+type BackToSourceQuotaConfig struct {
+	// Enable limits the concurrent back-to-source peers of the applications in the scheduler cluster,
+	// the quotas of applications and source domains are configured by the applications in manager.
+	Enable bool `yaml:"enable" mapstructure:"enable"`
+
+	// Interval is the interval of renewing the leases of back-to-source peers,
+	// and releasing the leases of finished peers.
+	Interval time.Duration `yaml:"interval" mapstructure:"interval"`
+
+	// TTL is time to live of the leases, the leases held by a dead scheduler
+	// are released after TTL. It should be greater than the interval.
+	TTL time.Duration `yaml:"ttl" mapstructure:"ttl"`
+
+	// Redis configuration, which stores the leases shared by schedulers.
+	Redis SharedRedisConfig `yaml:"redis" mapstructure:"redis"`
+}
+
+// SharedRedisConfig is the redis shared by the schedulers in the same cluster.
+type SharedRedisConfig struct {
+	// Addrs is server addresses.
+	Addrs []string `yaml:"addrs" mapstructure:"addrs"`
+
+	// MasterName is the sentinel master name.
+	MasterName string `yaml:"masterName" mapstructure:"masterName"`
+
+	// Username is server username.
+	Username string `yaml:"username" mapstructure:"username"`
+
+	// Password is server password.
+	Password string `yaml:"password" mapstructure:"password"`
+
+	// DB is server database.
+	DB int `yaml:"db" mapstructure:"db"`
+}
+
 // New default configuration.
 func New() *Config {
 	return &Config{
@@ -356,6 +514,29 @@ func New() *Config {
 				HostGCInterval:       DefaultSchedulerHostGCInterval,
 				HostTTL:              DefaultSchedulerHostTTL,
 			},
+			EvaluatorWeights: EvaluatorWeightsConfig{
+				FinishedPiece:           DefaultEvaluatorFinishedPieceWeight,
+				ParentHostUploadSuccess: DefaultEvaluatorParentHostUploadSuccessWeight,
+				FreeUpload:              DefaultEvaluatorFreeUploadWeight,
+				HostType:                DefaultEvaluatorHostTypeWeight,
+				IDCAffinity:             DefaultEvaluatorIDCAffinityWeight,
+				LocationAffinity:        DefaultEvaluatorLocationAffinityWeight,
+				RTT:                     DefaultEvaluatorRTTWeight,
+			},
+			DecisionLog: DecisionLogConfig{
+				Enable: false,
+				TTL:    DefaultSchedulerDecisionLogTTL,
+				Limit:  DefaultSchedulerDecisionLogLimit,
+			},
+			Snapshot: SnapshotConfig{
+				Enable:   false,
+				Interval: DefaultSchedulerSnapshotInterval,
+				TTL:      DefaultSchedulerSnapshotTTL,
+			},
+			Preemption: PreemptionConfig{
+				Enable:   false,
+				Interval: DefaultSchedulerPreemptionInterval,
+			},
 		},
 		DynConfig: DynConfig{
 			RefreshInterval: DefaultDynConfigRefreshInterval,
@@ -375,6 +556,7 @@ func New() *Config {
 			GlobalWorkerNum:    DefaultJobGlobalWorkerNum,
 			SchedulerWorkerNum: DefaultJobSchedulerWorkerNum,
 			LocalWorkerNum:     DefaultJobLocalWorkerNum,
+			PreheatConcurrency: DefaultJobPreheatConcurrency,
 			Redis: RedisConfig{
 				BrokerDB:  DefaultJobRedisBrokerDB,
 				BackendDB: DefaultJobRedisBackendDB,
@@ -384,6 +566,10 @@ func New() *Config {
 			MaxSize:    DefaultStorageMaxSize,
 			MaxBackups: DefaultStorageMaxBackups,
 			BufferSize: DefaultStorageBufferSize,
+			Format:     DefaultStorageFormat,
+			Export: StorageExportConfig{
+				Enable: false,
+			},
 		},
 		Metrics: MetricsConfig{
 			Enable:     false,
@@ -407,6 +593,7 @@ func New() *Config {
 			Enable:          true,
 			SyncInterval:    DefaultNetworkTopologySyncInterval,
 			CollectInterval: DefaultNetworkTopologyCollectInterval,
+			HostTTL:         DefaultNetworkTopologyHostTTL,
 			Probe: ProbeConfig{
 				QueueLength:  DefaultProbeQueueLength,
 				SyncInterval: DefaultProbeSyncInterval,
@@ -418,6 +605,22 @@ func New() *Config {
 			Addr:     DefaultTrainerAddr,
 			Interval: DefaultTrainerInterval,
 		},
+		Replication: ReplicationConfig{
+			Enable:   false,
+			Interval: DefaultReplicationInterval,
+			TTL:      DefaultReplicationTTL,
+			Redis: SharedRedisConfig{
+				DB: DefaultReplicationRedisDB,
+			},
+		},
+		BackToSourceQuota: BackToSourceQuotaConfig{
+			Enable:   false,
+			Interval: DefaultBackToSourceQuotaInterval,
+			TTL:      DefaultBackToSourceQuotaTTL,
+			Redis: SharedRedisConfig{
+				DB: DefaultBackToSourceQuotaRedisDB,
+			},
+		},
 	}
 }
 
@@ -487,6 +690,36 @@ func (cfg *Config) Validate() error {
 		return errors.New("scheduler requires parameter hostTTL")
 	}
 
+	if err := cfg.Scheduler.EvaluatorWeights.Validate(); err != nil {
+		return fmt.Errorf("scheduler requires parameter evaluatorWeights: %w", err)
+	}
+
+	if cfg.Scheduler.DecisionLog.Enable {
+		if cfg.Scheduler.DecisionLog.TTL <= 0 {
+			return errors.New("scheduler requires parameter decisionLog ttl")
+		}
+
+		if cfg.Scheduler.DecisionLog.Limit <= 0 {
+			return errors.New("scheduler requires parameter decisionLog limit")
+		}
+	}
+
+	if cfg.Scheduler.Snapshot.Enable {
+		if cfg.Scheduler.Snapshot.Interval <= 0 {
+			return errors.New("scheduler requires parameter snapshot interval")
+		}
+
+		if cfg.Scheduler.Snapshot.TTL <= 0 {
+			return errors.New("scheduler requires parameter snapshot ttl")
+		}
+	}
+
+	if cfg.Scheduler.Preemption.Enable {
+		if cfg.Scheduler.Preemption.Interval < 0 {
+			return errors.New("scheduler requires parameter preemption interval")
+		}
+	}
+
 	if cfg.DynConfig.RefreshInterval <= 0 {
 		return errors.New("dynconfig requires parameter refreshInterval")
 	}
@@ -516,6 +749,10 @@ func (cfg *Config) Validate() error {
 			return errors.New("job requires parameter localWorkerNum")
 		}
 
+		if cfg.Job.PreheatConcurrency == 0 {
+			return errors.New("job requires parameter preheatConcurrency")
+		}
+
 		if len(cfg.Job.Redis.Addrs) == 0 {
 			return errors.New("job requires parameter addrs")
 		}
@@ -541,6 +778,10 @@ func (cfg *Config) Validate() error {
 		return errors.New("storage requires parameter bufferSize")
 	}
 
+	if cfg.Storage.Format != "csv" && cfg.Storage.Format != "jsonl" {
+		return errors.New("storage requires parameter format")
+	}
+
 	if cfg.Metrics.Enable {
 		if cfg.Metrics.Addr == "" {
 			return errors.New("metrics requires parameter addr")
@@ -577,6 +818,10 @@ func (cfg *Config) Validate() error {
 		return errors.New("networkTopology requires parameter collectInterval")
 	}
 
+	if cfg.NetworkTopology.HostTTL <= 0 {
+		return errors.New("networkTopology requires parameter hostTTL")
+	}
+
 	if cfg.NetworkTopology.Probe.QueueLength <= 0 {
 		return errors.New("probe requires parameter queueLength")
 	}
@@ -599,6 +844,34 @@ func (cfg *Config) Validate() error {
 		}
 	}
 
+	if cfg.Replication.Enable {
+		if cfg.Replication.Interval <= 0 {
+			return errors.New("replication requires parameter interval")
+		}
+
+		if cfg.Replication.TTL <= cfg.Replication.Interval {
+			return errors.New("replication requires parameter ttl which is greater than interval")
+		}
+
+		if len(cfg.Replication.Redis.Addrs) == 0 {
+			return errors.New("replication requires parameter redis addrs")
+		}
+	}
+
+	if cfg.BackToSourceQuota.Enable {
+		if cfg.BackToSourceQuota.Interval <= 0 {
+			return errors.New("backToSourceQuota requires parameter interval")
+		}
+
+		if cfg.BackToSourceQuota.TTL <= cfg.BackToSourceQuota.Interval {
+			return errors.New("backToSourceQuota requires parameter ttl which is greater than interval")
+		}
+
+		if len(cfg.BackToSourceQuota.Redis.Addrs) == 0 {
+			return errors.New("backToSourceQuota requires parameter redis addrs")
+		}
+	}
+
 	return nil
 }
 
@@ -43,6 +43,7 @@ var (
 		GlobalWorkerNum:    DefaultJobGlobalWorkerNum,
 		SchedulerWorkerNum: DefaultJobSchedulerWorkerNum,
 		LocalWorkerNum:     DefaultJobLocalWorkerNum,
+		PreheatConcurrency: DefaultJobPreheatConcurrency,
 		Redis: RedisConfig{
 			Addrs:      []string{"127.0.0.1:6379"},
 			MasterName: "master",
@@ -86,6 +87,29 @@ func TestConfig_Load(t *testing.T) {
 				HostGCInterval:       1 * time.Minute,
 				HostTTL:              1 * time.Minute,
 			},
+			EvaluatorWeights: EvaluatorWeightsConfig{
+				FinishedPiece:           0.3,
+				ParentHostUploadSuccess: 0.2,
+				FreeUpload:              0.2,
+				HostType:                0.1,
+				IDCAffinity:             0.05,
+				LocationAffinity:        0.05,
+				RTT:                     0.1,
+			},
+			DecisionLog: DecisionLogConfig{
+				Enable: true,
+				TTL:    5 * time.Minute,
+				Limit:  20,
+			},
+			Snapshot: SnapshotConfig{
+				Enable:   true,
+				Interval: 2 * time.Minute,
+				TTL:      20 * time.Minute,
+			},
+			Preemption: PreemptionConfig{
+				Enable:   true,
+				Interval: 10 * time.Second,
+			},
 		},
 		Server: ServerConfig{
 			AdvertiseIP:   net.ParseIP("127.0.0.1"),
@@ -121,6 +145,7 @@ func TestConfig_Load(t *testing.T) {
 			GlobalWorkerNum:    1,
 			SchedulerWorkerNum: 1,
 			LocalWorkerNum:     5,
+			PreheatConcurrency: 10,
 			Redis: RedisConfig{
 				Addrs:      []string{"foo", "bar"},
 				MasterName: "baz",
@@ -135,6 +160,10 @@ func TestConfig_Load(t *testing.T) {
 			MaxSize:    1,
 			MaxBackups: 1,
 			BufferSize: 1,
+			Format:     "jsonl",
+			Export: StorageExportConfig{
+				Enable: true,
+			},
 		},
 		Metrics: MetricsConfig{
 			Enable:     false,
@@ -159,6 +188,7 @@ func TestConfig_Load(t *testing.T) {
 			Enable:          true,
 			SyncInterval:    30 * time.Second,
 			CollectInterval: 60 * time.Second,
+			HostTTL:         time.Hour,
 			Probe: ProbeConfig{
 				QueueLength:  5,
 				SyncInterval: 30 * time.Second,
@@ -170,6 +200,30 @@ func TestConfig_Load(t *testing.T) {
 			Addr:     "127.0.0.1:9000",
 			Interval: 10 * time.Minute,
 		},
+		Replication: ReplicationConfig{
+			Enable:   true,
+			Interval: 5 * time.Second,
+			TTL:      30 * time.Second,
+			Redis: SharedRedisConfig{
+				Addrs:      []string{"127.0.0.1:6379"},
+				MasterName: "master",
+				Username:   "baz",
+				Password:   "bax",
+				DB:         4,
+			},
+		},
+		BackToSourceQuota: BackToSourceQuotaConfig{
+			Enable:   true,
+			Interval: 5 * time.Second,
+			TTL:      30 * time.Second,
+			Redis: SharedRedisConfig{
+				Addrs:      []string{"127.0.0.1:6379"},
+				MasterName: "master",
+				Username:   "baz",
+				Password:   "bax",
+				DB:         5,
+			},
+		},
 	}
 
 	schedulerConfigYAML := &Config{}
@@ -396,6 +450,102 @@ func TestConfig_Validate(t *testing.T) {
 				assert.EqualError(err, "scheduler requires parameter hostTTL")
 			},
 		},
+		{
+			name:   "scheduler requires parameter evaluatorWeights whose sum is 1",
+			config: New(),
+			mock: func(cfg *Config) {
+				cfg.Manager = mockManagerConfig
+				cfg.Job = mockJobConfig
+				cfg.Scheduler.EvaluatorWeights.FinishedPiece = 0.5
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "scheduler requires parameter evaluatorWeights: sum of weights is 1.30, not 1")
+			},
+		},
+		{
+			name:   "scheduler requires parameter evaluatorWeights which is not negative",
+			config: New(),
+			mock: func(cfg *Config) {
+				cfg.Manager = mockManagerConfig
+				cfg.Job = mockJobConfig
+				cfg.Scheduler.EvaluatorWeights.FinishedPiece = -0.1
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "scheduler requires parameter evaluatorWeights: invalid weight -0.10")
+			},
+		},
+		{
+			name:   "scheduler requires parameter decisionLog ttl",
+			config: New(),
+			mock: func(cfg *Config) {
+				cfg.Manager = mockManagerConfig
+				cfg.Job = mockJobConfig
+				cfg.Scheduler.DecisionLog.Enable = true
+				cfg.Scheduler.DecisionLog.TTL = 0
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "scheduler requires parameter decisionLog ttl")
+			},
+		},
+		{
+			name:   "scheduler requires parameter decisionLog limit",
+			config: New(),
+			mock: func(cfg *Config) {
+				cfg.Manager = mockManagerConfig
+				cfg.Job = mockJobConfig
+				cfg.Scheduler.DecisionLog.Enable = true
+				cfg.Scheduler.DecisionLog.Limit = 0
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "scheduler requires parameter decisionLog limit")
+			},
+		},
+		{
+			name:   "scheduler requires parameter snapshot interval",
+			config: New(),
+			mock: func(cfg *Config) {
+				cfg.Manager = mockManagerConfig
+				cfg.Job = mockJobConfig
+				cfg.Scheduler.Snapshot.Enable = true
+				cfg.Scheduler.Snapshot.Interval = 0
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "scheduler requires parameter snapshot interval")
+			},
+		},
+		{
+			name:   "scheduler requires parameter preemption interval",
+			config: New(),
+			mock: func(cfg *Config) {
+				cfg.Manager = mockManagerConfig
+				cfg.Job = mockJobConfig
+				cfg.Scheduler.Preemption.Enable = true
+				cfg.Scheduler.Preemption.Interval = -1
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "scheduler requires parameter preemption interval")
+			},
+		},
+		{
+			name:   "scheduler requires parameter snapshot ttl",
+			config: New(),
+			mock: func(cfg *Config) {
+				cfg.Manager = mockManagerConfig
+				cfg.Job = mockJobConfig
+				cfg.Scheduler.Snapshot.Enable = true
+				cfg.Scheduler.Snapshot.TTL = 0
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "scheduler requires parameter snapshot ttl")
+			},
+		},
 		{
 			name:   "dynconfig requires parameter refreshInterval",
 			config: New(),
@@ -487,6 +637,19 @@ func TestConfig_Validate(t *testing.T) {
 				assert.EqualError(err, "job requires parameter localWorkerNum")
 			},
 		},
+		{
+			name:   "job requires parameter preheatConcurrency",
+			config: New(),
+			mock: func(cfg *Config) {
+				cfg.Manager = mockManagerConfig
+				cfg.Job = mockJobConfig
+				cfg.Job.PreheatConcurrency = 0
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "job requires parameter preheatConcurrency")
+			},
+		},
 		{
 			name:   "job requires parameter addrs",
 			config: New(),
@@ -565,6 +728,19 @@ func TestConfig_Validate(t *testing.T) {
 				assert.EqualError(err, "storage requires parameter bufferSize")
 			},
 		},
+		{
+			name:   "storage requires parameter format",
+			config: New(),
+			mock: func(cfg *Config) {
+				cfg.Manager = mockManagerConfig
+				cfg.Job = mockJobConfig
+				cfg.Storage.Format = "parquet"
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "storage requires parameter format")
+			},
+		},
 		{
 			name:   "metrics requires parameter addr",
 			config: New(),
@@ -675,6 +851,19 @@ func TestConfig_Validate(t *testing.T) {
 				assert.EqualError(err, "networkTopology requires parameter collectInterval")
 			},
 		},
+		{
+			name:   "networkTopology requires parameter hostTTL",
+			config: New(),
+			mock: func(cfg *Config) {
+				cfg.Manager = mockManagerConfig
+				cfg.Job = mockJobConfig
+				cfg.NetworkTopology.HostTTL = 0
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "networkTopology requires parameter hostTTL")
+			},
+		},
 		{
 			name:   "probe requires parameter queueLength",
 			config: New(),
@@ -742,6 +931,88 @@ func TestConfig_Validate(t *testing.T) {
 				assert.EqualError(err, "trainer requires parameter interval")
 			},
 		},
+		{
+			name:   "replication requires parameter interval",
+			config: New(),
+			mock: func(cfg *Config) {
+				cfg.Manager = mockManagerConfig
+				cfg.Job = mockJobConfig
+				cfg.Replication.Enable = true
+				cfg.Replication.Interval = 0
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "replication requires parameter interval")
+			},
+		},
+		{
+			name:   "replication requires parameter ttl which is greater than interval",
+			config: New(),
+			mock: func(cfg *Config) {
+				cfg.Manager = mockManagerConfig
+				cfg.Job = mockJobConfig
+				cfg.Replication.Enable = true
+				cfg.Replication.TTL = cfg.Replication.Interval
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "replication requires parameter ttl which is greater than interval")
+			},
+		},
+		{
+			name:   "replication requires parameter redis addrs",
+			config: New(),
+			mock: func(cfg *Config) {
+				cfg.Manager = mockManagerConfig
+				cfg.Job = mockJobConfig
+				cfg.Replication.Enable = true
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "replication requires parameter redis addrs")
+			},
+		},
+		{
+			name:   "backToSourceQuota requires parameter interval",
+			config: New(),
+			mock: func(cfg *Config) {
+				cfg.Manager = mockManagerConfig
+				cfg.Job = mockJobConfig
+				cfg.BackToSourceQuota.Enable = true
+				cfg.BackToSourceQuota.Interval = 0
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "backToSourceQuota requires parameter interval")
+			},
+		},
+		{
+			name:   "backToSourceQuota requires parameter ttl which is greater than interval",
+			config: New(),
+			mock: func(cfg *Config) {
+				cfg.Manager = mockManagerConfig
+				cfg.Job = mockJobConfig
+				cfg.BackToSourceQuota.Enable = true
+				cfg.BackToSourceQuota.TTL = cfg.BackToSourceQuota.Interval
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "backToSourceQuota requires parameter ttl which is greater than interval")
+			},
+		},
+		{
+			name:   "backToSourceQuota requires parameter redis addrs",
+			config: New(),
+			mock: func(cfg *Config) {
+				cfg.Manager = mockManagerConfig
+				cfg.Job = mockJobConfig
+				cfg.BackToSourceQuota.Enable = true
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "backToSourceQuota requires parameter redis addrs")
+			},
+		},
 	}
 
 	for _, tc := range tests {
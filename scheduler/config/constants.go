@@ -40,6 +40,50 @@ const (
 	DefaultSchedulerFilterParentRangeLimit = 40
 )
 
+const (
+	// DefaultEvaluatorFinishedPieceWeight is default weight of finished piece score.
+	DefaultEvaluatorFinishedPieceWeight = 0.2
+
+	// DefaultEvaluatorParentHostUploadSuccessWeight is default weight of parent's host upload success score.
+	DefaultEvaluatorParentHostUploadSuccessWeight = 0.2
+
+	// DefaultEvaluatorFreeUploadWeight is default weight of free upload score.
+	DefaultEvaluatorFreeUploadWeight = 0.15
+
+	// DefaultEvaluatorHostTypeWeight is default weight of host type score.
+	DefaultEvaluatorHostTypeWeight = 0.15
+
+	// DefaultEvaluatorIDCAffinityWeight is default weight of IDC affinity score.
+	DefaultEvaluatorIDCAffinityWeight = 0.05
+
+	// DefaultEvaluatorLocationAffinityWeight is default weight of location affinity score.
+	DefaultEvaluatorLocationAffinityWeight = 0.05
+
+	// DefaultEvaluatorRTTWeight is default weight of round-trip time score.
+	DefaultEvaluatorRTTWeight = 0.2
+)
+
+const (
+	// DefaultSchedulerDecisionLogTTL is default time to live of the scheduling decisions.
+	DefaultSchedulerDecisionLogTTL = 10 * time.Minute
+
+	// DefaultSchedulerDecisionLogLimit is default limit the number of decisions recorded for a peer.
+	DefaultSchedulerDecisionLogLimit = 10
+)
+
+const (
+	// DefaultSchedulerSnapshotInterval is default interval of snapshot.
+	DefaultSchedulerSnapshotInterval = 1 * time.Minute
+
+	// DefaultSchedulerSnapshotTTL is default time to live of snapshot.
+	DefaultSchedulerSnapshotTTL = 10 * time.Minute
+)
+
+const (
+	// DefaultSchedulerPreemptionInterval is default minimum interval between two preemptions in the same host.
+	DefaultSchedulerPreemptionInterval = 5 * time.Second
+)
+
 const (
 	// DefaultServerPort is default port for server.
 	DefaultServerPort = 8002
@@ -112,6 +156,9 @@ const (
 	// DefaultJobGlobalWorkerNum is default local worker number for job.
 	DefaultJobLocalWorkerNum = 1000
 
+	// DefaultJobPreheatConcurrency is default number of peers preheating concurrently in a preheat job.
+	DefaultJobPreheatConcurrency = 50
+
 	// DefaultJobRedisBrokerDB is default db for redis broker.
 	DefaultJobRedisBrokerDB = 1
 
@@ -149,6 +196,9 @@ const (
 
 	// DefaultStorageBufferSize is the default size of buffer container.
 	DefaultStorageBufferSize = 100
+
+	// DefaultStorageFormat is the default format of storage file.
+	DefaultStorageFormat = "csv"
 )
 
 const (
@@ -160,6 +210,10 @@ const (
 	// DefaultNetworkTopologyCollectInterval is the default interval of collecting network topology.
 	DefaultNetworkTopologyCollectInterval = 60 * time.Second
 
+	// DefaultNetworkTopologyHostTTL is the default time to live of the hosts
+	// which are not managed by the scheduler in network topology.
+	DefaultNetworkTopologyHostTTL = 1 * time.Hour
+
 	// DefaultProbeQueueLength is the default length of probe queue in directed graph.
 	DefaultProbeQueueLength = 5
 
@@ -179,3 +233,23 @@ const (
 	// DefaultTrainerInterval is the default interval of training.
 	DefaultTrainerInterval = 7 * 24 * time.Hour
 )
+
+const (
+	// DefaultReplicationInterval is the default interval of replicating tasks.
+	DefaultReplicationInterval = 10 * time.Second
+
+	// DefaultReplicationTTL is the default time to live of replicas.
+	DefaultReplicationTTL = 1 * time.Minute
+
+	// DefaultReplicationRedisDB is the default db for replication.
+	DefaultReplicationRedisDB = 3
+
+	// DefaultBackToSourceQuotaInterval is the default interval of renewing back-to-source leases.
+	DefaultBackToSourceQuotaInterval = 10 * time.Second
+
+	// DefaultBackToSourceQuotaTTL is the default time to live of back-to-source leases.
+	DefaultBackToSourceQuotaTTL = 1 * time.Minute
+
+	// DefaultBackToSourceQuotaRedisDB is the default db for back-to-source quota.
+	DefaultBackToSourceQuotaRedisDB = 4
+)
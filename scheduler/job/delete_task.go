@@ -0,0 +1,323 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	machineryv1tasks "github.com/RichardKnop/machinery/v1/tasks"
+	"github.com/go-http-utils/headers"
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	commonv1 "d7y.io/api/pkg/apis/common/v1"
+	dfdaemonv1 "d7y.io/api/pkg/apis/dfdaemon/v1"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	internaljob "d7y.io/dragonfly/v2/internal/job"
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	"d7y.io/dragonfly/v2/pkg/net/http"
+	"d7y.io/dragonfly/v2/scheduler/resource"
+)
+
+const (
+	// deleteTaskTimeout is timeout of deleting task in a host.
+	deleteTaskTimeout = 1 * time.Minute
+)
+
+// deleteTask evicts the task from the scheduler, and instructs the hosts
+// of peers holding the task to delete the data of task.
+func (j *job) deleteTask(ctx context.Context, req string) (string, error) {
+	deleteTask := &internaljob.DeleteTaskRequest{}
+	if err := internaljob.UnmarshalRequest(req, deleteTask); err != nil {
+		logger.Errorf("unmarshal request err: %s, request body: %s", err.Error(), req)
+		return "", err
+	}
+
+	if err := validator.New().Struct(deleteTask); err != nil {
+		logger.Errorf("delete task %s %s validate failed: %s", deleteTask.TaskID, deleteTask.URL, err.Error())
+		return "", err
+	}
+
+	taskID := deleteTask.TaskID
+	url := deleteTask.URL
+	var urlMeta *commonv1.UrlMeta
+	if url != "" {
+		urlMeta = newURLMeta(deleteTask.Tag, deleteTask.Digest, deleteTask.Filter, deleteTask.Application, deleteTask.Headers)
+		taskID = idgen.TaskIDV1(url, urlMeta)
+	}
+
+	log := logger.WithTask(taskID, url)
+	resp := &internaljob.DeleteTaskResponse{
+		TaskID:            taskID,
+		URL:               url,
+		SchedulerHostname: j.config.Server.Host,
+		SchedulerIP:       j.config.Server.AdvertiseIP.String(),
+	}
+
+	// The hosts failed to delete the task in the previous jobs are retried.
+	var hosts []*resource.Host
+	pending, hasPending := j.loadPendingDeleteTask(taskID)
+	if hasPending {
+		hosts = append(hosts, pending.hosts...)
+		if url == "" {
+			url, urlMeta = pending.url, pending.urlMeta
+			resp.URL = url
+		}
+	}
+
+	task, loaded := j.resource.TaskManager().Load(taskID)
+	if !loaded && !hasPending {
+		log.Info("task not found in scheduler")
+		return marshalResponse(resp)
+	}
+
+	if loaded {
+		// The task is found by task id, the url and the metadata are restored from task.
+		if url == "" {
+			url, urlMeta = taskURLMeta(task)
+			resp.URL = url
+			if idgen.TaskIDV1(url, urlMeta) != task.ID {
+				log.Warn("task id is not generated by url meta of task, hosts may not find the task")
+			}
+		}
+
+		for _, host := range j.evictTask(ctx, task) {
+			if hasPending && pending.contains(host.ID) {
+				continue
+			}
+
+			hosts = append(hosts, host)
+		}
+		log.Infof("task is evicted, delete task in %d hosts", len(hosts))
+	} else {
+		log.Infof("task is evicted before, retry to delete task in %d hosts", len(hosts))
+	}
+
+	// Delete task in hosts concurrently.
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for _, host := range hosts {
+		wg.Add(1)
+		go func(host *resource.Host) {
+			defer wg.Done()
+
+			hostResp := &internaljob.DeleteTaskHostResponse{
+				ID:       host.ID,
+				Hostname: host.Hostname,
+				IP:       host.IP,
+			}
+
+			if err := j.deleteTaskInHost(ctx, host, url, urlMeta); err != nil {
+				log.Errorf("delete task in host %s failed: %s", host.ID, err.Error())
+				hostResp.Error = err.Error()
+
+				mu.Lock()
+				resp.FailedHosts = append(resp.FailedHosts, hostResp)
+				mu.Unlock()
+				return
+			}
+
+			mu.Lock()
+			resp.SucceededHosts = append(resp.SucceededHosts, hostResp)
+			mu.Unlock()
+		}(host)
+	}
+	wg.Wait()
+
+	if len(resp.FailedHosts) > 0 {
+		// Keep the failed hosts, because the scheduler can not find them after the task is evicted.
+		failedHosts := &pendingDeleteTask{url: url, urlMeta: urlMeta}
+		for _, host := range hosts {
+			for _, hostResp := range resp.FailedHosts {
+				if hostResp.ID == host.ID {
+					failedHosts.hosts = append(failedHosts.hosts, host)
+					break
+				}
+			}
+		}
+		j.pendingDeleteTasks.Store(taskID, failedHosts)
+
+		// The result of failed job is dropped, report the failed hosts as the progress of job.
+		j.reportJobProgress(ctx, resp)
+
+		log.Errorf("delete task failed in %d of %d hosts", len(resp.FailedHosts), len(hosts))
+		return "", fmt.Errorf("delete task failed in %d of %d hosts", len(resp.FailedHosts), len(hosts))
+	}
+
+	return marshalResponse(resp)
+}
+
+// pendingDeleteTask is the evicted task whose data is not deleted in hosts.
+type pendingDeleteTask struct {
+	url     string
+	urlMeta *commonv1.UrlMeta
+	hosts   []*resource.Host
+}
+
+// contains returns whether the host is pending.
+func (p *pendingDeleteTask) contains(hostID string) bool {
+	for _, host := range p.hosts {
+		if host.ID == hostID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadPendingDeleteTask loads and removes the pending task, the failed hosts
+// are stored again if deleting task in them fails.
+func (j *job) loadPendingDeleteTask(taskID string) (*pendingDeleteTask, bool) {
+	value, loaded := j.pendingDeleteTasks.LoadAndDelete(taskID)
+	if !loaded {
+		return nil, false
+	}
+
+	return value.(*pendingDeleteTask), true
+}
+
+// evictTask makes the peers of task leave, and removes the peers, the task and
+// the replica of task from the scheduler, it returns the hosts of peers.
+func (j *job) evictTask(ctx context.Context, task *resource.Task) []*resource.Host {
+	var hosts []*resource.Host
+	visited := make(map[string]struct{})
+	for _, vertex := range task.DAG.GetVertices() {
+		peer := vertex.Value
+		if peer == nil {
+			continue
+		}
+
+		if !peer.FSM.Is(resource.PeerStateLeave) {
+			if err := peer.FSM.Event(ctx, resource.PeerEventLeave); err != nil {
+				peer.Log.Errorf("peer fsm event failed: %s", err.Error())
+			}
+		}
+		j.resource.PeerManager().Delete(peer.ID)
+
+		if _, ok := visited[peer.Host.ID]; !ok {
+			visited[peer.Host.ID] = struct{}{}
+			hosts = append(hosts, peer.Host)
+		}
+	}
+
+	j.resource.TaskManager().Delete(task.ID)
+
+	// The replica of task is deleted, otherwise the task and the peers
+	// holding the deleted data are restored in the next take over.
+	if replicator := j.resource.Replicator(); replicator != nil {
+		if err := replicator.Delete(ctx, task.ID); err != nil {
+			task.Log.Errorf("delete replica failed: %s", err.Error())
+		}
+	}
+
+	return hosts
+}
+
+// deleteTaskInHost instructs the dfdaemon of host to delete the data of task.
+func (j *job) deleteTaskInHost(ctx context.Context, host *resource.Host, url string, urlMeta *commonv1.UrlMeta) error {
+	ctx, cancel := context.WithTimeout(ctx, deleteTaskTimeout)
+	defer cancel()
+
+	dialOptions := []grpc.DialOption{}
+	if j.transportCredentials != nil {
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(j.transportCredentials))
+	} else {
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	client, err := j.getDfdaemonClient(ctx, fmt.Sprintf("%s:%d", host.IP, host.Port), dialOptions...)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return client.DeleteTask(ctx, &dfdaemonv1.DeleteTaskRequest{
+		Url:     url,
+		UrlMeta: urlMeta,
+	})
+}
+
+// reportJobProgress reports the progress of job to the result backend, the progress
+// is not reported if the job is not run by machinery worker.
+func (j *job) reportJobProgress(ctx context.Context, progress any) {
+	signature := machineryv1tasks.SignatureFromContext(ctx)
+	if signature == nil || j.localJob == nil {
+		return
+	}
+
+	if err := j.localJob.SetJobProgress(context.Background(), signature.UUID, progress); err != nil {
+		logger.Warnf("report progress of job %s failed: %s", signature.UUID, err.Error())
+	}
+}
+
+// newURLMeta returns the url meta generating task id with the url.
+func newURLMeta(tag, digest, filter, application string, header map[string]string) *commonv1.UrlMeta {
+	urlMeta := &commonv1.UrlMeta{
+		Digest:      digest,
+		Tag:         tag,
+		Filter:      filter,
+		Header:      header,
+		Application: application,
+	}
+
+	if r, ok := header[headers.Range]; ok {
+		// Range in dragonfly is without "bytes=".
+		urlMeta.Range = strings.TrimLeft(r, http.RangePrefix)
+	}
+
+	return urlMeta
+}
+
+// taskURLMeta returns the url and the url meta of task.
+func taskURLMeta(task *resource.Task) (string, *commonv1.UrlMeta) {
+	urlMeta := &commonv1.UrlMeta{
+		Tag:         task.Tag,
+		Filter:      strings.Join(task.Filters, idgen.URLFilterSeparator),
+		Header:      task.Header,
+		Application: task.Application,
+	}
+
+	if task.Digest != nil {
+		urlMeta.Digest = task.Digest.String()
+	}
+
+	if r, ok := task.Header[headers.Range]; ok {
+		// Range in dragonfly is without "bytes=".
+		urlMeta.Range = strings.TrimLeft(r, http.RangePrefix)
+	}
+
+	return task.URL, urlMeta
+}
+
+// marshalResponse returns the response in json as the result of job.
+func marshalResponse(resp any) (string, error) {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
@@ -0,0 +1,255 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+
+	commonv1 "d7y.io/api/pkg/apis/common/v1"
+	commonv2 "d7y.io/api/pkg/apis/common/v2"
+	dfdaemonv1 "d7y.io/api/pkg/apis/dfdaemon/v1"
+
+	internaljob "d7y.io/dragonfly/v2/internal/job"
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	dfdaemonclient "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon/client"
+	dfdaemonclientmocks "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon/client/mocks"
+	"d7y.io/dragonfly/v2/scheduler/config"
+	"d7y.io/dragonfly/v2/scheduler/resource"
+)
+
+var (
+	mockConfig = &config.Config{
+		Server: config.ServerConfig{
+			Host:        "scheduler",
+			AdvertiseIP: net.ParseIP("127.0.0.10"),
+		},
+	}
+
+	mockTaskURL = "http://example.com/foo"
+	mockTaskID  = idgen.TaskIDV1(mockTaskURL, &commonv1.UrlMeta{Tag: "d7y", Application: "bar"})
+)
+
+func TestJob_deleteTask(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    *internaljob.DeleteTaskRequest
+		mock   func(task *resource.Task, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder, mr *resource.MockReplicatorMockRecorder, mc *dfdaemonclientmocks.MockV1MockRecorder)
+		expect func(t *testing.T, task *resource.Task, resp *internaljob.DeleteTaskResponse, err error)
+	}{
+		{
+			name: "invalid request",
+			req:  &internaljob.DeleteTaskRequest{},
+			mock: func(task *resource.Task, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder, mr *resource.MockReplicatorMockRecorder, mc *dfdaemonclientmocks.MockV1MockRecorder) {
+			},
+			expect: func(t *testing.T, task *resource.Task, resp *internaljob.DeleteTaskResponse, err error) {
+				assert := assert.New(t)
+				assert.Error(err)
+			},
+		},
+		{
+			name: "task not found",
+			req:  &internaljob.DeleteTaskRequest{TaskID: mockTaskID},
+			mock: func(task *resource.Task, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder, mr *resource.MockReplicatorMockRecorder, mc *dfdaemonclientmocks.MockV1MockRecorder) {
+				mt.Load(gomock.Eq(mockTaskID)).Return(nil, false).Times(1)
+			},
+			expect: func(t *testing.T, task *resource.Task, resp *internaljob.DeleteTaskResponse, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(resp.TaskID, mockTaskID)
+				assert.Equal(resp.SchedulerHostname, mockConfig.Server.Host)
+				assert.Equal(len(resp.SucceededHosts), 0)
+			},
+		},
+		{
+			name: "delete task by url",
+			req:  &internaljob.DeleteTaskRequest{URL: mockTaskURL, Tag: "d7y", Application: "bar"},
+			mock: func(task *resource.Task, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder, mr *resource.MockReplicatorMockRecorder, mc *dfdaemonclientmocks.MockV1MockRecorder) {
+				mt.Load(gomock.Eq(mockTaskID)).Return(task, true).Times(1)
+				mp.Delete(gomock.Any()).Do(func(id string) { task.DeletePeer(id) }).Times(3)
+				mt.Delete(gomock.Eq(mockTaskID)).Times(1)
+				mr.Delete(gomock.Any(), gomock.Eq(mockTaskID)).Return(nil).Times(1)
+				mc.DeleteTask(gomock.Any(), gomock.Any()).Do(func(_ context.Context, req *dfdaemonv1.DeleteTaskRequest, _ ...grpc.CallOption) {
+					assert := assert.New(t)
+					assert.Equal(req.Url, mockTaskURL)
+					assert.Equal(idgen.TaskIDV1(req.Url, req.UrlMeta), mockTaskID)
+				}).Return(nil).Times(2)
+				mc.Close().Return(nil).Times(2)
+			},
+			expect: func(t *testing.T, task *resource.Task, resp *internaljob.DeleteTaskResponse, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(len(resp.SucceededHosts), 2)
+				assert.Equal(len(resp.FailedHosts), 0)
+				assert.Equal(task.PeerCount(), 0)
+			},
+		},
+		{
+			name: "delete task by task id",
+			req:  &internaljob.DeleteTaskRequest{TaskID: mockTaskID},
+			mock: func(task *resource.Task, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder, mr *resource.MockReplicatorMockRecorder, mc *dfdaemonclientmocks.MockV1MockRecorder) {
+				mt.Load(gomock.Eq(mockTaskID)).Return(task, true).Times(1)
+				mp.Delete(gomock.Any()).Do(func(id string) { task.DeletePeer(id) }).Times(3)
+				mt.Delete(gomock.Eq(mockTaskID)).Times(1)
+				mr.Delete(gomock.Any(), gomock.Eq(mockTaskID)).Return(nil).Times(1)
+				mc.DeleteTask(gomock.Any(), gomock.Any()).Do(func(_ context.Context, req *dfdaemonv1.DeleteTaskRequest, _ ...grpc.CallOption) {
+					assert := assert.New(t)
+					assert.Equal(idgen.TaskIDV1(req.Url, req.UrlMeta), mockTaskID)
+				}).Return(nil).Times(2)
+				mc.Close().Return(nil).Times(2)
+			},
+			expect: func(t *testing.T, task *resource.Task, resp *internaljob.DeleteTaskResponse, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(resp.URL, mockTaskURL)
+				assert.Equal(len(resp.SucceededHosts), 2)
+			},
+		},
+		{
+			name: "delete task in host failed",
+			req:  &internaljob.DeleteTaskRequest{TaskID: mockTaskID},
+			mock: func(task *resource.Task, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder, mr *resource.MockReplicatorMockRecorder, mc *dfdaemonclientmocks.MockV1MockRecorder) {
+				mt.Load(gomock.Eq(mockTaskID)).Return(task, true).Times(1)
+				mp.Delete(gomock.Any()).Times(3)
+				mt.Delete(gomock.Eq(mockTaskID)).Times(1)
+				mr.Delete(gomock.Any(), gomock.Eq(mockTaskID)).Return(nil).Times(1)
+				mc.DeleteTask(gomock.Any(), gomock.Any()).Return(errors.New("foo")).Times(2)
+				mc.Close().Return(nil).Times(2)
+			},
+			expect: func(t *testing.T, task *resource.Task, resp *internaljob.DeleteTaskResponse, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "delete task failed in 2 of 2 hosts")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			res := resource.NewMockResource(ctl)
+			taskManager := resource.NewMockTaskManager(ctl)
+			peerManager := resource.NewMockPeerManager(ctl)
+			replicator := resource.NewMockReplicator(ctl)
+			client := dfdaemonclientmocks.NewMockV1(ctl)
+			res.EXPECT().TaskManager().Return(taskManager).AnyTimes()
+			res.EXPECT().PeerManager().Return(peerManager).AnyTimes()
+			res.EXPECT().Replicator().Return(replicator).AnyTimes()
+
+			task := resource.NewTask(mockTaskID, mockTaskURL, "d7y", "bar", commonv2.TaskType_DFDAEMON, nil, nil, 1)
+			for _, peer := range []*resource.Peer{
+				resource.NewPeer("peer-0", task, mockHost),
+				resource.NewPeer("peer-1", task, mockHost),
+				resource.NewPeer("peer-2", task, mockSeedHost),
+			} {
+				task.StorePeer(peer)
+			}
+			tc.mock(task, taskManager.EXPECT(), peerManager.EXPECT(), replicator.EXPECT(), client.EXPECT())
+
+			j := &job{
+				resource: res,
+				config:   mockConfig,
+				getDfdaemonClient: func(context.Context, string, ...grpc.DialOption) (dfdaemonclient.V1, error) {
+					return client, nil
+				},
+			}
+
+			req, err := json.Marshal(tc.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := j.deleteTask(context.Background(), string(req))
+			resp := &internaljob.DeleteTaskResponse{}
+			if err == nil {
+				if err := json.Unmarshal([]byte(result), resp); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tc.expect(t, task, resp, err)
+		})
+	}
+}
+
+func TestJob_deleteTask_RetryFailedHosts(t *testing.T) {
+	assert := assert.New(t)
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	res := resource.NewMockResource(ctl)
+	taskManager := resource.NewMockTaskManager(ctl)
+	peerManager := resource.NewMockPeerManager(ctl)
+	client := dfdaemonclientmocks.NewMockV1(ctl)
+	res.EXPECT().TaskManager().Return(taskManager).AnyTimes()
+	res.EXPECT().PeerManager().Return(peerManager).AnyTimes()
+	res.EXPECT().Replicator().Return(nil).AnyTimes()
+
+	task := resource.NewTask(mockTaskID, mockTaskURL, "d7y", "bar", commonv2.TaskType_DFDAEMON, nil, nil, 1)
+	task.StorePeer(resource.NewPeer("peer-0", task, mockHost))
+	task.StorePeer(resource.NewPeer("peer-1", task, mockSeedHost))
+
+	j := &job{
+		resource: res,
+		config:   mockConfig,
+		getDfdaemonClient: func(context.Context, string, ...grpc.DialOption) (dfdaemonclient.V1, error) {
+			return client, nil
+		},
+	}
+
+	req, err := json.Marshal(&internaljob.DeleteTaskRequest{TaskID: mockTaskID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Deleting task in the seed host fails, and the task is evicted.
+	gomock.InOrder(
+		taskManager.EXPECT().Load(gomock.Eq(mockTaskID)).Return(task, true).Times(1),
+		taskManager.EXPECT().Load(gomock.Eq(mockTaskID)).Return(nil, false).Times(1),
+	)
+	peerManager.EXPECT().Delete(gomock.Any()).Times(2)
+	taskManager.EXPECT().Delete(gomock.Eq(mockTaskID)).Times(1)
+	client.EXPECT().Close().Return(nil).Times(3)
+	client.EXPECT().DeleteTask(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	client.EXPECT().DeleteTask(gomock.Any(), gomock.Any()).Return(errors.New("foo")).Times(1)
+	_, err = j.deleteTask(context.Background(), string(req))
+	assert.Error(err)
+
+	// The failed host is retried although the task is not found.
+	client.EXPECT().DeleteTask(gomock.Any(), gomock.Any()).Do(func(_ context.Context, req *dfdaemonv1.DeleteTaskRequest, _ ...grpc.CallOption) {
+		assert.Equal(req.Url, mockTaskURL)
+	}).Return(nil).Times(1)
+	result, err := j.deleteTask(context.Background(), string(req))
+	assert.NoError(err)
+
+	resp := &internaljob.DeleteTaskResponse{}
+	if err := json.Unmarshal([]byte(result), resp); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(resp.URL, mockTaskURL)
+	assert.Equal(len(resp.SucceededHosts), 1)
+	assert.Equal(len(resp.FailedHosts), 0)
+
+	_, ok := j.pendingDeleteTasks.Load(mockTaskID)
+	assert.False(ok)
+}
@@ -0,0 +1,208 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package job
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	internaljob "d7y.io/dragonfly/v2/internal/job"
+	"d7y.io/dragonfly/v2/pkg/container/set"
+	"d7y.io/dragonfly/v2/scheduler/resource"
+)
+
+const (
+	// drainHostTimeout is timeout of waiting for the hosts drained.
+	drainHostTimeout = 10 * time.Minute
+
+	// drainHostInterval is interval of checking whether the hosts are drained.
+	drainHostInterval = 1 * time.Second
+)
+
+// drainHost marks the hosts as draining, migrates the children downloading from
+// the hosts to other parents, and waits until the hosts are drained.
+func (j *job) drainHost(ctx context.Context, req string) (string, error) {
+	drainHost := &internaljob.DrainHostRequest{}
+	if err := internaljob.UnmarshalRequest(req, drainHost); err != nil {
+		logger.Errorf("unmarshal request err: %s, request body: %s", err.Error(), req)
+		return "", err
+	}
+
+	if err := validator.New().Struct(drainHost); err != nil {
+		logger.Errorf("drain host %s %s validate failed: %s", drainHost.HostID, drainHost.Hostname, err.Error())
+		return "", err
+	}
+
+	resp := &internaljob.DrainHostResponse{
+		SchedulerHostname: j.config.Server.Host,
+		SchedulerIP:       j.config.Server.AdvertiseIP.String(),
+		Hosts:             []*internaljob.DrainHostHostResponse{},
+	}
+
+	// Cancel draining of the deleted hosts, the hosts are not draining when they announce again.
+	if drainHost.Cancel {
+		j.resource.HostManager().CancelDraining(drainHost.HostID, drainHost.Hostname)
+	}
+
+	hosts := j.findDrainHosts(drainHost)
+	if len(hosts) == 0 {
+		logger.Infof("host %s %s not found in scheduler", drainHost.HostID, drainHost.Hostname)
+		return marshalResponse(resp)
+	}
+
+	for _, host := range hosts {
+		resp.Hosts = append(resp.Hosts, &internaljob.DrainHostHostResponse{
+			ID:       host.ID,
+			Hostname: host.Hostname,
+			IP:       host.IP,
+		})
+	}
+
+	// Cancel draining, the hosts can be selected as parents again.
+	if drainHost.Cancel {
+		for i, host := range hosts {
+			host.Draining.Store(false)
+			host.Log.Info("host cancels draining")
+			updateDrainHostResponse(resp.Hosts[i], host)
+		}
+
+		return marshalResponse(resp)
+	}
+
+	for i, host := range hosts {
+		host.Draining.Store(true)
+		host.Log.Info("host starts draining")
+		resp.Hosts[i].MigratedChildCount = j.migrateChildren(ctx, host)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, drainHostTimeout)
+	defer cancel()
+
+	tick := time.NewTicker(drainHostInterval)
+	defer tick.Stop()
+
+	for {
+		var drained int
+		for i, host := range hosts {
+			updateDrainHostResponse(resp.Hosts[i], host)
+			if resp.Hosts[i].Drained {
+				drained++
+			}
+		}
+
+		if drained == len(hosts) {
+			logger.Infof("%d hosts are drained", len(hosts))
+			return marshalResponse(resp)
+		}
+
+		j.reportJobProgress(ctx, resp)
+
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			// The hosts are still draining and not selected as parents, it is not a failure,
+			// the drained state of every host is reported in response.
+			logger.Warnf("%d of %d hosts are draining, not yet drained in %s", len(hosts)-drained, len(hosts), drainHostTimeout)
+			return marshalResponse(resp)
+		}
+	}
+}
+
+// findDrainHosts finds the hosts by the host id, or by the hostname.
+func (j *job) findDrainHosts(drainHost *internaljob.DrainHostRequest) []*resource.Host {
+	if drainHost.HostID != "" {
+		host, loaded := j.resource.HostManager().Load(drainHost.HostID)
+		if !loaded {
+			return nil
+		}
+
+		return []*resource.Host{host}
+	}
+
+	var hosts []*resource.Host
+	j.resource.HostManager().Range(func(_, value any) bool {
+		host, ok := value.(*resource.Host)
+		if !ok {
+			logger.Error("invalid host")
+			return true
+		}
+
+		if host.Hostname == drainHost.Hostname {
+			hosts = append(hosts, host)
+		}
+
+		return true
+	})
+
+	return hosts
+}
+
+// migrateChildren reschedules the running children downloading from the host,
+// the draining host is filtered by scheduling, so the children are migrated to
+// other parents. It returns the count of migrated children.
+func (j *job) migrateChildren(ctx context.Context, host *resource.Host) int {
+	var (
+		wg    sync.WaitGroup
+		count int
+	)
+	for _, child := range host.Children() {
+		if !child.FSM.Is(resource.PeerStateRunning) {
+			continue
+		}
+
+		blocklist := set.NewSafeSet[string]()
+		for _, id := range child.BlockParents.Values() {
+			blocklist.Add(id)
+		}
+
+		if _, loaded := child.LoadAnnouncePeerStream(); loaded {
+			count++
+			wg.Add(1)
+			go func(child *resource.Peer) {
+				defer wg.Done()
+				if err := j.scheduling.ScheduleCandidateParents(ctx, child, blocklist); err != nil {
+					child.Log.Errorf("migrate child failed: %s", err.Error())
+				}
+			}(child)
+			continue
+		}
+
+		if _, loaded := child.LoadReportPieceResultStream(); loaded {
+			count++
+			wg.Add(1)
+			go func(child *resource.Peer) {
+				defer wg.Done()
+				j.scheduling.ScheduleParentAndCandidateParents(ctx, child, blocklist)
+			}(child)
+		}
+	}
+
+	wg.Wait()
+	host.Log.Infof("%d children are migrated", count)
+	return count
+}
+
+// updateDrainHostResponse updates the draining state of host in response.
+func updateDrainHostResponse(resp *internaljob.DrainHostHostResponse, host *resource.Host) {
+	resp.Draining = host.Draining.Load()
+	resp.Drained = host.Drained()
+	resp.ConcurrentUploadCount = host.ConcurrentUploadCount.Load()
+}
@@ -0,0 +1,201 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	commonv2 "d7y.io/api/pkg/apis/common/v2"
+	schedulerv1mocks "d7y.io/api/pkg/apis/scheduler/v1/mocks"
+
+	internaljob "d7y.io/dragonfly/v2/internal/job"
+	"d7y.io/dragonfly/v2/pkg/container/set"
+	"d7y.io/dragonfly/v2/pkg/types"
+	"d7y.io/dragonfly/v2/scheduler/resource"
+	schedulingmocks "d7y.io/dragonfly/v2/scheduler/scheduling/mocks"
+)
+
+func TestJob_drainHost(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    *internaljob.DrainHostRequest
+		mock   func(host *resource.Host, child *resource.Peer, mh *resource.MockHostManagerMockRecorder, ms *schedulingmocks.MockSchedulingMockRecorder)
+		expect func(t *testing.T, host *resource.Host, resp *internaljob.DrainHostResponse, err error)
+	}{
+		{
+			name: "invalid request",
+			req:  &internaljob.DrainHostRequest{},
+			mock: func(host *resource.Host, child *resource.Peer, mh *resource.MockHostManagerMockRecorder, ms *schedulingmocks.MockSchedulingMockRecorder) {
+			},
+			expect: func(t *testing.T, host *resource.Host, resp *internaljob.DrainHostResponse, err error) {
+				assert := assert.New(t)
+				assert.Error(err)
+			},
+		},
+		{
+			name: "host not found",
+			req:  &internaljob.DrainHostRequest{HostID: "foo"},
+			mock: func(host *resource.Host, child *resource.Peer, mh *resource.MockHostManagerMockRecorder, ms *schedulingmocks.MockSchedulingMockRecorder) {
+				mh.Load(gomock.Eq("foo")).Return(nil, false).Times(1)
+			},
+			expect: func(t *testing.T, host *resource.Host, resp *internaljob.DrainHostResponse, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(resp.SchedulerHostname, mockConfig.Server.Host)
+				assert.Equal(len(resp.Hosts), 0)
+			},
+		},
+		{
+			name: "cancel draining",
+			req:  &internaljob.DrainHostRequest{HostID: "seed", Cancel: true},
+			mock: func(host *resource.Host, child *resource.Peer, mh *resource.MockHostManagerMockRecorder, ms *schedulingmocks.MockSchedulingMockRecorder) {
+				host.Draining.Store(true)
+				gomock.InOrder(
+					mh.CancelDraining(gomock.Eq("seed"), gomock.Eq("")).Times(1),
+					mh.Load(gomock.Eq("seed")).Return(host, true).Times(1),
+				)
+			},
+			expect: func(t *testing.T, host *resource.Host, resp *internaljob.DrainHostResponse, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.False(host.Draining.Load())
+				assert.Equal(len(resp.Hosts), 1)
+				assert.False(resp.Hosts[0].Draining)
+				assert.False(resp.Hosts[0].Drained)
+			},
+		},
+		{
+			name: "drain host by id and migrate children",
+			req:  &internaljob.DrainHostRequest{HostID: "seed"},
+			mock: func(host *resource.Host, child *resource.Peer, mh *resource.MockHostManagerMockRecorder, ms *schedulingmocks.MockSchedulingMockRecorder) {
+				mh.Load(gomock.Eq("seed")).Return(host, true).Times(1)
+				ms.ScheduleParentAndCandidateParents(gomock.Any(), gomock.Eq(child), gomock.Any()).Do(func(_ context.Context, child *resource.Peer, _ set.SafeSet[string]) {
+					if err := child.Task.DeletePeerInEdges(child.ID); err != nil {
+						t.Fatal(err)
+					}
+				}).Times(1)
+			},
+			expect: func(t *testing.T, host *resource.Host, resp *internaljob.DrainHostResponse, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(host.Draining.Load())
+				assert.Equal(len(resp.Hosts), 1)
+				assert.Equal(resp.Hosts[0].ID, host.ID)
+				assert.True(resp.Hosts[0].Draining)
+				assert.True(resp.Hosts[0].Drained)
+				assert.Equal(resp.Hosts[0].MigratedChildCount, 1)
+				assert.Equal(resp.Hosts[0].ConcurrentUploadCount, int32(0))
+			},
+		},
+		{
+			name: "drain host and host is not yet drained",
+			req:  &internaljob.DrainHostRequest{HostID: "seed"},
+			mock: func(host *resource.Host, child *resource.Peer, mh *resource.MockHostManagerMockRecorder, ms *schedulingmocks.MockSchedulingMockRecorder) {
+				host.ConcurrentUploadCount.Store(1)
+				mh.Load(gomock.Eq("seed")).Return(host, true).Times(1)
+				ms.ScheduleParentAndCandidateParents(gomock.Any(), gomock.Eq(child), gomock.Any()).Return().Times(1)
+			},
+			expect: func(t *testing.T, host *resource.Host, resp *internaljob.DrainHostResponse, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(host.Draining.Load())
+				assert.Equal(len(resp.Hosts), 1)
+				assert.True(resp.Hosts[0].Draining)
+				assert.False(resp.Hosts[0].Drained)
+				assert.Equal(resp.Hosts[0].ConcurrentUploadCount, int32(1))
+			},
+		},
+		{
+			name: "drain host by hostname",
+			req:  &internaljob.DrainHostRequest{Hostname: "node-0"},
+			mock: func(host *resource.Host, child *resource.Peer, mh *resource.MockHostManagerMockRecorder, ms *schedulingmocks.MockSchedulingMockRecorder) {
+				mh.Range(gomock.Any()).Do(func(f func(key, value any) bool) {
+					f(host.ID, host)
+					f(child.Host.ID, child.Host)
+				}).Times(1)
+			},
+			expect: func(t *testing.T, host *resource.Host, resp *internaljob.DrainHostResponse, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.False(host.Draining.Load())
+				assert.Equal(len(resp.Hosts), 1)
+				assert.Equal(resp.Hosts[0].Hostname, "node-0")
+				assert.True(resp.Hosts[0].Drained)
+				assert.Equal(resp.Hosts[0].MigratedChildCount, 0)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			res := resource.NewMockResource(ctl)
+			hostManager := resource.NewMockHostManager(ctl)
+			scheduling := schedulingmocks.NewMockScheduling(ctl)
+			stream := schedulerv1mocks.NewMockScheduler_ReportPieceResultServer(ctl)
+			res.EXPECT().HostManager().Return(hostManager).AnyTimes()
+
+			host := resource.NewHost("seed", "127.0.0.1", "seed-0", 8003, 8001, types.HostTypeSuperSeed)
+			childHost := resource.NewHost("normal", "127.0.0.3", "node-0", 8003, 8001, types.HostTypeNormal)
+			task := resource.NewTask(mockTaskID, mockTaskURL, "d7y", "bar", commonv2.TaskType_DFDAEMON, nil, nil, 1)
+			parent := resource.NewPeer("peer-0", task, host)
+			task.StorePeer(parent)
+			host.StorePeer(parent)
+			child := resource.NewPeer("peer-1", task, childHost)
+			child.FSM.SetState(resource.PeerStateRunning)
+			child.StoreReportPieceResultStream(stream)
+			task.StorePeer(child)
+			childHost.StorePeer(child)
+			if err := task.AddPeerEdge(parent, child); err != nil {
+				t.Fatal(err)
+			}
+
+			tc.mock(host, child, hostManager.EXPECT(), scheduling.EXPECT())
+
+			j := &job{
+				resource:   res,
+				scheduling: scheduling,
+				config:     mockConfig,
+			}
+
+			req, err := json.Marshal(tc.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			result, err := j.drainHost(ctx, string(req))
+			resp := &internaljob.DrainHostResponse{}
+			if err == nil {
+				if err := json.Unmarshal([]byte(result), resp); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tc.expect(t, host, resp, err)
+		})
+	}
+}
@@ -0,0 +1,119 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package job
+
+import (
+	"context"
+	"sort"
+
+	"github.com/go-playground/validator/v10"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	internaljob "d7y.io/dragonfly/v2/internal/job"
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	"d7y.io/dragonfly/v2/scheduler/resource"
+)
+
+// getTask finds the peers holding the task in the scheduler,
+// and returns how complete the copy of each peer is.
+func (j *job) getTask(ctx context.Context, req string) (string, error) {
+	getTask := &internaljob.GetTaskRequest{}
+	if err := internaljob.UnmarshalRequest(req, getTask); err != nil {
+		logger.Errorf("unmarshal request err: %s, request body: %s", err.Error(), req)
+		return "", err
+	}
+
+	if err := validator.New().Struct(getTask); err != nil {
+		logger.Errorf("get task %s %s validate failed: %s", getTask.TaskID, getTask.URL, err.Error())
+		return "", err
+	}
+
+	taskID := getTask.TaskID
+	if getTask.URL != "" {
+		taskID = idgen.TaskIDV1(getTask.URL, newURLMeta(getTask.Tag, getTask.Digest, getTask.Filter, getTask.Application, getTask.Headers))
+	}
+
+	resp := &internaljob.GetTaskResponse{
+		TaskID:            taskID,
+		URL:               getTask.URL,
+		SchedulerHostname: j.config.Server.Host,
+		SchedulerIP:       j.config.Server.AdvertiseIP.String(),
+		ContentLength:     -1,
+		Peers:             []*internaljob.GetTaskPeerResponse{},
+	}
+
+	task, loaded := j.resource.TaskManager().Load(taskID)
+	if !loaded {
+		logger.WithTask(taskID, getTask.URL).Info("task not found in scheduler")
+		return marshalResponse(resp)
+	}
+
+	resp.URL = task.URL
+	resp.State = task.FSM.Current()
+	resp.ContentLength = task.ContentLength.Load()
+	resp.TotalPieceCount = task.TotalPieceCount.Load()
+	for _, vertex := range task.DAG.GetVertices() {
+		peer := vertex.Value
+		if peer == nil {
+			continue
+		}
+
+		resp.Peers = append(resp.Peers, newGetTaskPeerResponse(peer, resp.TotalPieceCount))
+	}
+
+	// Peers are sorted by the completeness of copies, the most complete one is the first.
+	sort.SliceStable(resp.Peers, func(i, k int) bool {
+		if resp.Peers[i].FinishedPieceCount != resp.Peers[k].FinishedPieceCount {
+			return resp.Peers[i].FinishedPieceCount > resp.Peers[k].FinishedPieceCount
+		}
+
+		return resp.Peers[i].ID < resp.Peers[k].ID
+	})
+
+	task.Log.Infof("task is found in %d peers", len(resp.Peers))
+	return marshalResponse(resp)
+}
+
+// newGetTaskPeerResponse returns the copy of the task held by the peer.
+func newGetTaskPeerResponse(peer *resource.Peer, totalPieceCount int32) *internaljob.GetTaskPeerResponse {
+	resp := &internaljob.GetTaskPeerResponse{
+		ID:                 peer.ID,
+		State:              peer.FSM.Current(),
+		HostID:             peer.Host.ID,
+		HostType:           peer.Host.Type.Name(),
+		Hostname:           peer.Host.Hostname,
+		IP:                 peer.Host.IP,
+		Port:               peer.Host.Port,
+		FinishedPieceCount: peer.FinishedPieces.Count(),
+		CreatedAt:          peer.CreatedAt.Load(),
+		UpdatedAt:          peer.UpdatedAt.Load(),
+	}
+
+	peer.Pieces.Range(func(_, value any) bool {
+		if piece, ok := value.(*resource.Piece); ok {
+			resp.CompletedLength += int64(piece.Length)
+		}
+
+		return true
+	})
+
+	if totalPieceCount > 0 {
+		resp.Percentage = float64(resp.FinishedPieceCount) / float64(totalPieceCount) * 100
+	}
+
+	return resp
+}
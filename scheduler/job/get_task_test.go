@@ -0,0 +1,130 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	commonv2 "d7y.io/api/pkg/apis/common/v2"
+
+	internaljob "d7y.io/dragonfly/v2/internal/job"
+	"d7y.io/dragonfly/v2/scheduler/resource"
+)
+
+func TestJob_getTask(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    *internaljob.GetTaskRequest
+		mock   func(task *resource.Task, mt *resource.MockTaskManagerMockRecorder)
+		expect func(t *testing.T, resp *internaljob.GetTaskResponse, err error)
+	}{
+		{
+			name: "invalid request",
+			req:  &internaljob.GetTaskRequest{},
+			mock: func(task *resource.Task, mt *resource.MockTaskManagerMockRecorder) {},
+			expect: func(t *testing.T, resp *internaljob.GetTaskResponse, err error) {
+				assert := assert.New(t)
+				assert.Error(err)
+			},
+		},
+		{
+			name: "task not found",
+			req:  &internaljob.GetTaskRequest{TaskID: mockTaskID},
+			mock: func(task *resource.Task, mt *resource.MockTaskManagerMockRecorder) {
+				mt.Load(gomock.Eq(mockTaskID)).Return(nil, false).Times(1)
+			},
+			expect: func(t *testing.T, resp *internaljob.GetTaskResponse, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(resp.TaskID, mockTaskID)
+				assert.Equal(resp.State, "")
+				assert.Equal(resp.ContentLength, int64(-1))
+				assert.Equal(len(resp.Peers), 0)
+			},
+		},
+		{
+			name: "get task by url",
+			req:  &internaljob.GetTaskRequest{URL: mockTaskURL, Tag: "d7y", Application: "bar"},
+			mock: func(task *resource.Task, mt *resource.MockTaskManagerMockRecorder) {
+				mt.Load(gomock.Eq(mockTaskID)).Return(task, true).Times(1)
+			},
+			expect: func(t *testing.T, resp *internaljob.GetTaskResponse, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(resp.TaskID, mockTaskID)
+				assert.Equal(resp.URL, mockTaskURL)
+				assert.Equal(resp.State, resource.TaskStatePending)
+				assert.Equal(resp.TotalPieceCount, int32(4))
+				assert.Equal(len(resp.Peers), 2)
+				assert.Equal(resp.Peers[0].ID, "peer-1")
+				assert.Equal(resp.Peers[0].HostID, mockSeedHost.ID)
+				assert.Equal(resp.Peers[0].FinishedPieceCount, uint(2))
+				assert.Equal(resp.Peers[0].CompletedLength, int64(2048))
+				assert.Equal(resp.Peers[0].Percentage, float64(50))
+				assert.Equal(resp.Peers[1].ID, "peer-0")
+				assert.Equal(resp.Peers[1].FinishedPieceCount, uint(0))
+				assert.Equal(resp.Peers[1].Percentage, float64(0))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			res := resource.NewMockResource(ctl)
+			taskManager := resource.NewMockTaskManager(ctl)
+			res.EXPECT().TaskManager().Return(taskManager).AnyTimes()
+
+			task := resource.NewTask(mockTaskID, mockTaskURL, "d7y", "bar", commonv2.TaskType_DFDAEMON, nil, nil, 1)
+			task.TotalPieceCount.Store(4)
+			task.StorePeer(resource.NewPeer("peer-0", task, mockHost))
+			peer := resource.NewPeer("peer-1", task, mockSeedHost)
+			for i := int32(0); i < 2; i++ {
+				peer.FinishedPieces.Set(uint(i))
+				peer.StorePiece(&resource.Piece{Number: i, Length: 1024})
+			}
+			task.StorePeer(peer)
+			tc.mock(task, taskManager.EXPECT())
+
+			j := &job{
+				resource: res,
+				config:   mockConfig,
+			}
+
+			req, err := json.Marshal(tc.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := j.getTask(context.Background(), string(req))
+			resp := &internaljob.GetTaskResponse{}
+			if err == nil {
+				if err := json.Unmarshal([]byte(result), resp); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tc.expect(t, resp, err)
+		})
+	}
+}
@@ -20,13 +20,22 @@ package job
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/RichardKnop/machinery/v1"
+	machineryv1tasks "github.com/RichardKnop/machinery/v1/tasks"
 	"github.com/go-http-utils/headers"
 	"github.com/go-playground/validator/v10"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	cdnsystemv1 "d7y.io/api/pkg/apis/cdnsystem/v1"
 	commonv1 "d7y.io/api/pkg/apis/common/v1"
@@ -34,14 +43,21 @@ import (
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	internaljob "d7y.io/dragonfly/v2/internal/job"
 	"d7y.io/dragonfly/v2/pkg/idgen"
+	"d7y.io/dragonfly/v2/pkg/math"
 	"d7y.io/dragonfly/v2/pkg/net/http"
+	dfdaemonclient "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon/client"
+	"d7y.io/dragonfly/v2/pkg/types"
 	"d7y.io/dragonfly/v2/scheduler/config"
 	"d7y.io/dragonfly/v2/scheduler/resource"
+	"d7y.io/dragonfly/v2/scheduler/scheduling"
 )
 
 const (
 	// preheatTimeout is timeout of preheating.
 	preheatTimeout = 20 * time.Minute
+
+	// preheatProgressInterval is the minimum interval of reporting preheat progress.
+	preheatProgressInterval = 2 * time.Second
 )
 
 type Job interface {
@@ -53,10 +69,32 @@ type job struct {
 	schedulerJob *internaljob.Job
 	localJob     *internaljob.Job
 	resource     resource.Resource
+	scheduling   scheduling.Scheduling
 	config       *config.Config
+
+	// transportCredentials is the credentials of dfdaemon clients.
+	transportCredentials credentials.TransportCredentials
+
+	// getDfdaemonClient returns v1 version of the dfdaemon client.
+	getDfdaemonClient func(context.Context, string, ...grpc.DialOption) (dfdaemonclient.V1, error)
+
+	// pendingDeleteTasks keeps the hosts failed to delete the evicted task by task id,
+	// they are retried in the next delete task job.
+	pendingDeleteTasks sync.Map
+}
+
+// Option is a functional option for configuring the job.
+type Option func(j *job)
+
+// WithTransportCredentials returns a DialOption which configures a connection
+// level security credentials (e.g., TLS/SSL) of dfdaemon clients.
+func WithTransportCredentials(creds credentials.TransportCredentials) Option {
+	return func(j *job) {
+		j.transportCredentials = creds
+	}
 }
 
-func New(cfg *config.Config, resource resource.Resource) (Job, error) {
+func New(cfg *config.Config, resource resource.Resource, scheduling scheduling.Scheduling, options ...Option) (Job, error) {
 	redisConfig := &internaljob.Config{
 		Addrs:      cfg.Job.Redis.Addrs,
 		MasterName: cfg.Job.Redis.MasterName,
@@ -94,19 +132,28 @@ func New(cfg *config.Config, resource resource.Resource) (Job, error) {
 	logger.Infof("create local job queue: %v", localQueue)
 
 	t := &job{
-		globalJob:    globalJob,
-		schedulerJob: schedulerJob,
-		localJob:     localJob,
-		resource:     resource,
-		config:       cfg,
+		globalJob:         globalJob,
+		schedulerJob:      schedulerJob,
+		localJob:          localJob,
+		resource:          resource,
+		scheduling:        scheduling,
+		config:            cfg,
+		getDfdaemonClient: dfdaemonclient.GetV1,
+	}
+
+	for _, opt := range options {
+		opt(t)
 	}
 
 	namedJobFuncs := map[string]any{
-		internaljob.PreheatJob: t.preheat,
+		internaljob.PreheatJob:    t.preheat,
+		internaljob.DeleteTaskJob: t.deleteTask,
+		internaljob.GetTaskJob:    t.getTask,
+		internaljob.DrainHostJob:  t.drainHost,
 	}
 
 	if err := localJob.RegisterJob(namedJobFuncs); err != nil {
-		logger.Errorf("register preheat job to local queue error: %s", err.Error())
+		logger.Errorf("register jobs to local queue error: %s", err.Error())
 		return nil, err
 	}
 
@@ -142,23 +189,25 @@ func (j *job) Serve() {
 	}()
 }
 
-func (j *job) preheat(ctx context.Context, req string) error {
+// preheat triggers seed peer to download the task, the progress of preheating is reported
+// to the result backend periodically and returned as the result of job.
+func (j *job) preheat(ctx context.Context, req string) (string, error) {
 	ctx, cancel := context.WithTimeout(ctx, preheatTimeout)
 	defer cancel()
 
 	if !j.config.SeedPeer.Enable {
-		return errors.New("scheduler has disabled seed peer")
+		return "", errors.New("scheduler has disabled seed peer")
 	}
 
 	preheat := &internaljob.PreheatRequest{}
 	if err := internaljob.UnmarshalRequest(req, preheat); err != nil {
 		logger.Errorf("unmarshal request err: %s, request body: %s", err.Error(), req)
-		return err
+		return "", err
 	}
 
 	if err := validator.New().Struct(preheat); err != nil {
 		logger.Errorf("preheat %s validate failed: %s", preheat.URL, err.Error())
-		return err
+		return "", err
 	}
 
 	urlMeta := &commonv1.UrlMeta{
@@ -176,31 +225,343 @@ func (j *job) preheat(ctx context.Context, req string) error {
 		}
 	}
 
-	// Trigger seed peer download seeds.
 	taskID := idgen.TaskIDV1(preheat.URL, urlMeta)
 	log := logger.WithTask(taskID, preheat.URL)
-	log.Infof("preheat %s headers: %#v, tag: %s, range: %s, filter: %s, digest: %s",
-		preheat.URL, urlMeta.Header, urlMeta.Tag, urlMeta.Range, urlMeta.Filter, urlMeta.Digest)
-	stream, err := j.resource.SeedPeer().Client().ObtainSeeds(ctx, &cdnsystemv1.SeedRequest{
+	log.Infof("preheat %s headers: %#v, tag: %s, range: %s, filter: %s, digest: %s, scope: %s, peer count: %d",
+		preheat.URL, urlMeta.Header, urlMeta.Tag, urlMeta.Range, urlMeta.Filter, urlMeta.Digest, preheat.Scope, preheat.PeerCount)
+
+	progress := newPreheatProgress(ctx, j.localJob, &internaljob.PreheatResponse{
+		URL:               preheat.URL,
+		TaskID:            taskID,
+		SchedulerHostname: j.config.Server.Host,
+		SchedulerIP:       j.config.Server.AdvertiseIP.String(),
+		StartedAt:         time.Now(),
+	})
+
+	seedRequest := &cdnsystemv1.SeedRequest{
 		TaskId:  taskID,
 		Url:     preheat.URL,
 		UrlMeta: urlMeta,
-	})
+	}
+
+	// Trigger the seed peer selected by consistent hashing to download seeds,
+	// if preheating is not required to replicate the task to the selected peers.
+	if !isPreheatPeersSelected(preheat) {
+		if err := j.preheatSeedPeer(ctx, seedRequest, progress.peer(nil)); err != nil {
+			log.Errorf("preheat %s failed: %s", preheat.URL, err.Error())
+			progress.fail(err)
+			return "", err
+		}
+
+		log.Infof("preheat %s succeeded", preheat.URL)
+		return progress.result()
+	}
+
+	hosts, err := j.findPreheatHosts(taskID, preheat)
 	if err != nil {
 		log.Errorf("preheat %s failed: %s", preheat.URL, err.Error())
+		progress.fail(err)
+		return "", err
+	}
+
+	// Trigger the selected peers to download seeds by the bounded workers, preheating normal peers
+	// without peer count and filters selects all of the normal peers in the cluster.
+	concurrency := math.Min(len(hosts), int(j.config.Job.PreheatConcurrency))
+	log.Infof("preheat %s in %d hosts by %d workers", preheat.URL, len(hosts), concurrency)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		errs    []error
+		hostsCh = make(chan *resource.Host)
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for host := range hostsCh {
+				peerProgress := progress.peer(host)
+				if err := j.resource.SeedPeer().ObtainSeedsByHost(ctx, host, seedRequest, peerProgress.update); err != nil {
+					log.Errorf("preheat %s in host %s failed: %s", preheat.URL, host.ID, err.Error())
+					peerProgress.fail(err)
+
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("host %s: %w", host.ID, err))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, host := range hosts {
+		hostsCh <- host
+	}
+	close(hostsCh)
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		log.Errorf("preheat %s failed in %d of %d hosts", preheat.URL, len(errs), len(hosts))
+		progress.fail(err)
+		return "", err
+	}
+
+	log.Infof("preheat %s succeeded in %d hosts", preheat.URL, len(hosts))
+	return progress.result()
+}
+
+// preheatSeedPeer triggers the seed peer selected by consistent hashing to download seeds.
+func (j *job) preheatSeedPeer(ctx context.Context, req *cdnsystemv1.SeedRequest, progress *preheatPeerProgress) error {
+	stream, err := j.resource.SeedPeer().Client().ObtainSeeds(ctx, req)
+	if err != nil {
 		return err
 	}
 
 	for {
 		piece, err := stream.Recv()
 		if err != nil {
-			log.Errorf("preheat %s recive piece failed: %s", preheat.URL, err.Error())
 			return err
 		}
 
+		// The host of seed peer is known after the first piece is received.
+		if host, loaded := j.resource.HostManager().Load(piece.HostId); loaded {
+			progress.setHost(host)
+		}
+
+		progress.update(piece)
 		if piece.Done == true {
-			log.Infof("preheat %s succeeded", preheat.URL)
 			return nil
 		}
 	}
 }
+
+// isPreheatPeersSelected returns whether the preheating requires the selected peers to download seeds.
+func isPreheatPeersSelected(preheat *internaljob.PreheatRequest) bool {
+	return preheat.Scope == internaljob.PreheatScopeNormalPeer || preheat.PeerCount > 1 ||
+		preheat.IDC != "" || preheat.Location != "" || preheat.HostnamePattern != ""
+}
+
+// findPreheatHosts finds the hosts matched by the scope and the filters of preheating, the hosts
+// are ordered by rendezvous hashing of the task, so that the same task is preheated in the same hosts.
+func (j *job) findPreheatHosts(taskID string, preheat *internaljob.PreheatRequest) ([]*resource.Host, error) {
+	var hostnameRegexp *regexp.Regexp
+	if preheat.HostnamePattern != "" {
+		var err error
+		if hostnameRegexp, err = regexp.Compile(preheat.HostnamePattern); err != nil {
+			return nil, err
+		}
+	}
+
+	var hosts []*resource.Host
+	j.resource.HostManager().Range(func(_, value any) bool {
+		host, ok := value.(*resource.Host)
+		if !ok {
+			return true
+		}
+
+		if (preheat.Scope == internaljob.PreheatScopeNormalPeer) != (host.Type == types.HostTypeNormal) {
+			return true
+		}
+
+		if preheat.IDC != "" && host.Network.IDC != preheat.IDC {
+			return true
+		}
+
+		if preheat.Location != "" && !strings.HasPrefix(host.Network.Location, preheat.Location) {
+			return true
+		}
+
+		if hostnameRegexp != nil && !hostnameRegexp.MatchString(host.Hostname) {
+			return true
+		}
+
+		hosts = append(hosts, host)
+		return true
+	})
+
+	if len(hosts) == 0 {
+		return nil, errors.New("can not find hosts to preheat")
+	}
+
+	sort.Slice(hosts, func(i, j int) bool {
+		return rendezvousHash(taskID, hosts[i].ID) < rendezvousHash(taskID, hosts[j].ID)
+	})
+
+	if preheat.PeerCount > 0 && len(hosts) > int(preheat.PeerCount) {
+		hosts = hosts[:preheat.PeerCount]
+	}
+
+	return hosts, nil
+}
+
+// rendezvousHash returns the hash of the task in the host.
+func rendezvousHash(taskID, hostID string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(taskID))
+	h.Write([]byte(hostID))
+	return h.Sum64()
+}
+
+// preheatProgress reports the progress of preheating to the result backend of job.
+type preheatProgress struct {
+	job        *internaljob.Job
+	jobID      string
+	response   *internaljob.PreheatResponse
+	reportedAt time.Time
+
+	// mu protects response and reportedAt, which are updated by the peers concurrently.
+	mu sync.Mutex
+}
+
+// newPreheatProgress returns a new preheatProgress and reports the initial progress,
+// the progress is not reported if the job is not run by machinery worker.
+func newPreheatProgress(ctx context.Context, job *internaljob.Job, response *internaljob.PreheatResponse) *preheatProgress {
+	p := &preheatProgress{
+		job:      job,
+		response: response,
+	}
+
+	if signature := machineryv1tasks.SignatureFromContext(ctx); signature != nil {
+		p.jobID = signature.UUID
+	}
+
+	p.report(true)
+	return p
+}
+
+// peer returns the progress of the peer in the host, the host can be nil if it is unknown.
+func (p *preheatProgress) peer(host *resource.Host) *preheatPeerProgress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	response := &internaljob.PreheatPeerResponse{}
+	p.response.Peers = append(p.response.Peers, response)
+
+	peerProgress := &preheatPeerProgress{progress: p, response: response}
+	if host != nil {
+		peerProgress.setHostLocked(host)
+	}
+
+	return peerProgress
+}
+
+// fail reports the progress of failed preheating.
+func (p *preheatProgress) fail(err error) {
+	p.mu.Lock()
+	p.response.Done = true
+	p.response.Error = err.Error()
+	p.mu.Unlock()
+
+	p.report(true)
+}
+
+// result returns the progress in json as the result of job.
+func (p *preheatProgress) result() (string, error) {
+	p.mu.Lock()
+	p.response.Done = true
+	p.mu.Unlock()
+
+	p.report(true)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	b, err := json.Marshal(p.response)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// report reports the progress if the interval is elapsed or force is true.
+func (p *preheatProgress) report(force bool) {
+	p.mu.Lock()
+	now := time.Now()
+	if !force && now.Sub(p.reportedAt) < preheatProgressInterval {
+		p.mu.Unlock()
+		return
+	}
+
+	p.response.Elapsed = now.Sub(p.response.StartedAt)
+	p.response.UpdatedAt = now
+	p.reportedAt = now
+	if p.jobID == "" || p.job == nil {
+		p.mu.Unlock()
+		return
+	}
+
+	b, err := json.Marshal(p.response)
+	p.mu.Unlock()
+	if err != nil {
+		logger.WithTask(p.response.TaskID, p.response.URL).Warnf("marshal preheat progress failed: %s", err.Error())
+		return
+	}
+
+	// Report with background context, the failed preheating still needs to report
+	// its progress after the context is canceled.
+	if err := p.job.SetJobProgress(context.Background(), p.jobID, json.RawMessage(b)); err != nil {
+		logger.WithTask(p.response.TaskID, p.response.URL).Warnf("report preheat progress failed: %s", err.Error())
+	}
+}
+
+// preheatPeerProgress updates the progress of a peer preheating the url.
+type preheatPeerProgress struct {
+	progress *preheatProgress
+	response *internaljob.PreheatPeerResponse
+}
+
+// setHost sets the host of peer.
+func (p *preheatPeerProgress) setHost(host *resource.Host) {
+	p.progress.mu.Lock()
+	defer p.progress.mu.Unlock()
+
+	p.setHostLocked(host)
+}
+
+// setHostLocked sets the host of peer, the caller must hold the lock of progress.
+func (p *preheatPeerProgress) setHostLocked(host *resource.Host) {
+	p.response.HostID = host.ID
+	p.response.Hostname = host.Hostname
+	p.response.IP = host.IP
+}
+
+// update updates the progress by the piece downloaded by the peer.
+func (p *preheatPeerProgress) update(piece *cdnsystemv1.PieceSeed) {
+	p.progress.mu.Lock()
+	if piece.PeerId != "" {
+		p.response.ID = piece.PeerId
+	}
+
+	if piece.HostId != "" {
+		p.response.HostID = piece.HostId
+	}
+
+	if piece.PieceInfo != nil && piece.PieceInfo.PieceNum >= 0 {
+		p.response.FinishedPieceCount++
+		p.response.CompletedLength += int64(piece.PieceInfo.RangeSize)
+	}
+
+	if piece.TotalPieceCount > 0 {
+		p.response.TotalPieceCount = piece.TotalPieceCount
+	}
+
+	if piece.ContentLength > 0 {
+		p.response.ContentLength = piece.ContentLength
+	}
+
+	p.response.Done = piece.Done
+	p.progress.mu.Unlock()
+
+	p.progress.report(piece.Done)
+}
+
+// fail updates the progress of the failed peer.
+func (p *preheatPeerProgress) fail(err error) {
+	p.progress.mu.Lock()
+	p.response.Done = true
+	p.response.Error = err.Error()
+	p.progress.mu.Unlock()
+
+	p.progress.report(true)
+}
@@ -0,0 +1,467 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	machineryv1tasks "github.com/RichardKnop/machinery/v1/tasks"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	cdnsystemv1 "d7y.io/api/pkg/apis/cdnsystem/v1"
+	cdnsystemv1mocks "d7y.io/api/pkg/apis/cdnsystem/v1/mocks"
+	commonv1 "d7y.io/api/pkg/apis/common/v1"
+
+	internaljob "d7y.io/dragonfly/v2/internal/job"
+	"d7y.io/dragonfly/v2/pkg/types"
+	"d7y.io/dragonfly/v2/scheduler/resource"
+)
+
+var (
+	mockSeedHost = resource.NewHost("seed", "127.0.0.1", "seed-0", 8003, 8001, types.HostTypeSuperSeed,
+		resource.WithNetwork(resource.Network{IDC: "foo", Location: "a|b"}))
+
+	mockOtherSeedHost = resource.NewHost("other-seed", "127.0.0.2", "seed-1", 8003, 8001, types.HostTypeStrongSeed,
+		resource.WithNetwork(resource.Network{IDC: "bar", Location: "a|c"}))
+
+	mockHost = resource.NewHost("normal", "127.0.0.3", "node-0", 8003, 8001, types.HostTypeNormal,
+		resource.WithNetwork(resource.Network{IDC: "foo", Location: "a|b"}))
+)
+
+func TestJob_isPreheatPeersSelected(t *testing.T) {
+	tests := []struct {
+		name    string
+		preheat *internaljob.PreheatRequest
+		expect  bool
+	}{
+		{
+			name:    "preheat seed peer by default",
+			preheat: &internaljob.PreheatRequest{},
+			expect:  false,
+		},
+		{
+			name:    "preheat a seed peer",
+			preheat: &internaljob.PreheatRequest{Scope: internaljob.PreheatScopeSeedPeer, PeerCount: 1},
+			expect:  false,
+		},
+		{
+			name:    "preheat seed peers",
+			preheat: &internaljob.PreheatRequest{Scope: internaljob.PreheatScopeSeedPeer, PeerCount: 2},
+			expect:  true,
+		},
+		{
+			name:    "preheat seed peers by idc",
+			preheat: &internaljob.PreheatRequest{IDC: "foo"},
+			expect:  true,
+		},
+		{
+			name:    "preheat normal peers",
+			preheat: &internaljob.PreheatRequest{Scope: internaljob.PreheatScopeNormalPeer},
+			expect:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(isPreheatPeersSelected(tc.preheat), tc.expect)
+		})
+	}
+}
+
+func TestJob_findPreheatHosts(t *testing.T) {
+	tests := []struct {
+		name    string
+		preheat *internaljob.PreheatRequest
+		expect  func(t *testing.T, hosts []*resource.Host, err error)
+	}{
+		{
+			name:    "find seed peers",
+			preheat: &internaljob.PreheatRequest{Scope: internaljob.PreheatScopeSeedPeer},
+			expect: func(t *testing.T, hosts []*resource.Host, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.ElementsMatch([]string{hosts[0].ID, hosts[1].ID}, []string{mockSeedHost.ID, mockOtherSeedHost.ID})
+			},
+		},
+		{
+			name:    "find seed peers with peer count",
+			preheat: &internaljob.PreheatRequest{PeerCount: 1},
+			expect: func(t *testing.T, hosts []*resource.Host, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(len(hosts), 1)
+				assert.NotEqual(hosts[0].Type, types.HostTypeNormal)
+			},
+		},
+		{
+			name:    "find seed peers by location",
+			preheat: &internaljob.PreheatRequest{Location: "a|c"},
+			expect: func(t *testing.T, hosts []*resource.Host, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(len(hosts), 1)
+				assert.Equal(hosts[0].ID, mockOtherSeedHost.ID)
+			},
+		},
+		{
+			name:    "find normal peers by idc and hostname pattern",
+			preheat: &internaljob.PreheatRequest{Scope: internaljob.PreheatScopeNormalPeer, IDC: "foo", HostnamePattern: "^node-"},
+			expect: func(t *testing.T, hosts []*resource.Host, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(len(hosts), 1)
+				assert.Equal(hosts[0].ID, mockHost.ID)
+			},
+		},
+		{
+			name:    "hosts not found",
+			preheat: &internaljob.PreheatRequest{Scope: internaljob.PreheatScopeNormalPeer, IDC: "bar"},
+			expect: func(t *testing.T, hosts []*resource.Host, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "can not find hosts to preheat")
+			},
+		},
+		{
+			name:    "invalid hostname pattern",
+			preheat: &internaljob.PreheatRequest{HostnamePattern: "["},
+			expect: func(t *testing.T, hosts []*resource.Host, err error) {
+				assert := assert.New(t)
+				assert.Error(err)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			res := resource.NewMockResource(ctl)
+			hostManager := resource.NewMockHostManager(ctl)
+			res.EXPECT().HostManager().Return(hostManager).AnyTimes()
+			hostManager.EXPECT().Range(gomock.Any()).Do(func(f func(any, any) bool) {
+				for _, host := range []*resource.Host{mockSeedHost, mockOtherSeedHost, mockHost} {
+					if !f(host.ID, host) {
+						return
+					}
+				}
+			}).AnyTimes()
+
+			j := &job{resource: res}
+			hosts, err := j.findPreheatHosts("task", tc.preheat)
+			tc.expect(t, hosts, err)
+		})
+	}
+}
+
+// newTestPreheatProgress returns the preheat progress reported to miniredis by the job of jobID.
+func newTestPreheatProgress(t *testing.T, jobID string) (*preheatProgress, *internaljob.Job) {
+	mr := miniredis.RunT(t)
+	localJob, err := internaljob.New(&internaljob.Config{Addrs: []string{mr.Addr()}}, internaljob.GlobalQueue)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if jobID != "" {
+		task, err := machineryv1tasks.NewWithSignature(func() error { return nil }, &machineryv1tasks.Signature{UUID: jobID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx = task.Context
+	}
+
+	return newPreheatProgress(ctx, localJob, &internaljob.PreheatResponse{
+		URL:       "http://example.com/foo",
+		TaskID:    "task",
+		StartedAt: time.Now(),
+	}), localJob
+}
+
+// getPreheatProgress returns the preheat progress reported by the job of jobID.
+func getPreheatProgress(t *testing.T, localJob *internaljob.Job, jobID string) *internaljob.PreheatResponse {
+	progresses, err := localJob.GetJobProgresses(context.Background(), jobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	progress, ok := progresses[jobID]
+	if !ok {
+		return nil
+	}
+
+	response := &internaljob.PreheatResponse{}
+	if err := json.Unmarshal(progress, response); err != nil {
+		t.Fatal(err)
+	}
+
+	return response
+}
+
+func TestJob_preheatProgress(t *testing.T) {
+	mockPiece := &cdnsystemv1.PieceSeed{
+		PeerId:          "peer",
+		HostId:          mockHost.ID,
+		PieceInfo:       &commonv1.PieceInfo{PieceNum: 0, RangeSize: 10},
+		TotalPieceCount: 2,
+		ContentLength:   20,
+	}
+
+	tests := []struct {
+		name   string
+		jobID  string
+		run    func(t *testing.T, p *preheatProgress)
+		expect func(t *testing.T, response *internaljob.PreheatResponse)
+	}{
+		{
+			name:  "report the initial progress",
+			jobID: "foo",
+			run:   func(t *testing.T, p *preheatProgress) {},
+			expect: func(t *testing.T, response *internaljob.PreheatResponse) {
+				assert := assert.New(t)
+				assert.Equal(response.URL, "http://example.com/foo")
+				assert.Equal(response.TaskID, "task")
+				assert.Equal(len(response.Peers), 0)
+				assert.False(response.Done)
+			},
+		},
+		{
+			name:  "progress is not reported within the interval",
+			jobID: "foo",
+			run: func(t *testing.T, p *preheatProgress) {
+				p.peer(mockHost).update(mockPiece)
+			},
+			expect: func(t *testing.T, response *internaljob.PreheatResponse) {
+				assert := assert.New(t)
+				assert.Equal(len(response.Peers), 0)
+			},
+		},
+		{
+			name:  "progress is reported after the interval",
+			jobID: "foo",
+			run: func(t *testing.T, p *preheatProgress) {
+				peerProgress := p.peer(mockHost)
+				p.reportedAt = time.Now().Add(-preheatProgressInterval)
+				peerProgress.update(mockPiece)
+			},
+			expect: func(t *testing.T, response *internaljob.PreheatResponse) {
+				assert := assert.New(t)
+				assert.Equal(len(response.Peers), 1)
+				assert.Equal(response.Peers[0].ID, "peer")
+				assert.Equal(response.Peers[0].HostID, mockHost.ID)
+				assert.Equal(response.Peers[0].Hostname, mockHost.Hostname)
+				assert.Equal(response.Peers[0].IP, mockHost.IP)
+				assert.Equal(response.Peers[0].FinishedPieceCount, int32(1))
+				assert.Equal(response.Peers[0].CompletedLength, int64(10))
+				assert.Equal(response.Peers[0].TotalPieceCount, int32(2))
+				assert.Equal(response.Peers[0].ContentLength, int64(20))
+				assert.False(response.Peers[0].Done)
+			},
+		},
+		{
+			name:  "progress of done peer is reported within the interval",
+			jobID: "foo",
+			run: func(t *testing.T, p *preheatProgress) {
+				peerProgress := p.peer(mockHost)
+				peerProgress.update(mockPiece)
+				peerProgress.update(&cdnsystemv1.PieceSeed{
+					PeerId:    "peer",
+					PieceInfo: &commonv1.PieceInfo{PieceNum: 1, RangeSize: 10},
+					Done:      true,
+				})
+			},
+			expect: func(t *testing.T, response *internaljob.PreheatResponse) {
+				assert := assert.New(t)
+				assert.Equal(len(response.Peers), 1)
+				assert.Equal(response.Peers[0].FinishedPieceCount, int32(2))
+				assert.Equal(response.Peers[0].CompletedLength, int64(20))
+				assert.True(response.Peers[0].Done)
+				assert.False(response.Done)
+			},
+		},
+		{
+			name:  "progress of failed peer is reported within the interval",
+			jobID: "foo",
+			run: func(t *testing.T, p *preheatProgress) {
+				peerProgress := p.peer(nil)
+				peerProgress.update(mockPiece)
+				peerProgress.fail(errors.New("bar"))
+			},
+			expect: func(t *testing.T, response *internaljob.PreheatResponse) {
+				assert := assert.New(t)
+				assert.Equal(len(response.Peers), 1)
+				assert.Equal(response.Peers[0].HostID, mockHost.ID)
+				assert.Equal(response.Peers[0].Hostname, "")
+				assert.Equal(response.Peers[0].Error, "bar")
+				assert.True(response.Peers[0].Done)
+			},
+		},
+		{
+			name:  "progress of failed preheating is reported within the interval",
+			jobID: "foo",
+			run: func(t *testing.T, p *preheatProgress) {
+				p.peer(mockHost).update(mockPiece)
+				p.fail(errors.New("bar"))
+			},
+			expect: func(t *testing.T, response *internaljob.PreheatResponse) {
+				assert := assert.New(t)
+				assert.Equal(len(response.Peers), 1)
+				assert.Equal(response.Error, "bar")
+				assert.True(response.Done)
+			},
+		},
+		{
+			name:  "progress of succeeded preheating is reported as result",
+			jobID: "foo",
+			run: func(t *testing.T, p *preheatProgress) {
+				p.peer(mockHost).update(mockPiece)
+				result, err := p.result()
+				assert := assert.New(t)
+				assert.NoError(err)
+
+				response := &internaljob.PreheatResponse{}
+				assert.NoError(json.Unmarshal([]byte(result), response))
+				assert.True(response.Done)
+				assert.Equal(len(response.Peers), 1)
+			},
+			expect: func(t *testing.T, response *internaljob.PreheatResponse) {
+				assert := assert.New(t)
+				assert.Equal(len(response.Peers), 1)
+				assert.Equal(response.Error, "")
+				assert.True(response.Done)
+			},
+		},
+		{
+			name:  "progress is not reported without job id",
+			jobID: "",
+			run: func(t *testing.T, p *preheatProgress) {
+				p.fail(errors.New("bar"))
+			},
+			expect: func(t *testing.T, response *internaljob.PreheatResponse) {
+				assert := assert.New(t)
+				assert.Nil(response)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, localJob := newTestPreheatProgress(t, tc.jobID)
+			tc.run(t, p)
+			tc.expect(t, getPreheatProgress(t, localJob, "foo"))
+		})
+	}
+}
+
+func TestJob_preheatSeedPeer(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(ms *cdnsystemv1mocks.MockSeeder_ObtainSeedsClientMockRecorder, mh *resource.MockHostManagerMockRecorder)
+		expect func(t *testing.T, response *internaljob.PreheatResponse, err error)
+	}{
+		{
+			name: "preheat seed peer succeeded",
+			mock: func(ms *cdnsystemv1mocks.MockSeeder_ObtainSeedsClientMockRecorder, mh *resource.MockHostManagerMockRecorder) {
+				gomock.InOrder(
+					ms.Recv().Return(&cdnsystemv1.PieceSeed{PeerId: "peer", HostId: mockSeedHost.ID}, nil).Times(1),
+					mh.Load(gomock.Eq(mockSeedHost.ID)).Return(mockSeedHost, true).Times(1),
+					ms.Recv().Return(&cdnsystemv1.PieceSeed{
+						PeerId:          "peer",
+						HostId:          mockSeedHost.ID,
+						PieceInfo:       &commonv1.PieceInfo{PieceNum: 0, RangeSize: 10},
+						TotalPieceCount: 1,
+						ContentLength:   10,
+						Done:            true,
+					}, nil).Times(1),
+					mh.Load(gomock.Eq(mockSeedHost.ID)).Return(mockSeedHost, true).Times(1),
+				)
+			},
+			expect: func(t *testing.T, response *internaljob.PreheatResponse, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(len(response.Peers), 1)
+				assert.Equal(response.Peers[0].ID, "peer")
+				assert.Equal(response.Peers[0].HostID, mockSeedHost.ID)
+				assert.Equal(response.Peers[0].Hostname, mockSeedHost.Hostname)
+				assert.Equal(response.Peers[0].IP, mockSeedHost.IP)
+				assert.Equal(response.Peers[0].FinishedPieceCount, int32(1))
+				assert.True(response.Peers[0].Done)
+			},
+		},
+		{
+			name: "host of seed peer is not found",
+			mock: func(ms *cdnsystemv1mocks.MockSeeder_ObtainSeedsClientMockRecorder, mh *resource.MockHostManagerMockRecorder) {
+				gomock.InOrder(
+					ms.Recv().Return(&cdnsystemv1.PieceSeed{PeerId: "peer", HostId: mockSeedHost.ID, Done: true}, nil).Times(1),
+					mh.Load(gomock.Eq(mockSeedHost.ID)).Return(nil, false).Times(1),
+				)
+			},
+			expect: func(t *testing.T, response *internaljob.PreheatResponse, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(len(response.Peers), 1)
+				assert.Equal(response.Peers[0].HostID, mockSeedHost.ID)
+				assert.Equal(response.Peers[0].Hostname, "")
+				assert.True(response.Peers[0].Done)
+			},
+		},
+		{
+			name: "receive piece failed",
+			mock: func(ms *cdnsystemv1mocks.MockSeeder_ObtainSeedsClientMockRecorder, mh *resource.MockHostManagerMockRecorder) {
+				ms.Recv().Return(nil, errors.New("foo")).Times(1)
+			},
+			expect: func(t *testing.T, response *internaljob.PreheatResponse, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "foo")
+				assert.Equal(len(response.Peers), 1)
+				assert.False(response.Peers[0].Done)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			res := resource.NewMockResource(ctl)
+			hostManager := resource.NewMockHostManager(ctl)
+			seedPeer := resource.NewMockSeedPeer(ctl)
+			seedPeerClient := resource.NewMockSeedPeerClient(ctl)
+			stream := cdnsystemv1mocks.NewMockSeeder_ObtainSeedsClient(ctl)
+			res.EXPECT().HostManager().Return(hostManager).AnyTimes()
+			res.EXPECT().SeedPeer().Return(seedPeer).AnyTimes()
+			seedPeer.EXPECT().Client().Return(seedPeerClient).AnyTimes()
+			seedPeerClient.EXPECT().ObtainSeeds(gomock.Any(), gomock.Any()).Return(stream, nil).Times(1)
+			tc.mock(stream.EXPECT(), hostManager.EXPECT())
+
+			p, localJob := newTestPreheatProgress(t, "foo")
+			j := &job{resource: res}
+			err := j.preheatSeedPeer(context.Background(), &cdnsystemv1.SeedRequest{TaskId: "task"}, p.peer(nil))
+
+			// The progress of failed peer is reported by the caller.
+			p.report(true)
+			tc.expect(t, getPreheatProgress(t, localJob, "foo"), err)
+		})
+	}
+}
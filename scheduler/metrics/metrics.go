@@ -224,6 +224,34 @@ var (
 		Help:      "Counter of the number of failed of the leaving host.",
 	})
 
+	SyncProbesCount = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: types.MetricsNamespace,
+		Subsystem: types.SchedulerMetricsName,
+		Name:      "sync_probes_total",
+		Help:      "Counter of the number of the synchronizing probes.",
+	})
+
+	SyncProbesFailureCount = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: types.MetricsNamespace,
+		Subsystem: types.SchedulerMetricsName,
+		Name:      "sync_probes_failure_total",
+		Help:      "Counter of the number of failed of the synchronizing probes.",
+	})
+
+	SyncNetworkTopologyCount = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: types.MetricsNamespace,
+		Subsystem: types.SchedulerMetricsName,
+		Name:      "sync_network_topology_total",
+		Help:      "Counter of the number of the synchronizing network topology.",
+	})
+
+	SyncNetworkTopologyFailureCount = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: types.MetricsNamespace,
+		Subsystem: types.SchedulerMetricsName,
+		Name:      "sync_network_topology_failure_total",
+		Help:      "Counter of the number of failed of the synchronizing network topology.",
+	})
+
 	Traffic = promauto.NewCounterVec(prometheus.CounterOpts{
 		Namespace: types.MetricsNamespace,
 		Subsystem: types.SchedulerMetricsName,
@@ -253,6 +281,20 @@ var (
 		Help:      "Gauge of the number of concurrent of the scheduling.",
 	})
 
+	PreemptionCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: types.MetricsNamespace,
+		Subsystem: types.SchedulerMetricsName,
+		Name:      "preemption_total",
+		Help:      "Counter of the number of the upload slots preempted by the peer with higher priority.",
+	}, []string{"priority", "preempted_priority", "task_type", "host_type"})
+
+	BackToSourceQuotaExceededCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: types.MetricsNamespace,
+		Subsystem: types.SchedulerMetricsName,
+		Name:      "back_to_source_quota_exceeded_total",
+		Help:      "Counter of the number of the back-to-source rejected by the quotas of application.",
+	}, []string{"application", "task_type"})
+
 	VersionGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: types.MetricsNamespace,
 		Subsystem: types.SchedulerMetricsName,
@@ -261,11 +303,25 @@ var (
 	}, []string{"major", "minor", "git_version", "git_commit", "platform", "build_time", "go_version", "go_tags", "go_gcflags"})
 )
 
-func New(cfg *config.MetricsConfig, svr *grpc.Server) *http.Server {
+// Option is a functional option for configuring the metrics server.
+type Option func(mux *http.ServeMux)
+
+// WithHandler registers the handler for the pattern in the metrics server,
+// e.g. the debug handler of scheduler.
+func WithHandler(pattern string, handler http.Handler) Option {
+	return func(mux *http.ServeMux) {
+		mux.Handle(pattern, handler)
+	}
+}
+
+func New(cfg *config.MetricsConfig, svr *grpc.Server, options ...Option) *http.Server {
 	grpc_prometheus.Register(svr)
 
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.Handler())
+	for _, opt := range options {
+		opt(mux)
+	}
 
 	VersionGauge.WithLabelValues(version.Major, version.Minor, version.GitVersion, version.GitCommit, version.Platform, version.BuildTime, version.GoVersion, version.Gotags, version.Gogcflags).Set(1)
 	return &http.Server{
@@ -18,6 +18,7 @@ package metrics
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"google.golang.org/grpc"
@@ -40,3 +41,19 @@ func TestNew(t *testing.T) {
 		t.Errorf("expected server.Handler to be a *http.ServeMux, but got %T", server.Handler)
 	}
 }
+
+func TestNewWithHandler(t *testing.T) {
+	cfg := &config.MetricsConfig{
+		Addr: "localhost:8080",
+	}
+	svr := grpc.NewServer()
+	server := New(cfg, svr, WithHandler("/debug", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	w := httptest.NewRecorder()
+	server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug", nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected status code to be %d, but got %d", http.StatusNoContent, w.Code)
+	}
+}
@@ -0,0 +1,583 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+//go:generate mockgen -destination mocks/network_topology_mock.go -source network_topology.go -package mocks
+
+package networktopology
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/types"
+	"d7y.io/dragonfly/v2/scheduler/config"
+	"d7y.io/dragonfly/v2/scheduler/resource"
+	"d7y.io/dragonfly/v2/scheduler/storage"
+)
+
+const (
+	// SnapshotFileName is the file name of network topology snapshot in the data directory.
+	SnapshotFileName = "networktopology.json"
+)
+
+// NetworkTopology is the interface used for the directed graph of hosts,
+// the edge from the source host to the destination host stores probes
+// of the destination host measured by the source host.
+type NetworkTopology interface {
+	// Serve starts to evict expired hosts, snapshot and collect network topology periodically.
+	Serve()
+
+	// Stop stops network topology and writes the last snapshot.
+	Stop() error
+
+	// Has returns whether the edge from the source host to the destination host exists.
+	Has(string, string) bool
+
+	// Store stores the edge from the source host to the destination host.
+	Store(*resource.Host, *resource.Host)
+
+	// Probes loads probes of the edge from the source host to the destination host.
+	Probes(string, string) (Probes, bool)
+
+	// Enqueue enqueues the probe of the destination host measured by the source host.
+	Enqueue(*resource.Host, *Probe) error
+
+	// DeleteHost deletes the host and all edges connected to the host.
+	DeleteHost(string)
+
+	// DestHostIDs returns ids of the destination hosts probed by the source host.
+	DestHostIDs(string) []string
+
+	// ProbedCount returns the number of times the host has been probed.
+	ProbedCount(string) uint64
+
+	// FindProbedHosts finds the least probed hosts to be probed by the host.
+	FindProbedHosts(string) []*resource.Host
+
+	// AverageRTT returns the average round-trip time from the source host to the destination host.
+	AverageRTT(string, string) (time.Duration, bool)
+
+	// Snapshot writes network topology to the snapshot file.
+	Snapshot() error
+
+	// Collect writes the probes of edges in network topology to the storage.
+	Collect() error
+}
+
+// networkTopology contains content for network topology.
+type networkTopology struct {
+	// config is the network topology config.
+	config *config.NetworkTopologyConfig
+
+	// hostManager is the host manager of resource.
+	hostManager resource.HostManager
+
+	// hosts stores hosts in network topology,
+	// the key is the host id and the value is *resource.Host.
+	hosts *sync.Map
+
+	// edges stores destination hosts of the source host,
+	// the key is the source host id and the value is *sync.Map
+	// whose key is the destination host id and value is Probes.
+	edges *sync.Map
+
+	// probedCounts stores the number of times the host has been probed,
+	// the key is the host id and the value is *atomic.Uint64.
+	probedCounts *sync.Map
+
+	// snapshotPath is the path of snapshot file.
+	snapshotPath string
+
+	// storage is the storage of network topology records.
+	storage storage.Storage
+
+	// done is the channel to stop snapshot.
+	done chan struct{}
+
+	// once ensures network topology is stopped only once.
+	once *sync.Once
+}
+
+// snapshot is the persistent form of network topology.
+type snapshot struct {
+	// Hosts are the metadata of hosts in network topology.
+	Hosts map[string]*snapshotHost `json:"hosts"`
+
+	// Edges are the probes of edges in network topology.
+	Edges []*snapshotEdge `json:"edges"`
+
+	// ProbedCounts are the number of times the hosts have been probed.
+	ProbedCounts map[string]uint64 `json:"probed_counts"`
+
+	// CreatedAt is the creation time of snapshot.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// snapshotHost is the persistent form of host metadata.
+type snapshotHost struct {
+	ID             string    `json:"id"`
+	Type           int       `json:"type"`
+	Hostname       string    `json:"hostname"`
+	IP             string    `json:"ip"`
+	Port           int32     `json:"port"`
+	DownloadPort   int32     `json:"download_port"`
+	SecurityDomain string    `json:"security_domain"`
+	Location       string    `json:"location"`
+	IDC            string    `json:"idc"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// snapshotEdge is the persistent form of the edge.
+type snapshotEdge struct {
+	SrcHostID  string           `json:"src_host_id"`
+	DestHostID string           `json:"dest_host_id"`
+	Probes     []*snapshotProbe `json:"probes"`
+}
+
+// snapshotProbe is the persistent form of the probe.
+type snapshotProbe struct {
+	RTT       time.Duration `json:"rtt"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// New network topology interface, and restores network topology from the snapshot in data directory.
+func New(cfg *config.NetworkTopologyConfig, hostManager resource.HostManager, dataDir string, storage storage.Storage) (NetworkTopology, error) {
+	n := &networkTopology{
+		config:       cfg,
+		hostManager:  hostManager,
+		hosts:        &sync.Map{},
+		edges:        &sync.Map{},
+		probedCounts: &sync.Map{},
+		snapshotPath: filepath.Join(dataDir, SnapshotFileName),
+		storage:      storage,
+		done:         make(chan struct{}),
+		once:         &sync.Once{},
+	}
+
+	if err := n.restore(); err != nil {
+		return nil, err
+	}
+
+	// Prune the hosts, edges and probes of the hosts reclaimed by host manager.
+	hostManager.Register(n)
+	return n, nil
+}
+
+// Serve starts to evict expired hosts, snapshot and collect network topology periodically.
+func (n *networkTopology) Serve() {
+	tick := time.NewTicker(n.config.CollectInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-tick.C:
+			n.evict()
+			if err := n.Snapshot(); err != nil {
+				logger.Errorf("snapshot network topology failed: %s", err.Error())
+			}
+
+			if err := n.Collect(); err != nil {
+				logger.Errorf("collect network topology failed: %s", err.Error())
+			}
+		case <-n.done:
+			return
+		}
+	}
+}
+
+// Stop stops network topology and writes the last snapshot,
+// stopping network topology again does nothing.
+func (n *networkTopology) Stop() error {
+	var err error
+	n.once.Do(func() {
+		n.hostManager.Deregister(n)
+		close(n.done)
+		err = n.Snapshot()
+	})
+
+	return err
+}
+
+// Has returns whether the edge from the source host to the destination host exists.
+func (n *networkTopology) Has(srcHostID string, destHostID string) bool {
+	_, ok := n.Probes(srcHostID, destHostID)
+	return ok
+}
+
+// Store stores the edge from the source host to the destination host.
+func (n *networkTopology) Store(srcHost *resource.Host, destHost *resource.Host) {
+	n.hosts.Store(srcHost.ID, srcHost)
+	n.hosts.Store(destHost.ID, destHost)
+
+	rawDestHosts, _ := n.edges.LoadOrStore(srcHost.ID, &sync.Map{})
+	destHosts := rawDestHosts.(*sync.Map)
+	if _, ok := destHosts.Load(destHost.ID); ok {
+		return
+	}
+
+	destHosts.LoadOrStore(destHost.ID, NewProbes(n.config.Probe.QueueLength, srcHost))
+}
+
+// Probes loads probes of the edge from the source host to the destination host.
+func (n *networkTopology) Probes(srcHostID string, destHostID string) (Probes, bool) {
+	rawDestHosts, ok := n.edges.Load(srcHostID)
+	if !ok {
+		return nil, false
+	}
+
+	rawProbes, ok := rawDestHosts.(*sync.Map).Load(destHostID)
+	if !ok {
+		return nil, false
+	}
+
+	return rawProbes.(Probes), true
+}
+
+// Enqueue enqueues the probe of the destination host measured by the source host.
+func (n *networkTopology) Enqueue(srcHost *resource.Host, probe *Probe) error {
+	if probe.Host == nil {
+		return errors.New("invalid probe host")
+	}
+
+	n.Store(srcHost, probe.Host)
+	probes, ok := n.Probes(srcHost.ID, probe.Host.ID)
+	if !ok {
+		return errors.New("probes not found")
+	}
+
+	if err := probes.Enqueue(probe); err != nil {
+		return err
+	}
+
+	rawProbedCount, _ := n.probedCounts.LoadOrStore(probe.Host.ID, atomic.NewUint64(0))
+	rawProbedCount.(*atomic.Uint64).Inc()
+	return nil
+}
+
+// DeleteHost deletes the host and all edges connected to the host.
+func (n *networkTopology) DeleteHost(hostID string) {
+	n.hosts.Delete(hostID)
+	n.edges.Delete(hostID)
+	n.edges.Range(func(_, value any) bool {
+		value.(*sync.Map).Delete(hostID)
+		return true
+	})
+
+	n.probedCounts.Delete(hostID)
+}
+
+// OnDeleteHost deletes the host from network topology when the host is reclaimed by host manager.
+func (n *networkTopology) OnDeleteHost(host *resource.Host) {
+	n.DeleteHost(host.ID)
+}
+
+// evict deletes the hosts which are not managed by host manager and not updated
+// within the host TTL, such as the hosts restored from the snapshot or synchronized
+// by other schedulers, the hosts in host manager are pruned by OnDeleteHost.
+func (n *networkTopology) evict() {
+	n.hosts.Range(func(_, value any) bool {
+		host := value.(*resource.Host)
+		if _, loaded := n.hostManager.Load(host.ID); loaded {
+			return true
+		}
+
+		if time.Since(host.UpdatedAt.Load()) > n.config.HostTTL {
+			logger.Infof("network topology evicts host %s", host.ID)
+			n.DeleteHost(host.ID)
+		}
+
+		return true
+	})
+}
+
+// DestHostIDs returns ids of the destination hosts probed by the source host.
+func (n *networkTopology) DestHostIDs(srcHostID string) []string {
+	var destHostIDs []string
+	rawDestHosts, ok := n.edges.Load(srcHostID)
+	if !ok {
+		return destHostIDs
+	}
+
+	rawDestHosts.(*sync.Map).Range(func(key, _ any) bool {
+		destHostIDs = append(destHostIDs, key.(string))
+		return true
+	})
+
+	sort.Strings(destHostIDs)
+	return destHostIDs
+}
+
+// ProbedCount returns the number of times the host has been probed.
+func (n *networkTopology) ProbedCount(hostID string) uint64 {
+	rawProbedCount, ok := n.probedCounts.Load(hostID)
+	if !ok {
+		return 0
+	}
+
+	return rawProbedCount.(*atomic.Uint64).Load()
+}
+
+// FindProbedHosts finds the least probed hosts to be probed by the host,
+// the number of hosts is limited by the sync count of probe.
+func (n *networkTopology) FindProbedHosts(hostID string) []*resource.Host {
+	var candidateHosts []*resource.Host
+	n.hostManager.Range(func(_, value any) bool {
+		host, ok := value.(*resource.Host)
+		if !ok {
+			return true
+		}
+
+		if host.ID != hostID {
+			candidateHosts = append(candidateHosts, host)
+		}
+
+		return true
+	})
+
+	sort.SliceStable(candidateHosts, func(i, j int) bool {
+		return n.ProbedCount(candidateHosts[i].ID) < n.ProbedCount(candidateHosts[j].ID)
+	})
+
+	if len(candidateHosts) > n.config.Probe.SyncCount {
+		candidateHosts = candidateHosts[:n.config.Probe.SyncCount]
+	}
+
+	return candidateHosts
+}
+
+// AverageRTT returns the average round-trip time from the source host to the destination host.
+func (n *networkTopology) AverageRTT(srcHostID string, destHostID string) (time.Duration, bool) {
+	probes, ok := n.Probes(srcHostID, destHostID)
+	if !ok || probes.Length() == 0 {
+		return 0, false
+	}
+
+	return probes.AverageRTT(), true
+}
+
+// Snapshot writes network topology to the snapshot file.
+func (n *networkTopology) Snapshot() error {
+	s := &snapshot{
+		Hosts:        map[string]*snapshotHost{},
+		ProbedCounts: map[string]uint64{},
+		CreatedAt:    time.Now(),
+	}
+
+	n.hosts.Range(func(_, value any) bool {
+		host := value.(*resource.Host)
+		s.Hosts[host.ID] = newSnapshotHost(host)
+		return true
+	})
+
+	n.edges.Range(func(rawSrcHostID, rawDestHosts any) bool {
+		srcHostID := rawSrcHostID.(string)
+		rawDestHosts.(*sync.Map).Range(func(rawDestHostID, rawProbes any) bool {
+			edge := &snapshotEdge{
+				SrcHostID:  srcHostID,
+				DestHostID: rawDestHostID.(string),
+			}
+
+			for e := rawProbes.(Probes).Items().Front(); e != nil; e = e.Next() {
+				probe, ok := e.Value.(*Probe)
+				if !ok {
+					continue
+				}
+
+				edge.Probes = append(edge.Probes, &snapshotProbe{
+					RTT:       probe.RTT,
+					CreatedAt: probe.CreatedAt,
+				})
+			}
+
+			s.Edges = append(s.Edges, edge)
+			return true
+		})
+
+		return true
+	})
+
+	n.probedCounts.Range(func(key, value any) bool {
+		s.ProbedCounts[key.(string)] = value.(*atomic.Uint64).Load()
+		return true
+	})
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	// Write to temporary file and rename it to make the snapshot atomic.
+	tmpPath := n.snapshotPath + ".tmp"
+	if err := os.WriteFile(tmpPath, b, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, n.snapshotPath)
+}
+
+// Collect writes the probes of edges in network topology to the storage,
+// one record per edge, the edges without probes are skipped.
+func (n *networkTopology) Collect() error {
+	createdAt := time.Now().UnixNano()
+
+	var err error
+	n.edges.Range(func(rawSrcHostID, rawDestHosts any) bool {
+		rawSrcHost, ok := n.hosts.Load(rawSrcHostID)
+		if !ok {
+			return true
+		}
+		srcHost := rawSrcHost.(*resource.Host)
+
+		rawDestHosts.(*sync.Map).Range(func(rawDestHostID, rawProbes any) bool {
+			probes := rawProbes.(Probes)
+			if probes.Length() == 0 {
+				return true
+			}
+
+			rawDestHost, ok := n.hosts.Load(rawDestHostID)
+			if !ok {
+				return true
+			}
+			destHost := rawDestHost.(*resource.Host)
+
+			if err = n.storage.CreateNetworkTopology(storage.NetworkTopology{
+				Host: storage.SrcHost{
+					ID:       srcHost.ID,
+					Type:     srcHost.Type.Name(),
+					Hostname: srcHost.Hostname,
+					IP:       srcHost.IP,
+					Port:     srcHost.Port,
+					Network:  srcHost.Network,
+				},
+				DestHost: storage.DestHost{
+					ID:       destHost.ID,
+					Type:     destHost.Type.Name(),
+					Hostname: destHost.Hostname,
+					IP:       destHost.IP,
+					Port:     destHost.Port,
+					Network:  destHost.Network,
+					Probes: storage.Probes{
+						AverageRTT: probes.AverageRTT().Nanoseconds(),
+						Count:      int32(probes.Length()),
+						CreatedAt:  probes.CreatedAt().UnixNano(),
+						UpdatedAt:  probes.UpdatedAt().UnixNano(),
+					},
+				},
+				CreatedAt: createdAt,
+			}); err != nil {
+				return false
+			}
+
+			return true
+		})
+
+		return err == nil
+	})
+
+	return err
+}
+
+// restore restores network topology from the snapshot file.
+func (n *networkTopology) restore() error {
+	b, err := os.ReadFile(n.snapshotPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	s := &snapshot{}
+	if err := json.Unmarshal(b, s); err != nil {
+		// The snapshot is broken, discard it and rebuild the network topology.
+		logger.Warnf("unmarshal network topology snapshot failed: %s", err.Error())
+		return nil
+	}
+
+	hosts := make(map[string]*resource.Host, len(s.Hosts))
+	for hostID, snapshotHost := range s.Hosts {
+		hosts[hostID] = snapshotHost.host()
+	}
+
+	for _, edge := range s.Edges {
+		srcHost, ok := hosts[edge.SrcHostID]
+		if !ok {
+			continue
+		}
+
+		destHost, ok := hosts[edge.DestHostID]
+		if !ok {
+			continue
+		}
+
+		n.Store(srcHost, destHost)
+		probes, _ := n.Probes(srcHost.ID, destHost.ID)
+		for _, probe := range edge.Probes {
+			if err := probes.Enqueue(NewProbe(destHost, probe.RTT, probe.CreatedAt)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for hostID, probedCount := range s.ProbedCounts {
+		n.probedCounts.Store(hostID, atomic.NewUint64(probedCount))
+	}
+
+	logger.Infof("restore network topology with %d edges from %s", len(s.Edges), n.snapshotPath)
+	return nil
+}
+
+// newSnapshotHost returns the persistent form of host metadata.
+func newSnapshotHost(host *resource.Host) *snapshotHost {
+	return &snapshotHost{
+		ID:             host.ID,
+		Type:           int(host.Type),
+		Hostname:       host.Hostname,
+		IP:             host.IP,
+		Port:           host.Port,
+		DownloadPort:   host.DownloadPort,
+		SecurityDomain: host.Network.SecurityDomain,
+		Location:       host.Network.Location,
+		IDC:            host.Network.IDC,
+		UpdatedAt:      host.UpdatedAt.Load(),
+	}
+}
+
+// host returns the host from the persistent form of host metadata,
+// the update time is kept so that the host TTL is not reset by restoring.
+func (h *snapshotHost) host() *resource.Host {
+	host := resource.NewHost(h.ID, h.IP, h.Hostname, h.Port, h.DownloadPort, types.HostType(h.Type),
+		resource.WithNetwork(resource.Network{
+			SecurityDomain: h.SecurityDomain,
+			Location:       h.Location,
+			IDC:            h.IDC,
+		}))
+
+	if !h.UpdatedAt.IsZero() {
+		host.UpdatedAt.Store(h.UpdatedAt)
+	}
+
+	return host
+}
@@ -0,0 +1,456 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package networktopology
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/scheduler/config"
+	"d7y.io/dragonfly/v2/scheduler/resource"
+	"d7y.io/dragonfly/v2/scheduler/storage"
+	storagemocks "d7y.io/dragonfly/v2/scheduler/storage/mocks"
+)
+
+var mockNetworkTopologyConfig = &config.NetworkTopologyConfig{
+	Enable:          true,
+	SyncInterval:    30 * time.Second,
+	CollectInterval: 60 * time.Second,
+	HostTTL:         time.Hour,
+	Probe: config.ProbeConfig{
+		QueueLength:  5,
+		SyncInterval: 30 * time.Second,
+		SyncCount:    1,
+	},
+}
+
+func TestNetworkTopology_New(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(dataDir string)
+		expect func(t *testing.T, n NetworkTopology, err error)
+	}{
+		{
+			name: "new network topology",
+			mock: func(dataDir string) {},
+			expect: func(t *testing.T, n NetworkTopology, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(reflect.TypeOf(n).Elem().Name(), "networkTopology")
+			},
+		},
+		{
+			name: "new network topology with broken snapshot",
+			mock: func(dataDir string) {
+				if err := os.WriteFile(filepath.Join(dataDir, SnapshotFileName), []byte("foo"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expect: func(t *testing.T, n NetworkTopology, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(len(n.DestHostIDs(mockHost.ID)), 0)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			hostManager := resource.NewMockHostManager(ctl)
+			hostManager.EXPECT().Register(gomock.Any()).AnyTimes()
+			storage := storagemocks.NewMockStorage(ctl)
+
+			dataDir := t.TempDir()
+			tc.mock(dataDir)
+			n, err := New(mockNetworkTopologyConfig, hostManager, dataDir, storage)
+			tc.expect(t, n, err)
+		})
+	}
+}
+
+func TestNetworkTopology_Enqueue(t *testing.T) {
+	tests := []struct {
+		name   string
+		probes []*Probe
+		expect func(t *testing.T, n NetworkTopology, err error)
+	}{
+		{
+			name:   "enqueue probe",
+			probes: []*Probe{NewProbe(mockSeedHost, 10*time.Millisecond, time.Now())},
+			expect: func(t *testing.T, n NetworkTopology, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(n.Has(mockHost.ID, mockSeedHost.ID))
+				assert.False(n.Has(mockSeedHost.ID, mockHost.ID))
+				assert.Equal(n.DestHostIDs(mockHost.ID), []string{mockSeedHost.ID})
+				assert.Equal(n.ProbedCount(mockSeedHost.ID), uint64(1))
+				assert.Equal(n.ProbedCount(mockHost.ID), uint64(0))
+
+				rtt, ok := n.AverageRTT(mockHost.ID, mockSeedHost.ID)
+				assert.True(ok)
+				assert.Equal(rtt, 10*time.Millisecond)
+			},
+		},
+		{
+			name: "enqueue probes",
+			probes: []*Probe{
+				NewProbe(mockSeedHost, 10*time.Millisecond, time.Now()),
+				NewProbe(mockSeedHost, 20*time.Millisecond, time.Now()),
+			},
+			expect: func(t *testing.T, n NetworkTopology, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(n.ProbedCount(mockSeedHost.ID), uint64(2))
+
+				probes, ok := n.Probes(mockHost.ID, mockSeedHost.ID)
+				assert.True(ok)
+				assert.Equal(probes.Length(), 2)
+
+				rtt, ok := n.AverageRTT(mockHost.ID, mockSeedHost.ID)
+				assert.True(ok)
+				assert.Equal(rtt, 19*time.Millisecond)
+			},
+		},
+		{
+			name:   "enqueue probe without host",
+			probes: []*Probe{NewProbe(nil, 10*time.Millisecond, time.Now())},
+			expect: func(t *testing.T, n NetworkTopology, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "invalid probe host")
+
+				_, ok := n.AverageRTT(mockHost.ID, mockSeedHost.ID)
+				assert.False(ok)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			hostManager := resource.NewMockHostManager(ctl)
+			hostManager.EXPECT().Register(gomock.Any()).AnyTimes()
+			storage := storagemocks.NewMockStorage(ctl)
+
+			n, err := New(mockNetworkTopologyConfig, hostManager, t.TempDir(), storage)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, probe := range tc.probes {
+				if err = n.Enqueue(mockHost, probe); err != nil {
+					break
+				}
+			}
+
+			tc.expect(t, n, err)
+		})
+	}
+}
+
+func TestNetworkTopology_DeleteHost(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	hostManager := resource.NewMockHostManager(ctl)
+	hostManager.EXPECT().Register(gomock.Any()).AnyTimes()
+	storage := storagemocks.NewMockStorage(ctl)
+
+	n, err := New(mockNetworkTopologyConfig, hostManager, t.TempDir(), storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert := assert.New(t)
+	assert.NoError(n.Enqueue(mockHost, NewProbe(mockSeedHost, 10*time.Millisecond, time.Now())))
+	assert.NoError(n.Enqueue(mockSeedHost, NewProbe(mockHost, 10*time.Millisecond, time.Now())))
+
+	n.DeleteHost(mockSeedHost.ID)
+	assert.False(n.Has(mockHost.ID, mockSeedHost.ID))
+	assert.False(n.Has(mockSeedHost.ID, mockHost.ID))
+	assert.Equal(n.ProbedCount(mockSeedHost.ID), uint64(0))
+	assert.Equal(n.ProbedCount(mockHost.ID), uint64(1))
+}
+
+func TestNetworkTopology_OnDeleteHost(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	hostManager := resource.NewMockHostManager(ctl)
+	storage := storagemocks.NewMockStorage(ctl)
+
+	var observer resource.HostObserver
+	hostManager.EXPECT().Register(gomock.Any()).Do(func(o resource.HostObserver) { observer = o }).Times(1)
+	n, err := New(mockNetworkTopologyConfig, hostManager, t.TempDir(), storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert := assert.New(t)
+	assert.NoError(n.Enqueue(mockHost, NewProbe(mockSeedHost, 10*time.Millisecond, time.Now())))
+	assert.NoError(n.Enqueue(mockSeedHost, NewProbe(mockHost, 10*time.Millisecond, time.Now())))
+
+	observer.OnDeleteHost(mockSeedHost)
+	assert.False(n.Has(mockHost.ID, mockSeedHost.ID))
+	assert.False(n.Has(mockSeedHost.ID, mockHost.ID))
+	assert.Equal(n.DestHostIDs(mockHost.ID), []string(nil))
+	assert.Equal(n.ProbedCount(mockSeedHost.ID), uint64(0))
+}
+
+func TestNetworkTopology_evict(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	hostManager := resource.NewMockHostManager(ctl)
+	hostManager.EXPECT().Register(gomock.Any()).Times(1)
+	storage := storagemocks.NewMockStorage(ctl)
+
+	n, err := New(mockNetworkTopologyConfig, hostManager, t.TempDir(), storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	managedHost := resource.NewHost("foo", "127.0.0.2", "foo", 8003, 8001, mockHost.Type)
+	managedHost.UpdatedAt.Store(time.Now().Add(-2 * mockNetworkTopologyConfig.HostTTL))
+	expiredHost := resource.NewHost("bar", "127.0.0.3", "bar", 8003, 8001, mockHost.Type)
+	expiredHost.UpdatedAt.Store(time.Now().Add(-2 * mockNetworkTopologyConfig.HostTTL))
+	host := resource.NewHost("baz", "127.0.0.4", "baz", 8003, 8001, mockHost.Type)
+
+	assert := assert.New(t)
+	assert.NoError(n.Enqueue(managedHost, NewProbe(expiredHost, 10*time.Millisecond, time.Now())))
+	assert.NoError(n.Enqueue(host, NewProbe(expiredHost, 10*time.Millisecond, time.Now())))
+	assert.NoError(n.Enqueue(expiredHost, NewProbe(host, 10*time.Millisecond, time.Now())))
+
+	hostManager.EXPECT().Load(gomock.Any()).DoAndReturn(func(id string) (*resource.Host, bool) {
+		return managedHost, id == managedHost.ID
+	}).Times(3)
+	n.(*networkTopology).evict()
+
+	assert.False(n.Has(managedHost.ID, expiredHost.ID))
+	assert.False(n.Has(host.ID, expiredHost.ID))
+	assert.False(n.Has(expiredHost.ID, host.ID))
+	assert.Equal(n.ProbedCount(expiredHost.ID), uint64(0))
+	assert.Equal(n.ProbedCount(host.ID), uint64(1))
+
+	_, ok := n.(*networkTopology).hosts.Load(expiredHost.ID)
+	assert.False(ok)
+	_, ok = n.(*networkTopology).hosts.Load(managedHost.ID)
+	assert.True(ok)
+	_, ok = n.(*networkTopology).hosts.Load(host.ID)
+	assert.True(ok)
+}
+
+func TestNetworkTopology_FindProbedHosts(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(n NetworkTopology, mr *resource.MockHostManagerMockRecorder)
+		expect func(t *testing.T, hosts []*resource.Host)
+	}{
+		{
+			name: "find the least probed host",
+			mock: func(n NetworkTopology, mr *resource.MockHostManagerMockRecorder) {
+				mockOtherHost := resource.NewHost("foo", "127.0.0.2", "foo", 8003, 8001, mockHost.Type)
+				if err := n.Enqueue(mockHost, NewProbe(mockSeedHost, 10*time.Millisecond, time.Now())); err != nil {
+					t.Fatal(err)
+				}
+
+				mr.Range(gomock.Any()).Do(func(f func(any, any) bool) {
+					for _, host := range []*resource.Host{mockHost, mockSeedHost, mockOtherHost} {
+						if !f(host.ID, host) {
+							return
+						}
+					}
+				}).Times(1)
+			},
+			expect: func(t *testing.T, hosts []*resource.Host) {
+				assert := assert.New(t)
+				assert.Equal(len(hosts), 1)
+				assert.Equal(hosts[0].ID, "foo")
+			},
+		},
+		{
+			name: "host manager is empty",
+			mock: func(n NetworkTopology, mr *resource.MockHostManagerMockRecorder) {
+				mr.Range(gomock.Any()).Times(1)
+			},
+			expect: func(t *testing.T, hosts []*resource.Host) {
+				assert := assert.New(t)
+				assert.Equal(len(hosts), 0)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			hostManager := resource.NewMockHostManager(ctl)
+			hostManager.EXPECT().Register(gomock.Any()).AnyTimes()
+			storage := storagemocks.NewMockStorage(ctl)
+
+			n, err := New(mockNetworkTopologyConfig, hostManager, t.TempDir(), storage)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tc.mock(n, hostManager.EXPECT())
+			tc.expect(t, n.FindProbedHosts(mockHost.ID))
+		})
+	}
+}
+
+func TestNetworkTopology_Snapshot(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	hostManager := resource.NewMockHostManager(ctl)
+	hostManager.EXPECT().Register(gomock.Any()).AnyTimes()
+	storage := storagemocks.NewMockStorage(ctl)
+
+	dataDir := t.TempDir()
+	n, err := New(mockNetworkTopologyConfig, hostManager, dataDir, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert := assert.New(t)
+	assert.NoError(n.Enqueue(mockHost, NewProbe(mockSeedHost, 10*time.Millisecond, time.Now())))
+	assert.NoError(n.Enqueue(mockHost, NewProbe(mockSeedHost, 20*time.Millisecond, time.Now())))
+	assert.NoError(n.Snapshot())
+
+	restored, err := New(mockNetworkTopologyConfig, hostManager, dataDir, storage)
+	assert.NoError(err)
+	assert.True(restored.Has(mockHost.ID, mockSeedHost.ID))
+	assert.Equal(restored.ProbedCount(mockSeedHost.ID), uint64(2))
+
+	probes, ok := restored.Probes(mockHost.ID, mockSeedHost.ID)
+	assert.True(ok)
+	assert.Equal(probes.Length(), 2)
+	peek, ok := probes.Peek()
+	assert.True(ok)
+	assert.Equal(peek.Host.Hostname, mockSeedHost.Hostname)
+	assert.Equal(peek.Host.Network.IDC, mockSeedHost.Network.IDC)
+	assert.True(peek.Host.UpdatedAt.Load().Equal(mockSeedHost.UpdatedAt.Load()))
+
+	rtt, ok := restored.AverageRTT(mockHost.ID, mockSeedHost.ID)
+	assert.True(ok)
+	assert.Equal(rtt, 19*time.Millisecond)
+}
+
+func TestNetworkTopology_Stop(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	hostManager := resource.NewMockHostManager(ctl)
+	hostManager.EXPECT().Register(gomock.Any()).Times(1)
+	hostManager.EXPECT().Deregister(gomock.Any()).Times(1)
+	storage := storagemocks.NewMockStorage(ctl)
+
+	n, err := New(mockNetworkTopologyConfig, hostManager, t.TempDir(), storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert := assert.New(t)
+	assert.NoError(n.Stop())
+	assert.NotPanics(func() {
+		assert.NoError(n.Stop())
+	})
+}
+
+func TestNetworkTopology_Collect(t *testing.T) {
+	tests := []struct {
+		name   string
+		probes []*Probe
+		mock   func(ms *storagemocks.MockStorageMockRecorder)
+		expect func(t *testing.T, err error)
+	}{
+		{
+			name: "collect probes of edges",
+			probes: []*Probe{
+				NewProbe(mockSeedHost, 10*time.Millisecond, time.Now()),
+				NewProbe(mockSeedHost, 20*time.Millisecond, time.Now()),
+			},
+			mock: func(ms *storagemocks.MockStorageMockRecorder) {
+				ms.CreateNetworkTopology(gomock.Any()).Do(func(networkTopology storage.NetworkTopology) {
+					assert := assert.New(t)
+					assert.Equal(networkTopology.Host.ID, mockHost.ID)
+					assert.Equal(networkTopology.Host.Type, mockHost.Type.Name())
+					assert.Equal(networkTopology.DestHost.ID, mockSeedHost.ID)
+					assert.Equal(networkTopology.DestHost.Network, mockSeedHost.Network)
+					assert.Equal(networkTopology.DestHost.Probes.AverageRTT, (19 * time.Millisecond).Nanoseconds())
+					assert.Equal(networkTopology.DestHost.Probes.Count, int32(2))
+					assert.NotZero(networkTopology.CreatedAt)
+				}).Return(nil).Times(1)
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+			},
+		},
+		{
+			name:   "network topology is empty",
+			probes: []*Probe{},
+			mock:   func(ms *storagemocks.MockStorageMockRecorder) {},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+			},
+		},
+		{
+			name: "create network topology failed",
+			probes: []*Probe{
+				NewProbe(mockSeedHost, 10*time.Millisecond, time.Now()),
+			},
+			mock: func(ms *storagemocks.MockStorageMockRecorder) {
+				ms.CreateNetworkTopology(gomock.Any()).Return(errors.New("foo")).Times(1)
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "foo")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			hostManager := resource.NewMockHostManager(ctl)
+			hostManager.EXPECT().Register(gomock.Any()).AnyTimes()
+			storage := storagemocks.NewMockStorage(ctl)
+
+			n, err := New(mockNetworkTopologyConfig, hostManager, t.TempDir(), storage)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, probe := range tc.probes {
+				if err := n.Enqueue(mockHost, probe); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tc.mock(storage.EXPECT())
+			tc.expect(t, n.Collect())
+		})
+	}
+}
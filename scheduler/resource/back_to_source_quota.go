@@ -0,0 +1,248 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+//go:generate mockgen -destination back_to_source_quota_mock.go -source back_to_source_quota.go -package resource
+
+package resource
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/scheduler/config"
+	"d7y.io/dragonfly/v2/scheduler/metrics"
+)
+
+// BackToSourceQuota is the interface used for limiting the concurrent back-to-source
+// peers of the applications in the scheduler cluster.
+type BackToSourceQuota interface {
+	// Acquire acquires the back-to-source quotas of the application and the source domain
+	// of peer's task, it returns false if any of the quotas is exhausted.
+	Acquire(context.Context, *Peer) bool
+
+	// Serve starts to renew the leases of back-to-source peers and
+	// release the leases of finished peers periodically.
+	Serve()
+
+	// Stop stops serving and releases the leases held by the scheduler.
+	Stop()
+}
+
+// AcquireBackToSourceQuota acquires the back-to-source quotas of application for the peer
+// before the peer or the seed peer triggered by the peer downloads back-to-source, the quota
+// is nil if back-to-source quota is disabled. It returns false if the quotas are exhausted.
+func AcquireBackToSourceQuota(ctx context.Context, quota BackToSourceQuota, peer *Peer) bool {
+	if quota == nil {
+		return true
+	}
+
+	if quota.Acquire(ctx, peer) {
+		return true
+	}
+
+	peer.Log.Infof("back-to-source quota of application %s is exceeded", peer.Task.Application)
+	metrics.BackToSourceQuotaExceededCount.WithLabelValues(peer.Task.Application, peer.Task.Type.String()).Inc()
+	return false
+}
+
+// backToSourceQuota implements BackToSourceQuota.
+type backToSourceQuota struct {
+	// config is the back-to-source quota config.
+	config *config.BackToSourceQuotaConfig
+
+	// store is the quota store shared by schedulers.
+	store QuotaStore
+
+	// Scheduler dynamic configuration, which provides the quotas of applications.
+	dynconfig config.DynconfigInterface
+
+	// leases are the keys of quotas acquired by peers.
+	leases map[*Peer][]string
+
+	// mu protects leases.
+	mu sync.Mutex
+
+	// done is the channel to stop serving.
+	done chan struct{}
+}
+
+// newBackToSourceQuota returns a new BackToSourceQuota interface.
+func newBackToSourceQuota(cfg *config.BackToSourceQuotaConfig, store QuotaStore, dynconfig config.DynconfigInterface) BackToSourceQuota {
+	return &backToSourceQuota{
+		config:    cfg,
+		store:     store,
+		dynconfig: dynconfig,
+		leases:    make(map[*Peer][]string),
+		done:      make(chan struct{}),
+	}
+}
+
+// Acquire acquires the back-to-source quotas of the application and the source domain
+// of peer's task, it returns false if any of the quotas is exhausted. The peer is allowed
+// to back-to-source if the quota store is unavailable.
+func (q *backToSourceQuota) Acquire(ctx context.Context, peer *Peer) bool {
+	limits := q.limits(peer.Task)
+	if len(limits) == 0 {
+		return true
+	}
+
+	var keys []string
+	for key, limit := range limits {
+		ok, err := q.store.Acquire(ctx, key, peer.ID, limit, q.config.TTL)
+		if err != nil {
+			peer.Log.Errorf("acquire back-to-source quota %s failed: %s", key, err.Error())
+			continue
+		}
+
+		if !ok {
+			peer.Log.Infof("back-to-source quota %s is exhausted", key)
+			q.release(ctx, peer, keys)
+			return false
+		}
+
+		keys = append(keys, key)
+	}
+
+	q.mu.Lock()
+	q.leases[peer] = keys
+	q.mu.Unlock()
+	return true
+}
+
+// Serve starts to renew the leases of back-to-source peers and
+// release the leases of finished peers periodically.
+func (q *backToSourceQuota) Serve() {
+	tick := time.NewTicker(q.config.Interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-tick.C:
+			q.renew(context.Background())
+		case <-q.done:
+			return
+		}
+	}
+}
+
+// Stop stops serving and releases the leases held by the scheduler.
+func (q *backToSourceQuota) Stop() {
+	close(q.done)
+
+	q.mu.Lock()
+	leases := q.leases
+	q.leases = make(map[*Peer][]string)
+	q.mu.Unlock()
+
+	for peer, keys := range leases {
+		q.release(context.Background(), peer, keys)
+	}
+}
+
+// renew renews the leases of peers which are still downloading,
+// and releases the leases of peers which are finished.
+func (q *backToSourceQuota) renew(ctx context.Context) {
+	q.mu.Lock()
+	leases := make(map[*Peer][]string, len(q.leases))
+	for peer, keys := range q.leases {
+		leases[peer] = keys
+	}
+	q.mu.Unlock()
+
+	for peer, keys := range leases {
+		if peer.FSM.Is(PeerStateSucceeded) || peer.FSM.Is(PeerStateFailed) || peer.FSM.Is(PeerStateLeave) {
+			q.mu.Lock()
+			delete(q.leases, peer)
+			q.mu.Unlock()
+
+			q.release(ctx, peer, keys)
+			continue
+		}
+
+		limits := q.limits(peer.Task)
+		for _, key := range keys {
+			limit, ok := limits[key]
+			if !ok {
+				continue
+			}
+
+			if _, err := q.store.Acquire(ctx, key, peer.ID, limit, q.config.TTL); err != nil {
+				peer.Log.Errorf("renew back-to-source quota %s failed: %s", key, err.Error())
+			}
+		}
+	}
+}
+
+// release releases the leases of quotas held by the peer.
+func (q *backToSourceQuota) release(ctx context.Context, peer *Peer, keys []string) {
+	for _, key := range keys {
+		if err := q.store.Release(ctx, key, peer.ID); err != nil {
+			peer.Log.Errorf("release back-to-source quota %s failed: %s", key, err.Error())
+		}
+	}
+}
+
+// limits returns the limits of quotas by key for the task, the quotas
+// without limit are ignored.
+func (q *backToSourceQuota) limits(task *Task) map[string]uint32 {
+	if task.Application == "" {
+		return nil
+	}
+
+	clusterConfig, err := q.dynconfig.GetSchedulerClusterConfig()
+	if err != nil {
+		logger.Errorf("get scheduler cluster config failed: %s", err.Error())
+		return nil
+	}
+
+	quota, ok := clusterConfig.ApplicationBackToSourceQuotas[task.Application]
+	if !ok || quota == nil {
+		return nil
+	}
+
+	limits := make(map[string]uint32)
+	if quota.Limit > 0 {
+		limits[applicationQuotaKey(task.Application)] = quota.Limit
+	}
+
+	if len(quota.Domains) > 0 {
+		u, err := url.Parse(task.URL)
+		if err != nil {
+			task.Log.Warnf("parse url failed: %s", err.Error())
+			return limits
+		}
+
+		if limit := quota.Domains[u.Hostname()]; limit > 0 {
+			limits[domainQuotaKey(task.Application, u.Hostname())] = limit
+		}
+	}
+
+	return limits
+}
+
+// applicationQuotaKey returns the key of back-to-source quota of application.
+func applicationQuotaKey(application string) string {
+	return fmt.Sprintf("back-to-source:applications:%s", application)
+}
+
+// domainQuotaKey returns the key of back-to-source quota of the source domain in application.
+func domainQuotaKey(application, domain string) string {
+	return fmt.Sprintf("back-to-source:applications:%s:domains:%s", application, domain)
+}
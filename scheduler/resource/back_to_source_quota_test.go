@@ -0,0 +1,138 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resource
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	commonv2 "d7y.io/api/pkg/apis/common/v2"
+
+	"d7y.io/dragonfly/v2/manager/types"
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	"d7y.io/dragonfly/v2/scheduler/config"
+	configmocks "d7y.io/dragonfly/v2/scheduler/config/mocks"
+)
+
+var mockBackToSourceQuotaConfig = &config.BackToSourceQuotaConfig{
+	Enable:   true,
+	Interval: time.Second,
+	TTL:      time.Minute,
+}
+
+func TestBackToSourceQuota_Acquire(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(t *testing.T, q BackToSourceQuota, peer *Peer, md *configmocks.MockDynconfigInterfaceMockRecorder)
+		expect func(t *testing.T, ok bool)
+	}{
+		{
+			name: "get scheduler cluster config failed",
+			mock: func(t *testing.T, q BackToSourceQuota, peer *Peer, md *configmocks.MockDynconfigInterfaceMockRecorder) {
+				md.GetSchedulerClusterConfig().Return(types.SchedulerClusterConfig{}, errors.New("foo")).Times(1)
+			},
+			expect: func(t *testing.T, ok bool) {
+				assert := assert.New(t)
+				assert.True(ok)
+			},
+		},
+		{
+			name: "application has no quota",
+			mock: func(t *testing.T, q BackToSourceQuota, peer *Peer, md *configmocks.MockDynconfigInterfaceMockRecorder) {
+				md.GetSchedulerClusterConfig().Return(types.SchedulerClusterConfig{}, nil).Times(1)
+			},
+			expect: func(t *testing.T, ok bool) {
+				assert := assert.New(t)
+				assert.True(ok)
+			},
+		},
+		{
+			name: "quota of application is exhausted",
+			mock: func(t *testing.T, q BackToSourceQuota, peer *Peer, md *configmocks.MockDynconfigInterfaceMockRecorder) {
+				md.GetSchedulerClusterConfig().Return(types.SchedulerClusterConfig{
+					ApplicationBackToSourceQuotas: map[string]*types.BackToSourceQuotaConfig{
+						mockTaskApplication: {Limit: 1},
+					},
+				}, nil).Times(2)
+
+				assert.True(t, q.Acquire(context.Background(), NewPeer(idgen.PeerIDV2(), peer.Task, peer.Host)))
+			},
+			expect: func(t *testing.T, ok bool) {
+				assert := assert.New(t)
+				assert.False(ok)
+			},
+		},
+		{
+			name: "quota of source domain is exhausted",
+			mock: func(t *testing.T, q BackToSourceQuota, peer *Peer, md *configmocks.MockDynconfigInterfaceMockRecorder) {
+				md.GetSchedulerClusterConfig().Return(types.SchedulerClusterConfig{
+					ApplicationBackToSourceQuotas: map[string]*types.BackToSourceQuotaConfig{
+						mockTaskApplication: {Limit: 10, Domains: map[string]uint32{"example.com": 1}},
+					},
+				}, nil).Times(2)
+
+				assert.True(t, q.Acquire(context.Background(), NewPeer(idgen.PeerIDV2(), peer.Task, peer.Host)))
+			},
+			expect: func(t *testing.T, ok bool) {
+				assert := assert.New(t)
+				assert.False(ok)
+			},
+		},
+		{
+			name: "quota is released when peer finished",
+			mock: func(t *testing.T, q BackToSourceQuota, peer *Peer, md *configmocks.MockDynconfigInterfaceMockRecorder) {
+				md.GetSchedulerClusterConfig().Return(types.SchedulerClusterConfig{
+					ApplicationBackToSourceQuotas: map[string]*types.BackToSourceQuotaConfig{
+						mockTaskApplication: {Limit: 1},
+					},
+				}, nil).Times(2)
+
+				finishedPeer := NewPeer(idgen.PeerIDV2(), peer.Task, peer.Host)
+				assert.True(t, q.Acquire(context.Background(), finishedPeer))
+				finishedPeer.FSM.SetState(PeerStateSucceeded)
+				q.(*backToSourceQuota).renew(context.Background())
+			},
+			expect: func(t *testing.T, ok bool) {
+				assert := assert.New(t)
+				assert.True(ok)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+
+			mockHost := NewHost(
+				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
+			mockTask := NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, WithDigest(mockTaskDigest), WithPieceLength(mockTaskPieceLength))
+			peer := NewPeer(mockPeerID, mockTask, mockHost)
+
+			q := newBackToSourceQuota(mockBackToSourceQuotaConfig, NewLocalQuotaStore(), dynconfig)
+			tc.mock(t, q, peer, dynconfig.EXPECT())
+			tc.expect(t, q.Acquire(context.Background(), peer))
+		})
+	}
+}
@@ -18,11 +18,14 @@ package resource
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"time"
 
 	"go.uber.org/atomic"
 
+	commonv2 "d7y.io/api/pkg/apis/common/v2"
+
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/pkg/types"
 	"d7y.io/dragonfly/v2/scheduler/config"
@@ -170,6 +173,13 @@ type Host struct {
 	// UploadFailedCount is upload failed count.
 	UploadFailedCount *atomic.Int64
 
+	// Draining is true if the host is draining, the draining host
+	// is not selected as the parent of new children.
+	Draining *atomic.Bool
+
+	// PreemptedAt is the time of the last preemption in the host.
+	PreemptedAt *atomic.Time
+
 	// Peer sync map.
 	Peers *sync.Map
 
@@ -189,133 +199,144 @@ type Host struct {
 // CPU contains content for cpu.
 type CPU struct {
 	// Number of logical cores in the system.
-	LogicalCount uint32 `csv:"logicalCount"`
+	LogicalCount uint32 `csv:"logicalCount" json:"logicalCount"`
 
 	// Number of physical cores in the system.
-	PhysicalCount uint32 `csv:"physicalCount"`
+	PhysicalCount uint32 `csv:"physicalCount" json:"physicalCount"`
 
 	// Percent calculates the percentage of cpu used.
-	Percent float64 `csv:"percent"`
+	Percent float64 `csv:"percent" json:"percent"`
 
 	// Calculates the percentage of cpu used by process.
-	ProcessPercent float64 `csv:"processPercent"`
+	ProcessPercent float64 `csv:"processPercent" json:"processPercent"`
 
 	// Times contains the amounts of time the CPU has spent performing different kinds of work.
-	Times CPUTimes `csv:"times"`
+	Times CPUTimes `csv:"times" json:"times"`
 }
 
 // CPUTimes contains content for cpu times.
 type CPUTimes struct {
 	// CPU time of user.
-	User float64 `csv:"user"`
+	User float64 `csv:"user" json:"user"`
 
 	// CPU time of system.
-	System float64 `csv:"system"`
+	System float64 `csv:"system" json:"system"`
 
 	// CPU time of idle.
-	Idle float64 `csv:"idle"`
+	Idle float64 `csv:"idle" json:"idle"`
 
 	// CPU time of nice.
-	Nice float64 `csv:"nice"`
+	Nice float64 `csv:"nice" json:"nice"`
 
 	// CPU time of iowait.
-	Iowait float64 `csv:"iowait"`
+	Iowait float64 `csv:"iowait" json:"iowait"`
 
 	// CPU time of irq.
-	Irq float64 `csv:"irq"`
+	Irq float64 `csv:"irq" json:"irq"`
 
 	// CPU time of softirq.
-	Softirq float64 `csv:"softirq"`
+	Softirq float64 `csv:"softirq" json:"softirq"`
 
 	// CPU time of steal.
-	Steal float64 `csv:"steal"`
+	Steal float64 `csv:"steal" json:"steal"`
 
 	// CPU time of guest.
-	Guest float64 `csv:"guest"`
+	Guest float64 `csv:"guest" json:"guest"`
 
 	// CPU time of guest nice.
-	GuestNice float64 `csv:"guestNice"`
+	GuestNice float64 `csv:"guestNice" json:"guestNice"`
 }
 
 // Memory contains content for memory.
 type Memory struct {
 	// Total amount of RAM on this system.
-	Total uint64 `csv:"total"`
+	Total uint64 `csv:"total" json:"total"`
 
 	// RAM available for programs to allocate.
-	Available uint64 `csv:"available"`
+	Available uint64 `csv:"available" json:"available"`
 
 	// RAM used by programs.
-	Used uint64 `csv:"used"`
+	Used uint64 `csv:"used" json:"used"`
 
 	// Percentage of RAM used by programs.
-	UsedPercent float64 `csv:"usedPercent"`
+	UsedPercent float64 `csv:"usedPercent" json:"usedPercent"`
 
 	// Calculates the percentage of memory used by process.
-	ProcessUsedPercent float64 `csv:"processUsedPercent"`
+	ProcessUsedPercent float64 `csv:"processUsedPercent" json:"processUsedPercent"`
 
 	// This is the kernel's notion of free memory.
-	Free uint64 `csv:"free"`
+	Free uint64 `csv:"free" json:"free"`
 }
 
 // Network contains content for network.
 type Network struct {
 	// Return count of tcp connections opened and status is ESTABLISHED.
-	TCPConnectionCount uint32 `csv:"tcpConnectionCount"`
+	TCPConnectionCount uint32 `csv:"tcpConnectionCount" json:"tcpConnectionCount"`
 
 	// Return count of upload tcp connections opened and status is ESTABLISHED.
-	UploadTCPConnectionCount uint32 `csv:"uploadTCPConnectionCount"`
+	UploadTCPConnectionCount uint32 `csv:"uploadTCPConnectionCount" json:"uploadTCPConnectionCount"`
 
 	// Security domain for network.
-	SecurityDomain string `csv:"securityDomain"`
+	SecurityDomain string `csv:"securityDomain" json:"securityDomain"`
 
 	// Location path(area|country|province|city|...).
-	Location string `csv:"location"`
+	Location string `csv:"location" json:"location"`
 
 	// IDC where the peer host is located
-	IDC string `csv:"idc"`
+	IDC string `csv:"idc" json:"idc"`
+
+	// UploadRate is the bytes sent by the network interface of host per second.
+	// It is skipped in csv records, because csv columns are written by position
+	// and the trainer parses the records with a fixed schema. The jsonl records
+	// carry it by the json key.
+	UploadRate uint64 `csv:"-" json:"uploadRate"`
+
+	// UploadBandwidth is the capacity of bytes sent by the network interface
+	// of host per second, it is zero if the capacity is unknown. It is skipped
+	// in csv records as UploadRate.
+	UploadBandwidth uint64 `csv:"-" json:"uploadBandwidth"`
 }
 
 // Build contains content for build.
 type Build struct {
 	// Git version.
-	GitVersion string `csv:"gitVersion"`
+	GitVersion string `csv:"gitVersion" json:"gitVersion"`
 
 	// Git commit.
-	GitCommit string `csv:"gitCommit"`
+	GitCommit string `csv:"gitCommit" json:"gitCommit"`
 
 	// Golang version.
-	GoVersion string `csv:"goVersion"`
+	GoVersion string `csv:"goVersion" json:"goVersion"`
 
 	// Build platform.
-	Platform string `csv:"platform"`
+	Platform string `csv:"platform" json:"platform"`
 }
 
 // Disk contains content for disk.
 type Disk struct {
 	// Total amount of disk on the data path of dragonfly.
-	Total uint64 `csv:"total"`
+	Total uint64 `csv:"total" json:"total"`
 
 	// Free amount of disk on the data path of dragonfly.
-	Free uint64 `csv:"free"`
+	Free uint64 `csv:"free" json:"free"`
 
 	// Used amount of disk on the data path of dragonfly.
-	Used uint64 `csv:"used"`
+	Used uint64 `csv:"used" json:"used"`
 
 	// Used percent of disk on the data path of dragonfly directory.
-	UsedPercent float64 `csv:"usedPercent"`
+	UsedPercent float64 `csv:"usedPercent" json:"usedPercent"`
 
 	// Total amount of indoes on the data path of dragonfly directory.
-	InodesTotal uint64 `csv:"inodesTotal"`
+	InodesTotal uint64 `csv:"inodesTotal" json:"inodesTotal"`
 
 	// Used amount of indoes on the data path of dragonfly directory.
-	InodesUsed uint64 `csv:"inodesUsed"`
+	InodesUsed uint64 `csv:"inodesUsed" json:"inodesUsed"`
 
 	// Free amount of indoes on the data path of dragonfly directory.
-	InodesFree uint64 `csv:"inodesFree"`
+	InodesFree uint64 `csv:"inodesFree" json:"inodesFree"`
 
 	// Used percent of indoes on the data path of dragonfly directory.
-	InodesUsedPercent float64 `csv:"inodesUsedPercent"`
+	InodesUsedPercent float64 `csv:"inodesUsedPercent" json:"inodesUsedPercent"`
 }
 
 // New host instance.
@@ -341,6 +362,8 @@ func NewHost(
 		ConcurrentUploadCount: atomic.NewInt32(0),
 		UploadCount:           atomic.NewInt64(0),
 		UploadFailedCount:     atomic.NewInt64(0),
+		Draining:              atomic.NewBool(false),
+		PreemptedAt:           atomic.NewTime(time.Time{}),
 		Peers:                 &sync.Map{},
 		PeerCount:             atomic.NewInt32(0),
 		CreatedAt:             atomic.NewTime(time.Now()),
@@ -401,3 +424,70 @@ func (h *Host) LeavePeers() {
 func (h *Host) FreeUploadCount() int32 {
 	return h.ConcurrentUploadLimit.Load() - h.ConcurrentUploadCount.Load()
 }
+
+// Drained returns whether the host is draining and has no children downloading from it.
+func (h *Host) Drained() bool {
+	return h.Draining.Load() && h.ConcurrentUploadCount.Load() <= 0
+}
+
+// Children returns the children downloading from the peers of host.
+func (h *Host) Children() []*Peer {
+	var children []*Peer
+	visited := make(map[string]struct{})
+	h.Peers.Range(func(_, value any) bool {
+		peer, ok := value.(*Peer)
+		if !ok {
+			h.Log.Error("invalid peer")
+			return true
+		}
+
+		for _, child := range peer.Children() {
+			if _, ok := visited[child.ID]; ok {
+				continue
+			}
+
+			visited[child.ID] = struct{}{}
+			children = append(children, child)
+		}
+
+		return true
+	})
+
+	return children
+}
+
+// PreemptibleChildren returns the children downloading from the peers of host,
+// whose priority ranks lower than the priority. The children are sorted by rank
+// in ascending order, so the child with the lowest priority is preempted first.
+func (h *Host) PreemptibleChildren(priority commonv2.Priority) []*Peer {
+	var children []*Peer
+	visited := make(map[string]struct{})
+	h.Peers.Range(func(_, value any) bool {
+		peer, ok := value.(*Peer)
+		if !ok {
+			h.Log.Error("invalid peer")
+			return true
+		}
+
+		for _, child := range peer.Children() {
+			if PriorityRank(child.Priority) >= PriorityRank(priority) {
+				continue
+			}
+
+			if _, ok := visited[child.ID]; ok {
+				continue
+			}
+
+			visited[child.ID] = struct{}{}
+			children = append(children, child)
+		}
+
+		return true
+	})
+
+	sort.SliceStable(children, func(i, j int) bool {
+		return PriorityRank(children[i].Priority) < PriorityRank(children[j].Priority)
+	})
+
+	return children
+}
@@ -47,20 +47,52 @@ type HostManager interface {
 	// Delete deletes host for a key.
 	Delete(string)
 
+	// Range calls f sequentially for each key and value present in the map.
+	// If f returns false, range stops the iteration.
+	Range(f func(any, any) bool)
+
+	// CancelDraining cancels the draining state of the deleted hosts by the host id,
+	// or by the hostname, then the hosts are not draining when they are stored again.
+	CancelDraining(string, string)
+
+	// Register allows an instance to register itself to observe the deleted hosts.
+	Register(HostObserver)
+
+	// Deregister allows an instance to remove itself from the collection of observers.
+	Deregister(HostObserver)
+
 	// Try to reclaim host.
 	RunGC() error
 }
 
+// HostObserver is the interface used for observing the hosts deleted by host manager.
+type HostObserver interface {
+	// OnDeleteHost is called after the host is deleted from host manager.
+	OnDeleteHost(*Host)
+}
+
 // hostManager contains content for host manager.
 type hostManager struct {
 	// Host sync map.
 	*sync.Map
+
+	// drainingHosts is the hostnames of the deleted draining hosts by the host id,
+	// the draining state is kept when the hosts are stored again.
+	drainingHosts *sync.Map
+
+	// observers are notified when hosts are deleted.
+	observers map[HostObserver]struct{}
+
+	// observersMu protects observers.
+	observersMu sync.RWMutex
 }
 
 // New host manager interface.
 func newHostManager(cfg *config.GCConfig, gc pkggc.GC) (HostManager, error) {
 	h := &hostManager{
-		Map: &sync.Map{},
+		Map:           &sync.Map{},
+		drainingHosts: &sync.Map{},
+		observers:     map[HostObserver]struct{}{},
 	}
 
 	if err := gc.Add(pkggc.Task{
@@ -87,6 +119,7 @@ func (h *hostManager) Load(key string) (*Host, bool) {
 
 // Store sets host.
 func (h *hostManager) Store(host *Host) {
+	h.restoreDraining(host)
 	h.Map.Store(host.ID, host)
 }
 
@@ -94,13 +127,81 @@ func (h *hostManager) Store(host *Host) {
 // Otherwise, it stores and returns the given host.
 // The loaded result is true if the host was loaded, false if stored.
 func (h *hostManager) LoadOrStore(host *Host) (*Host, bool) {
+	if _, loaded := h.Map.Load(host.ID); !loaded {
+		h.restoreDraining(host)
+	}
+
 	rawHost, loaded := h.Map.LoadOrStore(host.ID, host)
 	return rawHost.(*Host), loaded
 }
 
 // Delete deletes host for a key.
 func (h *hostManager) Delete(key string) {
-	h.Map.Delete(key)
+	rawHost, loaded := h.Map.LoadAndDelete(key)
+	if !loaded {
+		return
+	}
+
+	host, ok := rawHost.(*Host)
+	if !ok {
+		return
+	}
+
+	// Keep the draining state, the host is still draining when it is stored again.
+	if host.Draining.Load() {
+		h.drainingHosts.Store(host.ID, host.Hostname)
+	}
+
+	h.observersMu.RLock()
+	defer h.observersMu.RUnlock()
+	for o := range h.observers {
+		o.OnDeleteHost(host)
+	}
+}
+
+// Register allows an instance to register itself to observe the deleted hosts.
+func (h *hostManager) Register(o HostObserver) {
+	h.observersMu.Lock()
+	defer h.observersMu.Unlock()
+	h.observers[o] = struct{}{}
+}
+
+// Deregister allows an instance to remove itself from the collection of observers.
+func (h *hostManager) Deregister(o HostObserver) {
+	h.observersMu.Lock()
+	defer h.observersMu.Unlock()
+	delete(h.observers, o)
+}
+
+// CancelDraining cancels the draining state of the deleted hosts by the host id,
+// or by the hostname, then the hosts are not draining when they are stored again.
+func (h *hostManager) CancelDraining(id, hostname string) {
+	if id != "" {
+		h.drainingHosts.Delete(id)
+		return
+	}
+
+	h.drainingHosts.Range(func(key, value any) bool {
+		if value == hostname {
+			h.drainingHosts.Delete(key)
+		}
+
+		return true
+	})
+}
+
+// restoreDraining restores the draining state of the host which is deleted when draining.
+func (h *hostManager) restoreDraining(host *Host) {
+	if _, loaded := h.drainingHosts.LoadAndDelete(host.ID); loaded {
+		host.Draining.Store(true)
+		host.Log.Info("host keeps draining")
+	}
+}
+
+// Range calls f sequentially for each key and value present in the map.
+// If f returns false, range stops the iteration.
+func (h *hostManager) Range(f func(key, value any) bool) {
+	h.Map.Range(f)
 }
 
 // Try to reclaim host.
@@ -33,6 +33,18 @@ func (m *MockHostManager) EXPECT() *MockHostManagerMockRecorder {
 	return m.recorder
 }
 
+// CancelDraining mocks base method.
+func (m *MockHostManager) CancelDraining(arg0, arg1 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CancelDraining", arg0, arg1)
+}
+
+// CancelDraining indicates an expected call of CancelDraining.
+func (mr *MockHostManagerMockRecorder) CancelDraining(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelDraining", reflect.TypeOf((*MockHostManager)(nil).CancelDraining), arg0, arg1)
+}
+
 // Delete mocks base method.
 func (m *MockHostManager) Delete(arg0 string) {
 	m.ctrl.T.Helper()
@@ -45,6 +57,18 @@ func (mr *MockHostManagerMockRecorder) Delete(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockHostManager)(nil).Delete), arg0)
 }
 
+// Deregister mocks base method.
+func (m *MockHostManager) Deregister(arg0 HostObserver) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Deregister", arg0)
+}
+
+// Deregister indicates an expected call of Deregister.
+func (mr *MockHostManagerMockRecorder) Deregister(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deregister", reflect.TypeOf((*MockHostManager)(nil).Deregister), arg0)
+}
+
 // Load mocks base method.
 func (m *MockHostManager) Load(arg0 string) (*Host, bool) {
 	m.ctrl.T.Helper()
@@ -75,6 +99,30 @@ func (mr *MockHostManagerMockRecorder) LoadOrStore(arg0 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadOrStore", reflect.TypeOf((*MockHostManager)(nil).LoadOrStore), arg0)
 }
 
+// Range mocks base method.
+func (m *MockHostManager) Range(f func(any, any) bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Range", f)
+}
+
+// Range indicates an expected call of Range.
+func (mr *MockHostManagerMockRecorder) Range(f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Range", reflect.TypeOf((*MockHostManager)(nil).Range), f)
+}
+
+// Register mocks base method.
+func (m *MockHostManager) Register(arg0 HostObserver) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Register", arg0)
+}
+
+// Register indicates an expected call of Register.
+func (mr *MockHostManagerMockRecorder) Register(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockHostManager)(nil).Register), arg0)
+}
+
 // RunGC mocks base method.
 func (m *MockHostManager) RunGC() error {
 	m.ctrl.T.Helper()
@@ -100,3 +148,38 @@ func (mr *MockHostManagerMockRecorder) Store(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Store", reflect.TypeOf((*MockHostManager)(nil).Store), arg0)
 }
+
+// MockHostObserver is a mock of HostObserver interface.
+type MockHostObserver struct {
+	ctrl     *gomock.Controller
+	recorder *MockHostObserverMockRecorder
+}
+
+// MockHostObserverMockRecorder is the mock recorder for MockHostObserver.
+type MockHostObserverMockRecorder struct {
+	mock *MockHostObserver
+}
+
+// NewMockHostObserver creates a new mock instance.
+func NewMockHostObserver(ctrl *gomock.Controller) *MockHostObserver {
+	mock := &MockHostObserver{ctrl: ctrl}
+	mock.recorder = &MockHostObserverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHostObserver) EXPECT() *MockHostObserverMockRecorder {
+	return m.recorder
+}
+
+// OnDeleteHost mocks base method.
+func (m *MockHostObserver) OnDeleteHost(arg0 *Host) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnDeleteHost", arg0)
+}
+
+// OnDeleteHost indicates an expected call of OnDeleteHost.
+func (mr *MockHostObserverMockRecorder) OnDeleteHost(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnDeleteHost", reflect.TypeOf((*MockHostObserver)(nil).OnDeleteHost), arg0)
+}
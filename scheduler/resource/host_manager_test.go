@@ -252,6 +252,15 @@ func TestHostManager_LoadOrStore(t *testing.T) {
 	}
 }
 
+// testHostObserver records the ids of the deleted hosts.
+type testHostObserver struct {
+	deletedHostIDs []string
+}
+
+func (o *testHostObserver) OnDeleteHost(host *Host) {
+	o.deletedHostIDs = append(o.deletedHostIDs, host.ID)
+}
+
 func TestHostManager_Delete(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -271,6 +280,83 @@ func TestHostManager_Delete(t *testing.T) {
 				assert.Equal(loaded, false)
 			},
 		},
+		{
+			name: "delete host and notify observers",
+			mock: func(m *gc.MockGCMockRecorder) {
+				m.Add(gomock.Any()).Return(nil).Times(1)
+			},
+			expect: func(t *testing.T, hostManager HostManager, mockHost *Host) {
+				assert := assert.New(t)
+				observer := &testHostObserver{}
+				deregistered := &testHostObserver{}
+				hostManager.Register(observer)
+				hostManager.Register(deregistered)
+				hostManager.Deregister(deregistered)
+
+				hostManager.Store(mockHost)
+				hostManager.Delete(mockHost.ID)
+				hostManager.Delete(mockHost.ID)
+				assert.Equal(observer.deletedHostIDs, []string{mockHost.ID})
+				assert.Equal(len(deregistered.deletedHostIDs), 0)
+			},
+		},
+		{
+			name: "delete draining host and store it again",
+			mock: func(m *gc.MockGCMockRecorder) {
+				m.Add(gomock.Any()).Return(nil).Times(1)
+			},
+			expect: func(t *testing.T, hostManager HostManager, mockHost *Host) {
+				assert := assert.New(t)
+				mockHost.Draining.Store(true)
+				hostManager.Store(mockHost)
+				hostManager.Delete(mockHost.ID)
+
+				host := NewHost(
+					mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+					mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
+				host, loaded := hostManager.LoadOrStore(host)
+				assert.Equal(loaded, false)
+				assert.True(host.Draining.Load())
+			},
+		},
+		{
+			name: "cancel draining of deleted host by id",
+			mock: func(m *gc.MockGCMockRecorder) {
+				m.Add(gomock.Any()).Return(nil).Times(1)
+			},
+			expect: func(t *testing.T, hostManager HostManager, mockHost *Host) {
+				assert := assert.New(t)
+				mockHost.Draining.Store(true)
+				hostManager.Store(mockHost)
+				hostManager.Delete(mockHost.ID)
+				hostManager.CancelDraining(mockHost.ID, "")
+
+				host := NewHost(
+					mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+					mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
+				hostManager.Store(host)
+				assert.False(host.Draining.Load())
+			},
+		},
+		{
+			name: "cancel draining of deleted host by hostname",
+			mock: func(m *gc.MockGCMockRecorder) {
+				m.Add(gomock.Any()).Return(nil).Times(1)
+			},
+			expect: func(t *testing.T, hostManager HostManager, mockHost *Host) {
+				assert := assert.New(t)
+				mockHost.Draining.Store(true)
+				hostManager.Store(mockHost)
+				hostManager.Delete(mockHost.ID)
+				hostManager.CancelDraining("", mockHost.Hostname)
+
+				host := NewHost(
+					mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+					mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
+				hostManager.Store(host)
+				assert.False(host.Draining.Load())
+			},
+		},
 		{
 			name: "delete key does not exist",
 			mock: func(m *gc.MockGCMockRecorder) {
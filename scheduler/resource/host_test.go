@@ -704,3 +704,155 @@ func TestHost_FreeUploadCount(t *testing.T) {
 		})
 	}
 }
+
+func TestHost_Drained(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(host *Host)
+		expect func(t *testing.T, drained bool)
+	}{
+		{
+			name: "host is not draining",
+			mock: func(host *Host) {},
+			expect: func(t *testing.T, drained bool) {
+				assert := assert.New(t)
+				assert.False(drained)
+			},
+		},
+		{
+			name: "draining host has children",
+			mock: func(host *Host) {
+				host.Draining.Store(true)
+				host.ConcurrentUploadCount.Store(1)
+			},
+			expect: func(t *testing.T, drained bool) {
+				assert := assert.New(t)
+				assert.False(drained)
+			},
+		},
+		{
+			name: "draining host has no child",
+			mock: func(host *Host) {
+				host.Draining.Store(true)
+			},
+			expect: func(t *testing.T, drained bool) {
+				assert := assert.New(t)
+				assert.True(drained)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			host := NewHost(
+				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
+			tc.mock(host)
+			tc.expect(t, host.Drained())
+		})
+	}
+}
+
+func TestHost_Children(t *testing.T) {
+	host := NewHost(
+		mockRawSeedHost.ID, mockRawSeedHost.IP, mockRawSeedHost.Hostname,
+		mockRawSeedHost.Port, mockRawSeedHost.DownloadPort, mockRawSeedHost.Type)
+	childHost := NewHost(
+		mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+		mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
+	mockTask := NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, WithDigest(mockTaskDigest))
+
+	assert := assert.New(t)
+	assert.Equal(len(host.Children()), 0)
+
+	var parents []*Peer
+	for i := 0; i < 2; i++ {
+		parent := NewPeer(idgen.PeerIDV2(), mockTask, host)
+		mockTask.StorePeer(parent)
+		host.StorePeer(parent)
+		parents = append(parents, parent)
+	}
+
+	child := NewPeer(idgen.PeerIDV2(), mockTask, childHost)
+	mockTask.StorePeer(child)
+	for _, parent := range parents {
+		if err := mockTask.AddPeerEdge(parent, child); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	children := host.Children()
+	assert.Equal(len(children), 1)
+	assert.Equal(children[0].ID, child.ID)
+}
+
+func TestHost_PreemptibleChildren(t *testing.T) {
+	tests := []struct {
+		name     string
+		priority commonv2.Priority
+		expect   func(t *testing.T, children []*Peer)
+	}{
+		{
+			name:     "children with lower priority are preemptible",
+			priority: commonv2.Priority_LEVEL6,
+			expect: func(t *testing.T, children []*Peer) {
+				assert := assert.New(t)
+				assert.Equal(len(children), 2)
+				assert.Equal(children[0].Priority, commonv2.Priority_LEVEL2)
+				assert.Equal(children[1].Priority, commonv2.Priority_LEVEL3)
+			},
+		},
+		{
+			name:     "default priority ranks as the highest priority",
+			priority: commonv2.Priority_LEVEL0,
+			expect: func(t *testing.T, children []*Peer) {
+				assert := assert.New(t)
+				assert.Equal(len(children), 2)
+				assert.Equal(children[0].Priority, commonv2.Priority_LEVEL2)
+				assert.Equal(children[1].Priority, commonv2.Priority_LEVEL3)
+			},
+		},
+		{
+			name:     "children with the same priority are not preemptible",
+			priority: commonv2.Priority_LEVEL3,
+			expect: func(t *testing.T, children []*Peer) {
+				assert := assert.New(t)
+				assert.Equal(len(children), 1)
+				assert.Equal(children[0].Priority, commonv2.Priority_LEVEL2)
+			},
+		},
+		{
+			name:     "no child is preemptible",
+			priority: commonv2.Priority_LEVEL2,
+			expect: func(t *testing.T, children []*Peer) {
+				assert := assert.New(t)
+				assert.Equal(len(children), 0)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			host := NewHost(
+				mockRawSeedHost.ID, mockRawSeedHost.IP, mockRawSeedHost.Hostname,
+				mockRawSeedHost.Port, mockRawSeedHost.DownloadPort, mockRawSeedHost.Type)
+			childHost := NewHost(
+				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
+			mockTask := NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, WithDigest(mockTaskDigest))
+
+			parent := NewPeer(mockSeedPeerID, mockTask, host)
+			mockTask.StorePeer(parent)
+			host.StorePeer(parent)
+			for _, priority := range []commonv2.Priority{commonv2.Priority_LEVEL3, commonv2.Priority_LEVEL0, commonv2.Priority_LEVEL2} {
+				child := NewPeer(idgen.PeerIDV2(), mockTask, childHost, WithPriority(priority))
+				mockTask.StorePeer(child)
+				if err := mockTask.AddPeerEdge(parent, child); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tc.expect(t, host.PreemptibleChildren(tc.priority))
+		})
+	}
+}
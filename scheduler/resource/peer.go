@@ -45,6 +45,10 @@ import (
 const (
 	// Download tiny file timeout.
 	downloadTinyFileContextTimeout = 30 * time.Second
+
+	// PieceDigestMismatchThreshold is the number of distinct children reporting the pieces
+	// downloaded from the peer do not match digest, the peer is corrupted when it is reached.
+	PieceDigestMismatchThreshold = 2
 )
 
 const (
@@ -175,6 +179,10 @@ type Peer struct {
 	// BlockParents is bad parents ids.
 	BlockParents set.SafeSet[string]
 
+	// DigestMismatchChildren is ids of the children reporting
+	// the pieces downloaded from the peer do not match digest.
+	DigestMismatchChildren set.SafeSet[string]
+
 	// NeedBackToSource needs downloaded from source.
 	//
 	// When peer is registering, at the same time,
@@ -209,6 +217,7 @@ func NewPeer(id string, task *Task, host *Host, options ...PeerOption) *Peer {
 		Task:                    task,
 		Host:                    host,
 		BlockParents:            set.NewSafeSet[string](),
+		DigestMismatchChildren:  set.NewSafeSet[string](),
 		NeedBackToSource:        atomic.NewBool(false),
 		PieceUpdatedAt:          atomic.NewTime(time.Now()),
 		CreatedAt:               atomic.NewTime(time.Now()),
@@ -469,6 +478,16 @@ func (p *Peer) DownloadTinyFile() ([]byte, error) {
 	return io.ReadAll(resp.Body)
 }
 
+// PriorityRank returns the rank of priority, the larger rank is the higher priority.
+// LEVEL0 is the default priority, and it is scheduled as LEVEL6, so it ranks as LEVEL6.
+func PriorityRank(priority commonv2.Priority) int {
+	if priority == commonv2.Priority_LEVEL0 {
+		return int(commonv2.Priority_LEVEL6)
+	}
+
+	return int(priority)
+}
+
 // CalculatePriority returns priority of peer.
 func (p *Peer) CalculatePriority(dynconfig config.DynconfigInterface) commonv2.Priority {
 	if p.Priority != commonv2.Priority_LEVEL0 {
@@ -48,6 +48,10 @@ type PeerManager interface {
 	// Delete deletes peer for a key.
 	Delete(string)
 
+	// Range calls f sequentially for each key and value present in the map.
+	// If f returns false, range stops the iteration.
+	Range(f func(any, any) bool)
+
 	// Try to reclaim peer.
 	RunGC() error
 }
@@ -140,6 +144,12 @@ func (p *peerManager) Delete(key string) {
 	}
 }
 
+// Range calls f sequentially for each key and value present in the map.
+// If f returns false, range stops the iteration.
+func (p *peerManager) Range(f func(key, value any) bool) {
+	p.Map.Range(f)
+}
+
 // Try to reclaim peer.
 func (p *peerManager) RunGC() error {
 	p.Map.Range(func(_, value any) bool {
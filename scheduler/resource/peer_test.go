@@ -806,6 +806,37 @@ func TestPeer_DownloadTinyFile(t *testing.T) {
 	}
 }
 
+func TestPriorityRank(t *testing.T) {
+	tests := []struct {
+		name     string
+		priority commonv2.Priority
+		expect   int
+	}{
+		{
+			name:     "default priority ranks as LEVEL6",
+			priority: commonv2.Priority_LEVEL0,
+			expect:   int(commonv2.Priority_LEVEL6),
+		},
+		{
+			name:     "LEVEL1 ranks the lowest",
+			priority: commonv2.Priority_LEVEL1,
+			expect:   int(commonv2.Priority_LEVEL1),
+		},
+		{
+			name:     "LEVEL3 ranks as its level",
+			priority: commonv2.Priority_LEVEL3,
+			expect:   int(commonv2.Priority_LEVEL3),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(PriorityRank(tc.priority), tc.expect)
+		})
+	}
+}
+
 func TestPeer_CalculatePriority(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -0,0 +1,176 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+//go:generate mockgen -destination quota_store_mock.go -source quota_store.go -package resource
+
+package resource
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"d7y.io/dragonfly/v2/scheduler/config"
+)
+
+const (
+	// quotaStoreNamespace is the prefix of keys in quota store.
+	quotaStoreNamespace = "scheduler:quotas"
+)
+
+// acquireQuotaScript removes the expired leases of quota, and acquires the lease
+// for the member if the member holds the lease already or the quota is not exhausted.
+//
+// KEYS[1]: key of quota.
+// ARGV[1]: current time in milliseconds.
+// ARGV[2]: limit of quota.
+// ARGV[3]: member.
+// ARGV[4]: expiration time of lease in milliseconds.
+// ARGV[5]: time to live of key in milliseconds.
+var acquireQuotaScript = redis.NewScript(`
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", ARGV[1])
+if redis.call("ZSCORE", KEYS[1], ARGV[3]) or redis.call("ZCARD", KEYS[1]) < tonumber(ARGV[2]) then
+	redis.call("ZADD", KEYS[1], ARGV[4], ARGV[3])
+	redis.call("PEXPIRE", KEYS[1], ARGV[5])
+	return 1
+end
+return 0
+`)
+
+// QuotaStore is the interface used for storing the leases of quotas,
+// which are shared by the schedulers in the same cluster.
+type QuotaStore interface {
+	// Acquire acquires the lease of quota for the member with time to live,
+	// and renews the lease if the member holds it already. It returns false
+	// if the quota is exhausted.
+	Acquire(context.Context, string, string, uint32, time.Duration) (bool, error)
+
+	// Release releases the lease of quota held by the member.
+	Release(context.Context, string, string) error
+}
+
+// redisQuotaStore implements QuotaStore by redis, the leases of quota
+// are stored in a sorted set scored by the expiration time.
+type redisQuotaStore struct {
+	// rdb is redis universal client.
+	rdb redis.UniversalClient
+
+	// clusterID is the id of scheduler cluster, which isolates
+	// the quotas of different clusters.
+	clusterID uint
+}
+
+// NewRedisQuotaStore returns a new QuotaStore interface backed by redis.
+func NewRedisQuotaStore(cfg *config.SharedRedisConfig, clusterID uint) (QuotaStore, error) {
+	rdb := redis.NewUniversalClient(&redis.UniversalOptions{
+		Addrs:      cfg.Addrs,
+		MasterName: cfg.MasterName,
+		DB:         cfg.DB,
+		Username:   cfg.Username,
+		Password:   cfg.Password,
+	})
+
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		return nil, err
+	}
+
+	return &redisQuotaStore{rdb: rdb, clusterID: clusterID}, nil
+}
+
+// Acquire acquires the lease of quota for the member with time to live,
+// and renews the lease if the member holds it already. It returns false
+// if the quota is exhausted.
+func (s *redisQuotaStore) Acquire(ctx context.Context, key, member string, limit uint32, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	n, err := acquireQuotaScript.Run(ctx, s.rdb, []string{s.quotaKey(key)},
+		now.UnixMilli(), limit, member, now.Add(ttl).UnixMilli(), ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+// Release releases the lease of quota held by the member.
+func (s *redisQuotaStore) Release(ctx context.Context, key, member string) error {
+	return s.rdb.ZRem(ctx, s.quotaKey(key), member).Err()
+}
+
+// quotaKey returns the key of quota.
+func (s *redisQuotaStore) quotaKey(key string) string {
+	return fmt.Sprintf("%s:%d:%s", quotaStoreNamespace, s.clusterID, key)
+}
+
+// localQuotaStore implements QuotaStore in memory, it can only be
+// shared by the schedulers in the same process, such as tests.
+type localQuotaStore struct {
+	// leases are the expiration time of leases by member and key.
+	leases map[string]map[string]time.Time
+
+	// mu protects leases.
+	mu sync.Mutex
+}
+
+// NewLocalQuotaStore returns a new QuotaStore interface in memory.
+func NewLocalQuotaStore() QuotaStore {
+	return &localQuotaStore{leases: make(map[string]map[string]time.Time)}
+}
+
+// Acquire acquires the lease of quota for the member with time to live,
+// and renews the lease if the member holds it already. It returns false
+// if the quota is exhausted.
+func (s *localQuotaStore) Acquire(_ context.Context, key, member string, limit uint32, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	leases, ok := s.leases[key]
+	if !ok {
+		leases = make(map[string]time.Time)
+		s.leases[key] = leases
+	}
+
+	for m, expireAt := range leases {
+		if !expireAt.After(now) {
+			delete(leases, m)
+		}
+	}
+
+	if _, ok := leases[member]; !ok && uint32(len(leases)) >= limit {
+		return false, nil
+	}
+
+	leases[member] = now.Add(ttl)
+	return true, nil
+}
+
+// Release releases the lease of quota held by the member.
+func (s *localQuotaStore) Release(_ context.Context, key, member string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if leases, ok := s.leases[key]; ok {
+		delete(leases, member)
+		if len(leases) == 0 {
+			delete(s.leases, key)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resource
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalQuotaStore_Acquire(t *testing.T) {
+	tests := []struct {
+		name   string
+		ttl    time.Duration
+		mock   func(t *testing.T, s QuotaStore)
+		expect func(t *testing.T, ok bool, err error)
+	}{
+		{
+			name: "quota is not exhausted",
+			ttl:  time.Minute,
+			mock: func(t *testing.T, s QuotaStore) {},
+			expect: func(t *testing.T, ok bool, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(ok)
+			},
+		},
+		{
+			name: "quota is exhausted",
+			ttl:  time.Minute,
+			mock: func(t *testing.T, s QuotaStore) {
+				if _, err := s.Acquire(context.Background(), "foo", "baz", 1, time.Minute); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expect: func(t *testing.T, ok bool, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.False(ok)
+			},
+		},
+		{
+			name: "member holds the lease already",
+			ttl:  time.Minute,
+			mock: func(t *testing.T, s QuotaStore) {
+				if _, err := s.Acquire(context.Background(), "foo", "bar", 1, time.Minute); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expect: func(t *testing.T, ok bool, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(ok)
+			},
+		},
+		{
+			name: "lease expires",
+			ttl:  time.Minute,
+			mock: func(t *testing.T, s QuotaStore) {
+				if _, err := s.Acquire(context.Background(), "foo", "baz", 1, time.Millisecond); err != nil {
+					t.Fatal(err)
+				}
+				time.Sleep(10 * time.Millisecond)
+			},
+			expect: func(t *testing.T, ok bool, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(ok)
+			},
+		},
+		{
+			name: "lease is released",
+			ttl:  time.Minute,
+			mock: func(t *testing.T, s QuotaStore) {
+				if _, err := s.Acquire(context.Background(), "foo", "baz", 1, time.Minute); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := s.Release(context.Background(), "foo", "baz"); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expect: func(t *testing.T, ok bool, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(ok)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewLocalQuotaStore()
+			tc.mock(t, s)
+			ok, err := s.Acquire(context.Background(), "foo", "bar", 1, tc.ttl)
+			tc.expect(t, ok, err)
+		})
+	}
+}
@@ -0,0 +1,198 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+//go:generate mockgen -destination replica_store_mock.go -source replica_store.go -package resource
+
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"d7y.io/dragonfly/v2/pkg/cache"
+	"d7y.io/dragonfly/v2/scheduler/config"
+)
+
+const (
+	// replicaStoreNamespace is the prefix of keys in replica store.
+	replicaStoreNamespace = "scheduler:replication"
+)
+
+// ReplicaStore is the interface used for storing the replicas of tasks,
+// which are shared by the schedulers in the same cluster.
+type ReplicaStore interface {
+	// StoreReplica stores the replica of task with time to live.
+	StoreReplica(context.Context, string, []byte, time.Duration) error
+
+	// LoadReplica returns the replica of task and whether the replica exists.
+	LoadReplica(context.Context, string) ([]byte, bool, error)
+
+	// DeleteReplica deletes the replica of task.
+	DeleteReplica(context.Context, string) error
+
+	// KeepAlive refreshes the liveness of scheduler with time to live.
+	KeepAlive(context.Context, string, time.Duration) error
+
+	// IsAlive returns whether the scheduler is alive.
+	IsAlive(context.Context, string) (bool, error)
+
+	// Leave removes the liveness of scheduler.
+	Leave(context.Context, string) error
+}
+
+// redisReplicaStore implements ReplicaStore by redis.
+type redisReplicaStore struct {
+	// rdb is redis universal client.
+	rdb redis.UniversalClient
+
+	// clusterID is the id of scheduler cluster, which isolates
+	// the replicas of different clusters.
+	clusterID uint
+}
+
+// NewRedisReplicaStore returns a new ReplicaStore interface backed by redis.
+func NewRedisReplicaStore(cfg *config.SharedRedisConfig, clusterID uint) (ReplicaStore, error) {
+	rdb := redis.NewUniversalClient(&redis.UniversalOptions{
+		Addrs:      cfg.Addrs,
+		MasterName: cfg.MasterName,
+		DB:         cfg.DB,
+		Username:   cfg.Username,
+		Password:   cfg.Password,
+	})
+
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		return nil, err
+	}
+
+	return &redisReplicaStore{rdb: rdb, clusterID: clusterID}, nil
+}
+
+// StoreReplica stores the replica of task with time to live.
+func (s *redisReplicaStore) StoreReplica(ctx context.Context, taskID string, data []byte, ttl time.Duration) error {
+	return s.rdb.Set(ctx, s.taskKey(taskID), data, ttl).Err()
+}
+
+// LoadReplica returns the replica of task and whether the replica exists.
+func (s *redisReplicaStore) LoadReplica(ctx context.Context, taskID string) ([]byte, bool, error) {
+	data, err := s.rdb.Get(ctx, s.taskKey(taskID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, false, nil
+		}
+
+		return nil, false, err
+	}
+
+	return data, true, nil
+}
+
+// DeleteReplica deletes the replica of task.
+func (s *redisReplicaStore) DeleteReplica(ctx context.Context, taskID string) error {
+	return s.rdb.Del(ctx, s.taskKey(taskID)).Err()
+}
+
+// KeepAlive refreshes the liveness of scheduler with time to live.
+func (s *redisReplicaStore) KeepAlive(ctx context.Context, schedulerID string, ttl time.Duration) error {
+	return s.rdb.Set(ctx, s.schedulerKey(schedulerID), time.Now().Unix(), ttl).Err()
+}
+
+// IsAlive returns whether the scheduler is alive.
+func (s *redisReplicaStore) IsAlive(ctx context.Context, schedulerID string) (bool, error) {
+	n, err := s.rdb.Exists(ctx, s.schedulerKey(schedulerID)).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// Leave removes the liveness of scheduler.
+func (s *redisReplicaStore) Leave(ctx context.Context, schedulerID string) error {
+	return s.rdb.Del(ctx, s.schedulerKey(schedulerID)).Err()
+}
+
+// taskKey returns the key of task replica.
+func (s *redisReplicaStore) taskKey(taskID string) string {
+	return fmt.Sprintf("%s:%d:tasks:%s", replicaStoreNamespace, s.clusterID, taskID)
+}
+
+// schedulerKey returns the key of scheduler liveness.
+func (s *redisReplicaStore) schedulerKey(schedulerID string) string {
+	return fmt.Sprintf("%s:%d:schedulers:%s", replicaStoreNamespace, s.clusterID, schedulerID)
+}
+
+// localReplicaStore implements ReplicaStore in memory, it can only be
+// shared by the schedulers in the same process, such as tests.
+type localReplicaStore struct {
+	// replicas caches the replicas by task id.
+	replicas cache.Cache
+
+	// schedulers caches the liveness by scheduler id.
+	schedulers cache.Cache
+}
+
+// NewLocalReplicaStore returns a new ReplicaStore interface in memory.
+func NewLocalReplicaStore() ReplicaStore {
+	return &localReplicaStore{
+		replicas:   cache.New(cache.NoExpiration, time.Minute),
+		schedulers: cache.New(cache.NoExpiration, time.Minute),
+	}
+}
+
+// StoreReplica stores the replica of task with time to live.
+func (s *localReplicaStore) StoreReplica(_ context.Context, taskID string, data []byte, ttl time.Duration) error {
+	s.replicas.Set(taskID, data, ttl)
+	return nil
+}
+
+// LoadReplica returns the replica of task and whether the replica exists.
+func (s *localReplicaStore) LoadReplica(_ context.Context, taskID string) ([]byte, bool, error) {
+	// Get of cache does not check the expiration of item.
+	value, _, ok := s.replicas.GetWithExpiration(taskID)
+	if !ok {
+		return nil, false, nil
+	}
+
+	return value.([]byte), true, nil
+}
+
+// DeleteReplica deletes the replica of task.
+func (s *localReplicaStore) DeleteReplica(_ context.Context, taskID string) error {
+	s.replicas.Delete(taskID)
+	return nil
+}
+
+// KeepAlive refreshes the liveness of scheduler with time to live.
+func (s *localReplicaStore) KeepAlive(_ context.Context, schedulerID string, ttl time.Duration) error {
+	s.schedulers.Set(schedulerID, time.Now(), ttl)
+	return nil
+}
+
+// IsAlive returns whether the scheduler is alive.
+func (s *localReplicaStore) IsAlive(_ context.Context, schedulerID string) (bool, error) {
+	_, _, ok := s.schedulers.GetWithExpiration(schedulerID)
+	return ok, nil
+}
+
+// Leave removes the liveness of scheduler.
+func (s *localReplicaStore) Leave(_ context.Context, schedulerID string) error {
+	s.schedulers.Delete(schedulerID)
+	return nil
+}
@@ -0,0 +1,147 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resource
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalReplicaStore_Replica(t *testing.T) {
+	tests := []struct {
+		name   string
+		ttl    time.Duration
+		expect func(t *testing.T, s ReplicaStore)
+	}{
+		{
+			name: "load replica",
+			ttl:  time.Minute,
+			expect: func(t *testing.T, s ReplicaStore) {
+				assert := assert.New(t)
+				data, ok, err := s.LoadReplica(context.Background(), mockTaskID)
+				assert.NoError(err)
+				assert.True(ok)
+				assert.Equal(data, []byte("foo"))
+			},
+		},
+		{
+			name: "replica does not exist",
+			ttl:  time.Minute,
+			expect: func(t *testing.T, s ReplicaStore) {
+				assert := assert.New(t)
+				_, ok, err := s.LoadReplica(context.Background(), "bar")
+				assert.NoError(err)
+				assert.False(ok)
+			},
+		},
+		{
+			name: "replica is deleted",
+			ttl:  time.Minute,
+			expect: func(t *testing.T, s ReplicaStore) {
+				assert := assert.New(t)
+				assert.NoError(s.DeleteReplica(context.Background(), mockTaskID))
+				_, ok, err := s.LoadReplica(context.Background(), mockTaskID)
+				assert.NoError(err)
+				assert.False(ok)
+			},
+		},
+		{
+			name: "replica expires",
+			ttl:  time.Millisecond,
+			expect: func(t *testing.T, s ReplicaStore) {
+				assert := assert.New(t)
+				time.Sleep(10 * time.Millisecond)
+				_, ok, err := s.LoadReplica(context.Background(), mockTaskID)
+				assert.NoError(err)
+				assert.False(ok)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewLocalReplicaStore()
+			if err := s.StoreReplica(context.Background(), mockTaskID, []byte("foo"), tc.ttl); err != nil {
+				t.Fatal(err)
+			}
+
+			tc.expect(t, s)
+		})
+	}
+}
+
+func TestLocalReplicaStore_KeepAlive(t *testing.T) {
+	tests := []struct {
+		name   string
+		ttl    time.Duration
+		mock   func(s ReplicaStore)
+		expect func(t *testing.T, alive bool, err error)
+	}{
+		{
+			name: "scheduler is alive",
+			ttl:  time.Minute,
+			mock: func(s ReplicaStore) {},
+			expect: func(t *testing.T, alive bool, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(alive)
+			},
+		},
+		{
+			name: "liveness of scheduler expires",
+			ttl:  time.Millisecond,
+			mock: func(s ReplicaStore) {
+				time.Sleep(10 * time.Millisecond)
+			},
+			expect: func(t *testing.T, alive bool, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.False(alive)
+			},
+		},
+		{
+			name: "scheduler leaves",
+			ttl:  time.Minute,
+			mock: func(s ReplicaStore) {
+				if err := s.Leave(context.Background(), "foo"); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expect: func(t *testing.T, alive bool, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.False(alive)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewLocalReplicaStore()
+			if err := s.KeepAlive(context.Background(), "foo", tc.ttl); err != nil {
+				t.Fatal(err)
+			}
+
+			tc.mock(s)
+			alive, err := s.IsAlive(context.Background(), "foo")
+			tc.expect(t, alive, err)
+		})
+	}
+}
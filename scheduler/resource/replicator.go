@@ -0,0 +1,248 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+//go:generate mockgen -destination replicator_mock.go -source replicator.go -package resource
+
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/scheduler/config"
+)
+
+// Replicator is the interface used for replicating the tasks between
+// the schedulers in the same cluster.
+type Replicator interface {
+	// Serve starts to replicate the tasks periodically.
+	Serve()
+
+	// Replicate stores the replicas of tasks owned by the scheduler.
+	Replicate(context.Context) error
+
+	// Takeover restores the task from the replica if the owner of replica is not alive,
+	// and the scheduler becomes the owner of task.
+	Takeover(context.Context, string) (*Task, bool)
+
+	// Delete deletes the replica of task, the task can not be taken over.
+	Delete(context.Context, string) error
+
+	// Stop stops replicating and removes the liveness of scheduler.
+	Stop() error
+}
+
+// replica is the replicated form of task, including the peers in
+// the DAG of task and the hosts of peers.
+type replica struct {
+	// SchedulerID is the id of scheduler which owns the task.
+	SchedulerID string `json:"scheduler_id"`
+
+	// Task is the replicated task.
+	Task *snapshotTask `json:"task"`
+
+	// Hosts are the hosts of peers.
+	Hosts []*snapshotHost `json:"hosts"`
+
+	// Peers are the peers in the DAG of task.
+	Peers []*snapshotPeer `json:"peers"`
+
+	// CreatedAt is the creation time of replica.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// replicator implements Replicator.
+type replicator struct {
+	// schedulerID is the id of scheduler.
+	schedulerID string
+
+	// config is the replication config.
+	config *config.ReplicationConfig
+
+	// store is the replica store shared by schedulers.
+	store ReplicaStore
+
+	// Host manager interface.
+	hostManager HostManager
+
+	// Task manager interface.
+	taskManager TaskManager
+
+	// Peer manager interface.
+	peerManager PeerManager
+
+	// done is the channel to stop replicating.
+	done chan struct{}
+}
+
+// newReplicator returns a new Replicator interface.
+func newReplicator(schedulerID string, cfg *config.ReplicationConfig, store ReplicaStore,
+	hostManager HostManager, taskManager TaskManager, peerManager PeerManager) Replicator {
+	return &replicator{
+		schedulerID: schedulerID,
+		config:      cfg,
+		store:       store,
+		hostManager: hostManager,
+		taskManager: taskManager,
+		peerManager: peerManager,
+		done:        make(chan struct{}),
+	}
+}
+
+// Serve starts to replicate the tasks periodically.
+func (r *replicator) Serve() {
+	tick := time.NewTicker(r.config.Interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-tick.C:
+			if err := r.Replicate(context.Background()); err != nil {
+				logger.Errorf("replicate tasks failed: %s", err.Error())
+			}
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// Replicate stores the replicas of tasks owned by the scheduler.
+func (r *replicator) Replicate(ctx context.Context) error {
+	if err := r.store.KeepAlive(ctx, r.schedulerID, r.config.TTL); err != nil {
+		return err
+	}
+
+	var tasks []*Task
+	r.taskManager.Range(func(_, value any) bool {
+		task, ok := value.(*Task)
+		if !ok {
+			return true
+		}
+
+		if task.FSM.Is(TaskStateLeave) || task.PeerCount() == 0 {
+			return true
+		}
+
+		tasks = append(tasks, task)
+		return true
+	})
+
+	for _, task := range tasks {
+		if err := r.storeReplica(ctx, task); err != nil {
+			task.Log.Errorf("store replica failed: %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// Takeover restores the task from the replica if the owner of replica is not alive,
+// and the scheduler becomes the owner of task.
+func (r *replicator) Takeover(ctx context.Context, taskID string) (*Task, bool) {
+	data, ok, err := r.store.LoadReplica(ctx, taskID)
+	if err != nil {
+		logger.Errorf("load replica of task %s failed: %s", taskID, err.Error())
+		return nil, false
+	}
+
+	if !ok {
+		return nil, false
+	}
+
+	rep := &replica{}
+	if err := json.Unmarshal(data, rep); err != nil || rep.Task == nil {
+		logger.Warnf("replica of task %s is broken", taskID)
+		return nil, false
+	}
+
+	// The task is scheduled by the owner if the owner is alive.
+	if rep.SchedulerID != r.schedulerID {
+		alive, err := r.store.IsAlive(ctx, rep.SchedulerID)
+		if err != nil {
+			logger.Errorf("load liveness of scheduler %s failed: %s", rep.SchedulerID, err.Error())
+			return nil, false
+		}
+
+		if alive {
+			return nil, false
+		}
+	}
+
+	task, loaded := r.taskManager.LoadOrStore(rep.Task.task())
+	if loaded {
+		return task, true
+	}
+
+	hosts := make(map[string]*Host, len(rep.Hosts))
+	for _, snapshotHost := range rep.Hosts {
+		host, _ := r.hostManager.LoadOrStore(snapshotHost.host())
+		hosts[host.ID] = host
+	}
+
+	peers := restorePeers(r.peerManager, rep.Peers, map[string]*Task{task.ID: task}, hosts)
+	task.Log.Infof("take over task with %d peers from scheduler %s", len(peers), rep.SchedulerID)
+
+	if err := r.storeReplica(ctx, task); err != nil {
+		task.Log.Errorf("store replica failed: %s", err.Error())
+	}
+
+	return task, true
+}
+
+// Delete deletes the replica of task, the task can not be taken over.
+func (r *replicator) Delete(ctx context.Context, taskID string) error {
+	return r.store.DeleteReplica(ctx, taskID)
+}
+
+// Stop stops replicating and removes the liveness of scheduler.
+func (r *replicator) Stop() error {
+	close(r.done)
+	return r.store.Leave(context.Background(), r.schedulerID)
+}
+
+// storeReplica stores the replica of task owned by the scheduler.
+func (r *replicator) storeReplica(ctx context.Context, task *Task) error {
+	rep := &replica{
+		SchedulerID: r.schedulerID,
+		Task:        newSnapshotTask(task),
+		CreatedAt:   time.Now(),
+	}
+
+	hosts := make(map[string]struct{})
+	for _, vertex := range task.DAG.GetVertices() {
+		peer := vertex.Value
+		if peer == nil {
+			continue
+		}
+
+		rep.Peers = append(rep.Peers, newSnapshotPeer(peer))
+		if _, ok := hosts[peer.Host.ID]; ok {
+			continue
+		}
+
+		hosts[peer.Host.ID] = struct{}{}
+		rep.Hosts = append(rep.Hosts, newSnapshotHost(peer.Host))
+	}
+
+	b, err := json.Marshal(rep)
+	if err != nil {
+		return err
+	}
+
+	return r.store.StoreReplica(ctx, task.ID, b, r.config.TTL)
+}
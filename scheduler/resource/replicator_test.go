@@ -0,0 +1,153 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resource
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	commonv2 "d7y.io/api/pkg/apis/common/v2"
+
+	"d7y.io/dragonfly/v2/pkg/gc"
+	"d7y.io/dragonfly/v2/scheduler/config"
+)
+
+// newMockReplicationConfig returns the config of scheduler with replication enabled.
+func newMockReplicationConfig(ip, hostname string) *config.Config {
+	return &config.Config{
+		Server: config.ServerConfig{
+			AdvertiseIP: net.ParseIP(ip),
+			Host:        hostname,
+		},
+		Scheduler: config.SchedulerConfig{
+			GC: config.GCConfig{
+				PeerGCInterval: 100,
+				PeerTTL:        1000,
+				TaskGCInterval: 100,
+				HostGCInterval: 100,
+			},
+		},
+		Replication: config.ReplicationConfig{
+			Enable:   true,
+			Interval: time.Second,
+			TTL:      time.Minute,
+		},
+	}
+}
+
+func TestReplicator_Takeover(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(t *testing.T, owner Resource)
+		expect func(t *testing.T, r Resource, task *Task, ok bool)
+	}{
+		{
+			name: "take over task whose owner died",
+			mock: func(t *testing.T, owner Resource) {
+				if err := owner.Stop(); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expect: func(t *testing.T, r Resource, task *Task, ok bool) {
+				assert := assert.New(t)
+				assert.True(ok)
+				assert.True(task.FSM.Is(TaskStateRunning))
+				assert.Equal(task.PeerCount(), 2)
+
+				loadedTask, loaded := r.TaskManager().Load(mockTaskID)
+				assert.True(loaded)
+				assert.Same(loadedTask, task)
+
+				peer, loaded := r.PeerManager().Load(mockPeerID)
+				assert.True(loaded)
+				assert.Same(peer.Task, task)
+				assert.Equal(len(peer.Parents()), 1)
+				assert.Equal(peer.Parents()[0].ID, mockSeedPeerID)
+
+				_, loaded = r.HostManager().Load(mockRawSeedHost.ID)
+				assert.True(loaded)
+			},
+		},
+		{
+			name: "owner of task is alive",
+			mock: func(t *testing.T, owner Resource) {},
+			expect: func(t *testing.T, r Resource, task *Task, ok bool) {
+				assert := assert.New(t)
+				assert.False(ok)
+				_, loaded := r.TaskManager().Load(mockTaskID)
+				assert.False(loaded)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			gc := gc.NewMockGC(ctl)
+			gc.EXPECT().Add(gomock.Any()).Return(nil).Times(6)
+
+			store := NewLocalReplicaStore()
+			owner, err := New(newMockReplicationConfig("127.0.0.1", "foo"), gc, nil, WithReplicaStore(store))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := New(newMockReplicationConfig("127.0.0.2", "bar"), gc, nil, WithReplicaStore(store))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mockHost := NewHost(
+				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
+			mockSeedHost := NewHost(
+				mockRawSeedHost.ID, mockRawSeedHost.IP, mockRawSeedHost.Hostname,
+				mockRawSeedHost.Port, mockRawSeedHost.DownloadPort, mockRawSeedHost.Type)
+			owner.HostManager().Store(mockHost)
+			owner.HostManager().Store(mockSeedHost)
+
+			mockTask := NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, WithDigest(mockTaskDigest), WithPieceLength(mockTaskPieceLength))
+			mockTask.FSM.SetState(TaskStateRunning)
+			owner.TaskManager().Store(mockTask)
+
+			parent := NewPeer(mockSeedPeerID, mockTask, mockSeedHost)
+			parent.FSM.SetState(PeerStateSucceeded)
+			owner.PeerManager().Store(parent)
+
+			child := NewPeer(mockPeerID, mockTask, mockHost)
+			child.FSM.SetState(PeerStateRunning)
+			owner.PeerManager().Store(child)
+			if err := mockTask.AddPeerEdge(parent, child); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := owner.Replicator().Replicate(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			tc.mock(t, owner)
+			task, ok := r.Replicator().Takeover(context.Background(), mockTaskID)
+			tc.expect(t, r, task, ok)
+		})
+	}
+}
@@ -19,11 +19,16 @@
 package resource
 
 import (
+	"errors"
+	"path/filepath"
+
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 
+	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/pkg/gc"
+	"d7y.io/dragonfly/v2/pkg/idgen"
 	"d7y.io/dragonfly/v2/scheduler/config"
 )
 
@@ -41,6 +46,18 @@ type Resource interface {
 	// Task manager interface.
 	TaskManager() TaskManager
 
+	// Serve starts to snapshot resource periodically if snapshot is enabled.
+	Serve()
+
+	// Snapshot writes hosts, tasks and peers to the snapshot file.
+	Snapshot() error
+
+	// Replicator interface, it is nil if replication is disabled.
+	Replicator() Replicator
+
+	// BackToSourceQuota interface, it is nil if back-to-source quota is disabled.
+	BackToSourceQuota() BackToSourceQuota
+
 	// Stop resource serivce.
 	Stop() error
 }
@@ -64,6 +81,27 @@ type resource struct {
 
 	// TransportCredentials stores the Authenticator required to setup a client connection.
 	transportCredentials credentials.TransportCredentials
+
+	// dataDir is the data directory of scheduler, which stores the snapshot file.
+	dataDir string
+
+	// snapshotPath is the path of snapshot file.
+	snapshotPath string
+
+	// done is the channel to stop snapshot.
+	done chan struct{}
+
+	// replicaStore is the store of replicas shared by schedulers.
+	replicaStore ReplicaStore
+
+	// Replicator interface.
+	replicator Replicator
+
+	// quotaStore is the store of quotas shared by schedulers.
+	quotaStore QuotaStore
+
+	// BackToSourceQuota interface.
+	backToSourceQuota BackToSourceQuota
 }
 
 // Option is a functional option for configuring the resource.
@@ -77,9 +115,33 @@ func WithTransportCredentials(creds credentials.TransportCredentials) Option {
 	}
 }
 
-// New returns Resource interface.
+// WithDataDir sets the data directory which stores the snapshot file.
+func WithDataDir(dataDir string) Option {
+	return func(r *resource) {
+		r.dataDir = dataDir
+	}
+}
+
+// WithReplicaStore sets the store of replicas shared by schedulers,
+// the redis store is used by default.
+func WithReplicaStore(store ReplicaStore) Option {
+	return func(r *resource) {
+		r.replicaStore = store
+	}
+}
+
+// WithQuotaStore sets the store of quotas shared by schedulers,
+// the redis store is used by default.
+func WithQuotaStore(store QuotaStore) Option {
+	return func(r *resource) {
+		r.quotaStore = store
+	}
+}
+
+// New returns Resource interface, and restores resource from the snapshot
+// in data directory if snapshot is enabled.
 func New(cfg *config.Config, gc gc.GC, dynconfig config.DynconfigInterface, options ...Option) (Resource, error) {
-	resource := &resource{config: cfg}
+	resource := &resource{config: cfg, done: make(chan struct{})}
 
 	for _, opt := range options {
 		opt(resource)
@@ -120,7 +182,46 @@ func New(cfg *config.Config, gc gc.GC, dynconfig config.DynconfigInterface, opti
 			return nil, err
 		}
 
-		resource.seedPeer = newSeedPeer(client, peerManager, hostManager)
+		resource.seedPeer = newSeedPeer(client, peerManager, hostManager, dialOptions...)
+	}
+
+	// Restore resource from the snapshot.
+	if cfg.Scheduler.Snapshot.Enable {
+		if resource.dataDir == "" {
+			return nil, errors.New("snapshot requires data dir")
+		}
+
+		resource.snapshotPath = filepath.Join(resource.dataDir, SnapshotFileName)
+		if err := resource.restore(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Initialize replicator interface.
+	if cfg.Replication.Enable {
+		if resource.replicaStore == nil {
+			store, err := NewRedisReplicaStore(&cfg.Replication.Redis, cfg.Manager.SchedulerClusterID)
+			if err != nil {
+				return nil, err
+			}
+			resource.replicaStore = store
+		}
+
+		schedulerID := idgen.HostIDV2(cfg.Server.AdvertiseIP.String(), cfg.Server.Host)
+		resource.replicator = newReplicator(schedulerID, &cfg.Replication, resource.replicaStore, hostManager, taskManager, peerManager)
+	}
+
+	// Initialize back-to-source quota interface.
+	if cfg.BackToSourceQuota.Enable {
+		if resource.quotaStore == nil {
+			store, err := NewRedisQuotaStore(&cfg.BackToSourceQuota.Redis, cfg.Manager.SchedulerClusterID)
+			if err != nil {
+				return nil, err
+			}
+			resource.quotaStore = store
+		}
+
+		resource.backToSourceQuota = newBackToSourceQuota(&cfg.BackToSourceQuota, resource.quotaStore, dynconfig)
 	}
 
 	return resource, nil
@@ -146,8 +247,36 @@ func (r *resource) TaskManager() TaskManager {
 	return r.taskManager
 }
 
-// Stop resource serivce.
+// Replicator interface.
+func (r *resource) Replicator() Replicator {
+	return r.replicator
+}
+
+// BackToSourceQuota interface.
+func (r *resource) BackToSourceQuota() BackToSourceQuota {
+	return r.backToSourceQuota
+}
+
+// Stop resource serivce, and writes the last snapshot if snapshot is enabled.
 func (r *resource) Stop() error {
+	// The failure of stopping a component is logged, and the other components are still stopped.
+	if r.config.Scheduler.Snapshot.Enable {
+		close(r.done)
+		if err := r.Snapshot(); err != nil {
+			logger.Errorf("snapshot resource failed: %s", err.Error())
+		}
+	}
+
+	if r.config.Replication.Enable {
+		if err := r.replicator.Stop(); err != nil {
+			logger.Errorf("stop replicator failed: %s", err.Error())
+		}
+	}
+
+	if r.config.BackToSourceQuota.Enable {
+		r.backToSourceQuota.Stop()
+	}
+
 	if r.config.SeedPeer.Enable {
 		return r.seedPeer.Stop()
 	}
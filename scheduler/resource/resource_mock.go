@@ -33,6 +33,20 @@ func (m *MockResource) EXPECT() *MockResourceMockRecorder {
 	return m.recorder
 }
 
+// BackToSourceQuota mocks base method.
+func (m *MockResource) BackToSourceQuota() BackToSourceQuota {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BackToSourceQuota")
+	ret0, _ := ret[0].(BackToSourceQuota)
+	return ret0
+}
+
+// BackToSourceQuota indicates an expected call of BackToSourceQuota.
+func (mr *MockResourceMockRecorder) BackToSourceQuota() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BackToSourceQuota", reflect.TypeOf((*MockResource)(nil).BackToSourceQuota))
+}
+
 // HostManager mocks base method.
 func (m *MockResource) HostManager() HostManager {
 	m.ctrl.T.Helper()
@@ -61,6 +75,20 @@ func (mr *MockResourceMockRecorder) PeerManager() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PeerManager", reflect.TypeOf((*MockResource)(nil).PeerManager))
 }
 
+// Replicator mocks base method.
+func (m *MockResource) Replicator() Replicator {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Replicator")
+	ret0, _ := ret[0].(Replicator)
+	return ret0
+}
+
+// Replicator indicates an expected call of Replicator.
+func (mr *MockResourceMockRecorder) Replicator() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Replicator", reflect.TypeOf((*MockResource)(nil).Replicator))
+}
+
 // SeedPeer mocks base method.
 func (m *MockResource) SeedPeer() SeedPeer {
 	m.ctrl.T.Helper()
@@ -75,6 +103,32 @@ func (mr *MockResourceMockRecorder) SeedPeer() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SeedPeer", reflect.TypeOf((*MockResource)(nil).SeedPeer))
 }
 
+// Serve mocks base method.
+func (m *MockResource) Serve() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Serve")
+}
+
+// Serve indicates an expected call of Serve.
+func (mr *MockResourceMockRecorder) Serve() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Serve", reflect.TypeOf((*MockResource)(nil).Serve))
+}
+
+// Snapshot mocks base method.
+func (m *MockResource) Snapshot() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Snapshot")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Snapshot indicates an expected call of Snapshot.
+func (mr *MockResourceMockRecorder) Snapshot() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*MockResource)(nil).Snapshot))
+}
+
 // Stop mocks base method.
 func (m *MockResource) Stop() error {
 	m.ctrl.T.Helper()
@@ -163,6 +163,32 @@ func TestResource_New(t *testing.T) {
 				assert.NoError(err)
 			},
 		},
+		{
+			name: "new resource failed because of snapshot requires data dir",
+			config: &config.Config{
+				Scheduler: config.SchedulerConfig{
+					GC: config.GCConfig{
+						PeerGCInterval: 100,
+						PeerTTL:        1000,
+						TaskGCInterval: 100,
+						HostGCInterval: 100,
+					},
+					Snapshot: config.SnapshotConfig{
+						Enable: true,
+					},
+				},
+				SeedPeer: config.SeedPeerConfig{
+					Enable: false,
+				},
+			},
+			mock: func(mg *gc.MockGCMockRecorder, md *configmocks.MockDynconfigInterfaceMockRecorder) {
+				mg.Add(gomock.Any()).Return(nil).Times(3)
+			},
+			expect: func(t *testing.T, resource Resource, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "snapshot requires data dir")
+			},
+		},
 	}
 
 	for _, tc := range tests {
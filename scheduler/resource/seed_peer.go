@@ -25,16 +25,21 @@ import (
 	"time"
 
 	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
 
 	cdnsystemv1 "d7y.io/api/pkg/apis/cdnsystem/v1"
 	commonv1 "d7y.io/api/pkg/apis/common/v1"
 	commonv2 "d7y.io/api/pkg/apis/common/v2"
+	dfdaemonv2 "d7y.io/api/pkg/apis/dfdaemon/v2"
 	schedulerv1 "d7y.io/api/pkg/apis/scheduler/v1"
 
+	"d7y.io/dragonfly/v2/pkg/dfnet"
 	"d7y.io/dragonfly/v2/pkg/digest"
 	"d7y.io/dragonfly/v2/pkg/idgen"
 	"d7y.io/dragonfly/v2/pkg/net/http"
+	cdnsystemclient "d7y.io/dragonfly/v2/pkg/rpc/cdnsystem/client"
 	"d7y.io/dragonfly/v2/pkg/rpc/common"
+	dfdaemonclient "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon/client"
 	"d7y.io/dragonfly/v2/pkg/types"
 	"d7y.io/dragonfly/v2/scheduler/metrics"
 )
@@ -42,6 +47,10 @@ import (
 const (
 	// Default value of seed peer failed timeout.
 	SeedPeerFailedTimeout = 30 * time.Minute
+
+	// seedPeerStatInterval is the interval of stating the task during the seed peer downloads it,
+	// so that the downloaded pieces are stored to the seed peer and the task as they arrive.
+	seedPeerStatInterval = 1 * time.Second
 )
 
 // SeedPeer is the interface used for seed peer.
@@ -54,6 +63,11 @@ type SeedPeer interface {
 	// Used only in v1 version of the grpc.
 	TriggerTask(context.Context, *http.Range, *Task) (*Peer, *schedulerv1.PeerResult, error)
 
+	// ObtainSeedsByHost triggers the peer of the host to download task, fn is called
+	// with every piece downloaded by the peer until the task is done.
+	// The host can be seed peer or normal peer, both of them serve the seeder service.
+	ObtainSeedsByHost(context.Context, *Host, *cdnsystemv1.SeedRequest, func(*cdnsystemv1.PieceSeed)) error
+
 	// Client returns grpc client of seed peer.
 	Client() SeedPeerClient
 
@@ -69,27 +83,259 @@ type seedPeer struct {
 	peerManager PeerManager
 	// hostManager is HostManager interface.
 	hostManager HostManager
+	// dialOptions is the grpc dial options of v2 version of the seed peer client.
+	dialOptions []grpc.DialOption
+	// getClientV2 returns v2 version of the seed peer client.
+	getClientV2 func(context.Context, string, ...grpc.DialOption) (dfdaemonclient.V2, error)
+	// getClientByAddr returns the seeder client of the host.
+	getClientByAddr func(context.Context, dfnet.NetAddr, ...grpc.DialOption) (cdnsystemclient.Client, error)
+	// statInterval is the interval of stating the task during the seed peer downloads it.
+	statInterval time.Duration
 }
 
 // New SeedPeer interface.
-func newSeedPeer(client SeedPeerClient, peerManager PeerManager, hostManager HostManager) SeedPeer {
+func newSeedPeer(client SeedPeerClient, peerManager PeerManager, hostManager HostManager, dialOptions ...grpc.DialOption) SeedPeer {
 	return &seedPeer{
-		client:      client,
-		peerManager: peerManager,
-		hostManager: hostManager,
+		client:          client,
+		peerManager:     peerManager,
+		hostManager:     hostManager,
+		dialOptions:     dialOptions,
+		getClientV2:     dfdaemonclient.GetV2,
+		getClientByAddr: cdnsystemclient.GetClientByAddr,
+		statInterval:    seedPeerStatInterval,
 	}
 }
 
-// TODO Implement DownloadTask
 // DownloadTask downloads task back-to-source.
 // Used only in v2 version of the grpc.
 func (s *seedPeer) DownloadTask(ctx context.Context, task *Task, hostType types.HostType) error {
-	// ctx, cancel := context.WithCancel(trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx)))
-	// defer cancel()
+	ctx, cancel := context.WithCancel(trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx)))
+	defer cancel()
+
+	// The succeeded task is not switched to running, because the seed peer
+	// downloads the task again only if the task is not finished.
+	if !task.FSM.Is(TaskStateSucceeded) && task.FSM.Can(TaskEventDownload) {
+		if err := task.FSM.Event(ctx, TaskEventDownload); err != nil {
+			task.Log.Errorf("task fsm event failed: %s", err.Error())
+		}
+	}
+
+	peer, err := s.downloadTask(ctx, task, hostType)
+	if err != nil {
+		// If the seed peer downloads failed, set peer status is PeerStateFailed,
+		// then task will not trigger the seed peer until SeedPeerFailedTimeout.
+		if peer != nil && peer.FSM.Can(PeerEventDownloadFailed) {
+			if err := peer.FSM.Event(ctx, PeerEventDownloadFailed); err != nil {
+				peer.Log.Errorf("peer fsm event failed: %s", err.Error())
+			}
+		}
+
+		s.handleTaskFailure(ctx, task)
+		return err
+	}
 
+	s.handleTaskSuccess(ctx, task)
 	return nil
 }
 
+// handleTaskSuccess handles the task downloaded by seed peer successfully.
+func (s *seedPeer) handleTaskSuccess(ctx context.Context, task *Task) {
+	if task.FSM.Is(TaskStateSucceeded) {
+		return
+	}
+
+	if err := task.FSM.Event(ctx, TaskEventDownloadSucceeded); err != nil {
+		task.Log.Errorf("task fsm event failed: %s", err.Error())
+	}
+}
+
+// handleTaskFailure handles the task downloaded by seed peer failed.
+func (s *seedPeer) handleTaskFailure(ctx context.Context, task *Task) {
+	if !task.FSM.Can(TaskEventDownloadFailed) {
+		return
+	}
+
+	if err := task.FSM.Event(ctx, TaskEventDownloadFailed); err != nil {
+		task.Log.Errorf("task fsm event failed: %s", err.Error())
+	}
+}
+
+// downloadTask triggers the seed peer of the host type to download task, and returns
+// the seed peer, the peer is nil if the seed peer is not initialized.
+func (s *seedPeer) downloadTask(ctx context.Context, task *Task, hostType types.HostType) (*Peer, error) {
+	// Select the seed peer which has the most free upload count.
+	host, loaded := s.loadSeedHost(hostType)
+	if !loaded {
+		task.Log.Errorf("can not find %s seed host", hostType.Name())
+		return nil, fmt.Errorf("can not find %s seed host", hostType.Name())
+	}
+	host.UpdatedAt.Store(time.Now())
+
+	// Initialize seed peer before dialing, so that the failure of
+	// dialing is recorded by the failed seed peer.
+	peer, err := s.initSeedPeerV2(ctx, task, host)
+	if err != nil {
+		return peer, err
+	}
+
+	client, err := s.getClientV2(ctx, fmt.Sprintf("%s:%d", host.IP, host.Port), s.dialOptions...)
+	if err != nil {
+		return peer, err
+	}
+	defer client.Close()
+
+	download := &commonv2.Download{
+		Url:              task.URL,
+		Type:             task.Type,
+		Tag:              task.Tag,
+		Application:      task.Application,
+		Priority:         commonv2.Priority_LEVEL0,
+		Filters:          task.Filters,
+		Header:           task.Header,
+		PieceLength:      task.PieceLength,
+		NeedBackToSource: true,
+	}
+
+	if task.Digest != nil {
+		download.Digest = task.Digest.String()
+	}
+
+	// DownloadTask returns when the task is downloaded, so the task is stated periodically
+	// during the downloading, and children can download the pieces from the seed peer
+	// before the task is finished. The failure of downloading returns immediately.
+	downloadErr := make(chan error, 1)
+	go func() {
+		downloadErr <- client.DownloadTask(ctx, &dfdaemonv2.DownloadTaskRequest{Download: download})
+	}()
+
+	tick := time.NewTicker(s.statInterval)
+	defer tick.Stop()
+
+	for downloading := true; downloading; {
+		select {
+		case err := <-downloadErr:
+			if err != nil {
+				return peer, err
+			}
+
+			downloading = false
+		case <-tick.C:
+			resp, err := client.StatTask(ctx, &dfdaemonv2.StatTaskRequest{TaskId: task.ID})
+			if err != nil {
+				peer.Log.Warnf("stat task failed: %s", err.Error())
+				continue
+			}
+
+			s.storePieces(peer, task, host, resp.Pieces)
+		}
+	}
+
+	// Stat the task downloaded by seed peer, and update
+	// the pieces of task and seed peer.
+	resp, err := client.StatTask(ctx, &dfdaemonv2.StatTaskRequest{TaskId: task.ID})
+	if err != nil {
+		return peer, err
+	}
+	s.storePieces(peer, task, host, resp.Pieces)
+
+	peer.UpdatedAt.Store(time.Now())
+	peer.PieceUpdatedAt.Store(time.Now())
+	task.ContentLength.Store(resp.ContentLength)
+	task.TotalPieceCount.Store(resp.PieceCount)
+	if err := peer.FSM.Event(ctx, PeerEventDownloadSucceeded); err != nil {
+		return peer, err
+	}
+
+	peer.Log.Infof("seed peer downloads task successfully, content length is %d and piece count is %d",
+		resp.ContentLength, resp.PieceCount)
+	return peer, nil
+}
+
+// storePieces stores the pieces downloaded by the seed peer to the seed peer and the task,
+// and the pieces stored before are skipped.
+func (s *seedPeer) storePieces(peer *Peer, task *Task, host *Host, respPieces []*commonv2.Piece) {
+	var stored bool
+	for _, respPiece := range respPieces {
+		if peer.FinishedPieces.Test(uint(respPiece.Number)) {
+			continue
+		}
+
+		piece := &Piece{
+			Number:      respPiece.Number,
+			ParentID:    respPiece.ParentId,
+			Offset:      respPiece.Offset,
+			Length:      respPiece.Length,
+			TrafficType: commonv2.TrafficType_BACK_TO_SOURCE,
+			Cost:        respPiece.Cost.AsDuration(),
+			CreatedAt:   respPiece.CreatedAt.AsTime(),
+		}
+
+		if respPiece.Digest != "" {
+			d, err := digest.Parse(respPiece.Digest)
+			if err != nil {
+				peer.Log.Errorf("invalid piece digest %s: %s", respPiece.Digest, err.Error())
+			} else {
+				piece.Digest = d
+			}
+		}
+
+		peer.StorePiece(piece)
+		peer.FinishedPieces.Set(uint(piece.Number))
+		peer.AppendPieceCost(piece.Cost)
+		task.StorePiece(piece)
+		stored = true
+
+		// Collect Traffic metrics.
+		metrics.Traffic.WithLabelValues(piece.TrafficType.String(), task.Type.String(),
+			task.Tag, task.Application, host.Type.Name()).Add(float64(piece.Length))
+	}
+
+	// When the pieces are downloaded successfully, peer.UpdatedAt needs to be
+	// updated to prevent the peer from being GC during the download process.
+	if stored {
+		peer.UpdatedAt.Store(time.Now())
+		peer.PieceUpdatedAt.Store(time.Now())
+	}
+}
+
+// loadSeedHost returns the seed host of the host type,
+// which has the most free upload count.
+func (s *seedPeer) loadSeedHost(hostType types.HostType) (*Host, bool) {
+	var seedHost *Host
+	s.hostManager.Range(func(_, value any) bool {
+		host, ok := value.(*Host)
+		if !ok || host.Type != hostType {
+			return true
+		}
+
+		if seedHost == nil || host.FreeUploadCount() > seedHost.FreeUploadCount() {
+			seedHost = host
+		}
+
+		return true
+	})
+
+	return seedHost, seedHost != nil
+}
+
+// Initialize seed peer for v2 version of the grpc.
+func (s *seedPeer) initSeedPeerV2(ctx context.Context, task *Task, host *Host) (*Peer, error) {
+	// New and store seed peer without range.
+	peer := NewPeer(idgen.PeerIDV2(), task, host, WithPriority(commonv2.Priority_LEVEL0))
+	s.peerManager.Store(peer)
+	peer.Log.Info("seed peer has been stored")
+
+	if err := peer.FSM.Event(ctx, PeerEventRegisterNormal); err != nil {
+		return peer, err
+	}
+
+	if err := peer.FSM.Event(ctx, PeerEventDownload); err != nil {
+		return peer, err
+	}
+
+	return peer, nil
+}
+
 // TriggerTask triggers the seed peer to download task.
 // Used only in v1 version of the grpc.
 func (s *seedPeer) TriggerTask(ctx context.Context, rg *http.Range, task *Task) (*Peer, *schedulerv1.PeerResult, error) {
@@ -242,6 +488,37 @@ func (s *seedPeer) initSeedPeer(ctx context.Context, rg *http.Range, task *Task,
 	return peer, nil
 }
 
+// ObtainSeedsByHost triggers the peer of the host to download task, fn is called
+// with every piece downloaded by the peer until the task is done.
+// The host can be seed peer or normal peer, both of them serve the seeder service.
+func (s *seedPeer) ObtainSeedsByHost(ctx context.Context, host *Host, req *cdnsystemv1.SeedRequest, fn func(*cdnsystemv1.PieceSeed)) error {
+	client, err := s.getClientByAddr(ctx, dfnet.NetAddr{
+		Type: dfnet.TCP,
+		Addr: fmt.Sprintf("%s:%d", host.IP, host.Port),
+	}, s.dialOptions...)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	stream, err := client.ObtainSeeds(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	for {
+		piece, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		fn(piece)
+		if piece.Done {
+			return nil
+		}
+	}
+}
+
 // Client is seed peer grpc client.
 func (s *seedPeer) Client() SeedPeerClient {
 	return s.client
@@ -8,7 +8,8 @@ import (
 	context "context"
 	reflect "reflect"
 
-	v1 "d7y.io/api/pkg/apis/scheduler/v1"
+	v1 "d7y.io/api/pkg/apis/cdnsystem/v1"
+	v10 "d7y.io/api/pkg/apis/scheduler/v1"
 	http "d7y.io/dragonfly/v2/pkg/net/http"
 	types "d7y.io/dragonfly/v2/pkg/types"
 	gomock "github.com/golang/mock/gomock"
@@ -65,6 +66,20 @@ func (mr *MockSeedPeerMockRecorder) DownloadTask(arg0, arg1, arg2 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadTask", reflect.TypeOf((*MockSeedPeer)(nil).DownloadTask), arg0, arg1, arg2)
 }
 
+// ObtainSeedsByHost mocks base method.
+func (m *MockSeedPeer) ObtainSeedsByHost(arg0 context.Context, arg1 *Host, arg2 *v1.SeedRequest, arg3 func(*v1.PieceSeed)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ObtainSeedsByHost", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ObtainSeedsByHost indicates an expected call of ObtainSeedsByHost.
+func (mr *MockSeedPeerMockRecorder) ObtainSeedsByHost(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ObtainSeedsByHost", reflect.TypeOf((*MockSeedPeer)(nil).ObtainSeedsByHost), arg0, arg1, arg2, arg3)
+}
+
 // Stop mocks base method.
 func (m *MockSeedPeer) Stop() error {
 	m.ctrl.T.Helper()
@@ -80,11 +95,11 @@ func (mr *MockSeedPeerMockRecorder) Stop() *gomock.Call {
 }
 
 // TriggerTask mocks base method.
-func (m *MockSeedPeer) TriggerTask(arg0 context.Context, arg1 *http.Range, arg2 *Task) (*Peer, *v1.PeerResult, error) {
+func (m *MockSeedPeer) TriggerTask(arg0 context.Context, arg1 *http.Range, arg2 *Task) (*Peer, *v10.PeerResult, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "TriggerTask", arg0, arg1, arg2)
 	ret0, _ := ret[0].(*Peer)
-	ret1, _ := ret[1].(*v1.PeerResult)
+	ret1, _ := ret[1].(*v10.PeerResult)
 	ret2, _ := ret[2].(error)
 	return ret0, ret1, ret2
 }
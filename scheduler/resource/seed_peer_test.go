@@ -21,12 +21,26 @@ import (
 	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	gomock "github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
+	cdnsystemv1 "d7y.io/api/pkg/apis/cdnsystem/v1"
+	cdnsystemv1mocks "d7y.io/api/pkg/apis/cdnsystem/v1/mocks"
 	commonv2 "d7y.io/api/pkg/apis/common/v2"
+	dfdaemonv2 "d7y.io/api/pkg/apis/dfdaemon/v2"
 	schedulerv1 "d7y.io/api/pkg/apis/scheduler/v1"
+
+	"d7y.io/dragonfly/v2/pkg/dfnet"
+	cdnsystemclient "d7y.io/dragonfly/v2/pkg/rpc/cdnsystem/client"
+	cdnsystemclientmocks "d7y.io/dragonfly/v2/pkg/rpc/cdnsystem/client/mocks"
+	dfdaemonclient "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon/client"
+	dfdaemonclientmocks "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon/client/mocks"
+	"d7y.io/dragonfly/v2/pkg/types"
 )
 
 func TestSeedPeer_newSeedPeer(t *testing.T) {
@@ -90,3 +104,326 @@ func TestSeedPeer_TriggerTask(t *testing.T) {
 		})
 	}
 }
+
+func TestSeedPeer_DownloadTask(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialErr error
+		mock    func(seedHost *Host, mh *MockHostManagerMockRecorder, mp *MockPeerManagerMockRecorder, mc *dfdaemonclientmocks.MockV2MockRecorder)
+		expect  func(t *testing.T, task *Task, err error)
+	}{
+		{
+			name: "seed host not found",
+			mock: func(seedHost *Host, mh *MockHostManagerMockRecorder, mp *MockPeerManagerMockRecorder, mc *dfdaemonclientmocks.MockV2MockRecorder) {
+				mh.Range(gomock.Any()).Times(1)
+			},
+			expect: func(t *testing.T, task *Task, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "can not find super seed host")
+				assert.True(task.FSM.Is(TaskStateFailed))
+			},
+		},
+		{
+			name:    "dial seed peer failed",
+			dialErr: errors.New("foo"),
+			mock: func(seedHost *Host, mh *MockHostManagerMockRecorder, mp *MockPeerManagerMockRecorder, mc *dfdaemonclientmocks.MockV2MockRecorder) {
+				gomock.InOrder(
+					mh.Range(gomock.Any()).Do(func(f func(any, any) bool) {
+						f(seedHost.ID, seedHost)
+					}).Times(1),
+					mp.Store(gomock.Any()).Do(func(peer *Peer) {
+						peer.Task.StorePeer(peer)
+					}).Times(1),
+				)
+			},
+			expect: func(t *testing.T, task *Task, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "foo")
+				assert.True(task.IsSeedPeerFailed())
+				assert.True(task.FSM.Is(TaskStateFailed))
+			},
+		},
+		{
+			name: "download task failed",
+			mock: func(seedHost *Host, mh *MockHostManagerMockRecorder, mp *MockPeerManagerMockRecorder, mc *dfdaemonclientmocks.MockV2MockRecorder) {
+				gomock.InOrder(
+					mh.Range(gomock.Any()).Do(func(f func(any, any) bool) {
+						f(seedHost.ID, seedHost)
+					}).Times(1),
+					mp.Store(gomock.Any()).Do(func(peer *Peer) {
+						peer.Task.StorePeer(peer)
+					}).Times(1),
+					mc.DownloadTask(gomock.Any(), gomock.Any()).Return(errors.New("foo")).Times(1),
+					mc.Close().Return(nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, task *Task, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "foo")
+				assert.True(task.IsSeedPeerFailed())
+				assert.True(task.FSM.Is(TaskStateFailed))
+			},
+		},
+		{
+			name: "stat task failed",
+			mock: func(seedHost *Host, mh *MockHostManagerMockRecorder, mp *MockPeerManagerMockRecorder, mc *dfdaemonclientmocks.MockV2MockRecorder) {
+				gomock.InOrder(
+					mh.Range(gomock.Any()).Do(func(f func(any, any) bool) {
+						f(seedHost.ID, seedHost)
+					}).Times(1),
+					mp.Store(gomock.Any()).Do(func(peer *Peer) {
+						peer.Task.StorePeer(peer)
+					}).Times(1),
+					mc.DownloadTask(gomock.Any(), gomock.Any()).Return(nil).Times(1),
+					mc.StatTask(gomock.Any(), gomock.Any()).Return(nil, errors.New("foo")).Times(1),
+					mc.Close().Return(nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, task *Task, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "foo")
+				assert.True(task.IsSeedPeerFailed())
+				assert.True(task.FSM.Is(TaskStateFailed))
+			},
+		},
+		{
+			name: "download task succeeded",
+			mock: func(seedHost *Host, mh *MockHostManagerMockRecorder, mp *MockPeerManagerMockRecorder, mc *dfdaemonclientmocks.MockV2MockRecorder) {
+				gomock.InOrder(
+					mh.Range(gomock.Any()).Do(func(f func(any, any) bool) {
+						f(seedHost.ID, seedHost)
+					}).Times(1),
+					mp.Store(gomock.Any()).Do(func(peer *Peer) {
+						peer.Task.StorePeer(peer)
+					}).Times(1),
+					mc.DownloadTask(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, req *dfdaemonv2.DownloadTaskRequest, opts ...any) {
+						assert := assert.New(t)
+						assert.Equal(req.Download.Url, mockTaskURL)
+						assert.Equal(req.Download.Digest, mockTaskDigest.String())
+						assert.True(req.Download.NeedBackToSource)
+					}).Return(nil).Times(1),
+					mc.StatTask(gomock.Any(), gomock.Any()).Return(&commonv2.Task{
+						Id:            mockTaskID,
+						ContentLength: 1024,
+						PieceCount:    1,
+						Pieces: []*commonv2.Piece{
+							{
+								Number:    0,
+								Offset:    0,
+								Length:    1024,
+								Digest:    mockPieceDigest.String(),
+								Cost:      durationpb.New(time.Second),
+								CreatedAt: timestamppb.Now(),
+							},
+						},
+					}, nil).Times(1),
+					mc.Close().Return(nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, task *Task, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.False(task.IsSeedPeerFailed())
+				assert.True(task.FSM.Is(TaskStateSucceeded))
+				assert.Equal(task.ContentLength.Load(), int64(1024))
+				assert.Equal(task.TotalPieceCount.Load(), int32(1))
+
+				piece, loaded := task.LoadPiece(0)
+				assert.True(loaded)
+				assert.Equal(piece.Length, uint64(1024))
+				assert.Equal(piece.Digest.String(), mockPieceDigest.String())
+
+				peer, loaded := task.LoadSeedPeer()
+				assert.True(loaded)
+				assert.True(peer.FSM.Is(PeerStateSucceeded))
+				assert.True(peer.FinishedPieces.Test(0))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			hostManager := NewMockHostManager(ctl)
+			peerManager := NewMockPeerManager(ctl)
+			client := NewMockSeedPeerClient(ctl)
+			clientV2 := dfdaemonclientmocks.NewMockV2(ctl)
+			mockSeedHost := NewHost(
+				mockRawSeedHost.ID, mockRawSeedHost.IP, mockRawSeedHost.Hostname,
+				mockRawSeedHost.Port, mockRawSeedHost.DownloadPort, mockRawSeedHost.Type)
+			tc.mock(mockSeedHost, hostManager.EXPECT(), peerManager.EXPECT(), clientV2.EXPECT())
+
+			sp := newSeedPeer(client, peerManager, hostManager)
+			sp.(*seedPeer).getClientV2 = func(context.Context, string, ...grpc.DialOption) (dfdaemonclient.V2, error) {
+				if tc.dialErr != nil {
+					return nil, tc.dialErr
+				}
+
+				return clientV2, nil
+			}
+
+			mockTask := NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, WithDigest(mockTaskDigest))
+			tc.expect(t, mockTask, sp.DownloadTask(context.Background(), mockTask, types.HostTypeSuperSeed))
+		})
+	}
+}
+
+func TestSeedPeer_DownloadTaskWithPieces(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	hostManager := NewMockHostManager(ctl)
+	peerManager := NewMockPeerManager(ctl)
+	client := NewMockSeedPeerClient(ctl)
+	clientV2 := dfdaemonclientmocks.NewMockV2(ctl)
+	mockSeedHost := NewHost(
+		mockRawSeedHost.ID, mockRawSeedHost.IP, mockRawSeedHost.Hostname,
+		mockRawSeedHost.Port, mockRawSeedHost.DownloadPort, mockRawSeedHost.Type)
+	mockTask := NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, WithDigest(mockTaskDigest))
+
+	newPiece := func(number int32) *commonv2.Piece {
+		return &commonv2.Piece{
+			Number:    number,
+			Offset:    uint64(number) * 1024,
+			Length:    1024,
+			Digest:    mockPieceDigest.String(),
+			Cost:      durationpb.New(time.Second),
+			CreatedAt: timestamppb.Now(),
+		}
+	}
+
+	// The pieces downloaded are stored before the download is finished.
+	piecesStored := make(chan struct{})
+	hostManager.EXPECT().Range(gomock.Any()).Do(func(f func(any, any) bool) {
+		f(mockSeedHost.ID, mockSeedHost)
+	}).Times(1)
+	peerManager.EXPECT().Store(gomock.Any()).Do(func(peer *Peer) {
+		peer.Task.StorePeer(peer)
+	}).Times(1)
+	clientV2.EXPECT().DownloadTask(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, req *dfdaemonv2.DownloadTaskRequest, opts ...any) error {
+		// The download is not limited by the timeout of grpc invoke.
+		_, ok := ctx.Deadline()
+		assert.False(t, ok)
+
+		select {
+		case <-piecesStored:
+			return nil
+		case <-time.After(5 * time.Second):
+			return errors.New("pieces are not stored during downloading")
+		}
+	}).Times(1)
+	clientV2.EXPECT().StatTask(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, *dfdaemonv2.StatTaskRequest, ...any) (*commonv2.Task, error) {
+		if _, loaded := mockTask.LoadPiece(0); loaded {
+			select {
+			case <-piecesStored:
+			default:
+				close(piecesStored)
+			}
+
+			return &commonv2.Task{Id: mockTaskID, ContentLength: 2048, PieceCount: 2, Pieces: []*commonv2.Piece{newPiece(0), newPiece(1)}}, nil
+		}
+
+		return &commonv2.Task{Id: mockTaskID, Pieces: []*commonv2.Piece{newPiece(0)}}, nil
+	}).MinTimes(2)
+	clientV2.EXPECT().Close().Return(nil).Times(1)
+
+	sp := newSeedPeer(client, peerManager, hostManager)
+	sp.(*seedPeer).statInterval = 10 * time.Millisecond
+	sp.(*seedPeer).getClientV2 = func(context.Context, string, ...grpc.DialOption) (dfdaemonclient.V2, error) {
+		return clientV2, nil
+	}
+
+	assert := assert.New(t)
+	assert.NoError(sp.DownloadTask(context.Background(), mockTask, types.HostTypeSuperSeed))
+	assert.True(mockTask.FSM.Is(TaskStateSucceeded))
+	assert.Equal(mockTask.ContentLength.Load(), int64(2048))
+	assert.Equal(mockTask.TotalPieceCount.Load(), int32(2))
+
+	peer, loaded := mockTask.LoadSeedPeer()
+	assert.True(loaded)
+	assert.True(peer.FSM.Is(PeerStateSucceeded))
+	assert.Equal(peer.FinishedPieces.Count(), uint(2))
+	assert.Equal(len(peer.PieceCosts()), 2)
+}
+
+func TestSeedPeer_ObtainSeedsByHost(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(mc *cdnsystemclientmocks.MockClientMockRecorder, ms *cdnsystemv1mocks.MockSeeder_ObtainSeedsClientMockRecorder, stream cdnsystemv1.Seeder_ObtainSeedsClient)
+		expect func(t *testing.T, pieces []*cdnsystemv1.PieceSeed, err error)
+	}{
+		{
+			name: "obtain seeds failed",
+			mock: func(mc *cdnsystemclientmocks.MockClientMockRecorder, ms *cdnsystemv1mocks.MockSeeder_ObtainSeedsClientMockRecorder, stream cdnsystemv1.Seeder_ObtainSeedsClient) {
+				gomock.InOrder(
+					mc.ObtainSeeds(gomock.Any(), gomock.Any()).Return(nil, errors.New("foo")).Times(1),
+					mc.Close().Return(nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, pieces []*cdnsystemv1.PieceSeed, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "foo")
+				assert.Equal(len(pieces), 0)
+			},
+		},
+		{
+			name: "receive piece failed",
+			mock: func(mc *cdnsystemclientmocks.MockClientMockRecorder, ms *cdnsystemv1mocks.MockSeeder_ObtainSeedsClientMockRecorder, stream cdnsystemv1.Seeder_ObtainSeedsClient) {
+				gomock.InOrder(
+					mc.ObtainSeeds(gomock.Any(), gomock.Any()).Return(stream, nil).Times(1),
+					ms.Recv().Return(&cdnsystemv1.PieceSeed{PeerId: "foo"}, nil).Times(1),
+					ms.Recv().Return(nil, errors.New("bar")).Times(1),
+					mc.Close().Return(nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, pieces []*cdnsystemv1.PieceSeed, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "bar")
+				assert.Equal(len(pieces), 1)
+			},
+		},
+		{
+			name: "obtain seeds succeeded",
+			mock: func(mc *cdnsystemclientmocks.MockClientMockRecorder, ms *cdnsystemv1mocks.MockSeeder_ObtainSeedsClientMockRecorder, stream cdnsystemv1.Seeder_ObtainSeedsClient) {
+				gomock.InOrder(
+					mc.ObtainSeeds(gomock.Any(), gomock.Any()).Return(stream, nil).Times(1),
+					ms.Recv().Return(&cdnsystemv1.PieceSeed{PeerId: "foo"}, nil).Times(1),
+					ms.Recv().Return(&cdnsystemv1.PieceSeed{PeerId: "foo", Done: true}, nil).Times(1),
+					mc.Close().Return(nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, pieces []*cdnsystemv1.PieceSeed, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(len(pieces), 2)
+				assert.True(pieces[1].Done)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			hostManager := NewMockHostManager(ctl)
+			peerManager := NewMockPeerManager(ctl)
+			client := NewMockSeedPeerClient(ctl)
+			clientByAddr := cdnsystemclientmocks.NewMockClient(ctl)
+			stream := cdnsystemv1mocks.NewMockSeeder_ObtainSeedsClient(ctl)
+			tc.mock(clientByAddr.EXPECT(), stream.EXPECT(), stream)
+
+			sp := newSeedPeer(client, peerManager, hostManager)
+			sp.(*seedPeer).getClientByAddr = func(_ context.Context, netAddr dfnet.NetAddr, _ ...grpc.DialOption) (cdnsystemclient.Client, error) {
+				assert.Equal(t, netAddr.Addr, "127.0.0.1:8080")
+				return clientByAddr, nil
+			}
+
+			host := NewHost(mockRawHost.ID, "127.0.0.1", mockRawHost.Hostname, 8080, mockRawHost.DownloadPort, mockRawHost.Type)
+			var pieces []*cdnsystemv1.PieceSeed
+			err := sp.ObtainSeedsByHost(context.Background(), host, &cdnsystemv1.SeedRequest{TaskId: mockTaskID, Url: mockTaskURL}, func(piece *cdnsystemv1.PieceSeed) {
+				pieces = append(pieces, piece)
+			})
+			tc.expect(t, pieces, err)
+		})
+	}
+}
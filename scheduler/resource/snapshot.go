@@ -0,0 +1,573 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resource
+
+import (
+	"encoding/json"
+	"errors"
+	"net/textproto"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bits-and-blooms/bitset"
+	"github.com/go-http-utils/headers"
+
+	commonv2 "d7y.io/api/pkg/apis/common/v2"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/digest"
+	nethttp "d7y.io/dragonfly/v2/pkg/net/http"
+	"d7y.io/dragonfly/v2/pkg/types"
+)
+
+const (
+	// SnapshotFileName is the file name of resource snapshot in the data directory.
+	SnapshotFileName = "resource.json"
+)
+
+var (
+	// sensitiveHeaders are the headers carrying credentials, which are not persisted.
+	sensitiveHeaders = map[string]struct{}{
+		headers.Authorization:      {},
+		headers.ProxyAuthorization: {},
+		headers.Cookie:             {},
+		headers.SetCookie:          {},
+	}
+
+	// sensitiveHeaderKeywords are the keywords of the custom headers carrying
+	// credentials, such as X-Dragonfly-Oras-Authorization and X-Amz-Security-Token.
+	sensitiveHeaderKeywords = []string{"auth", "token", "cookie", "secret", "signature", "credential", "password"}
+)
+
+// snapshot is the persistent form of hosts, tasks and peers.
+type snapshot struct {
+	// Hosts are the hosts in host manager.
+	Hosts []*snapshotHost `json:"hosts"`
+
+	// Tasks are the tasks in task manager.
+	Tasks []*snapshotTask `json:"tasks"`
+
+	// Peers are the peers in peer manager, including the parents in the DAG of task.
+	Peers []*snapshotPeer `json:"peers"`
+
+	// CreatedAt is the creation time of snapshot.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// snapshotHost is the persistent form of host.
+type snapshotHost struct {
+	ID                    string         `json:"id"`
+	Type                  types.HostType `json:"type"`
+	Hostname              string         `json:"hostname"`
+	IP                    string         `json:"ip"`
+	Port                  int32          `json:"port"`
+	DownloadPort          int32          `json:"download_port"`
+	OS                    string         `json:"os"`
+	Platform              string         `json:"platform"`
+	PlatformFamily        string         `json:"platform_family"`
+	PlatformVersion       string         `json:"platform_version"`
+	KernelVersion         string         `json:"kernel_version"`
+	CPU                   CPU            `json:"cpu"`
+	Memory                Memory         `json:"memory"`
+	Network               Network        `json:"network"`
+	Disk                  Disk           `json:"disk"`
+	Build                 Build          `json:"build"`
+	ConcurrentUploadLimit int32          `json:"concurrent_upload_limit"`
+	UploadCount           int64          `json:"upload_count"`
+	UploadFailedCount     int64          `json:"upload_failed_count"`
+	Draining              bool           `json:"draining"`
+	CreatedAt             time.Time      `json:"created_at"`
+	UpdatedAt             time.Time      `json:"updated_at"`
+}
+
+// snapshotTask is the persistent form of task.
+type snapshotTask struct {
+	ID                string            `json:"id"`
+	Type              commonv2.TaskType `json:"type"`
+	URL               string            `json:"url"`
+	Digest            string            `json:"digest"`
+	Tag               string            `json:"tag"`
+	Application       string            `json:"application"`
+	Filters           []string          `json:"filters"`
+	Header            map[string]string `json:"header"`
+	PieceLength       int32             `json:"piece_length"`
+	DirectPiece       []byte            `json:"direct_piece"`
+	ContentLength     int64             `json:"content_length"`
+	TotalPieceCount   int32             `json:"total_piece_count"`
+	BackToSourceLimit int32             `json:"back_to_source_limit"`
+	BackToSourcePeers []string          `json:"back_to_source_peers"`
+	State             string            `json:"state"`
+	Pieces            []*snapshotPiece  `json:"pieces"`
+	PeerFailedCount   int32             `json:"peer_failed_count"`
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+}
+
+// snapshotPeer is the persistent form of peer.
+type snapshotPeer struct {
+	ID               string            `json:"id"`
+	TaskID           string            `json:"task_id"`
+	HostID           string            `json:"host_id"`
+	Range            *nethttp.Range    `json:"range"`
+	Priority         commonv2.Priority `json:"priority"`
+	Pieces           []*snapshotPiece  `json:"pieces"`
+	FinishedPieces   *bitset.BitSet    `json:"finished_pieces"`
+	PieceCosts       []time.Duration   `json:"piece_costs"`
+	Cost             time.Duration     `json:"cost"`
+	State            string            `json:"state"`
+	ParentIDs        []string          `json:"parent_ids"`
+	BlockParents     []string          `json:"block_parents"`
+	NeedBackToSource bool              `json:"need_back_to_source"`
+	PieceUpdatedAt   time.Time         `json:"piece_updated_at"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+}
+
+// snapshotPiece is the persistent form of piece.
+type snapshotPiece struct {
+	Number      int32                `json:"number"`
+	ParentID    string               `json:"parent_id"`
+	Offset      uint64               `json:"offset"`
+	Length      uint64               `json:"length"`
+	Digest      string               `json:"digest"`
+	TrafficType commonv2.TrafficType `json:"traffic_type"`
+	Cost        time.Duration        `json:"cost"`
+	CreatedAt   time.Time            `json:"created_at"`
+}
+
+// Serve starts to snapshot resource periodically if snapshot is enabled.
+func (r *resource) Serve() {
+	if !r.config.Scheduler.Snapshot.Enable {
+		return
+	}
+
+	tick := time.NewTicker(r.config.Scheduler.Snapshot.Interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-tick.C:
+			if err := r.Snapshot(); err != nil {
+				logger.Errorf("snapshot resource failed: %s", err.Error())
+			}
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// Snapshot writes hosts, tasks and peers to the snapshot file.
+func (r *resource) Snapshot() error {
+	if !r.config.Scheduler.Snapshot.Enable {
+		return errors.New("snapshot is disabled")
+	}
+
+	s := &snapshot{CreatedAt: time.Now()}
+
+	r.hostManager.Range(func(_, value any) bool {
+		host, ok := value.(*Host)
+		if !ok {
+			return true
+		}
+
+		s.Hosts = append(s.Hosts, newSnapshotHost(host))
+		return true
+	})
+
+	r.taskManager.Range(func(_, value any) bool {
+		task, ok := value.(*Task)
+		if !ok {
+			return true
+		}
+
+		s.Tasks = append(s.Tasks, newSnapshotTask(task))
+		return true
+	})
+
+	r.peerManager.Range(func(_, value any) bool {
+		peer, ok := value.(*Peer)
+		if !ok {
+			return true
+		}
+
+		s.Peers = append(s.Peers, newSnapshotPeer(peer))
+		return true
+	})
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	// Write to temporary file and rename it to make the snapshot atomic.
+	tmpPath := r.snapshotPath + ".tmp"
+	if err := os.WriteFile(tmpPath, b, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, r.snapshotPath)
+}
+
+// restore restores hosts, tasks and peers from the snapshot file,
+// the snapshot is discarded if it is broken or older than TTL.
+func (r *resource) restore() error {
+	b, err := os.ReadFile(r.snapshotPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	s := &snapshot{}
+	if err := json.Unmarshal(b, s); err != nil {
+		logger.Warnf("unmarshal resource snapshot failed: %s", err.Error())
+		return nil
+	}
+
+	if time.Since(s.CreatedAt) > r.config.Scheduler.Snapshot.TTL {
+		logger.Warnf("resource snapshot created at %s is stale, discard it", s.CreatedAt.String())
+		return nil
+	}
+
+	hosts := make(map[string]*Host, len(s.Hosts))
+	for _, snapshotHost := range s.Hosts {
+		host := snapshotHost.host()
+		r.hostManager.Store(host)
+		hosts[host.ID] = host
+	}
+
+	tasks := make(map[string]*Task, len(s.Tasks))
+	for _, snapshotTask := range s.Tasks {
+		task := snapshotTask.task()
+		r.taskManager.Store(task)
+		tasks[task.ID] = task
+	}
+
+	peers := restorePeers(r.peerManager, s.Peers, tasks, hosts)
+
+	// Restore the upload count of hosts after the edges are added,
+	// because adding edges increases the upload count.
+	for _, snapshotHost := range s.Hosts {
+		hosts[snapshotHost.ID].UploadCount.Store(snapshotHost.UploadCount)
+	}
+
+	logger.Infof("restore %d hosts, %d tasks and %d peers from %s", len(hosts), len(tasks), len(peers), r.snapshotPath)
+	return nil
+}
+
+// restorePeers stores the peers whose task and host are restored, and then
+// restores the DAG of tasks after all peers are stored.
+func restorePeers(peerManager PeerManager, snapshotPeers []*snapshotPeer, tasks map[string]*Task, hosts map[string]*Host) map[string]*Peer {
+	peers := make(map[string]*Peer, len(snapshotPeers))
+	for _, snapshotPeer := range snapshotPeers {
+		task, ok := tasks[snapshotPeer.TaskID]
+		if !ok {
+			continue
+		}
+
+		host, ok := hosts[snapshotPeer.HostID]
+		if !ok {
+			continue
+		}
+
+		peer := snapshotPeer.peer(task, host)
+		peerManager.Store(peer)
+		peers[peer.ID] = peer
+	}
+
+	for _, snapshotPeer := range snapshotPeers {
+		peer, ok := peers[snapshotPeer.ID]
+		if !ok {
+			continue
+		}
+
+		for _, parentID := range snapshotPeer.ParentIDs {
+			parent, ok := peers[parentID]
+			if !ok {
+				continue
+			}
+
+			if err := peer.Task.AddPeerEdge(parent, peer); err != nil {
+				peer.Log.Warnf("restore edge with parent %s failed: %s", parentID, err.Error())
+			}
+		}
+	}
+
+	return peers
+}
+
+// newSnapshotHost returns the persistent form of host.
+func newSnapshotHost(host *Host) *snapshotHost {
+	return &snapshotHost{
+		ID:                    host.ID,
+		Type:                  host.Type,
+		Hostname:              host.Hostname,
+		IP:                    host.IP,
+		Port:                  host.Port,
+		DownloadPort:          host.DownloadPort,
+		OS:                    host.OS,
+		Platform:              host.Platform,
+		PlatformFamily:        host.PlatformFamily,
+		PlatformVersion:       host.PlatformVersion,
+		KernelVersion:         host.KernelVersion,
+		CPU:                   host.CPU,
+		Memory:                host.Memory,
+		Network:               host.Network,
+		Disk:                  host.Disk,
+		Build:                 host.Build,
+		ConcurrentUploadLimit: host.ConcurrentUploadLimit.Load(),
+		UploadCount:           host.UploadCount.Load(),
+		UploadFailedCount:     host.UploadFailedCount.Load(),
+		Draining:              host.Draining.Load(),
+		CreatedAt:             host.CreatedAt.Load(),
+		UpdatedAt:             host.UpdatedAt.Load(),
+	}
+}
+
+// host returns the host from the persistent form of host.
+func (h *snapshotHost) host() *Host {
+	host := NewHost(h.ID, h.IP, h.Hostname, h.Port, h.DownloadPort, h.Type,
+		WithOS(h.OS),
+		WithPlatform(h.Platform),
+		WithPlatformFamily(h.PlatformFamily),
+		WithPlatformVersion(h.PlatformVersion),
+		WithKernelVersion(h.KernelVersion),
+		WithCPU(h.CPU),
+		WithMemory(h.Memory),
+		WithNetwork(h.Network),
+		WithDisk(h.Disk),
+		WithBuild(h.Build),
+		WithConcurrentUploadLimit(h.ConcurrentUploadLimit),
+	)
+
+	host.UploadFailedCount.Store(h.UploadFailedCount)
+	host.Draining.Store(h.Draining)
+	host.CreatedAt.Store(h.CreatedAt)
+	host.UpdatedAt.Store(h.UpdatedAt)
+	return host
+}
+
+// newSnapshotTask returns the persistent form of task.
+func newSnapshotTask(task *Task) *snapshotTask {
+	t := &snapshotTask{
+		ID:                task.ID,
+		Type:              task.Type,
+		URL:               task.URL,
+		Tag:               task.Tag,
+		Application:       task.Application,
+		Filters:           task.Filters,
+		Header:            persistentHeader(task.Header),
+		PieceLength:       task.PieceLength,
+		DirectPiece:       task.DirectPiece,
+		ContentLength:     task.ContentLength.Load(),
+		TotalPieceCount:   task.TotalPieceCount.Load(),
+		BackToSourceLimit: task.BackToSourceLimit.Load(),
+		BackToSourcePeers: task.BackToSourcePeers.Values(),
+		State:             task.FSM.Current(),
+		PeerFailedCount:   task.PeerFailedCount.Load(),
+		CreatedAt:         task.CreatedAt.Load(),
+		UpdatedAt:         task.UpdatedAt.Load(),
+	}
+
+	if task.Digest != nil {
+		t.Digest = task.Digest.String()
+	}
+
+	task.Pieces.Range(func(_, value any) bool {
+		if piece, ok := value.(*Piece); ok {
+			t.Pieces = append(t.Pieces, newSnapshotPiece(piece))
+		}
+
+		return true
+	})
+
+	return t
+}
+
+// persistentHeader returns the header of task without the sensitive headers, the headers
+// are persisted to the snapshot and shared with schedulers. The task taken over or restored
+// gets the sensitive headers again when the peers register.
+func persistentHeader(header map[string]string) map[string]string {
+	if header == nil {
+		return nil
+	}
+
+	h := make(map[string]string, len(header))
+	for key, value := range header {
+		if isSensitiveHeader(key) {
+			continue
+		}
+
+		h[key] = value
+	}
+
+	return h
+}
+
+// isSensitiveHeader returns whether the header carries credentials.
+func isSensitiveHeader(key string) bool {
+	if _, ok := sensitiveHeaders[textproto.CanonicalMIMEHeaderKey(key)]; ok {
+		return true
+	}
+
+	key = strings.ToLower(key)
+	for _, keyword := range sensitiveHeaderKeywords {
+		if strings.Contains(key, keyword) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// task returns the task from the persistent form of task.
+func (t *snapshotTask) task() *Task {
+	options := []TaskOption{WithPieceLength(t.PieceLength)}
+	if d, err := digest.Parse(t.Digest); err == nil {
+		options = append(options, WithDigest(d))
+	}
+
+	task := NewTask(t.ID, t.URL, t.Tag, t.Application, t.Type, t.Filters, t.Header, t.BackToSourceLimit, options...)
+	if t.DirectPiece != nil {
+		task.DirectPiece = t.DirectPiece
+	}
+
+	task.ContentLength.Store(t.ContentLength)
+	task.TotalPieceCount.Store(t.TotalPieceCount)
+	for _, peerID := range t.BackToSourcePeers {
+		task.BackToSourcePeers.Add(peerID)
+	}
+
+	for _, piece := range t.Pieces {
+		task.StorePiece(piece.piece())
+	}
+
+	task.FSM.SetState(t.State)
+	task.PeerFailedCount.Store(t.PeerFailedCount)
+	task.CreatedAt.Store(t.CreatedAt)
+	task.UpdatedAt.Store(t.UpdatedAt)
+	return task
+}
+
+// newSnapshotPeer returns the persistent form of peer.
+func newSnapshotPeer(peer *Peer) *snapshotPeer {
+	p := &snapshotPeer{
+		ID:               peer.ID,
+		TaskID:           peer.Task.ID,
+		HostID:           peer.Host.ID,
+		Range:            peer.Range,
+		Priority:         peer.Priority,
+		FinishedPieces:   peer.FinishedPieces.Clone(),
+		PieceCosts:       peer.PieceCosts(),
+		Cost:             peer.Cost.Load(),
+		State:            peer.FSM.Current(),
+		BlockParents:     peer.BlockParents.Values(),
+		NeedBackToSource: peer.NeedBackToSource.Load(),
+		PieceUpdatedAt:   peer.PieceUpdatedAt.Load(),
+		CreatedAt:        peer.CreatedAt.Load(),
+		UpdatedAt:        peer.UpdatedAt.Load(),
+	}
+
+	peer.Pieces.Range(func(_, value any) bool {
+		if piece, ok := value.(*Piece); ok {
+			p.Pieces = append(p.Pieces, newSnapshotPiece(piece))
+		}
+
+		return true
+	})
+
+	for _, parent := range peer.Parents() {
+		p.ParentIDs = append(p.ParentIDs, parent.ID)
+	}
+
+	return p
+}
+
+// peer returns the peer from the persistent form of peer, the streams of peer
+// are not restored and will be stored when the peer reconnects.
+func (p *snapshotPeer) peer(task *Task, host *Host) *Peer {
+	options := []PeerOption{WithPriority(p.Priority)}
+	if p.Range != nil {
+		options = append(options, WithRange(*p.Range))
+	}
+
+	peer := NewPeer(p.ID, task, host, options...)
+	for _, piece := range p.Pieces {
+		peer.StorePiece(piece.piece())
+	}
+
+	if p.FinishedPieces != nil {
+		peer.FinishedPieces = p.FinishedPieces
+	}
+
+	for _, cost := range p.PieceCosts {
+		peer.AppendPieceCost(cost)
+	}
+
+	peer.Cost.Store(p.Cost)
+	peer.FSM.SetState(p.State)
+	for _, parentID := range p.BlockParents {
+		peer.BlockParents.Add(parentID)
+	}
+
+	peer.NeedBackToSource.Store(p.NeedBackToSource)
+	peer.PieceUpdatedAt.Store(p.PieceUpdatedAt)
+	peer.CreatedAt.Store(p.CreatedAt)
+	peer.UpdatedAt.Store(p.UpdatedAt)
+	return peer
+}
+
+// newSnapshotPiece returns the persistent form of piece.
+func newSnapshotPiece(piece *Piece) *snapshotPiece {
+	p := &snapshotPiece{
+		Number:      piece.Number,
+		ParentID:    piece.ParentID,
+		Offset:      piece.Offset,
+		Length:      piece.Length,
+		TrafficType: piece.TrafficType,
+		Cost:        piece.Cost,
+		CreatedAt:   piece.CreatedAt,
+	}
+
+	if piece.Digest != nil {
+		p.Digest = piece.Digest.String()
+	}
+
+	return p
+}
+
+// piece returns the piece from the persistent form of piece.
+func (p *snapshotPiece) piece() *Piece {
+	piece := &Piece{
+		Number:      p.Number,
+		ParentID:    p.ParentID,
+		Offset:      p.Offset,
+		Length:      p.Length,
+		TrafficType: p.TrafficType,
+		Cost:        p.Cost,
+		CreatedAt:   p.CreatedAt,
+	}
+
+	if d, err := digest.Parse(p.Digest); err == nil {
+		piece.Digest = d
+	}
+
+	return piece
+}
@@ -0,0 +1,288 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resource
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	commonv2 "d7y.io/api/pkg/apis/common/v2"
+
+	"d7y.io/dragonfly/v2/pkg/gc"
+	"d7y.io/dragonfly/v2/scheduler/config"
+)
+
+var mockSnapshotConfig = &config.Config{
+	Scheduler: config.SchedulerConfig{
+		GC: config.GCConfig{
+			PeerGCInterval: 100,
+			PeerTTL:        1000,
+			TaskGCInterval: 100,
+			HostGCInterval: 100,
+		},
+		Snapshot: config.SnapshotConfig{
+			Enable:   true,
+			Interval: time.Minute,
+			TTL:      time.Hour,
+		},
+	},
+	SeedPeer: config.SeedPeerConfig{
+		Enable: false,
+	},
+}
+
+func TestResource_Snapshot(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	gc := gc.NewMockGC(ctl)
+	gc.EXPECT().Add(gomock.Any()).Return(nil).Times(6)
+
+	dataDir := t.TempDir()
+	r, err := New(mockSnapshotConfig, gc, nil, WithDataDir(dataDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockHost := NewHost(
+		mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+		mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type,
+		WithNetwork(mockNetwork), WithConcurrentUploadLimit(10))
+	mockSeedHost := NewHost(
+		mockRawSeedHost.ID, mockRawSeedHost.IP, mockRawSeedHost.Hostname,
+		mockRawSeedHost.Port, mockRawSeedHost.DownloadPort, mockRawSeedHost.Type)
+	mockHost.UploadFailedCount.Store(2)
+	mockHost.Draining.Store(true)
+	r.HostManager().Store(mockHost)
+	r.HostManager().Store(mockSeedHost)
+
+	mockTask := NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, WithDigest(mockTaskDigest), WithPieceLength(mockTaskPieceLength))
+	mockTask.FSM.SetState(TaskStateRunning)
+	mockTask.TotalPieceCount.Store(2)
+	mockTask.StorePiece(mockPiece)
+	r.TaskManager().Store(mockTask)
+
+	parent := NewPeer(mockSeedPeerID, mockTask, mockSeedHost)
+	parent.FSM.SetState(PeerStateSucceeded)
+	parent.FinishedPieces.Set(0).Set(1)
+	parent.StorePiece(mockPiece)
+	r.PeerManager().Store(parent)
+
+	child := NewPeer(mockPeerID, mockTask, mockHost, WithPriority(commonv2.Priority_LEVEL1))
+	child.FSM.SetState(PeerStateRunning)
+	child.FinishedPieces.Set(0)
+	child.BlockParents.Add("foo")
+	r.PeerManager().Store(child)
+	if err := mockTask.AddPeerEdge(parent, child); err != nil {
+		t.Fatal(err)
+	}
+
+	assert := assert.New(t)
+	assert.NoError(r.Snapshot())
+
+	restored, err := New(mockSnapshotConfig, gc, nil, WithDataDir(dataDir))
+	assert.NoError(err)
+
+	host, loaded := restored.HostManager().Load(mockHost.ID)
+	assert.True(loaded)
+	assert.Equal(host.Network, mockNetwork)
+	assert.Equal(host.ConcurrentUploadLimit.Load(), int32(10))
+	assert.Equal(host.UploadFailedCount.Load(), int64(2))
+	assert.True(host.Draining.Load())
+	assert.Equal(host.PeerCount.Load(), int32(1))
+
+	seedHost, loaded := restored.HostManager().Load(mockSeedHost.ID)
+	assert.True(loaded)
+	assert.Equal(seedHost.ConcurrentUploadCount.Load(), int32(1))
+	assert.Equal(seedHost.UploadCount.Load(), mockSeedHost.UploadCount.Load())
+
+	task, loaded := restored.TaskManager().Load(mockTask.ID)
+	assert.True(loaded)
+	assert.Equal(task.Digest.String(), mockTaskDigest.String())
+	assert.Equal(task.PieceLength, mockTaskPieceLength)
+	assert.Equal(task.TotalPieceCount.Load(), int32(2))
+	assert.True(task.FSM.Is(TaskStateRunning))
+	assert.Equal(task.PeerCount(), 2)
+	piece, loaded := task.LoadPiece(mockPiece.Number)
+	assert.True(loaded)
+	assert.Equal(piece.Digest.String(), mockPieceDigest.String())
+
+	peer, loaded := restored.PeerManager().Load(child.ID)
+	assert.True(loaded)
+	assert.True(peer.FSM.Is(PeerStateRunning))
+	assert.Equal(peer.Priority, commonv2.Priority_LEVEL1)
+	assert.Equal(peer.FinishedPieces.Count(), uint(1))
+	assert.True(peer.BlockParents.Contains("foo"))
+	assert.Equal(len(peer.Parents()), 1)
+	assert.Equal(peer.Parents()[0].ID, parent.ID)
+	assert.Same(peer.Task, task)
+	assert.Same(peer.Host, host)
+
+	_, loaded = peer.LoadAnnouncePeerStream()
+	assert.False(loaded)
+}
+
+func TestResource_Stop(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	gc := gc.NewMockGC(ctl)
+	gc.EXPECT().Add(gomock.Any()).Return(nil).Times(3)
+
+	dataDir := t.TempDir()
+	r, err := New(mockSnapshotConfig, gc, nil, WithDataDir(dataDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Snapshot fails when the data directory is removed, but stopping resource continues.
+	if err := os.RemoveAll(dataDir); err != nil {
+		t.Fatal(err)
+	}
+
+	assert := assert.New(t)
+	assert.Error(r.Snapshot())
+	assert.NoError(r.Stop())
+	_, ok := <-r.(*resource).done
+	assert.False(ok)
+}
+
+func TestResource_restore(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(dataDir string)
+		expect func(t *testing.T, r Resource, err error)
+	}{
+		{
+			name: "snapshot does not exist",
+			mock: func(dataDir string) {},
+			expect: func(t *testing.T, r Resource, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+			},
+		},
+		{
+			name: "snapshot is broken",
+			mock: func(dataDir string) {
+				if err := os.WriteFile(filepath.Join(dataDir, SnapshotFileName), []byte("foo"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expect: func(t *testing.T, r Resource, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+			},
+		},
+		{
+			name: "snapshot is stale",
+			mock: func(dataDir string) {
+				b := []byte(fmt.Sprintf(`{"hosts":[{"id":"%s"}],"created_at":"%s"}`,
+					mockRawHost.ID, time.Now().Add(-2*time.Hour).Format(time.RFC3339)))
+				if err := os.WriteFile(filepath.Join(dataDir, SnapshotFileName), b, 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expect: func(t *testing.T, r Resource, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				_, loaded := r.HostManager().Load(mockRawHost.ID)
+				assert.False(loaded)
+			},
+		},
+		{
+			name: "snapshot is fresh",
+			mock: func(dataDir string) {
+				b := []byte(fmt.Sprintf(`{"hosts":[{"id":"%s"}],"created_at":"%s"}`,
+					mockRawHost.ID, time.Now().Format(time.RFC3339)))
+				if err := os.WriteFile(filepath.Join(dataDir, SnapshotFileName), b, 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expect: func(t *testing.T, r Resource, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				_, loaded := r.HostManager().Load(mockRawHost.ID)
+				assert.True(loaded)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			gc := gc.NewMockGC(ctl)
+			gc.EXPECT().Add(gomock.Any()).Return(nil).Times(3)
+
+			dataDir := t.TempDir()
+			tc.mock(dataDir)
+			r, err := New(mockSnapshotConfig, gc, nil, WithDataDir(dataDir))
+			tc.expect(t, r, err)
+		})
+	}
+}
+
+func TestPersistentHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		header map[string]string
+		expect map[string]string
+	}{
+		{
+			name:   "header is nil",
+			header: nil,
+			expect: nil,
+		},
+		{
+			name: "header has no sensitive headers",
+			header: map[string]string{
+				"Range":        "bytes=0-9",
+				"Content-Type": "application/octet-stream",
+			},
+			expect: map[string]string{
+				"Range":        "bytes=0-9",
+				"Content-Type": "application/octet-stream",
+			},
+		},
+		{
+			name: "header has sensitive headers",
+			header: map[string]string{
+				"Range":                          "bytes=0-9",
+				"authorization":                  "Bearer foo",
+				"Proxy-Authorization":            "Basic foo",
+				"Cookie":                         "foo=bar",
+				"X-Dragonfly-Oras-Authorization": "foo",
+				"X-Amz-Security-Token":           "foo",
+				"X-Goog-Signature":               "foo",
+			},
+			expect: map[string]string{
+				"Range": "bytes=0-9",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(tc.expect, persistentHeader(tc.header))
+		})
+	}
+}
@@ -283,6 +283,33 @@ func (t *Task) AddPeerEdge(fromPeer *Peer, toPeer *Peer) error {
 	return nil
 }
 
+// DeletePeerEdge deletes the edge between peers.
+func (t *Task) DeletePeerEdge(fromPeer *Peer, toPeer *Peer) error {
+	vertex, err := t.DAG.GetVertex(toPeer.ID)
+	if err != nil {
+		return err
+	}
+
+	var found bool
+	for _, parent := range vertex.Parents.Values() {
+		if parent.ID == fromPeer.ID {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return errors.New("edge not found")
+	}
+
+	if err := t.DAG.DeleteEdge(fromPeer.ID, toPeer.ID); err != nil {
+		return err
+	}
+
+	fromPeer.Host.ConcurrentUploadCount.Dec()
+	return nil
+}
+
 // DeletePeerInEdges deletes inedges of peer.
 func (t *Task) DeletePeerInEdges(key string) error {
 	vertex, err := t.DAG.GetVertex(key)
@@ -592,6 +592,70 @@ func TestTask_DeletePeerInEdges(t *testing.T) {
 	}
 }
 
+func TestTask_DeletePeerEdge(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect func(t *testing.T, mockHost *Host, task *Task)
+	}{
+		{
+			name: "delete peer edge",
+			expect: func(t *testing.T, mockHost *Host, task *Task) {
+				assert := assert.New(t)
+				mockPeerE := NewPeer(idgen.PeerIDV1("127.0.0.1"), task, mockHost)
+				mockPeerF := NewPeer(idgen.PeerIDV1("127.0.0.1"), task, mockHost)
+				mockPeerG := NewPeer(idgen.PeerIDV1("127.0.0.1"), task, mockHost)
+				task.StorePeer(mockPeerE)
+				task.StorePeer(mockPeerF)
+				task.StorePeer(mockPeerG)
+
+				assert.NoError(task.AddPeerEdge(mockPeerE, mockPeerF))
+				assert.NoError(task.AddPeerEdge(mockPeerG, mockPeerF))
+				assert.Equal(mockHost.ConcurrentUploadCount.Load(), int32(2))
+
+				assert.NoError(task.DeletePeerEdge(mockPeerE, mockPeerF))
+				assert.Equal(len(mockPeerE.Children()), 0)
+				assert.Equal(len(mockPeerF.Parents()), 1)
+				assert.Equal(mockPeerF.Parents()[0].ID, mockPeerG.ID)
+				assert.Equal(mockHost.ConcurrentUploadCount.Load(), int32(1))
+			},
+		},
+		{
+			name: "edge not found",
+			expect: func(t *testing.T, mockHost *Host, task *Task) {
+				assert := assert.New(t)
+				mockPeerE := NewPeer(idgen.PeerIDV1("127.0.0.1"), task, mockHost)
+				mockPeerF := NewPeer(idgen.PeerIDV1("127.0.0.1"), task, mockHost)
+				task.StorePeer(mockPeerE)
+				task.StorePeer(mockPeerF)
+
+				assert.EqualError(task.DeletePeerEdge(mockPeerE, mockPeerF), "edge not found")
+				assert.Equal(mockHost.ConcurrentUploadCount.Load(), int32(0))
+			},
+		},
+		{
+			name: "peer not found",
+			expect: func(t *testing.T, mockHost *Host, task *Task) {
+				assert := assert.New(t)
+				mockPeerE := NewPeer(idgen.PeerIDV1("127.0.0.1"), task, mockHost)
+				mockPeerF := NewPeer(idgen.PeerIDV1("127.0.0.1"), task, mockHost)
+
+				assert.Error(task.DeletePeerEdge(mockPeerE, mockPeerF))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockHost := NewHost(
+				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
+			task := NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit)
+
+			tc.expect(t, mockHost, task)
+		})
+	}
+}
+
 func TestTask_DeletePeerOutEdges(t *testing.T) {
 	tests := []struct {
 		name   string
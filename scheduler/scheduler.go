@@ -47,9 +47,11 @@ import (
 	"d7y.io/dragonfly/v2/scheduler/config"
 	"d7y.io/dragonfly/v2/scheduler/job"
 	"d7y.io/dragonfly/v2/scheduler/metrics"
+	"d7y.io/dragonfly/v2/scheduler/networktopology"
 	"d7y.io/dragonfly/v2/scheduler/resource"
 	"d7y.io/dragonfly/v2/scheduler/rpcserver"
 	"d7y.io/dragonfly/v2/scheduler/scheduling"
+	"d7y.io/dragonfly/v2/scheduler/scheduling/evaluator"
 	"d7y.io/dragonfly/v2/scheduler/storage"
 )
 
@@ -90,6 +92,9 @@ type Server struct {
 	// Announcer interface.
 	announcer announcer.Announcer
 
+	// Network topology interface.
+	networkTopology networktopology.NetworkTopology
+
 	// GC service.
 	gc gc.GC
 }
@@ -176,26 +181,59 @@ func New(ctx context.Context, cfg *config.Config, d dfpath.Dfpath) (*Server, err
 	s.gc = gc.New(gc.WithLogger(logger.GCLogger))
 
 	// Initialize resource.
-	resource, err := resource.New(cfg, s.gc, dynconfig, resource.WithTransportCredentials(clientTransportCredentials))
+	resource, err := resource.New(cfg, s.gc, dynconfig, resource.WithTransportCredentials(clientTransportCredentials), resource.WithDataDir(d.DataDir()))
 	if err != nil {
 		return nil, err
 	}
 	s.resource = resource
 
-	// Initialize scheduling.
-	scheduling := scheduling.New(&cfg.Scheduler, dynconfig, d.PluginDir())
-
 	// Initialize Storage.
-	storage, err := storage.New(
+	s.storage, err = storage.New(
 		d.DataDir(),
 		cfg.Storage.MaxSize,
 		cfg.Storage.MaxBackups,
 		cfg.Storage.BufferSize,
+		storage.WithFormat(storage.Format(cfg.Storage.Format)),
 	)
 	if err != nil {
 		return nil, err
 	}
-	s.storage = storage
+
+	// Initialize network topology.
+	if cfg.NetworkTopology.Enable {
+		s.networkTopology, err = networktopology.New(&cfg.NetworkTopology, resource.HostManager(), d.DataDir(), s.storage)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Initialize scheduling.
+	evaluatorOptions := []evaluator.Option{
+		evaluator.WithModelDir(filepath.Join(d.DataDir(), evaluator.ModelDirName)),
+		evaluator.WithWeights(cfg.Scheduler.EvaluatorWeights),
+		evaluator.WithDynconfig(dynconfig),
+		evaluator.WithModelFetcher(evaluator.NewModelFetcher(s.managerClient, cfg.Server.Host, cfg.Server.AdvertiseIP.String())),
+		evaluator.WithPreemption(cfg.Scheduler.Preemption.Enable),
+	}
+	if s.networkTopology != nil {
+		evaluatorOptions = append(evaluatorOptions, evaluator.WithNetworkTopology(s.networkTopology))
+	}
+
+	// Initialize decision log of scheduling, it is served by metrics server.
+	var (
+		decisionLog    scheduling.DecisionLog
+		metricsOptions []metrics.Option
+	)
+	if cfg.Scheduler.DecisionLog.Enable {
+		decisionLog = scheduling.NewDecisionLog(cfg.Scheduler.DecisionLog.TTL, cfg.Scheduler.DecisionLog.Limit)
+		metricsOptions = append(metricsOptions, metrics.WithHandler(scheduling.DecisionLogPath, decisionLog))
+	}
+	scheduling := scheduling.New(&cfg.Scheduler, dynconfig, d.PluginDir(), decisionLog, resource.BackToSourceQuota(), evaluatorOptions...)
+
+	// Export downloads of storage by metrics server.
+	if cfg.Storage.Export.Enable {
+		metricsOptions = append(metricsOptions, metrics.WithHandler(storage.DownloadExportPath, storage.NewDownloadExportHandler(s.storage)))
+	}
 
 	// Initialize grpc service and server options of scheduler grpc server.
 	schedulerServerOptions := []grpc.ServerOption{}
@@ -210,12 +248,12 @@ func New(ctx context.Context, cfg *config.Config, d dfpath.Dfpath) (*Server, err
 		schedulerServerOptions = append(schedulerServerOptions, grpc.Creds(insecure.NewCredentials()))
 	}
 
-	svr := rpcserver.New(cfg, resource, scheduling, dynconfig, s.storage, schedulerServerOptions...)
+	svr := rpcserver.New(cfg, resource, scheduling, dynconfig, s.storage, s.networkTopology, schedulerServerOptions...)
 	s.grpcServer = svr
 
 	// Initialize job service.
 	if cfg.Job.Enable {
-		s.job, err = job.New(cfg, resource)
+		s.job, err = job.New(cfg, resource, scheduling, job.WithTransportCredentials(clientTransportCredentials))
 		if err != nil {
 			return nil, err
 		}
@@ -223,7 +261,7 @@ func New(ctx context.Context, cfg *config.Config, d dfpath.Dfpath) (*Server, err
 
 	// Initialize metrics.
 	if cfg.Metrics.Enable {
-		s.metricsServer = metrics.New(&cfg.Metrics, s.grpcServer)
+		s.metricsServer = metrics.New(&cfg.Metrics, s.grpcServer, metricsOptions...)
 	}
 
 	return s, nil
@@ -248,6 +286,30 @@ func (s *Server) Serve() error {
 		logger.Info("job start successfully")
 	}
 
+	// Serve resource.
+	if s.config.Scheduler.Snapshot.Enable {
+		go s.resource.Serve()
+		logger.Info("resource snapshot start successfully")
+	}
+
+	// Serve replicator.
+	if s.config.Replication.Enable {
+		go s.resource.Replicator().Serve()
+		logger.Info("replicator start successfully")
+	}
+
+	// Serve back-to-source quota.
+	if s.config.BackToSourceQuota.Enable {
+		go s.resource.BackToSourceQuota().Serve()
+		logger.Info("back-to-source quota start successfully")
+	}
+
+	// Serve network topology.
+	if s.networkTopology != nil {
+		go s.networkTopology.Serve()
+		logger.Info("network topology start successfully")
+	}
+
 	// Started metrics server.
 	if s.metricsServer != nil {
 		go func() {
@@ -313,6 +375,23 @@ func (s *Server) Stop() {
 		logger.Info("clean download storage completed")
 	}
 
+	// Stop network topology before cleaning its storage,
+	// so that no records are collected after cleaning.
+	if s.networkTopology != nil {
+		if err := s.networkTopology.Stop(); err != nil {
+			logger.Errorf("stop network topology failed %s", err.Error())
+		} else {
+			logger.Info("stop network topology closed")
+		}
+	}
+
+	// Clean network topology storage.
+	if err := s.storage.ClearNetworkTopology(); err != nil {
+		logger.Errorf("clean network topology storage failed %s", err.Error())
+	} else {
+		logger.Info("clean network topology storage completed")
+	}
+
 	// Stop GC.
 	s.gc.Stop()
 	logger.Info("gc closed")
@@ -0,0 +1,295 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scheduling
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/cache"
+	"d7y.io/dragonfly/v2/pkg/container/set"
+	"d7y.io/dragonfly/v2/scheduler/resource"
+	"d7y.io/dragonfly/v2/scheduler/scheduling/evaluator"
+)
+
+const (
+	// DecisionLogPath is the http path of the decision log.
+	DecisionLogPath = "/debug/scheduling/decisions"
+)
+
+const (
+	// FilterReasonInBlocklist is the reason that the parent is in blocklist.
+	FilterReasonInBlocklist = "in blocklist"
+
+	// FilterReasonCanNotAddEdge is the reason that the parent can not add edge with the peer.
+	FilterReasonCanNotAddEdge = "can not add edge"
+
+	// FilterReasonSameHost is the reason that the parent host is the same as the peer host.
+	FilterReasonSameHost = "same host"
+
+	// FilterReasonBadNode is the reason that the parent is bad node.
+	FilterReasonBadNode = "bad node"
+
+	// FilterReasonNotInDAG is the reason that the parent can not be found in dag.
+	FilterReasonNotInDAG = "not in dag"
+
+	// FilterReasonDownloadState is the reason that the parent has no data to be downloaded.
+	FilterReasonDownloadState = "download state"
+
+	// FilterReasonFreeUploadEmpty is the reason that the parent's free upload is empty.
+	FilterReasonFreeUploadEmpty = "free upload empty"
+
+	// FilterReasonHostDraining is the reason that the parent's host is draining.
+	FilterReasonHostDraining = "host draining"
+)
+
+// Decision is the scheduling decision of the peer.
+type Decision struct {
+	// TaskID is the id of task.
+	TaskID string `json:"task_id"`
+
+	// PeerID is the id of peer.
+	PeerID string `json:"peer_id"`
+
+	// HostID is the id of peer's host.
+	HostID string `json:"host_id"`
+
+	// Blocklist is the parent ids which are blocked.
+	Blocklist []string `json:"blocklist"`
+
+	// FilteredParents are the parents filtered out.
+	FilteredParents []*FilteredParent `json:"filtered_parents"`
+
+	// CandidateParents are the candidate parents sorted by score.
+	CandidateParents []*CandidateParent `json:"candidate_parents"`
+
+	// CreatedAt is the creation time of decision.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FilteredParent is the parent filtered out with the reason.
+type FilteredParent struct {
+	// ID is the id of parent.
+	ID string `json:"id"`
+
+	// HostID is the id of parent's host.
+	HostID string `json:"host_id"`
+
+	// State is the state of parent.
+	State string `json:"state"`
+
+	// Reason is the reason why the parent is filtered out.
+	Reason string `json:"reason"`
+}
+
+// CandidateParent is the candidate parent with the scores.
+type CandidateParent struct {
+	// ID is the id of parent.
+	ID string `json:"id"`
+
+	// HostID is the id of parent's host.
+	HostID string `json:"host_id"`
+
+	// State is the state of parent.
+	State string `json:"state"`
+
+	// Score is the evaluation score of parent.
+	Score float64 `json:"score"`
+
+	// FeatureScores is the score of each feature, it is empty
+	// if the evaluator can not explain the evaluation.
+	FeatureScores map[string]float64 `json:"feature_scores,omitempty"`
+}
+
+// newDecision returns a new decision of the peer.
+func newDecision(peer *resource.Peer, blocklist set.SafeSet[string]) *Decision {
+	d := &Decision{
+		TaskID:    peer.Task.ID,
+		PeerID:    peer.ID,
+		HostID:    peer.Host.ID,
+		Blocklist: []string{},
+		CreatedAt: time.Now(),
+	}
+
+	if blocklist != nil {
+		d.Blocklist = append(d.Blocklist, blocklist.Values()...)
+		sort.Strings(d.Blocklist)
+	}
+
+	return d
+}
+
+// filter records the parent filtered out, it does nothing if the decision is nil.
+func (d *Decision) filter(parent *resource.Peer, reason string) {
+	if d == nil {
+		return
+	}
+
+	d.FilteredParents = append(d.FilteredParents, &FilteredParent{
+		ID:     parent.ID,
+		HostID: parent.Host.ID,
+		State:  parent.FSM.Current(),
+		Reason: reason,
+	})
+}
+
+// evaluate records the scores of candidate parents, it does nothing if the decision is nil.
+func (d *Decision) evaluate(e evaluator.Evaluator, peer *resource.Peer, candidateParents []*resource.Peer) {
+	if d == nil {
+		return
+	}
+
+	explainer, ok := e.(evaluator.Explainer)
+	taskTotalPieceCount := peer.Task.TotalPieceCount.Load()
+	for _, candidateParent := range candidateParents {
+		c := &CandidateParent{
+			ID:     candidateParent.ID,
+			HostID: candidateParent.Host.ID,
+			State:  candidateParent.FSM.Current(),
+			Score:  e.Evaluate(candidateParent, peer, taskTotalPieceCount),
+		}
+
+		if ok {
+			c.FeatureScores = explainer.Explain(candidateParent, peer, taskTotalPieceCount)
+		}
+
+		d.CandidateParents = append(d.CandidateParents, c)
+	}
+}
+
+// DecisionLog records the scheduling decisions of peers.
+type DecisionLog interface {
+	// Store stores the decision of the peer, the earliest decision
+	// is removed when the decisions of the peer reach the limit.
+	Store(*Decision)
+
+	// LoadByPeerID returns the decisions of the peer in order of creation.
+	LoadByPeerID(string) []*Decision
+
+	// LoadByTaskID returns the decisions of the peers in the task in order of creation.
+	LoadByTaskID(string) []*Decision
+
+	// ServeHTTP serves the decisions of the peer or the task by
+	// the query parameter peer_id or task_id.
+	ServeHTTP(http.ResponseWriter, *http.Request)
+}
+
+// decisionLog implements DecisionLog.
+type decisionLog struct {
+	// decisions caches the decisions by peer id.
+	decisions cache.Cache
+
+	// ttl is time to live of the decisions of a peer.
+	ttl time.Duration
+
+	// limit is the maximum number of decisions recorded for a peer.
+	limit int
+
+	// mu locks for storing decisions.
+	mu *sync.Mutex
+}
+
+// NewDecisionLog returns a new DecisionLog interface.
+func NewDecisionLog(ttl time.Duration, limit int) DecisionLog {
+	return &decisionLog{
+		decisions: cache.New(ttl, ttl),
+		ttl:       ttl,
+		limit:     limit,
+		mu:        &sync.Mutex{},
+	}
+}
+
+// Store stores the decision of the peer, the earliest decision
+// is removed when the decisions of the peer reach the limit.
+func (dl *decisionLog) Store(d *Decision) {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+
+	// Get of cache does not check the expiration of item,
+	// the expired decisions are dropped.
+	var decisions []*Decision
+	if value, _, ok := dl.decisions.GetWithExpiration(d.PeerID); ok {
+		decisions = value.([]*Decision)
+	}
+
+	// Copy the decisions to avoid data race with the loaded decisions.
+	decisions = append(append([]*Decision{}, decisions...), d)
+	if len(decisions) > dl.limit {
+		decisions = decisions[len(decisions)-dl.limit:]
+	}
+
+	dl.decisions.Set(d.PeerID, decisions, dl.ttl)
+}
+
+// LoadByPeerID returns the decisions of the peer in order of creation.
+func (dl *decisionLog) LoadByPeerID(peerID string) []*Decision {
+	// Get of cache does not check the expiration of item.
+	value, _, ok := dl.decisions.GetWithExpiration(peerID)
+	if !ok {
+		return []*Decision{}
+	}
+
+	return value.([]*Decision)
+}
+
+// LoadByTaskID returns the decisions of the peers in the task in order of creation.
+func (dl *decisionLog) LoadByTaskID(taskID string) []*Decision {
+	decisions := []*Decision{}
+	for _, item := range dl.decisions.Items() {
+		for _, d := range item.Object.([]*Decision) {
+			if d.TaskID == taskID {
+				decisions = append(decisions, d)
+			}
+		}
+	}
+
+	sort.SliceStable(decisions, func(i, j int) bool {
+		return decisions[i].CreatedAt.Before(decisions[j].CreatedAt)
+	})
+
+	return decisions
+}
+
+// ServeHTTP serves the decisions of the peer or the task by
+// the query parameter peer_id or task_id.
+func (dl *decisionLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var decisions []*Decision
+	query := r.URL.Query()
+	switch {
+	case query.Get("peer_id") != "":
+		decisions = dl.LoadByPeerID(query.Get("peer_id"))
+	case query.Get("task_id") != "":
+		decisions = dl.LoadByTaskID(query.Get("task_id"))
+	default:
+		http.Error(w, "query parameter peer_id or task_id is required", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(decisions); err != nil {
+		logger.Errorf("encode decisions failed: %s", err.Error())
+	}
+}
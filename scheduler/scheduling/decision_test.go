@@ -0,0 +1,253 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scheduling
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	commonv2 "d7y.io/api/pkg/apis/common/v2"
+
+	"d7y.io/dragonfly/v2/manager/types"
+	"d7y.io/dragonfly/v2/pkg/cache"
+	"d7y.io/dragonfly/v2/pkg/container/set"
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	configmocks "d7y.io/dragonfly/v2/scheduler/config/mocks"
+	"d7y.io/dragonfly/v2/scheduler/resource"
+	"d7y.io/dragonfly/v2/scheduler/scheduling/evaluator"
+)
+
+func TestDecisionLog_Store(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     int
+		decisions []*Decision
+		expect    func(t *testing.T, dl DecisionLog)
+	}{
+		{
+			name:  "store decisions of peers",
+			limit: 2,
+			decisions: []*Decision{
+				{TaskID: mockTaskID, PeerID: "foo", CreatedAt: time.Unix(1, 0)},
+				{TaskID: mockTaskID, PeerID: "bar", CreatedAt: time.Unix(2, 0)},
+				{TaskID: "baz", PeerID: "baz", CreatedAt: time.Unix(3, 0)},
+			},
+			expect: func(t *testing.T, dl DecisionLog) {
+				assert := assert.New(t)
+				assert.Equal(len(dl.LoadByPeerID("foo")), 1)
+				assert.Equal(len(dl.LoadByPeerID("bar")), 1)
+				assert.Equal(len(dl.LoadByPeerID("unknow")), 0)
+
+				decisions := dl.LoadByTaskID(mockTaskID)
+				assert.Equal(len(decisions), 2)
+				assert.Equal(decisions[0].PeerID, "foo")
+				assert.Equal(decisions[1].PeerID, "bar")
+			},
+		},
+		{
+			name:  "decisions of peer exceed the limit",
+			limit: 2,
+			decisions: []*Decision{
+				{TaskID: mockTaskID, PeerID: "foo", CreatedAt: time.Unix(1, 0)},
+				{TaskID: mockTaskID, PeerID: "foo", CreatedAt: time.Unix(2, 0)},
+				{TaskID: mockTaskID, PeerID: "foo", CreatedAt: time.Unix(3, 0)},
+			},
+			expect: func(t *testing.T, dl DecisionLog) {
+				assert := assert.New(t)
+				decisions := dl.LoadByPeerID("foo")
+				assert.Equal(len(decisions), 2)
+				assert.Equal(decisions[0].CreatedAt, time.Unix(2, 0))
+				assert.Equal(decisions[1].CreatedAt, time.Unix(3, 0))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dl := NewDecisionLog(time.Minute, tc.limit)
+			for _, d := range tc.decisions {
+				dl.Store(d)
+			}
+
+			tc.expect(t, dl)
+		})
+	}
+}
+
+func TestDecisionLog_Expiration(t *testing.T) {
+	ttl := 10 * time.Millisecond
+	dl := &decisionLog{
+		// The janitor does not remove the expired decisions during the test.
+		decisions: cache.New(ttl, time.Hour),
+		ttl:       ttl,
+		limit:     2,
+		mu:        &sync.Mutex{},
+	}
+
+	assert := assert.New(t)
+	dl.Store(&Decision{TaskID: mockTaskID, PeerID: "foo", CreatedAt: time.Unix(1, 0)})
+	assert.Equal(len(dl.LoadByPeerID("foo")), 1)
+
+	time.Sleep(2 * ttl)
+	assert.Equal(len(dl.LoadByPeerID("foo")), 0)
+	assert.Equal(len(dl.LoadByTaskID(mockTaskID)), 0)
+
+	// The expired decisions are not kept by the new decision.
+	dl.Store(&Decision{TaskID: mockTaskID, PeerID: "foo", CreatedAt: time.Unix(2, 0)})
+	decisions := dl.LoadByPeerID("foo")
+	assert.Equal(len(decisions), 1)
+	assert.Equal(decisions[0].CreatedAt, time.Unix(2, 0))
+}
+
+func TestDecisionLog_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		expect func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "load decisions by peer id",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s?peer_id=foo", DecisionLogPath),
+			expect: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert := assert.New(t)
+				assert.Equal(w.Code, http.StatusOK)
+
+				var decisions []*Decision
+				assert.NoError(json.Unmarshal(w.Body.Bytes(), &decisions))
+				assert.Equal(len(decisions), 1)
+				assert.Equal(decisions[0].PeerID, "foo")
+				assert.Equal(decisions[0].FilteredParents[0].Reason, FilterReasonInBlocklist)
+			},
+		},
+		{
+			name:   "load decisions by task id",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s?task_id=%s", DecisionLogPath, mockTaskID),
+			expect: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert := assert.New(t)
+				assert.Equal(w.Code, http.StatusOK)
+
+				var decisions []*Decision
+				assert.NoError(json.Unmarshal(w.Body.Bytes(), &decisions))
+				assert.Equal(len(decisions), 2)
+			},
+		},
+		{
+			name:   "query parameter is empty",
+			method: http.MethodGet,
+			url:    DecisionLogPath,
+			expect: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert := assert.New(t)
+				assert.Equal(w.Code, http.StatusBadRequest)
+			},
+		},
+		{
+			name:   "method is not allowed",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("%s?peer_id=foo", DecisionLogPath),
+			expect: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert := assert.New(t)
+				assert.Equal(w.Code, http.StatusMethodNotAllowed)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dl := NewDecisionLog(time.Minute, 1)
+			dl.Store(&Decision{
+				TaskID:          mockTaskID,
+				PeerID:          "foo",
+				Blocklist:       []string{"baz"},
+				FilteredParents: []*FilteredParent{{ID: "baz", Reason: FilterReasonInBlocklist}},
+				CreatedAt:       time.Now(),
+			})
+			dl.Store(&Decision{TaskID: mockTaskID, PeerID: "bar", CreatedAt: time.Now()})
+
+			w := httptest.NewRecorder()
+			dl.ServeHTTP(w, httptest.NewRequest(tc.method, tc.url, nil))
+			tc.expect(t, w)
+		})
+	}
+}
+
+func TestScheduling_FindCandidateParentsWithDecisionLog(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+	dynconfig.EXPECT().GetSchedulerClusterConfig().Return(types.SchedulerClusterConfig{}, errors.New("foo")).Times(1)
+
+	mockHost := resource.NewHost(
+		mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+		mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
+	mockTask := resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength))
+	peer := resource.NewPeer(mockPeerID, mockTask, mockHost)
+	peer.FSM.SetState(resource.PeerStateRunning)
+	peer.Task.StorePeer(peer)
+
+	var mockPeers []*resource.Peer
+	for i := 0; i < 2; i++ {
+		mockHost := resource.NewHost(
+			idgen.HostIDV2("127.0.0.1", uuid.New().String()), mockRawHost.IP, mockRawHost.Hostname,
+			mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
+		mockPeer := resource.NewPeer(idgen.PeerIDV1(fmt.Sprintf("127.0.0.%d", i)), mockTask, mockHost)
+		mockPeer.FSM.SetState(resource.PeerStateBackToSource)
+		peer.Task.StorePeer(mockPeer)
+		mockPeers = append(mockPeers, mockPeer)
+	}
+
+	blocklist := set.NewSafeSet[string]()
+	blocklist.Add(mockPeers[0].ID)
+
+	dl := NewDecisionLog(time.Minute, 1)
+	scheduling := New(mockSchedulerConfig, dynconfig, mockPluginDir, dl, nil)
+	parents, found := scheduling.FindCandidateParents(context.Background(), peer, blocklist)
+
+	assert := assert.New(t)
+	assert.True(found)
+	assert.Equal(len(parents), 1)
+
+	decisions := dl.LoadByPeerID(peer.ID)
+	assert.Equal(len(decisions), 1)
+	assert.Equal(decisions[0].TaskID, mockTask.ID)
+	assert.Equal(decisions[0].Blocklist, []string{mockPeers[0].ID})
+
+	reasons := map[string]string{}
+	for _, filteredParent := range decisions[0].FilteredParents {
+		reasons[filteredParent.ID] = filteredParent.Reason
+	}
+	assert.Equal(reasons[mockPeers[0].ID], FilterReasonInBlocklist)
+	assert.Equal(reasons[peer.ID], FilterReasonCanNotAddEdge)
+
+	assert.Equal(len(decisions[0].CandidateParents), 1)
+	assert.Equal(decisions[0].CandidateParents[0].ID, mockPeers[1].ID)
+	assert.Equal(decisions[0].CandidateParents[0].Score, evaluator.NewEvaluatorBase().Evaluate(mockPeers[1], peer, mockTask.TotalPieceCount.Load()))
+	assert.Contains(decisions[0].CandidateParents[0].FeatureScores, evaluator.FeatureFinishedPiece)
+}
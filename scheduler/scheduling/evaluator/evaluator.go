@@ -17,6 +17,9 @@
 package evaluator
 
 import (
+	"time"
+
+	"d7y.io/dragonfly/v2/scheduler/config"
 	"d7y.io/dragonfly/v2/scheduler/resource"
 )
 
@@ -39,16 +42,139 @@ type Evaluator interface {
 	IsBadNode(peer *resource.Peer) bool
 }
 
-func New(algorithm string, pluginDir string) Evaluator {
+const (
+	// FeatureFinishedPiece is the feature of finished piece.
+	FeatureFinishedPiece = "finished_piece"
+
+	// FeatureParentHostUploadSuccess is the feature of parent's host upload success.
+	FeatureParentHostUploadSuccess = "parent_host_upload_success"
+
+	// FeatureFreeUpload is the feature of free upload.
+	FeatureFreeUpload = "free_upload"
+
+	// FeatureHostType is the feature of host type.
+	FeatureHostType = "host_type"
+
+	// FeatureIDCAffinity is the feature of IDC affinity.
+	FeatureIDCAffinity = "idc_affinity"
+
+	// FeatureLocationAffinity is the feature of location affinity.
+	FeatureLocationAffinity = "location_affinity"
+
+	// FeatureRTT is the feature of round-trip time between hosts.
+	FeatureRTT = "rtt"
+
+	// FeatureFreeBandwidth is the feature of free upload bandwidth of parent's host.
+	FeatureFreeBandwidth = "free_bandwidth"
+)
+
+// Explainer is implemented by the evaluator which can explain the evaluation,
+// the evaluator of plugin may not implement it.
+type Explainer interface {
+	// Explain returns the score of each feature used by evaluation.
+	Explain(parent *resource.Peer, child *resource.Peer, taskPieceCount int32) map[string]float64
+}
+
+// NetworkTopology provides the round-trip time between hosts for evaluator.
+type NetworkTopology interface {
+	// AverageRTT returns the average round-trip time from the source host to the destination host.
+	AverageRTT(srcHostID string, destHostID string) (time.Duration, bool)
+}
+
+// Option is a functional option for configuring the evaluator.
+type Option func(o *evaluatorOptions)
+
+type evaluatorOptions struct {
+	// modelDir is the directory of models used by machine learning evaluator.
+	modelDir string
+
+	// networkTopology provides the round-trip time between hosts.
+	networkTopology NetworkTopology
+
+	// weights is the weights of scores used by evaluator.
+	weights config.EvaluatorWeightsConfig
+
+	// dynconfig updates the weights by the config of scheduler cluster.
+	dynconfig config.DynconfigInterface
+
+	// modelFetcher fetches the active model of scheduler used by machine learning evaluator.
+	modelFetcher ModelFetcher
+
+	// preemption counts the upload slots occupied by the children with
+	// lower priority as free upload for the child.
+	preemption bool
+}
+
+// newEvaluatorOptions returns the evaluator options with default weights.
+func newEvaluatorOptions() *evaluatorOptions {
+	return &evaluatorOptions{
+		weights: config.EvaluatorWeightsConfig{
+			FinishedPiece:           config.DefaultEvaluatorFinishedPieceWeight,
+			ParentHostUploadSuccess: config.DefaultEvaluatorParentHostUploadSuccessWeight,
+			FreeUpload:              config.DefaultEvaluatorFreeUploadWeight,
+			HostType:                config.DefaultEvaluatorHostTypeWeight,
+			IDCAffinity:             config.DefaultEvaluatorIDCAffinityWeight,
+			LocationAffinity:        config.DefaultEvaluatorLocationAffinityWeight,
+			RTT:                     config.DefaultEvaluatorRTTWeight,
+		},
+	}
+}
+
+// WithModelDir sets the directory of models used by machine learning evaluator.
+func WithModelDir(dir string) Option {
+	return func(o *evaluatorOptions) {
+		o.modelDir = dir
+	}
+}
+
+// WithNetworkTopology sets the network topology which provides the round-trip time between hosts.
+func WithNetworkTopology(networkTopology NetworkTopology) Option {
+	return func(o *evaluatorOptions) {
+		o.networkTopology = networkTopology
+	}
+}
+
+// WithWeights sets the weights of scores used by evaluator.
+func WithWeights(weights config.EvaluatorWeightsConfig) Option {
+	return func(o *evaluatorOptions) {
+		o.weights = weights
+	}
+}
+
+// WithPreemption sets whether the upload slots occupied by the children
+// with lower priority are counted as free upload for the child.
+func WithPreemption(enable bool) Option {
+	return func(o *evaluatorOptions) {
+		o.preemption = enable
+	}
+}
+
+// WithDynconfig sets the dynconfig which updates the weights by the config of scheduler cluster.
+func WithDynconfig(dynconfig config.DynconfigInterface) Option {
+	return func(o *evaluatorOptions) {
+		o.dynconfig = dynconfig
+	}
+}
+
+// WithModelFetcher sets the model fetcher which fetches the active model of scheduler
+// into the model directory used by machine learning evaluator.
+func WithModelFetcher(modelFetcher ModelFetcher) Option {
+	return func(o *evaluatorOptions) {
+		o.modelFetcher = modelFetcher
+	}
+}
+
+func New(algorithm string, pluginDir string, options ...Option) Evaluator {
 	switch algorithm {
 	case PluginAlgorithm:
 		if plugin, err := LoadPlugin(pluginDir); err == nil {
 			return plugin
 		}
-	// TODO Implement MLAlgorithm.
-	case MLAlgorithm, DefaultAlgorithm:
-		return NewEvaluatorBase()
+	case MLAlgorithm:
+		return NewEvaluatorML(options...)
+	case DefaultAlgorithm:
+		return NewEvaluatorBase(options...)
 	}
 
-	return NewEvaluatorBase()
+	return NewEvaluatorBase(options...)
 }
@@ -17,37 +17,23 @@
 package evaluator
 
 import (
+	gomath "math"
 	"math/big"
 	"strings"
+	"time"
 
 	"github.com/montanaflynn/stats"
+	"go.uber.org/atomic"
+
+	commonv2 "d7y.io/api/pkg/apis/common/v2"
 
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/pkg/math"
 	"d7y.io/dragonfly/v2/pkg/types"
+	"d7y.io/dragonfly/v2/scheduler/config"
 	"d7y.io/dragonfly/v2/scheduler/resource"
 )
 
-const (
-	// Finished piece weight.
-	finishedPieceWeight float64 = 0.2
-
-	// Parent's host upload success weight.
-	parentHostUploadSuccessWeight = 0.2
-
-	// Free upload weight.
-	freeUploadWeight = 0.15
-
-	// Host type weight.
-	hostTypeWeight = 0.15
-
-	// IDC affinity weight.
-	idcAffinityWeight = 0.15
-
-	// Location affinity weight.
-	locationAffinityWeight = 0.15
-)
-
 const (
 	// Maximum score.
 	maxScore float64 = 1
@@ -67,12 +53,80 @@ const (
 
 	// Maximum number of elements.
 	maxElementLen = 5
+
+	// minRTT is the round-trip time that gets the maximum RTT score.
+	minRTT = 100 * time.Microsecond
+
+	// maxRTT is the round-trip time that gets the minimum RTT score.
+	maxRTT = 1 * time.Second
 )
 
-type evaluatorBase struct{}
+type evaluatorBase struct {
+	// networkTopology provides the round-trip time between hosts.
+	networkTopology NetworkTopology
 
-func NewEvaluatorBase() Evaluator {
-	return &evaluatorBase{}
+	// defaultWeights is the weights of scores in the scheduler config.
+	defaultWeights config.EvaluatorWeightsConfig
+
+	// weights is the weights of scores currently being used,
+	// it is overridden by the config of scheduler cluster.
+	weights *atomic.Pointer[config.EvaluatorWeightsConfig]
+
+	// dynconfig provides the config of scheduler cluster.
+	dynconfig config.DynconfigInterface
+
+	// preemption counts the upload slots occupied by the children with
+	// lower priority as free upload for the child.
+	preemption bool
+}
+
+func NewEvaluatorBase(options ...Option) Evaluator {
+	o := newEvaluatorOptions()
+	for _, opt := range options {
+		opt(o)
+	}
+
+	return newEvaluatorBase(o)
+}
+
+// newEvaluatorBase returns a new evaluatorBase, if dynconfig is set,
+// the weights are updated by the config of scheduler cluster.
+func newEvaluatorBase(o *evaluatorOptions) *evaluatorBase {
+	weights := o.weights
+	eb := &evaluatorBase{
+		networkTopology: o.networkTopology,
+		defaultWeights:  o.weights,
+		weights:         atomic.NewPointer(&weights),
+		dynconfig:       o.dynconfig,
+		preemption:      o.preemption,
+	}
+
+	if o.dynconfig != nil {
+		o.dynconfig.Register(eb)
+	}
+
+	return eb
+}
+
+// OnNotify updates the weights by the config of scheduler cluster,
+// and the weights of scheduler config are used if the config is not set or invalid.
+func (eb *evaluatorBase) OnNotify(_ *config.DynconfigData) {
+	weights := eb.defaultWeights
+	if clusterConfig, err := eb.dynconfig.GetSchedulerClusterConfig(); err != nil {
+		logger.Errorf("get scheduler cluster config failed: %s", err.Error())
+	} else if clusterConfig.EvaluatorWeights != nil {
+		clusterWeights := config.EvaluatorWeightsConfig(*clusterConfig.EvaluatorWeights)
+		if err := clusterWeights.Validate(); err != nil {
+			logger.Errorf("invalid evaluator weights of scheduler cluster: %s", err.Error())
+		} else {
+			weights = clusterWeights
+		}
+	}
+
+	if *eb.weights.Load() != weights {
+		logger.Infof("evaluator weights have been updated: %#v", weights)
+		eb.weights.Store(&weights)
+	}
 }
 
 // The larger the value after evaluation, the higher the priority.
@@ -92,14 +146,65 @@ func (eb *evaluatorBase) Evaluate(parent *resource.Peer, child *resource.Peer, t
 		return minScore
 	}
 
-	return finishedPieceWeight*calculatePieceScore(parent, child, totalPieceCount) +
-		parentHostUploadSuccessWeight*calculateParentHostUploadSuccessScore(parent) +
-		freeUploadWeight*calculateFreeUploadScore(parent.Host) +
-		hostTypeWeight*calculateHostTypeScore(parent) +
+	weights := eb.weights.Load()
+
+	// If the round-trip time between hosts is measured, it is more accurate
+	// than the affinity of IDC and location labels, which may be wrong or missing.
+	if rttScore, ok := calculateRTTScoreWithOK(eb.networkTopology, parent.Host, child.Host); ok {
+		return weights.FinishedPiece*calculatePieceScore(parent, child, totalPieceCount) +
+			weights.ParentHostUploadSuccess*calculateParentHostUploadSuccessScore(parent) +
+			weights.FreeUpload*eb.freeUploadScore(parent, child) +
+			weights.HostType*calculateHostTypeScore(parent) +
+			weights.IDCAffinity*calculateIDCAffinityScore(parentIDC, childIDC) +
+			weights.LocationAffinity*calculateMultiElementAffinityScore(parentLocation, childLocation) +
+			weights.RTT*rttScore
+	}
+
+	idcAffinityWeight, locationAffinityWeight := affinityWeightsWithoutRTT(weights)
+	return weights.FinishedPiece*calculatePieceScore(parent, child, totalPieceCount) +
+		weights.ParentHostUploadSuccess*calculateParentHostUploadSuccessScore(parent) +
+		weights.FreeUpload*eb.freeUploadScore(parent, child) +
+		weights.HostType*calculateHostTypeScore(parent) +
 		idcAffinityWeight*calculateIDCAffinityScore(parentIDC, childIDC) +
 		locationAffinityWeight*calculateMultiElementAffinityScore(parentLocation, childLocation)
 }
 
+// affinityWeightsWithoutRTT returns the weights of IDC affinity and location affinity when the
+// round-trip time between hosts is not measured, the RTT weight is transferred to them in proportion
+// to their weights, or equally if both of them are 0, so the sum of weights is still 1.
+func affinityWeightsWithoutRTT(weights *config.EvaluatorWeightsConfig) (float64, float64) {
+	affinityWeight := weights.IDCAffinity + weights.LocationAffinity
+	if affinityWeight <= 0 {
+		return weights.RTT / 2, weights.RTT / 2
+	}
+
+	return weights.IDCAffinity + weights.RTT*weights.IDCAffinity/affinityWeight,
+		weights.LocationAffinity + weights.RTT*weights.LocationAffinity/affinityWeight
+}
+
+// Explain returns the score of each feature used by evaluation, the score of RTT
+// is returned only if the round-trip time between hosts is measured.
+func (eb *evaluatorBase) Explain(parent *resource.Peer, child *resource.Peer, totalPieceCount int32) map[string]float64 {
+	scores := map[string]float64{
+		FeatureFinishedPiece:           calculatePieceScore(parent, child, totalPieceCount),
+		FeatureParentHostUploadSuccess: calculateParentHostUploadSuccessScore(parent),
+		FeatureFreeUpload:              eb.rawFreeUploadScore(parent, child),
+		FeatureHostType:                calculateHostTypeScore(parent),
+		FeatureIDCAffinity:             calculateIDCAffinityScore(parent.Host.Network.IDC, child.Host.Network.IDC),
+		FeatureLocationAffinity:        calculateMultiElementAffinityScore(parent.Host.Network.Location, child.Host.Network.Location),
+	}
+
+	if rttScore, ok := calculateRTTScoreWithOK(eb.networkTopology, parent.Host, child.Host); ok {
+		scores[FeatureRTT] = rttScore
+	}
+
+	if freeBandwidthScore, ok := calculateFreeBandwidthScoreWithOK(parent.Host); ok {
+		scores[FeatureFreeBandwidth] = freeBandwidthScore
+	}
+
+	return scores
+}
+
 // calculatePieceScore 0.0~unlimited larger and better.
 func calculatePieceScore(parent *resource.Peer, child *resource.Peer, totalPieceCount int32) float64 {
 	// If the total piece is determined, normalize the number of
@@ -132,6 +237,55 @@ func calculateParentHostUploadSuccessScore(peer *resource.Peer) float64 {
 	return float64(uploadCount-uploadFailedCount) / float64(uploadCount)
 }
 
+// freeUploadScore returns the free upload score of parent's host for the child.
+// If the upload bandwidth of parent's host is announced, the score is limited by the
+// free bandwidth, so the host with free upload slots but saturated NIC is deprioritized.
+func (eb *evaluatorBase) freeUploadScore(parent *resource.Peer, child *resource.Peer) float64 {
+	score := eb.rawFreeUploadScore(parent, child)
+	if freeBandwidthScore, ok := calculateFreeBandwidthScoreWithOK(parent.Host); ok && freeBandwidthScore < score {
+		return freeBandwidthScore
+	}
+
+	return score
+}
+
+// rawFreeUploadScore returns the free upload score of parent's host for the child by the
+// upload slots only, it is aware of the child's priority if preemption is enabled.
+func (eb *evaluatorBase) rawFreeUploadScore(parent *resource.Peer, child *resource.Peer) float64 {
+	if eb.preemption {
+		return calculatePreemptiveFreeUploadScore(parent.Host, child.Priority)
+	}
+
+	return calculateFreeUploadScore(parent.Host)
+}
+
+// calculateFreeBandwidthScore 0.0~1.0 larger and better, the host whose
+// upload bandwidth is unknown is regarded as having free bandwidth.
+func calculateFreeBandwidthScore(host *resource.Host) float64 {
+	score, ok := calculateFreeBandwidthScoreWithOK(host)
+	if !ok {
+		return maxScore
+	}
+
+	return score
+}
+
+// calculateFreeBandwidthScoreWithOK 0.0~1.0 larger and better, it returns
+// false if the upload bandwidth of host is unknown.
+func calculateFreeBandwidthScoreWithOK(host *resource.Host) (float64, bool) {
+	uploadBandwidth := host.Network.UploadBandwidth
+	if uploadBandwidth == 0 {
+		return minScore, false
+	}
+
+	uploadRate := host.Network.UploadRate
+	if uploadRate >= uploadBandwidth {
+		return minScore, true
+	}
+
+	return float64(uploadBandwidth-uploadRate) / float64(uploadBandwidth), true
+}
+
 // calculateFreeUploadScore 0.0~1.0 larger and better.
 func calculateFreeUploadScore(host *resource.Host) float64 {
 	ConcurrentUploadLimit := host.ConcurrentUploadLimit.Load()
@@ -143,6 +297,22 @@ func calculateFreeUploadScore(host *resource.Host) float64 {
 	return minScore
 }
 
+// calculatePreemptiveFreeUploadScore 0.0~1.0 larger and better, the upload slots
+// occupied by the children with lower priority are counted as free upload.
+func calculatePreemptiveFreeUploadScore(host *resource.Host, priority commonv2.Priority) float64 {
+	concurrentUploadLimit := host.ConcurrentUploadLimit.Load()
+	freeUploadCount := host.FreeUploadCount() + int32(len(host.PreemptibleChildren(priority)))
+	if freeUploadCount > concurrentUploadLimit {
+		freeUploadCount = concurrentUploadLimit
+	}
+
+	if concurrentUploadLimit > 0 && freeUploadCount > 0 {
+		return float64(freeUploadCount) / float64(concurrentUploadLimit)
+	}
+
+	return minScore
+}
+
 // calculateHostTypeScore 0.0~1.0 larger and better.
 func calculateHostTypeScore(peer *resource.Peer) float64 {
 	// When the task is downloaded for the first time,
@@ -200,6 +370,40 @@ func calculateMultiElementAffinityScore(dst, src string) float64 {
 	return float64(score) / float64(maxElementLen)
 }
 
+// calculateRTTScore 0.0~1.0 larger and better.
+func calculateRTTScore(networkTopology NetworkTopology, parent *resource.Host, child *resource.Host) float64 {
+	score, _ := calculateRTTScoreWithOK(networkTopology, parent, child)
+	return score
+}
+
+// calculateRTTScoreWithOK returns the RTT score and whether the round-trip time
+// between hosts is measured.
+func calculateRTTScoreWithOK(networkTopology NetworkTopology, parent *resource.Host, child *resource.Host) (float64, bool) {
+	if networkTopology == nil {
+		return minScore, false
+	}
+
+	// Child host probes parent host, so the round-trip time
+	// is from child host to parent host.
+	rtt, ok := networkTopology.AverageRTT(child.ID, parent.ID)
+	if !ok || rtt <= 0 {
+		return minScore, false
+	}
+
+	if rtt <= minRTT {
+		return maxScore, true
+	}
+
+	if rtt >= maxRTT {
+		return minScore, true
+	}
+
+	// Score decreases logarithmically with round-trip time, because the
+	// difference of round-trip time in the same rack is in microseconds
+	// and the difference between regions is in milliseconds.
+	return maxScore - gomath.Log(float64(rtt)/float64(minRTT))/gomath.Log(float64(maxRTT)/float64(minRTT)), true
+}
+
 func (eb *evaluatorBase) IsBadNode(peer *resource.Peer) bool {
 	if peer.FSM.Is(resource.PeerStateFailed) || peer.FSM.Is(resource.PeerStateLeave) || peer.FSM.Is(resource.PeerStatePending) ||
 		peer.FSM.Is(resource.PeerStateReceivedTiny) || peer.FSM.Is(resource.PeerStateReceivedSmall) ||
@@ -17,18 +17,23 @@
 package evaluator
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/atomic"
 
 	commonv2 "d7y.io/api/pkg/apis/common/v2"
 
+	managertypes "d7y.io/dragonfly/v2/manager/types"
 	"d7y.io/dragonfly/v2/pkg/digest"
 	"d7y.io/dragonfly/v2/pkg/idgen"
 	"d7y.io/dragonfly/v2/pkg/types"
+	"d7y.io/dragonfly/v2/scheduler/config"
+	configmocks "d7y.io/dragonfly/v2/scheduler/config/mocks"
 	"d7y.io/dragonfly/v2/scheduler/resource"
 )
 
@@ -146,23 +151,41 @@ var (
 	mockPeerID                      = idgen.PeerIDV2()
 )
 
+type mockNetworkTopology map[string]time.Duration
+
+func (m mockNetworkTopology) AverageRTT(srcHostID string, destHostID string) (time.Duration, bool) {
+	rtt, ok := m[srcHostID+destHostID]
+	return rtt, ok
+}
+
 func TestEvaluatorBase_NewEvaluatorBase(t *testing.T) {
 	tests := []struct {
-		name   string
-		expect func(t *testing.T, e any)
+		name    string
+		options []Option
+		expect  func(t *testing.T, e any)
 	}{
 		{
 			name: "new evaluator commonv1",
 			expect: func(t *testing.T, e any) {
 				assert := assert.New(t)
 				assert.Equal(reflect.TypeOf(e).Elem().Name(), "evaluatorBase")
+				assert.Nil(e.(*evaluatorBase).networkTopology)
+			},
+		},
+		{
+			name:    "new evaluator with network topology",
+			options: []Option{WithNetworkTopology(mockNetworkTopology{})},
+			expect: func(t *testing.T, e any) {
+				assert := assert.New(t)
+				assert.Equal(reflect.TypeOf(e).Elem().Name(), "evaluatorBase")
+				assert.NotNil(e.(*evaluatorBase).networkTopology)
 			},
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			tc.expect(t, NewEvaluatorBase())
+			tc.expect(t, NewEvaluatorBase(tc.options...))
 		})
 	}
 }
@@ -173,6 +196,7 @@ func TestEvaluatorBase_Evaluate(t *testing.T) {
 		parent          *resource.Peer
 		child           *resource.Peer
 		totalPieceCount int32
+		options         []Option
 		mock            func(parent *resource.Peer, child *resource.Peer)
 		expect          func(t *testing.T, score float64)
 	}{
@@ -221,6 +245,31 @@ func TestEvaluatorBase_Evaluate(t *testing.T) {
 				assert.Equal(score, float64(0.55))
 			},
 		},
+		{
+			name: "parent network interface is saturated",
+			parent: resource.NewPeer(idgen.PeerIDV1("127.0.0.1"),
+				resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength)),
+				resource.NewHost(
+					mockRawSeedHost.ID, mockRawSeedHost.IP, mockRawSeedHost.Hostname,
+					mockRawSeedHost.Port, mockRawSeedHost.DownloadPort, mockRawSeedHost.Type)),
+			child: resource.NewPeer(idgen.PeerIDV1("127.0.0.1"),
+				resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength)),
+				resource.NewHost(
+					mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+					mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)),
+			totalPieceCount: 1,
+			mock: func(parent *resource.Peer, child *resource.Peer) {
+				parent.Host.Network.SecurityDomain = "bac"
+				child.Host.Network.SecurityDomain = "bac"
+				parent.FinishedPieces.Set(0)
+				parent.Host.Network.UploadRate = 100
+				parent.Host.Network.UploadBandwidth = 100
+			},
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.Equal(score, float64(0.4))
+			},
+		},
 		{
 			name: "parent security domain is empty",
 			parent: resource.NewPeer(idgen.PeerIDV1("127.0.0.1"),
@@ -267,17 +316,339 @@ func TestEvaluatorBase_Evaluate(t *testing.T) {
 				assert.Equal(score, float64(0.55))
 			},
 		},
+		{
+			name: "round-trip time is measured",
+			parent: resource.NewPeer(idgen.PeerIDV1("127.0.0.1"),
+				resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength)),
+				resource.NewHost(
+					mockRawSeedHost.ID, mockRawSeedHost.IP, mockRawSeedHost.Hostname,
+					mockRawSeedHost.Port, mockRawSeedHost.DownloadPort, mockRawSeedHost.Type)),
+			child: resource.NewPeer(idgen.PeerIDV1("127.0.0.1"),
+				resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength)),
+				resource.NewHost(
+					mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+					mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)),
+			totalPieceCount: 1,
+			options:         []Option{WithNetworkTopology(mockNetworkTopology{mockHostID + mockSeedHostID: 10 * time.Microsecond})},
+			mock: func(parent *resource.Peer, child *resource.Peer) {
+				parent.FinishedPieces.Set(0)
+			},
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.InDelta(score, float64(0.75), 0.0001)
+			},
+		},
+		{
+			name: "round-trip time is 10 milliseconds",
+			parent: resource.NewPeer(idgen.PeerIDV1("127.0.0.1"),
+				resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength)),
+				resource.NewHost(
+					mockRawSeedHost.ID, mockRawSeedHost.IP, mockRawSeedHost.Hostname,
+					mockRawSeedHost.Port, mockRawSeedHost.DownloadPort, mockRawSeedHost.Type)),
+			child: resource.NewPeer(idgen.PeerIDV1("127.0.0.1"),
+				resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength)),
+				resource.NewHost(
+					mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+					mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)),
+			totalPieceCount: 1,
+			options:         []Option{WithNetworkTopology(mockNetworkTopology{mockHostID + mockSeedHostID: 10 * time.Millisecond})},
+			mock: func(parent *resource.Peer, child *resource.Peer) {
+				parent.FinishedPieces.Set(0)
+			},
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.InDelta(score, float64(0.65), 0.0001)
+			},
+		},
+		{
+			name: "round-trip time is not measured",
+			parent: resource.NewPeer(idgen.PeerIDV1("127.0.0.1"),
+				resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength)),
+				resource.NewHost(
+					mockRawSeedHost.ID, mockRawSeedHost.IP, mockRawSeedHost.Hostname,
+					mockRawSeedHost.Port, mockRawSeedHost.DownloadPort, mockRawSeedHost.Type)),
+			child: resource.NewPeer(idgen.PeerIDV1("127.0.0.1"),
+				resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength)),
+				resource.NewHost(
+					mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+					mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)),
+			totalPieceCount: 1,
+			options:         []Option{WithNetworkTopology(mockNetworkTopology{})},
+			mock: func(parent *resource.Peer, child *resource.Peer) {
+				parent.FinishedPieces.Set(0)
+			},
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.InDelta(score, float64(0.55), 0.0001)
+			},
+		},
+		{
+			name: "evaluate with weights",
+			parent: resource.NewPeer(idgen.PeerIDV1("127.0.0.1"),
+				resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength)),
+				resource.NewHost(
+					mockRawSeedHost.ID, mockRawSeedHost.IP, mockRawSeedHost.Hostname,
+					mockRawSeedHost.Port, mockRawSeedHost.DownloadPort, mockRawSeedHost.Type)),
+			child: resource.NewPeer(idgen.PeerIDV1("127.0.0.1"),
+				resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength)),
+				resource.NewHost(
+					mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+					mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)),
+			totalPieceCount: 1,
+			options: []Option{WithWeights(config.EvaluatorWeightsConfig{
+				FinishedPiece:    0.5,
+				FreeUpload:       0.3,
+				LocationAffinity: 0.2,
+			})},
+			mock: func(parent *resource.Peer, child *resource.Peer) {
+				parent.FinishedPieces.Set(0)
+			},
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.InDelta(score, float64(0.8), 0.0001)
+			},
+		},
+		{
+			name: "evaluate with rtt weight",
+			parent: resource.NewPeer(idgen.PeerIDV1("127.0.0.1"),
+				resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength)),
+				resource.NewHost(
+					mockRawSeedHost.ID, mockRawSeedHost.IP, mockRawSeedHost.Hostname,
+					mockRawSeedHost.Port, mockRawSeedHost.DownloadPort, mockRawSeedHost.Type)),
+			child: resource.NewPeer(idgen.PeerIDV1("127.0.0.1"),
+				resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength)),
+				resource.NewHost(
+					mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+					mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)),
+			totalPieceCount: 1,
+			options: []Option{
+				WithNetworkTopology(mockNetworkTopology{mockHostID + mockSeedHostID: 10 * time.Microsecond}),
+				WithWeights(config.EvaluatorWeightsConfig{
+					FinishedPiece: 0.5,
+					HostType:      0.2,
+					RTT:           0.3,
+				}),
+			},
+			mock: func(parent *resource.Peer, child *resource.Peer) {
+				parent.FinishedPieces.Set(0)
+			},
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.InDelta(score, float64(0.8), 0.0001)
+			},
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			eb := NewEvaluatorBase()
+			eb := NewEvaluatorBase(tc.options...)
 			tc.mock(tc.parent, tc.child)
 			tc.expect(t, eb.Evaluate(tc.parent, tc.child, tc.totalPieceCount))
 		})
 	}
 }
 
+func TestEvaluatorBase_Explain(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		mock    func(parent *resource.Peer)
+		expect  func(t *testing.T, scores map[string]float64)
+	}{
+		{
+			name:    "explain without round-trip time",
+			options: []Option{},
+			mock:    func(parent *resource.Peer) {},
+			expect: func(t *testing.T, scores map[string]float64) {
+				assert := assert.New(t)
+				assert.Equal(scores, map[string]float64{
+					FeatureFinishedPiece:           1,
+					FeatureParentHostUploadSuccess: 1,
+					FeatureFreeUpload:              1,
+					FeatureHostType:                0,
+					FeatureIDCAffinity:             0,
+					FeatureLocationAffinity:        0,
+				})
+			},
+		},
+		{
+			name:    "explain with round-trip time",
+			options: []Option{WithNetworkTopology(mockNetworkTopology{mockHostID + mockSeedHostID: 10 * time.Microsecond})},
+			mock:    func(parent *resource.Peer) {},
+			expect: func(t *testing.T, scores map[string]float64) {
+				assert := assert.New(t)
+				assert.Equal(len(scores), 7)
+				assert.Equal(scores[FeatureRTT], float64(1))
+			},
+		},
+		{
+			name:    "explain with upload bandwidth",
+			options: []Option{},
+			mock: func(parent *resource.Peer) {
+				parent.Host.Network.UploadRate = 75
+				parent.Host.Network.UploadBandwidth = 100
+			},
+			expect: func(t *testing.T, scores map[string]float64) {
+				assert := assert.New(t)
+				assert.Equal(len(scores), 7)
+				assert.Equal(scores[FeatureFreeBandwidth], float64(0.25))
+				assert.Equal(scores[FeatureFreeUpload], float64(1))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockTask := resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength))
+			parent := resource.NewPeer(idgen.PeerIDV1("127.0.0.1"), mockTask, resource.NewHost(
+				mockRawSeedHost.ID, mockRawSeedHost.IP, mockRawSeedHost.Hostname,
+				mockRawSeedHost.Port, mockRawSeedHost.DownloadPort, mockRawSeedHost.Type))
+			child := resource.NewPeer(idgen.PeerIDV1("127.0.0.1"), mockTask, resource.NewHost(
+				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type))
+			parent.FinishedPieces.Set(0)
+			tc.mock(parent)
+
+			eb := NewEvaluatorBase(tc.options...)
+			tc.expect(t, eb.(Explainer).Explain(parent, child, 1))
+		})
+	}
+}
+
+func TestEvaluatorBase_OnNotify(t *testing.T) {
+	mockWeights := config.EvaluatorWeightsConfig{
+		FinishedPiece:           0.3,
+		ParentHostUploadSuccess: 0.2,
+		FreeUpload:              0.2,
+		HostType:                0.1,
+		IDCAffinity:             0.05,
+		LocationAffinity:        0.05,
+		RTT:                     0.1,
+	}
+
+	tests := []struct {
+		name   string
+		mock   func(md *configmocks.MockDynconfigInterfaceMockRecorder)
+		expect func(t *testing.T, weights config.EvaluatorWeightsConfig)
+	}{
+		{
+			name: "scheduler cluster config has evaluator weights",
+			mock: func(md *configmocks.MockDynconfigInterfaceMockRecorder) {
+				md.GetSchedulerClusterConfig().Return(managertypes.SchedulerClusterConfig{
+					EvaluatorWeights: &managertypes.SchedulerClusterEvaluatorWeights{
+						FinishedPiece:           0.3,
+						ParentHostUploadSuccess: 0.2,
+						FreeUpload:              0.2,
+						HostType:                0.1,
+						IDCAffinity:             0.05,
+						LocationAffinity:        0.05,
+						RTT:                     0.1,
+					},
+				}, nil).Times(1)
+			},
+			expect: func(t *testing.T, weights config.EvaluatorWeightsConfig) {
+				assert := assert.New(t)
+				assert.Equal(weights, mockWeights)
+			},
+		},
+		{
+			name: "scheduler cluster config has invalid evaluator weights",
+			mock: func(md *configmocks.MockDynconfigInterfaceMockRecorder) {
+				md.GetSchedulerClusterConfig().Return(managertypes.SchedulerClusterConfig{
+					EvaluatorWeights: &managertypes.SchedulerClusterEvaluatorWeights{
+						FinishedPiece:           0.9,
+						ParentHostUploadSuccess: 0.2,
+					},
+				}, nil).Times(1)
+			},
+			expect: func(t *testing.T, weights config.EvaluatorWeightsConfig) {
+				assert := assert.New(t)
+				assert.Equal(weights.FinishedPiece, config.DefaultEvaluatorFinishedPieceWeight)
+			},
+		},
+		{
+			name: "scheduler cluster config has no evaluator weights",
+			mock: func(md *configmocks.MockDynconfigInterfaceMockRecorder) {
+				md.GetSchedulerClusterConfig().Return(managertypes.SchedulerClusterConfig{FilterParentLimit: 4}, nil).Times(1)
+			},
+			expect: func(t *testing.T, weights config.EvaluatorWeightsConfig) {
+				assert := assert.New(t)
+				assert.Equal(weights.FinishedPiece, config.DefaultEvaluatorFinishedPieceWeight)
+			},
+		},
+		{
+			name: "get scheduler cluster config failed",
+			mock: func(md *configmocks.MockDynconfigInterfaceMockRecorder) {
+				md.GetSchedulerClusterConfig().Return(managertypes.SchedulerClusterConfig{}, errors.New("foo")).Times(1)
+			},
+			expect: func(t *testing.T, weights config.EvaluatorWeightsConfig) {
+				assert := assert.New(t)
+				assert.Equal(weights.FinishedPiece, config.DefaultEvaluatorFinishedPieceWeight)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			dynconfig.EXPECT().Register(gomock.Any()).Times(1)
+			tc.mock(dynconfig.EXPECT())
+
+			eb := NewEvaluatorBase(WithDynconfig(dynconfig)).(*evaluatorBase)
+			eb.OnNotify(&config.DynconfigData{})
+			tc.expect(t, *eb.weights.Load())
+		})
+	}
+}
+
+func TestEvaluatorBase_affinityWeightsWithoutRTT(t *testing.T) {
+	tests := []struct {
+		name                   string
+		weights                config.EvaluatorWeightsConfig
+		idcAffinityWeight      float64
+		locationAffinityWeight float64
+	}{
+		{
+			name: "rtt weight is transferred in proportion",
+			weights: config.EvaluatorWeightsConfig{
+				IDCAffinity:      0.05,
+				LocationAffinity: 0.15,
+				RTT:              0.2,
+			},
+			idcAffinityWeight:      0.1,
+			locationAffinityWeight: 0.3,
+		},
+		{
+			name: "rtt weight is transferred equally",
+			weights: config.EvaluatorWeightsConfig{
+				RTT: 0.2,
+			},
+			idcAffinityWeight:      0.1,
+			locationAffinityWeight: 0.1,
+		},
+		{
+			name: "rtt weight is 0",
+			weights: config.EvaluatorWeightsConfig{
+				IDCAffinity:      0.05,
+				LocationAffinity: 0.15,
+			},
+			idcAffinityWeight:      0.05,
+			locationAffinityWeight: 0.15,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			idcAffinityWeight, locationAffinityWeight := affinityWeightsWithoutRTT(&tc.weights)
+			assert.InDelta(idcAffinityWeight, tc.idcAffinityWeight, 0.0001)
+			assert.InDelta(locationAffinityWeight, tc.locationAffinityWeight, 0.0001)
+		})
+	}
+}
+
 func TestEvaluatorBase_calculatePieceScore(t *testing.T) {
 	mockHost := resource.NewHost(
 		mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
@@ -490,6 +861,152 @@ func TestEvaluatorBase_calculateFreeUploadScore(t *testing.T) {
 	}
 }
 
+func TestEvaluatorBase_calculateFreeBandwidthScoreWithOK(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(host *resource.Host)
+		expect func(t *testing.T, score float64, ok bool)
+	}{
+		{
+			name: "upload bandwidth is unknown",
+			mock: func(host *resource.Host) {
+				host.Network.UploadRate = 100
+			},
+			expect: func(t *testing.T, score float64, ok bool) {
+				assert := assert.New(t)
+				assert.False(ok)
+				assert.Equal(score, float64(0))
+			},
+		},
+		{
+			name: "upload bandwidth is free",
+			mock: func(host *resource.Host) {
+				host.Network.UploadBandwidth = 100
+			},
+			expect: func(t *testing.T, score float64, ok bool) {
+				assert := assert.New(t)
+				assert.True(ok)
+				assert.Equal(score, float64(1))
+			},
+		},
+		{
+			name: "upload bandwidth is partially used",
+			mock: func(host *resource.Host) {
+				host.Network.UploadRate = 40
+				host.Network.UploadBandwidth = 100
+			},
+			expect: func(t *testing.T, score float64, ok bool) {
+				assert := assert.New(t)
+				assert.True(ok)
+				assert.Equal(score, float64(0.6))
+			},
+		},
+		{
+			name: "upload bandwidth is saturated",
+			mock: func(host *resource.Host) {
+				host.Network.UploadRate = 120
+				host.Network.UploadBandwidth = 100
+			},
+			expect: func(t *testing.T, score float64, ok bool) {
+				assert := assert.New(t)
+				assert.True(ok)
+				assert.Equal(score, float64(0))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			host := resource.NewHost(
+				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
+			tc.mock(host)
+			score, ok := calculateFreeBandwidthScoreWithOK(host)
+			tc.expect(t, score, ok)
+		})
+	}
+}
+
+func TestEvaluatorBase_calculatePreemptiveFreeUploadScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int32
+		priority commonv2.Priority
+		expect   func(t *testing.T, score float64)
+	}{
+		{
+			name:     "upload slots of children with lower priority are free",
+			limit:    50,
+			priority: commonv2.Priority_LEVEL6,
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.Equal(score, float64(1))
+			},
+		},
+		{
+			name:     "upload slots of children with the same priority are not free",
+			limit:    50,
+			priority: commonv2.Priority_LEVEL3,
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.Equal(score, float64(0.96))
+			},
+		},
+		{
+			name:     "host has no free upload and children with lower priority",
+			limit:    2,
+			priority: commonv2.Priority_LEVEL3,
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.Equal(score, float64(0))
+			},
+		},
+		{
+			name:     "host has no free upload but children with lower priority",
+			limit:    2,
+			priority: commonv2.Priority_LEVEL6,
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.Equal(score, float64(1))
+			},
+		},
+		{
+			name:     "default priority ranks as the highest priority",
+			limit:    2,
+			priority: commonv2.Priority_LEVEL0,
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.Equal(score, float64(1))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			host := resource.NewHost(
+				mockRawSeedHost.ID, mockRawSeedHost.IP, mockRawSeedHost.Hostname,
+				mockRawSeedHost.Port, mockRawSeedHost.DownloadPort, mockRawSeedHost.Type,
+				resource.WithConcurrentUploadLimit(tc.limit))
+			childHost := resource.NewHost(
+				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
+			mockTask := resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength))
+			parent := resource.NewPeer(idgen.PeerIDV2(), mockTask, host)
+			mockTask.StorePeer(parent)
+			host.StorePeer(parent)
+			for i := 0; i < 2; i++ {
+				child := resource.NewPeer(idgen.PeerIDV2(), mockTask, childHost, resource.WithPriority(commonv2.Priority_LEVEL3))
+				mockTask.StorePeer(child)
+				if err := mockTask.AddPeerEdge(parent, child); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tc.expect(t, calculatePreemptiveFreeUploadScore(host, tc.priority))
+		})
+	}
+}
+
 func TestEvaluatorBase_calculateHostTypeScore(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -740,6 +1257,68 @@ func TestEvaluatorBase_calculateMultiElementAffinityScore(t *testing.T) {
 	}
 }
 
+func TestEvaluatorBase_calculateRTTScore(t *testing.T) {
+	mockHost := resource.NewHost(
+		mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+		mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
+	mockSeedHost := resource.NewHost(
+		mockRawSeedHost.ID, mockRawSeedHost.IP, mockRawSeedHost.Hostname,
+		mockRawSeedHost.Port, mockRawSeedHost.DownloadPort, mockRawSeedHost.Type)
+
+	tests := []struct {
+		name            string
+		networkTopology NetworkTopology
+		expect          func(t *testing.T, score float64)
+	}{
+		{
+			name:            "network topology is nil",
+			networkTopology: nil,
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.Equal(score, float64(0))
+			},
+		},
+		{
+			name:            "rtt not found",
+			networkTopology: mockNetworkTopology{},
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.Equal(score, float64(0))
+			},
+		},
+		{
+			name:            "rtt is less than minimum rtt",
+			networkTopology: mockNetworkTopology{mockHostID + mockSeedHostID: 10 * time.Microsecond},
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.Equal(score, float64(1))
+			},
+		},
+		{
+			name:            "rtt is greater than maximum rtt",
+			networkTopology: mockNetworkTopology{mockHostID + mockSeedHostID: 2 * time.Second},
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.Equal(score, float64(0))
+			},
+		},
+		{
+			name:            "rtt is 10 milliseconds",
+			networkTopology: mockNetworkTopology{mockHostID + mockSeedHostID: 10 * time.Millisecond},
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.InDelta(score, 0.5, 0.0001)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expect(t, calculateRTTScore(tc.networkTopology, mockSeedHost, mockHost))
+		})
+	}
+}
+
 func TestEvaluatorBase_IsBadNode(t *testing.T) {
 	mockHost := resource.NewHost(
 		mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
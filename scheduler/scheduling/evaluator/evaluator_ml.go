@@ -0,0 +1,466 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package evaluator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/atomic"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/manager/models"
+	"d7y.io/dragonfly/v2/scheduler/config"
+	"d7y.io/dragonfly/v2/scheduler/resource"
+)
+
+const (
+	// ActivationReLU is the rectified linear unit activation.
+	ActivationReLU = "relu"
+
+	// ActivationSigmoid is the sigmoid activation.
+	ActivationSigmoid = "sigmoid"
+
+	// ActivationLinear is the identity activation.
+	ActivationLinear = "linear"
+)
+
+const (
+	// ModelDirName is the directory name of models in the data directory.
+	ModelDirName = "models"
+)
+
+const (
+	// modelFileExt is the extension of the model file.
+	modelFileExt = ".json"
+
+	// activeModelFileName is the file name of the active model fetched from manager.
+	activeModelFileName = "active" + modelFileExt
+
+	// modelFetchTimeout is the timeout of fetching the active model from manager.
+	modelFetchTimeout = 1 * time.Minute
+
+	// featureLen is the number of features used by model,
+	// including finished piece, parent's host upload success, free upload,
+	// host type, IDC affinity, location affinity and RTT.
+	featureLen = 7
+
+	// featureWithFreeBandwidthLen is the number of features used by model
+	// trained with free bandwidth, the free bandwidth is the last feature.
+	featureWithFreeBandwidthLen = featureLen + 1
+)
+
+// Model is the model trained by the trainer, the metadata is
+// the same as models.Model in manager.
+type Model struct {
+	// ID is the id of model.
+	ID uint `json:"id"`
+
+	// Type is the type of model, only mlp is supported for inference.
+	Type string `json:"type"`
+
+	// BIO is the biography of model.
+	BIO string `json:"bio"`
+
+	// Version is the version of model.
+	Version string `json:"version"`
+
+	// State is the state of model.
+	State string `json:"state"`
+
+	// Evaluation is the evaluation metrics of model.
+	Evaluation map[string]any `json:"evaluation"`
+
+	// SchedulerID is the id of the scheduler which the model belongs to.
+	SchedulerID uint `json:"scheduler_id"`
+
+	// Layers are the fully connected layers of mlp.
+	Layers []Layer `json:"layers"`
+
+	// CreatedAt is the creation time of model.
+	CreatedAt time.Time `json:"created_at"`
+
+	// UpdatedAt is the update time of model.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Layer is the fully connected layer of mlp.
+type Layer struct {
+	// Weights is the weight matrix, the row length is the number of outputs
+	// and the column length is the number of inputs.
+	Weights [][]float64 `json:"weights"`
+
+	// Biases is the bias vector whose length is the number of outputs.
+	Biases []float64 `json:"biases"`
+
+	// Activation is the activation function of the layer.
+	Activation string `json:"activation"`
+}
+
+// LoadModel loads the latest active model in the directory.
+func LoadModel(dir string) (*Model, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, fmt.Sprintf("*%s", modelFileExt)))
+	if err != nil {
+		return nil, err
+	}
+
+	var model *Model
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		m := &Model{}
+		if err := json.Unmarshal(b, m); err != nil {
+			return nil, fmt.Errorf("unmarshal model %s failed: %w", path, err)
+		}
+
+		if m.State != models.ModelVersionStateActive {
+			continue
+		}
+
+		if model == nil || m.UpdatedAt.After(model.UpdatedAt) {
+			model = m
+		}
+	}
+
+	if model == nil {
+		return nil, errors.New("active model not found")
+	}
+
+	if err := model.validate(); err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+// validate checks the type and dimensions of the model.
+func (m *Model) validate() error {
+	if m.Type != models.ModelTypeMLP {
+		return fmt.Errorf("model type %s is not supported", m.Type)
+	}
+
+	if len(m.Layers) == 0 {
+		return errors.New("model has no layers")
+	}
+
+	inputLen := m.inputLen()
+	if inputLen != featureLen && inputLen != featureWithFreeBandwidthLen {
+		return fmt.Errorf("layer 0 requires %d or %d inputs", featureLen, featureWithFreeBandwidthLen)
+	}
+
+	for i, layer := range m.Layers {
+		if len(layer.Weights) == 0 || len(layer.Weights) != len(layer.Biases) {
+			return fmt.Errorf("layer %d weights and biases do not match", i)
+		}
+
+		for _, weights := range layer.Weights {
+			if len(weights) != inputLen {
+				return fmt.Errorf("layer %d requires %d inputs", i, inputLen)
+			}
+		}
+
+		switch layer.Activation {
+		case ActivationReLU, ActivationSigmoid, ActivationLinear, "":
+		default:
+			return fmt.Errorf("layer %d activation %s is not supported", i, layer.Activation)
+		}
+
+		inputLen = len(layer.Biases)
+	}
+
+	if inputLen != 1 {
+		return errors.New("model output must be a single value")
+	}
+
+	return nil
+}
+
+// inputLen returns the number of model inputs, which is the version of features.
+func (m *Model) inputLen() int {
+	if len(m.Layers) == 0 || len(m.Layers[0].Weights) == 0 {
+		return 0
+	}
+
+	return len(m.Layers[0].Weights[0])
+}
+
+// Predict calculates the score of features by forward propagation.
+func (m *Model) Predict(features []float64) float64 {
+	inputs := features
+	for _, layer := range m.Layers {
+		outputs := make([]float64, len(layer.Biases))
+		for i, weights := range layer.Weights {
+			output := layer.Biases[i]
+			for j, weight := range weights {
+				output += weight * inputs[j]
+			}
+
+			switch layer.Activation {
+			case ActivationReLU:
+				output = math.Max(output, 0)
+			case ActivationSigmoid:
+				output = 1 / (1 + math.Exp(-output))
+			}
+
+			outputs[i] = output
+		}
+
+		inputs = outputs
+	}
+
+	return inputs[0]
+}
+
+type evaluatorML struct {
+	*evaluatorBase
+
+	// modelDir is the directory of models.
+	modelDir string
+
+	// model is the active model, if model is nil,
+	// evaluator uses the rule-based score.
+	model *atomic.Pointer[Model]
+
+	// modifiedAt is the latest modification time of the model files
+	// when the model is loaded, the model is reloaded only if it changes.
+	modifiedAt *atomic.Time
+
+	// refreshing indicates whether the model is being reloaded.
+	refreshing *atomic.Bool
+
+	// modelFetcher fetches the active model of scheduler from manager.
+	modelFetcher ModelFetcher
+
+	// modelETag is the etag of the active model fetched from manager,
+	// it is only accessed by the refreshing goroutine.
+	modelETag string
+}
+
+// NewEvaluatorML returns a new machine learning evaluator,
+// it falls back to the rule-based score when no model is loaded.
+// If dynconfig is set, the model is reloaded in the background
+// when the active model in the model directory changes, and the
+// active model of scheduler is fetched into the model directory
+// first if the model fetcher is set.
+func NewEvaluatorML(options ...Option) Evaluator {
+	o := newEvaluatorOptions()
+	for _, opt := range options {
+		opt(o)
+	}
+
+	e := &evaluatorML{
+		evaluatorBase: newEvaluatorBase(o),
+		modelDir:      o.modelDir,
+		model:         atomic.NewPointer[Model](nil),
+		modifiedAt:    atomic.NewTime(time.Time{}),
+		refreshing:    atomic.NewBool(false),
+		modelFetcher:  o.modelFetcher,
+	}
+	e.refresh()
+
+	if o.dynconfig != nil {
+		o.dynconfig.Register(e)
+	}
+
+	return e
+}
+
+// OnNotify fetches and reloads the active model in the background, so that
+// the network and file I/O are not on the path of scheduling.
+func (e *evaluatorML) OnNotify(data *config.DynconfigData) {
+	if !e.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer e.refreshing.Store(false)
+		if data != nil && data.Scheduler != nil {
+			e.fetch(data.Scheduler.Id)
+		}
+
+		e.refresh()
+	}()
+}
+
+// The larger the value after evaluation, the higher the priority.
+func (e *evaluatorML) Evaluate(parent *resource.Peer, child *resource.Peer, totalPieceCount int32) float64 {
+	// If the SecurityDomain of hosts exists but is not equal,
+	// it cannot be scheduled as a parent.
+	if parent.Host.Network.SecurityDomain != "" &&
+		child.Host.Network.SecurityDomain != "" &&
+		parent.Host.Network.SecurityDomain != child.Host.Network.SecurityDomain {
+		return minScore
+	}
+
+	model := e.model.Load()
+	if model == nil {
+		return e.evaluatorBase.Evaluate(parent, child, totalPieceCount)
+	}
+
+	return model.Predict(e.features(parent, child, totalPieceCount, model.inputLen()))
+}
+
+// features returns the feature vector of parent and child, the order of features
+// is the same as the order of the model inputs. The free upload is the raw score of
+// upload slots which the models are trained with, and the free bandwidth is appended
+// only for the models trained with it.
+func (e *evaluatorML) features(parent *resource.Peer, child *resource.Peer, totalPieceCount int32, inputLen int) []float64 {
+	features := []float64{
+		calculatePieceScore(parent, child, totalPieceCount),
+		calculateParentHostUploadSuccessScore(parent),
+		e.rawFreeUploadScore(parent, child),
+		calculateHostTypeScore(parent),
+		calculateIDCAffinityScore(parent.Host.Network.IDC, child.Host.Network.IDC),
+		calculateMultiElementAffinityScore(parent.Host.Network.Location, child.Host.Network.Location),
+		calculateRTTScore(e.networkTopology, parent.Host, child.Host),
+	}
+
+	if inputLen == featureWithFreeBandwidthLen {
+		features = append(features, calculateFreeBandwidthScore(parent.Host))
+	}
+
+	return features
+}
+
+// refresh reloads the active model from the model directory if the model files
+// are modified, and keeps the previous model if loading fails.
+func (e *evaluatorML) refresh() {
+	if e.modelDir == "" {
+		return
+	}
+
+	modifiedAt, err := modelModifiedAt(e.modelDir)
+	if err != nil {
+		logger.Warnf("stat models in %s failed: %s", e.modelDir, err.Error())
+		return
+	}
+
+	if !modifiedAt.After(e.modifiedAt.Load()) {
+		return
+	}
+
+	model, err := LoadModel(e.modelDir)
+	if err != nil {
+		logger.Warnf("load model from %s failed: %s", e.modelDir, err.Error())
+		return
+	}
+
+	e.modifiedAt.Store(modifiedAt)
+	e.model.Store(model)
+	logger.Infof("load model %d version %s from %s", model.ID, model.Version, e.modelDir)
+}
+
+// fetch fetches the active model of scheduler from manager and writes it into
+// the model directory, the model is written only if it is modified and valid.
+func (e *evaluatorML) fetch(schedulerID uint64) {
+	if e.modelFetcher == nil || e.modelDir == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), modelFetchTimeout)
+	defer cancel()
+
+	data, etag, err := e.modelFetcher.Fetch(ctx, schedulerID, e.modelETag)
+	if err != nil {
+		logger.Warnf("fetch model of scheduler %d failed: %s", schedulerID, err.Error())
+		return
+	}
+
+	if data == nil {
+		return
+	}
+
+	model := &Model{}
+	if err := json.Unmarshal(data, model); err != nil {
+		logger.Warnf("unmarshal model of scheduler %d failed: %s", schedulerID, err.Error())
+		return
+	}
+
+	if model.State != models.ModelVersionStateActive {
+		logger.Warnf("model %d of scheduler %d is %s", model.ID, schedulerID, model.State)
+		return
+	}
+
+	if err := model.validate(); err != nil {
+		logger.Warnf("validate model %d of scheduler %d failed: %s", model.ID, schedulerID, err.Error())
+		return
+	}
+
+	if err := writeModel(e.modelDir, data); err != nil {
+		logger.Warnf("write model %d of scheduler %d failed: %s", model.ID, schedulerID, err.Error())
+		return
+	}
+
+	e.modelETag = etag
+	logger.Infof("fetch model %d version %s of scheduler %d", model.ID, model.Version, schedulerID)
+}
+
+// writeModel writes the active model into the model directory, the model file is
+// renamed from a temporary file so that the loading never reads a partial model.
+func writeModel(dir string, data []byte) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, fmt.Sprintf("%s.*", activeModelFileName))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), filepath.Join(dir, activeModelFileName))
+}
+
+// modelModifiedAt returns the latest modification time of the model files in the directory.
+func modelModifiedAt(dir string) (time.Time, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, fmt.Sprintf("*%s", modelFileExt)))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var modifiedAt time.Time
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if info.ModTime().After(modifiedAt) {
+			modifiedAt = info.ModTime()
+		}
+	}
+
+	return modifiedAt, nil
+}
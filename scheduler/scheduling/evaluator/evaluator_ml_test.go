@@ -0,0 +1,464 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package evaluator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	commonv2 "d7y.io/api/pkg/apis/common/v2"
+
+	"d7y.io/dragonfly/v2/manager/models"
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	"d7y.io/dragonfly/v2/scheduler/config"
+	configmocks "d7y.io/dragonfly/v2/scheduler/config/mocks"
+	"d7y.io/dragonfly/v2/scheduler/resource"
+)
+
+var mockModelDir = "./testdata/models"
+
+func TestEvaluatorML_NewEvaluatorML(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		expect  func(t *testing.T, e any)
+	}{
+		{
+			name:    "new evaluator with model",
+			options: []Option{WithModelDir(mockModelDir)},
+			expect: func(t *testing.T, e any) {
+				assert := assert.New(t)
+				assert.Equal(reflect.TypeOf(e).Elem().Name(), "evaluatorML")
+				assert.NotNil(e.(*evaluatorML).model.Load())
+				assert.Equal(e.(*evaluatorML).model.Load().Type, models.ModelTypeMLP)
+			},
+		},
+		{
+			name:    "new evaluator without model",
+			options: []Option{},
+			expect: func(t *testing.T, e any) {
+				assert := assert.New(t)
+				assert.Equal(reflect.TypeOf(e).Elem().Name(), "evaluatorML")
+				assert.Nil(e.(*evaluatorML).model.Load())
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expect(t, NewEvaluatorML(tc.options...))
+		})
+	}
+}
+
+func TestEvaluatorML_Evaluate(t *testing.T) {
+	tests := []struct {
+		name            string
+		options         []Option
+		totalPieceCount int32
+		mock            func(parent *resource.Peer, child *resource.Peer)
+		expect          func(t *testing.T, score float64, parent *resource.Peer, child *resource.Peer)
+	}{
+		{
+			name:            "security domain is not the same",
+			options:         []Option{WithModelDir(mockModelDir)},
+			totalPieceCount: 1,
+			mock: func(parent *resource.Peer, child *resource.Peer) {
+				parent.Host.Network.SecurityDomain = "foo"
+				child.Host.Network.SecurityDomain = "bar"
+			},
+			expect: func(t *testing.T, score float64, parent *resource.Peer, child *resource.Peer) {
+				assert := assert.New(t)
+				assert.Equal(score, float64(0))
+			},
+		},
+		{
+			name:            "evaluate without model",
+			options:         []Option{},
+			totalPieceCount: 1,
+			mock:            func(parent *resource.Peer, child *resource.Peer) {},
+			expect: func(t *testing.T, score float64, parent *resource.Peer, child *resource.Peer) {
+				assert := assert.New(t)
+				assert.Equal(score, NewEvaluatorBase().Evaluate(parent, child, 1))
+			},
+		},
+		{
+			name:            "evaluate with model",
+			options:         []Option{WithModelDir(mockModelDir)},
+			totalPieceCount: 1,
+			mock:            func(parent *resource.Peer, child *resource.Peer) {},
+			expect: func(t *testing.T, score float64, parent *resource.Peer, child *resource.Peer) {
+				assert := assert.New(t)
+				assert.Greater(score, float64(0))
+				assert.Less(score, float64(1))
+				assert.NotEqual(score, NewEvaluatorBase().Evaluate(parent, child, 1))
+			},
+		},
+		{
+			name: "evaluate with model and rtt",
+			options: []Option{
+				WithModelDir(mockModelDir),
+				WithNetworkTopology(mockNetworkTopology{mockHostID + mockSeedHostID: time.Millisecond}),
+			},
+			totalPieceCount: 1,
+			mock:            func(parent *resource.Peer, child *resource.Peer) {},
+			expect: func(t *testing.T, score float64, parent *resource.Peer, child *resource.Peer) {
+				assert := assert.New(t)
+				e := NewEvaluatorML(WithModelDir(mockModelDir))
+				assert.Greater(score, e.Evaluate(parent, child, 1))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSeedHost := resource.NewHost(
+				mockRawSeedHost.ID, mockRawSeedHost.IP, mockRawSeedHost.Hostname,
+				mockRawSeedHost.Port, mockRawSeedHost.DownloadPort, mockRawSeedHost.Type)
+			mockHost := resource.NewHost(
+				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
+			mockTask := resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength))
+			parent := resource.NewPeer(idgen.PeerIDV1("127.0.0.1"), mockTask, mockSeedHost)
+			child := resource.NewPeer(idgen.PeerIDV1("127.0.0.1"), mockTask, mockHost)
+
+			e := NewEvaluatorML(tc.options...)
+			tc.mock(parent, child)
+			tc.expect(t, e.Evaluate(parent, child, tc.totalPieceCount), parent, child)
+		})
+	}
+}
+
+func TestEvaluatorML_features(t *testing.T) {
+	tests := []struct {
+		name     string
+		inputLen int
+		mock     func(parent *resource.Peer)
+		expect   func(t *testing.T, features []float64)
+	}{
+		{
+			name:     "features without free bandwidth",
+			inputLen: featureLen,
+			mock: func(parent *resource.Peer) {
+				parent.Host.Network.UploadRate = 75
+				parent.Host.Network.UploadBandwidth = 100
+			},
+			expect: func(t *testing.T, features []float64) {
+				assert := assert.New(t)
+				assert.Equal(len(features), featureLen)
+				assert.Equal(features[2], float64(1))
+			},
+		},
+		{
+			name:     "features with free bandwidth",
+			inputLen: featureWithFreeBandwidthLen,
+			mock: func(parent *resource.Peer) {
+				parent.Host.Network.UploadRate = 75
+				parent.Host.Network.UploadBandwidth = 100
+			},
+			expect: func(t *testing.T, features []float64) {
+				assert := assert.New(t)
+				assert.Equal(len(features), featureWithFreeBandwidthLen)
+				assert.Equal(features[2], float64(1))
+				assert.Equal(features[7], float64(0.25))
+			},
+		},
+		{
+			name:     "features with unknown bandwidth",
+			inputLen: featureWithFreeBandwidthLen,
+			mock:     func(parent *resource.Peer) {},
+			expect: func(t *testing.T, features []float64) {
+				assert := assert.New(t)
+				assert.Equal(len(features), featureWithFreeBandwidthLen)
+				assert.Equal(features[7], float64(1))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSeedHost := resource.NewHost(
+				mockRawSeedHost.ID, mockRawSeedHost.IP, mockRawSeedHost.Hostname,
+				mockRawSeedHost.Port, mockRawSeedHost.DownloadPort, mockRawSeedHost.Type)
+			mockHost := resource.NewHost(
+				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
+			mockTask := resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength))
+			parent := resource.NewPeer(idgen.PeerIDV1("127.0.0.1"), mockTask, mockSeedHost)
+			child := resource.NewPeer(idgen.PeerIDV1("127.0.0.1"), mockTask, mockHost)
+			tc.mock(parent)
+
+			e := NewEvaluatorML().(*evaluatorML)
+			tc.expect(t, e.features(parent, child, 1, tc.inputLen))
+		})
+	}
+}
+
+func TestEvaluatorML_OnNotify(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+	dynconfig.EXPECT().Register(gomock.Any()).Times(2)
+
+	assert := assert.New(t)
+	dir := t.TempDir()
+	e := NewEvaluatorML(WithModelDir(dir), WithDynconfig(dynconfig)).(*evaluatorML)
+	assert.Nil(e.model.Load())
+
+	b, err := os.ReadFile(filepath.Join(mockModelDir, "mlp.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "mlp.json"), b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e.OnNotify(&config.DynconfigData{})
+	assert.Eventually(func() bool {
+		model := e.model.Load()
+		return model != nil && model.ID == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The model is not reloaded if the model files are not modified.
+	model := e.model.Load()
+	assert.Eventually(func() bool { return !e.refreshing.Load() }, 5*time.Second, 10*time.Millisecond)
+	e.OnNotify(&config.DynconfigData{})
+	assert.Eventually(func() bool { return !e.refreshing.Load() }, 5*time.Second, 10*time.Millisecond)
+	assert.Same(model, e.model.Load())
+
+	// The new active model is reloaded.
+	if err := os.WriteFile(filepath.Join(dir, "mlp-2.json"),
+		[]byte(`{"id":2,"type":"mlp","state":"active","updated_at":"2023-05-01T00:00:00Z","layers":[{"weights":[[1,1,1,1,1,1,1]],"biases":[0]}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	modifiedAt := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(dir, "mlp-2.json"), modifiedAt, modifiedAt); err != nil {
+		t.Fatal(err)
+	}
+
+	e.OnNotify(&config.DynconfigData{})
+	assert.Eventually(func() bool { return e.model.Load().ID == 2 }, 5*time.Second, 10*time.Millisecond)
+}
+
+type mockModelFetcher struct {
+	data  []byte
+	etag  string
+	etags []string
+}
+
+func (f *mockModelFetcher) Fetch(_ context.Context, _ uint64, etag string) ([]byte, string, error) {
+	f.etags = append(f.etags, etag)
+	if etag == f.etag {
+		return nil, etag, nil
+	}
+
+	return f.data, f.etag, nil
+}
+
+func TestEvaluatorML_fetch(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		expect func(t *testing.T, e *evaluatorML, fetcher *mockModelFetcher, dir string)
+	}{
+		{
+			name: "fetch active model",
+			data: []byte(`{"id":2,"type":"mlp","state":"active","updated_at":"2023-05-01T00:00:00Z","layers":[{"weights":[[1,1,1,1,1,1,1]],"biases":[0]}]}`),
+			expect: func(t *testing.T, e *evaluatorML, fetcher *mockModelFetcher, dir string) {
+				assert := assert.New(t)
+				assert.Equal(e.model.Load().ID, uint(2))
+				assert.Equal(e.modelETag, "foo")
+				assert.FileExists(filepath.Join(dir, activeModelFileName))
+
+				// The model is not written again if the etag is not modified.
+				e.fetch(1)
+				assert.Equal(fetcher.etags, []string{"", "foo"})
+			},
+		},
+		{
+			name: "fetch inactive model",
+			data: []byte(`{"id":2,"type":"mlp","state":"inactive","layers":[{"weights":[[1,1,1,1,1,1,1]],"biases":[0]}]}`),
+			expect: func(t *testing.T, e *evaluatorML, fetcher *mockModelFetcher, dir string) {
+				assert := assert.New(t)
+				assert.Nil(e.model.Load())
+				assert.Equal(e.modelETag, "")
+				assert.NoFileExists(filepath.Join(dir, activeModelFileName))
+			},
+		},
+		{
+			name: "fetch invalid model",
+			data: []byte(`{"id":2,"type":"gnn","state":"active"}`),
+			expect: func(t *testing.T, e *evaluatorML, fetcher *mockModelFetcher, dir string) {
+				assert := assert.New(t)
+				assert.Nil(e.model.Load())
+				assert.Equal(e.modelETag, "")
+				assert.NoFileExists(filepath.Join(dir, activeModelFileName))
+			},
+		},
+		{
+			name: "model not found",
+			expect: func(t *testing.T, e *evaluatorML, fetcher *mockModelFetcher, dir string) {
+				assert := assert.New(t)
+				assert.Nil(e.model.Load())
+				assert.NoFileExists(filepath.Join(dir, activeModelFileName))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), ModelDirName)
+			fetcher := &mockModelFetcher{data: tc.data, etag: "foo"}
+			if tc.data == nil {
+				fetcher.etag = ""
+			}
+
+			e := NewEvaluatorML(WithModelDir(dir), WithModelFetcher(fetcher)).(*evaluatorML)
+			e.fetch(1)
+			e.refresh()
+			tc.expect(t, e, fetcher, dir)
+		})
+	}
+}
+
+func TestEvaluatorML_LoadModel(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(dir string)
+		expect func(t *testing.T, model *Model, err error)
+	}{
+		{
+			name: "load active model",
+			mock: func(dir string) {
+				b, err := os.ReadFile(filepath.Join(mockModelDir, "mlp.json"))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if err := os.WriteFile(filepath.Join(dir, "mlp.json"), b, 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expect: func(t *testing.T, model *Model, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(model.ID, uint(1))
+				assert.Equal(model.State, models.ModelVersionStateActive)
+				assert.Equal(len(model.Layers), 2)
+			},
+		},
+		{
+			name: "active model not found",
+			mock: func(dir string) {
+				b, err := os.ReadFile(filepath.Join(mockModelDir, "gnn.json"))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if err := os.WriteFile(filepath.Join(dir, "gnn.json"), b, 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expect: func(t *testing.T, model *Model, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "active model not found")
+			},
+		},
+		{
+			name: "model type is not supported",
+			mock: func(dir string) {
+				if err := os.WriteFile(filepath.Join(dir, "gnn.json"), []byte(`{"type":"gnn","state":"active"}`), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expect: func(t *testing.T, model *Model, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "model type gnn is not supported")
+			},
+		},
+		{
+			name: "model inputs do not match features",
+			mock: func(dir string) {
+				if err := os.WriteFile(filepath.Join(dir, "mlp.json"),
+					[]byte(`{"type":"mlp","state":"active","layers":[{"weights":[[1,1]],"biases":[0]}]}`), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expect: func(t *testing.T, model *Model, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "layer 0 requires 7 or 8 inputs")
+			},
+		},
+		{
+			name: "load active model with free bandwidth",
+			mock: func(dir string) {
+				if err := os.WriteFile(filepath.Join(dir, "mlp.json"),
+					[]byte(`{"type":"mlp","state":"active","layers":[{"weights":[[1,1,1,1,1,1,1,1]],"biases":[0]}]}`), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expect: func(t *testing.T, model *Model, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(model.inputLen(), featureWithFreeBandwidthLen)
+			},
+		},
+		{
+			name: "model output is not a single value",
+			mock: func(dir string) {
+				if err := os.WriteFile(filepath.Join(dir, "mlp.json"),
+					[]byte(`{"type":"mlp","state":"active","layers":[{"weights":[[1,1,1,1,1,1,1],[1,1,1,1,1,1,1]],"biases":[0,0]}]}`), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expect: func(t *testing.T, model *Model, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "model output must be a single value")
+			},
+		},
+		{
+			name: "model file is invalid",
+			mock: func(dir string) {
+				if err := os.WriteFile(filepath.Join(dir, "mlp.json"), []byte("foo"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expect: func(t *testing.T, model *Model, err error) {
+				assert := assert.New(t)
+				assert.Error(err)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tc.mock(dir)
+			model, err := LoadModel(dir)
+			tc.expect(t, model, err)
+		})
+	}
+}
@@ -43,7 +43,7 @@ func TestEvaluator_New(t *testing.T) {
 			algorithm: "ml",
 			expect: func(t *testing.T, e any) {
 				assert := assert.New(t)
-				assert.Equal(reflect.TypeOf(e).Elem().Name(), "evaluatorBase")
+				assert.Equal(reflect.TypeOf(e).Elem().Name(), "evaluatorML")
 			},
 		},
 		{
@@ -0,0 +1,115 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package evaluator
+
+import (
+	"context"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	managerv2 "d7y.io/api/pkg/apis/manager/v2"
+
+	managertypes "d7y.io/dragonfly/v2/manager/types"
+	"d7y.io/dragonfly/v2/pkg/objectstorage"
+	managerclient "d7y.io/dragonfly/v2/pkg/rpc/manager/client"
+)
+
+// ModelFetcher is the interface used for fetching the active model of scheduler.
+type ModelFetcher interface {
+	// Fetch returns the data and etag of the active model of scheduler,
+	// it returns nil data if the model is not found or the etag is not modified.
+	Fetch(ctx context.Context, schedulerID uint64, etag string) ([]byte, string, error)
+}
+
+// modelFetcher fetches the active model from the object storage of manager.
+type modelFetcher struct {
+	// managerClient is the client of manager.
+	managerClient managerclient.V2
+
+	// hostname is the hostname of scheduler.
+	hostname string
+
+	// ip is the advertise ip of scheduler.
+	ip string
+
+	// newObjectStorage returns the client of object storage by the config of manager.
+	newObjectStorage func(*managerv2.ObjectStorage) (objectstorage.ObjectStorage, error)
+}
+
+// NewModelFetcher returns a new ModelFetcher which fetches the active model
+// from the object storage of manager.
+func NewModelFetcher(managerClient managerclient.V2, hostname, ip string) ModelFetcher {
+	return &modelFetcher{
+		managerClient:    managerClient,
+		hostname:         hostname,
+		ip:               ip,
+		newObjectStorage: newObjectStorage,
+	}
+}
+
+// Fetch returns the data and etag of the active model of scheduler.
+func (f *modelFetcher) Fetch(ctx context.Context, schedulerID uint64, etag string) ([]byte, string, error) {
+	config, err := f.managerClient.GetObjectStorage(ctx, &managerv2.GetObjectStorageRequest{
+		SourceType: managerv2.SourceType_SCHEDULER_SOURCE,
+		Hostname:   f.hostname,
+		Ip:         f.ip,
+	})
+	if err != nil {
+		// Object storage of manager is disabled, so there is no model to fetch.
+		if s, ok := status.FromError(err); ok && s.Code() == codes.NotFound {
+			return nil, etag, nil
+		}
+
+		return nil, "", err
+	}
+
+	client, err := f.newObjectStorage(config)
+	if err != nil {
+		return nil, "", err
+	}
+
+	objectKey := managertypes.MakeObjectKeyOfActiveModel(schedulerID)
+	metadata, isExist, err := client.GetObjectMetadata(ctx, managertypes.ModelBucketName, objectKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !isExist || metadata.ETag == etag {
+		return nil, etag, nil
+	}
+
+	reader, err := client.GetOject(ctx, managertypes.ModelBucketName, objectKey)
+	if err != nil {
+		return nil, "", err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, metadata.ETag, nil
+}
+
+// newObjectStorage returns the client of object storage by the config of manager.
+func newObjectStorage(config *managerv2.ObjectStorage) (objectstorage.ObjectStorage, error) {
+	return objectstorage.New(config.Name, config.Region, config.Endpoint,
+		config.AccessKey, config.SecretKey, objectstorage.WithS3ForcePathStyle(config.S3ForcePathStyle))
+}
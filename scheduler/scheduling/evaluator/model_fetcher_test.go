@@ -0,0 +1,151 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	managerv2 "d7y.io/api/pkg/apis/manager/v2"
+
+	managertypes "d7y.io/dragonfly/v2/manager/types"
+	"d7y.io/dragonfly/v2/pkg/objectstorage"
+	objectstoragemocks "d7y.io/dragonfly/v2/pkg/objectstorage/mocks"
+	managerclientmocks "d7y.io/dragonfly/v2/pkg/rpc/manager/client/mocks"
+)
+
+func TestModelFetcher_Fetch(t *testing.T) {
+	tests := []struct {
+		name   string
+		etag   string
+		mock   func(mc *managerclientmocks.MockV2MockRecorder, mo *objectstoragemocks.MockObjectStorageMockRecorder)
+		expect func(t *testing.T, data []byte, etag string, err error)
+	}{
+		{
+			name: "fetch modified model",
+			etag: "foo",
+			mock: func(mc *managerclientmocks.MockV2MockRecorder, mo *objectstoragemocks.MockObjectStorageMockRecorder) {
+				gomock.InOrder(
+					mc.GetObjectStorage(gomock.Any(), gomock.Any()).Return(&managerv2.ObjectStorage{Name: objectstorage.ServiceNameS3}, nil).Times(1),
+					mo.GetObjectMetadata(gomock.Any(), managertypes.ModelBucketName, managertypes.MakeObjectKeyOfActiveModel(1)).Return(&objectstorage.ObjectMetadata{ETag: "bar"}, true, nil).Times(1),
+					mo.GetOject(gomock.Any(), managertypes.ModelBucketName, managertypes.MakeObjectKeyOfActiveModel(1)).Return(io.NopCloser(strings.NewReader("baz")), nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, data []byte, etag string, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(string(data), "baz")
+				assert.Equal(etag, "bar")
+			},
+		},
+		{
+			name: "model is not modified",
+			etag: "foo",
+			mock: func(mc *managerclientmocks.MockV2MockRecorder, mo *objectstoragemocks.MockObjectStorageMockRecorder) {
+				gomock.InOrder(
+					mc.GetObjectStorage(gomock.Any(), gomock.Any()).Return(&managerv2.ObjectStorage{Name: objectstorage.ServiceNameS3}, nil).Times(1),
+					mo.GetObjectMetadata(gomock.Any(), managertypes.ModelBucketName, managertypes.MakeObjectKeyOfActiveModel(1)).Return(&objectstorage.ObjectMetadata{ETag: "foo"}, true, nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, data []byte, etag string, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Nil(data)
+				assert.Equal(etag, "foo")
+			},
+		},
+		{
+			name: "model not found",
+			mock: func(mc *managerclientmocks.MockV2MockRecorder, mo *objectstoragemocks.MockObjectStorageMockRecorder) {
+				gomock.InOrder(
+					mc.GetObjectStorage(gomock.Any(), gomock.Any()).Return(&managerv2.ObjectStorage{Name: objectstorage.ServiceNameS3}, nil).Times(1),
+					mo.GetObjectMetadata(gomock.Any(), managertypes.ModelBucketName, managertypes.MakeObjectKeyOfActiveModel(1)).Return(nil, false, nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, data []byte, etag string, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Nil(data)
+			},
+		},
+		{
+			name: "get object storage failed",
+			mock: func(mc *managerclientmocks.MockV2MockRecorder, mo *objectstoragemocks.MockObjectStorageMockRecorder) {
+				mc.GetObjectStorage(gomock.Any(), gomock.Any()).Return(nil, errors.New("foo")).Times(1)
+			},
+			expect: func(t *testing.T, data []byte, etag string, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "foo")
+				assert.Nil(data)
+			},
+		},
+		{
+			name: "object storage is disabled",
+			etag: "foo",
+			mock: func(mc *managerclientmocks.MockV2MockRecorder, mo *objectstoragemocks.MockObjectStorageMockRecorder) {
+				mc.GetObjectStorage(gomock.Any(), gomock.Any()).Return(nil, status.Error(codes.NotFound, "foo")).Times(1)
+			},
+			expect: func(t *testing.T, data []byte, etag string, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Nil(data)
+				assert.Equal(etag, "foo")
+			},
+		},
+		{
+			name: "get object failed",
+			mock: func(mc *managerclientmocks.MockV2MockRecorder, mo *objectstoragemocks.MockObjectStorageMockRecorder) {
+				gomock.InOrder(
+					mc.GetObjectStorage(gomock.Any(), gomock.Any()).Return(&managerv2.ObjectStorage{Name: objectstorage.ServiceNameS3}, nil).Times(1),
+					mo.GetObjectMetadata(gomock.Any(), managertypes.ModelBucketName, managertypes.MakeObjectKeyOfActiveModel(1)).Return(&objectstorage.ObjectMetadata{ETag: "bar"}, true, nil).Times(1),
+					mo.GetOject(gomock.Any(), managertypes.ModelBucketName, managertypes.MakeObjectKeyOfActiveModel(1)).Return(nil, errors.New("foo")).Times(1),
+				)
+			},
+			expect: func(t *testing.T, data []byte, etag string, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "foo")
+				assert.Nil(data)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			managerClient := managerclientmocks.NewMockV2(ctl)
+			objectStorage := objectstoragemocks.NewMockObjectStorage(ctl)
+			tc.mock(managerClient.EXPECT(), objectStorage.EXPECT())
+
+			f := NewModelFetcher(managerClient, "foo", "127.0.0.1").(*modelFetcher)
+			f.newObjectStorage = func(*managerv2.ObjectStorage) (objectstorage.ObjectStorage, error) {
+				return objectStorage, nil
+			}
+
+			data, etag, err := f.Fetch(context.Background(), 1, tc.etag)
+			tc.expect(t, data, etag, err)
+		})
+	}
+}
@@ -37,6 +37,7 @@ import (
 	"d7y.io/dragonfly/v2/pkg/container/set"
 	"d7y.io/dragonfly/v2/pkg/types"
 	"d7y.io/dragonfly/v2/scheduler/config"
+	"d7y.io/dragonfly/v2/scheduler/metrics"
 	"d7y.io/dragonfly/v2/scheduler/resource"
 	"d7y.io/dragonfly/v2/scheduler/scheduling/evaluator"
 )
@@ -66,13 +67,22 @@ type scheduling struct {
 
 	// Scheduler dynamic configuration.
 	dynconfig config.DynconfigInterface
+
+	// decisionLog records the scheduling decisions, it is nil if disabled.
+	decisionLog DecisionLog
+
+	// backToSourceQuota limits the back-to-source peers of applications, it is nil if disabled.
+	backToSourceQuota resource.BackToSourceQuota
 }
 
-func New(cfg *config.SchedulerConfig, dynconfig config.DynconfigInterface, pluginDir string) Scheduling {
+func New(cfg *config.SchedulerConfig, dynconfig config.DynconfigInterface, pluginDir string, decisionLog DecisionLog,
+	backToSourceQuota resource.BackToSourceQuota, options ...evaluator.Option) Scheduling {
 	return &scheduling{
-		evaluator: evaluator.New(cfg.Algorithm, pluginDir),
-		config:    cfg,
-		dynconfig: dynconfig,
+		evaluator:         evaluator.New(cfg.Algorithm, pluginDir, options...),
+		config:            cfg,
+		dynconfig:         dynconfig,
+		decisionLog:       decisionLog,
+		backToSourceQuota: backToSourceQuota,
 	}
 }
 
@@ -92,7 +102,10 @@ func (s *scheduling) ScheduleCandidateParents(ctx context.Context, peer *resourc
 		//
 		// Condition 1: Peer's NeedBackToSource is true.
 		// Condition 2: Scheduling exceeds the RetryBackToSourceLimit.
-		if peer.Task.CanBackToSource() {
+		//
+		// If the back-to-source quotas of application are exceeded,
+		// peer keeps scheduling until the RetryLimit.
+		if peer.Task.CanBackToSource() && !s.backToSourceQuotaExceeded(ctx, peer, n) {
 			// Check condition 1:
 			// Peer's NeedBackToSource is true.
 			if peer.NeedBackToSource.Load() {
@@ -199,6 +212,8 @@ func (s *scheduling) ScheduleCandidateParents(ctx context.Context, peer *resourc
 				peer.Log.Warnf("peer adds edge failed: %s", err.Error())
 				continue
 			}
+
+			s.preempt(peer, candidateParent)
 		}
 
 		peer.Log.Infof("scheduling success in %d times", n+1)
@@ -222,7 +237,10 @@ func (s *scheduling) ScheduleParentAndCandidateParents(ctx context.Context, peer
 		//
 		// Condition 1: Peer needs back-to-source.
 		// Condition 2: Scheduling exceeds the RetryBackToSourceLimit.
-		if peer.Task.CanBackToSource() {
+		//
+		// If the back-to-source quotas of application are exceeded,
+		// peer keeps scheduling until the RetryLimit.
+		if peer.Task.CanBackToSource() && !s.backToSourceQuotaExceeded(ctx, peer, n) {
 			// Check condition 1:
 			// Peer's NeedBackToSource is true.
 			if peer.NeedBackToSource.Load() {
@@ -367,6 +385,8 @@ func (s *scheduling) ScheduleParentAndCandidateParents(ctx context.Context, peer
 				peer.Log.Debugf("peer adds edge failed: %s", err.Error())
 				continue
 			}
+
+			s.preempt(peer, candidateParent)
 		}
 
 		peer.Log.Infof("scheduling success in %d times", n+1)
@@ -385,7 +405,10 @@ func (s *scheduling) FindCandidateParents(ctx context.Context, peer *resource.Pe
 	}
 
 	// Find the candidate parent that can be scheduled.
-	candidateParents := s.filterCandidateParents(peer, blocklist)
+	decision := s.newDecision(peer, blocklist)
+	defer s.storeDecision(decision)
+
+	candidateParents := s.filterCandidateParents(peer, blocklist, decision)
 	if len(candidateParents) == 0 {
 		peer.Log.Info("can not find candidate parents")
 		return []*resource.Peer{}, false
@@ -400,6 +423,8 @@ func (s *scheduling) FindCandidateParents(ctx context.Context, peer *resource.Pe
 		},
 	)
 
+	decision.evaluate(s.evaluator, peer, candidateParents)
+
 	var parentIDs []string
 	for _, candidateParent := range candidateParents {
 		parentIDs = append(parentIDs, candidateParent.ID)
@@ -419,8 +444,11 @@ func (s *scheduling) FindSuccessParent(ctx context.Context, peer *resource.Peer,
 		return nil, false
 	}
 
+	decision := s.newDecision(peer, blocklist)
+	defer s.storeDecision(decision)
+
 	// Find the candidate parent that can be scheduled.
-	candidateParents := s.filterCandidateParents(peer, blocklist)
+	candidateParents := s.filterCandidateParents(peer, blocklist, decision)
 	if len(candidateParents) == 0 {
 		peer.Log.Info("can not find candidate parents")
 		return nil, false
@@ -442,12 +470,33 @@ func (s *scheduling) FindSuccessParent(ctx context.Context, peer *resource.Peer,
 		},
 	)
 
+	decision.evaluate(s.evaluator, peer, successParents)
+
 	peer.Log.Infof("scheduling success parent is %s", successParents[0].ID)
 	return successParents[0], true
 }
 
-// filterCandidateParents filters the candidate parents that can be scheduled.
-func (s *scheduling) filterCandidateParents(peer *resource.Peer, blocklist set.SafeSet[string]) []*resource.Peer {
+// newDecision returns a new decision of the peer, it returns nil if the decision log is disabled.
+func (s *scheduling) newDecision(peer *resource.Peer, blocklist set.SafeSet[string]) *Decision {
+	if s.decisionLog == nil {
+		return nil
+	}
+
+	return newDecision(peer, blocklist)
+}
+
+// storeDecision stores the decision to the decision log.
+func (s *scheduling) storeDecision(decision *Decision) {
+	if s.decisionLog == nil || decision == nil {
+		return
+	}
+
+	s.decisionLog.Store(decision)
+}
+
+// filterCandidateParents filters the candidate parents that can be scheduled,
+// and records the filtered parents with reasons in the decision.
+func (s *scheduling) filterCandidateParents(peer *resource.Peer, blocklist set.SafeSet[string], decision *Decision) []*resource.Peer {
 	filterParentLimit := config.DefaultSchedulerFilterParentLimit
 	filterParentRangeLimit := config.DefaultSchedulerFilterParentRangeLimit
 	if config, err := s.dynconfig.GetSchedulerClusterConfig(); err == nil {
@@ -473,12 +522,14 @@ func (s *scheduling) filterCandidateParents(peer *resource.Peer, blocklist set.S
 		// Candidate parent is in blocklist.
 		if blocklist.Contains(candidateParent.ID) {
 			peer.Log.Debugf("parent %s is not selected because it is in blocklist", candidateParent.ID)
+			decision.filter(candidateParent, FilterReasonInBlocklist)
 			continue
 		}
 
 		// Candidate parent can add edge with peer.
 		if !peer.Task.CanAddPeerEdge(candidateParent.ID, peer.ID) {
 			peer.Log.Debugf("can not add edge with parent %s", candidateParent.ID)
+			decision.filter(candidateParent, FilterReasonCanNotAddEdge)
 			continue
 		}
 
@@ -487,12 +538,22 @@ func (s *scheduling) filterCandidateParents(peer *resource.Peer, blocklist set.S
 		// where two tasks are downloading and downloading each other.
 		if peer.Host.ID == candidateParent.Host.ID {
 			peer.Log.Debugf("parent %s host %s is the same as peer host", candidateParent.ID, candidateParent.Host.ID)
+			decision.filter(candidateParent, FilterReasonSameHost)
+			continue
+		}
+
+		// Candidate parent host is draining, it is not selected
+		// as the parent of new children.
+		if candidateParent.Host.Draining.Load() {
+			peer.Log.Debugf("parent %s is not selected because its host %s is draining", candidateParent.ID, candidateParent.Host.ID)
+			decision.filter(candidateParent, FilterReasonHostDraining)
 			continue
 		}
 
 		// Candidate parent is bad node.
 		if s.evaluator.IsBadNode(candidateParent) {
 			peer.Log.Debugf("parent %s is not selected because it is bad node", candidateParent.ID)
+			decision.filter(candidateParent, FilterReasonBadNode)
 			continue
 		}
 
@@ -500,6 +561,7 @@ func (s *scheduling) filterCandidateParents(peer *resource.Peer, blocklist set.S
 		inDegree, err := peer.Task.PeerInDegree(candidateParent.ID)
 		if err != nil {
 			peer.Log.Debugf("can not find parent %s vertex in dag", candidateParent.ID)
+			decision.filter(candidateParent, FilterReasonNotInDAG)
 			continue
 		}
 
@@ -512,13 +574,16 @@ func (s *scheduling) filterCandidateParents(peer *resource.Peer, blocklist set.S
 			!candidateParent.FSM.Is(resource.PeerStateSucceeded) {
 			peer.Log.Debugf("parent %s is not selected, because its download state is %d %d %s",
 				candidateParent.ID, inDegree, int(candidateParent.Host.Type), candidateParent.FSM.Current())
+			decision.filter(candidateParent, FilterReasonDownloadState)
 			continue
 		}
 
-		// Candidate parent's free upload is empty.
-		if candidateParent.Host.FreeUploadCount() <= 0 {
+		// Candidate parent's free upload is empty, and the upload slots
+		// can not be preempted by the peer.
+		if candidateParent.Host.FreeUploadCount() <= 0 && !s.canPreempt(peer, candidateParent) {
 			peer.Log.Debugf("parent %s is not selected because its free upload is empty, upload limit is %d, upload count is %d",
 				candidateParent.ID, candidateParent.Host.ConcurrentUploadLimit.Load(), candidateParent.Host.ConcurrentUploadCount.Load())
+			decision.filter(candidateParent, FilterReasonFreeUploadEmpty)
 			continue
 		}
 
@@ -530,6 +595,95 @@ func (s *scheduling) filterCandidateParents(peer *resource.Peer, blocklist set.S
 	return candidateParents
 }
 
+// backToSourceQuotaExceeded returns whether the peer needs back-to-source,
+// but the back-to-source quotas of application are exhausted.
+func (s *scheduling) backToSourceQuotaExceeded(ctx context.Context, peer *resource.Peer, n int) bool {
+	if s.backToSourceQuota == nil {
+		return false
+	}
+
+	if !peer.NeedBackToSource.Load() && n < s.config.RetryBackToSourceLimit {
+		return false
+	}
+
+	return !resource.AcquireBackToSourceQuota(ctx, s.backToSourceQuota, peer)
+}
+
+// canPreempt returns whether the peer can preempt the upload slot of parent's host,
+// which is occupied by the child with lower priority.
+func (s *scheduling) canPreempt(peer *resource.Peer, parent *resource.Peer) bool {
+	if !s.config.Preemption.Enable {
+		return false
+	}
+
+	// The peer with the lowest priority can not preempt any child.
+	if resource.PriorityRank(peer.Priority) <= resource.PriorityRank(commonv2.Priority_LEVEL1) {
+		return false
+	}
+
+	// Preemptions in the same host are throttled by interval.
+	if time.Since(parent.Host.PreemptedAt.Load()) < s.config.Preemption.Interval {
+		return false
+	}
+
+	return len(parent.Host.PreemptibleChildren(peer.Priority)) > 0
+}
+
+// preempt evicts the child with the lowest priority from parent's host if the host
+// is oversubscribed after the peer is added as a child of parent, and reschedules
+// the evicted child to other parents.
+func (s *scheduling) preempt(peer *resource.Peer, parent *resource.Peer) {
+	if parent.Host.FreeUploadCount() >= 0 || !s.canPreempt(peer, parent) {
+		return
+	}
+
+	children := parent.Host.PreemptibleChildren(peer.Priority)
+	if len(children) == 0 {
+		return
+	}
+
+	// Only the edges from the parents on the host are deleted, and the parents
+	// are blocked, so that the evicted child is not rescheduled to the same host.
+	child := children[0]
+	blocklist := set.NewSafeSet[string]()
+	var preempted bool
+	for _, p := range child.Parents() {
+		if p.Host.ID != parent.Host.ID {
+			continue
+		}
+
+		blocklist.Add(p.ID)
+		if err := child.Task.DeletePeerEdge(p, child); err != nil {
+			peer.Log.Errorf("preempt child %s from parent %s failed: %s", child.ID, p.ID, err.Error())
+			continue
+		}
+
+		preempted = true
+	}
+
+	if !preempted {
+		return
+	}
+
+	parent.Host.PreemptedAt.Store(time.Now())
+	peer.Log.Infof("preempt upload slot of host %s from child %s with priority %s",
+		parent.Host.ID, child.ID, child.Priority.String())
+	metrics.PreemptionCount.WithLabelValues(peer.Priority.String(), child.Priority.String(),
+		peer.Task.Type.String(), parent.Host.Type.Name()).Inc()
+
+	go func() {
+		if _, loaded := child.LoadAnnouncePeerStream(); loaded {
+			if err := s.ScheduleCandidateParents(context.Background(), child, blocklist); err != nil {
+				child.Log.Errorf("reschedule preempted child failed: %s", err.Error())
+			}
+
+			return
+		}
+
+		s.ScheduleParentAndCandidateParents(context.Background(), child, blocklist)
+	}()
+}
+
 // ConstructSuccessSmallTaskResponse constructs scheduling successful response of the small task.
 // Used only in v2 version of the grpc.
 func ConstructSuccessSmallTaskResponse(candidateParent *resource.Peer) *schedulerv2.AnnouncePeerResponse_SmallTaskResponse {
@@ -216,7 +216,7 @@ func TestScheduling_New(t *testing.T) {
 			defer ctl.Finish()
 			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
 
-			tc.expect(t, New(mockSchedulerConfig, dynconfig, tc.pluginDir))
+			tc.expect(t, New(mockSchedulerConfig, dynconfig, tc.pluginDir, nil, nil))
 		})
 	}
 }
@@ -443,7 +443,7 @@ func TestScheduling_ScheduleCandidateParents(t *testing.T) {
 			blocklist := set.NewSafeSet[string]()
 
 			tc.mock(cancel, peer, seedPeer, blocklist, stream, stream.EXPECT(), dynconfig.EXPECT())
-			scheduling := New(mockSchedulerConfig, dynconfig, mockPluginDir)
+			scheduling := New(mockSchedulerConfig, dynconfig, mockPluginDir, nil, nil)
 			tc.expect(t, peer, scheduling.ScheduleCandidateParents(ctx, peer, blocklist))
 		})
 	}
@@ -716,7 +716,7 @@ func TestScheduling_ScheduleParentAndCandidateParents(t *testing.T) {
 			blocklist := set.NewSafeSet[string]()
 
 			tc.mock(cancel, peer, seedPeer, blocklist, stream, stream.EXPECT(), dynconfig.EXPECT())
-			scheduling := New(mockSchedulerConfig, dynconfig, mockPluginDir)
+			scheduling := New(mockSchedulerConfig, dynconfig, mockPluginDir, nil, nil)
 			scheduling.ScheduleParentAndCandidateParents(ctx, peer, blocklist)
 			tc.expect(t, peer)
 		})
@@ -818,6 +818,22 @@ func TestScheduling_FindCandidateParents(t *testing.T) {
 				assert.False(ok)
 			},
 		},
+		{
+			name: "parent host is draining",
+			mock: func(peer *resource.Peer, mockPeers []*resource.Peer, blocklist set.SafeSet[string], md *configmocks.MockDynconfigInterfaceMockRecorder) {
+				peer.FSM.SetState(resource.PeerStateRunning)
+				mockPeers[0].FSM.SetState(resource.PeerStateSucceeded)
+				peer.Task.StorePeer(peer)
+				peer.Task.StorePeer(mockPeers[0])
+				mockPeers[0].Host.Draining.Store(true)
+
+				md.GetSchedulerClusterConfig().Return(types.SchedulerClusterConfig{}, errors.New("foo")).Times(1)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, mockPeers []*resource.Peer, parents []*resource.Peer, ok bool) {
+				assert := assert.New(t)
+				assert.False(ok)
+			},
+		},
 		{
 			name: "find back-to-source parent",
 			mock: func(peer *resource.Peer, mockPeers []*resource.Peer, blocklist set.SafeSet[string], md *configmocks.MockDynconfigInterfaceMockRecorder) {
@@ -993,7 +1009,7 @@ func TestScheduling_FindCandidateParents(t *testing.T) {
 
 			blocklist := set.NewSafeSet[string]()
 			tc.mock(peer, mockPeers, blocklist, dynconfig.EXPECT())
-			scheduling := New(mockSchedulerConfig, dynconfig, mockPluginDir)
+			scheduling := New(mockSchedulerConfig, dynconfig, mockPluginDir, nil, nil)
 			parents, found := scheduling.FindCandidateParents(context.Background(), peer, blocklist)
 			tc.expect(t, peer, mockPeers, parents, found)
 		})
@@ -1243,13 +1259,224 @@ func TestScheduling_FindSuccessParent(t *testing.T) {
 
 			blocklist := set.NewSafeSet[string]()
 			tc.mock(peer, mockPeers, blocklist, dynconfig.EXPECT())
-			scheduling := New(mockSchedulerConfig, dynconfig, mockPluginDir)
+			scheduling := New(mockSchedulerConfig, dynconfig, mockPluginDir, nil, nil)
 			parent, found := scheduling.FindSuccessParent(context.Background(), peer, blocklist)
 			tc.expect(t, peer, mockPeers, parent, found)
 		})
 	}
 }
 
+func TestScheduling_FindCandidateParentsWithPreemption(t *testing.T) {
+	tests := []struct {
+		name       string
+		preemption bool
+		priority   commonv2.Priority
+		throttled  bool
+		expect     func(t *testing.T, parent *resource.Peer, parents []*resource.Peer, ok bool)
+	}{
+		{
+			name:       "peer with higher priority preempts upload slot",
+			preemption: true,
+			priority:   commonv2.Priority_LEVEL6,
+			expect: func(t *testing.T, parent *resource.Peer, parents []*resource.Peer, ok bool) {
+				assert := assert.New(t)
+				assert.True(ok)
+				assert.Equal(len(parents), 1)
+				assert.Equal(parents[0].ID, parent.ID)
+			},
+		},
+		{
+			name:       "peer with default priority preempts upload slot",
+			preemption: true,
+			priority:   commonv2.Priority_LEVEL0,
+			expect: func(t *testing.T, parent *resource.Peer, parents []*resource.Peer, ok bool) {
+				assert := assert.New(t)
+				assert.True(ok)
+				assert.Equal(len(parents), 1)
+				assert.Equal(parents[0].ID, parent.ID)
+			},
+		},
+		{
+			name:       "peer with the same priority can not preempt upload slot",
+			preemption: true,
+			priority:   commonv2.Priority_LEVEL3,
+			expect: func(t *testing.T, parent *resource.Peer, parents []*resource.Peer, ok bool) {
+				assert := assert.New(t)
+				assert.False(ok)
+			},
+		},
+		{
+			name:       "preemption is throttled",
+			preemption: true,
+			priority:   commonv2.Priority_LEVEL6,
+			throttled:  true,
+			expect: func(t *testing.T, parent *resource.Peer, parents []*resource.Peer, ok bool) {
+				assert := assert.New(t)
+				assert.False(ok)
+			},
+		},
+		{
+			name:       "preemption is disabled",
+			preemption: false,
+			priority:   commonv2.Priority_LEVEL6,
+			expect: func(t *testing.T, parent *resource.Peer, parents []*resource.Peer, ok bool) {
+				assert := assert.New(t)
+				assert.False(ok)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			dynconfig.EXPECT().GetSchedulerClusterConfig().Return(types.SchedulerClusterConfig{}, errors.New("foo")).AnyTimes()
+
+			peer, parent, _ := newMockPreemptionPeers(tc.priority)
+			if tc.throttled {
+				parent.Host.PreemptedAt.Store(time.Now())
+			}
+
+			cfg := *mockSchedulerConfig
+			cfg.Preemption.Enable = tc.preemption
+			cfg.Preemption.Interval = time.Minute
+			scheduling := New(&cfg, dynconfig, mockPluginDir, nil, nil)
+			parents, ok := scheduling.FindCandidateParents(context.Background(), peer, set.NewSafeSet[string]())
+			tc.expect(t, parent, parents, ok)
+		})
+	}
+}
+
+func TestScheduling_preempt(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+	dynconfig.EXPECT().GetSchedulerClusterConfig().Return(types.SchedulerClusterConfig{}, errors.New("foo")).AnyTimes()
+
+	peer, parent, child := newMockPreemptionPeers(commonv2.Priority_LEVEL6)
+	cfg := *mockSchedulerConfig
+	cfg.Preemption.Enable = true
+	cfg.Preemption.Interval = time.Minute
+	s := New(&cfg, dynconfig, mockPluginDir, nil, nil).(*scheduling)
+
+	// The child also downloads from the parent on other host.
+	otherHost := resource.NewHost(
+		idgen.HostIDV2("127.0.0.2", "bar"), "127.0.0.2", "bar",
+		mockRawSeedHost.Port, mockRawSeedHost.DownloadPort, mockRawSeedHost.Type)
+	otherParent := resource.NewPeer(idgen.PeerIDV2(), peer.Task, otherHost)
+	peer.Task.StorePeer(otherParent)
+	assert := assert.New(t)
+	assert.NoError(peer.Task.AddPeerEdge(otherParent, child))
+
+	// Host is not oversubscribed before the peer is added as a child.
+	s.preempt(peer, parent)
+	assert.Equal(len(child.Parents()), 2)
+
+	assert.NoError(peer.Task.AddPeerEdge(parent, peer))
+	assert.Equal(parent.Host.FreeUploadCount(), int32(-1))
+	s.preempt(peer, parent)
+	assert.Equal(len(child.Parents()), 1)
+	assert.Equal(child.Parents()[0].ID, otherParent.ID)
+	assert.Equal(parent.Host.FreeUploadCount(), int32(0))
+	assert.Equal(otherHost.ConcurrentUploadCount.Load(), int32(1))
+	assert.False(parent.Host.PreemptedAt.Load().IsZero())
+}
+
+func TestScheduling_backToSourceQuotaExceeded(t *testing.T) {
+	tests := []struct {
+		name             string
+		n                int
+		needBackToSource bool
+		mock             func(mq *resource.MockBackToSourceQuotaMockRecorder)
+		expect           func(t *testing.T, exceeded bool)
+	}{
+		{
+			name:             "peer does not need back-to-source",
+			n:                0,
+			needBackToSource: false,
+			mock:             func(mq *resource.MockBackToSourceQuotaMockRecorder) {},
+			expect: func(t *testing.T, exceeded bool) {
+				assert := assert.New(t)
+				assert.False(exceeded)
+			},
+		},
+		{
+			name:             "peer needs back-to-source and acquires quota",
+			n:                0,
+			needBackToSource: true,
+			mock: func(mq *resource.MockBackToSourceQuotaMockRecorder) {
+				mq.Acquire(gomock.Any(), gomock.Any()).Return(true).Times(1)
+			},
+			expect: func(t *testing.T, exceeded bool) {
+				assert := assert.New(t)
+				assert.False(exceeded)
+			},
+		},
+		{
+			name:             "scheduling exceeds RetryBackToSourceLimit and quota is exhausted",
+			n:                mockSchedulerConfig.RetryBackToSourceLimit,
+			needBackToSource: false,
+			mock: func(mq *resource.MockBackToSourceQuotaMockRecorder) {
+				mq.Acquire(gomock.Any(), gomock.Any()).Return(false).Times(1)
+			},
+			expect: func(t *testing.T, exceeded bool) {
+				assert := assert.New(t)
+				assert.True(exceeded)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			quota := resource.NewMockBackToSourceQuota(ctl)
+			tc.mock(quota.EXPECT())
+
+			mockHost := resource.NewHost(
+				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
+			mockTask := resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength))
+			peer := resource.NewPeer(mockPeerID, mockTask, mockHost)
+			peer.NeedBackToSource.Store(tc.needBackToSource)
+
+			s := New(mockSchedulerConfig, dynconfig, mockPluginDir, nil, quota).(*scheduling)
+			tc.expect(t, s.backToSourceQuotaExceeded(context.Background(), peer, tc.n))
+		})
+	}
+}
+
+// newMockPreemptionPeers returns the peer with the priority, and the parent whose host
+// has no free upload because of the child with the lowest priority.
+func newMockPreemptionPeers(priority commonv2.Priority) (*resource.Peer, *resource.Peer, *resource.Peer) {
+	mockTask := resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength))
+	mockHost := resource.NewHost(
+		mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+		mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
+	peer := resource.NewPeer(mockPeerID, mockTask, mockHost, resource.WithPriority(priority))
+	peer.FSM.SetState(resource.PeerStateRunning)
+	mockTask.StorePeer(peer)
+
+	mockSeedHost := resource.NewHost(
+		mockRawSeedHost.ID, mockRawSeedHost.IP, mockRawSeedHost.Hostname,
+		mockRawSeedHost.Port, mockRawSeedHost.DownloadPort, mockRawSeedHost.Type,
+		resource.WithConcurrentUploadLimit(1))
+	parent := resource.NewPeer(mockSeedPeerID, mockTask, mockSeedHost)
+	parent.FSM.SetState(resource.PeerStateSucceeded)
+	mockTask.StorePeer(parent)
+	mockSeedHost.StorePeer(parent)
+
+	// The child is on the same host as the peer, so it is not the candidate parent of the peer.
+	child := resource.NewPeer(idgen.PeerIDV2(), mockTask, mockHost, resource.WithPriority(commonv2.Priority_LEVEL3))
+	child.FSM.SetState(resource.PeerStateRunning)
+	mockTask.StorePeer(child)
+	mockTask.AddPeerEdge(parent, child) // nolint: errcheck
+
+	return peer, parent, child
+}
+
 func TestScheduling_ConstructSuccessSmallTaskResponse(t *testing.T) {
 	tests := []struct {
 		name   string
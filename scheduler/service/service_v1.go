@@ -25,7 +25,10 @@ import (
 	"strings"
 	"time"
 
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
 
 	commonv1 "d7y.io/api/pkg/apis/common/v1"
 	commonv2 "d7y.io/api/pkg/apis/common/v2"
@@ -42,6 +45,7 @@ import (
 	"d7y.io/dragonfly/v2/pkg/types"
 	"d7y.io/dragonfly/v2/scheduler/config"
 	"d7y.io/dragonfly/v2/scheduler/metrics"
+	"d7y.io/dragonfly/v2/scheduler/networktopology"
 	"d7y.io/dragonfly/v2/scheduler/resource"
 	"d7y.io/dragonfly/v2/scheduler/scheduling"
 	"d7y.io/dragonfly/v2/scheduler/storage"
@@ -63,6 +67,9 @@ type V1 struct {
 
 	// Storage interface.
 	storage storage.Storage
+
+	// Network topology interface.
+	networkTopology networktopology.NetworkTopology
 }
 
 // New v1 version of service instance.
@@ -72,13 +79,15 @@ func NewV1(
 	scheduling scheduling.Scheduling,
 	dynconfig config.DynconfigInterface,
 	storage storage.Storage,
+	networkTopology networktopology.NetworkTopology,
 ) *V1 {
 	return &V1{
-		resource:   resource,
-		scheduling: scheduling,
-		config:     cfg,
-		dynconfig:  dynconfig,
-		storage:    storage,
+		resource:        resource,
+		scheduling:      scheduling,
+		config:          cfg,
+		dynconfig:       dynconfig,
+		storage:         storage,
+		networkTopology: networkTopology,
 	}
 }
 
@@ -457,6 +466,9 @@ func (v *V1) LeaveTask(ctx context.Context, req *schedulerv1.PeerTarget) error {
 
 // AnnounceHost announces host to scheduler.
 func (v *V1) AnnounceHost(ctx context.Context, req *schedulerv1.AnnounceHostRequest) error {
+	// The upload bandwidth of host is announced in grpc metadata.
+	bandwidth, _ := common.NetworkBandwidthFromIncomingContext(ctx)
+
 	// Get scheduler cluster client config by manager.
 	var concurrentUploadLimit int32
 	if clientConfig, err := v.dynconfig.GetSchedulerClusterClientConfig(); err == nil {
@@ -516,6 +528,8 @@ func (v *V1) AnnounceHost(ctx context.Context, req *schedulerv1.AnnounceHostRequ
 				SecurityDomain:           req.Network.SecurityDomain,
 				Location:                 req.Network.Location,
 				IDC:                      req.Network.Idc,
+				UploadRate:               bandwidth.UploadRate,
+				UploadBandwidth:          bandwidth.UploadBandwidth,
 			}))
 		}
 
@@ -605,6 +619,8 @@ func (v *V1) AnnounceHost(ctx context.Context, req *schedulerv1.AnnounceHostRequ
 			SecurityDomain:           req.Network.SecurityDomain,
 			Location:                 req.Network.Location,
 			IDC:                      req.Network.Idc,
+			UploadRate:               bandwidth.UploadRate,
+			UploadBandwidth:          bandwidth.UploadBandwidth,
 		}
 	}
 
@@ -645,9 +661,158 @@ func (v *V1) LeaveHost(ctx context.Context, req *schedulerv1.LeaveHostRequest) e
 	}
 
 	host.LeavePeers()
+
+	// Delete host from network topology.
+	if v.networkTopology != nil {
+		v.networkTopology.DeleteHost(host.ID)
+	}
+
 	return nil
 }
 
+// SyncProbes sync probes of the host, it receives probes measured by the host
+// and responds the hosts needs to be probed.
+func (v *V1) SyncProbes(stream schedulerv1.Scheduler_SyncProbesServer) error {
+	if v.networkTopology == nil {
+		return status.Error(codes.Unimplemented, "network topology is disabled")
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Infof("context was done")
+			return ctx.Err()
+		default:
+		}
+
+		req, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			logger.Errorf("receive probes failed: %s", err.Error())
+			return err
+		}
+
+		// Get host from host manager.
+		host, loaded := v.resource.HostManager().Load(req.ProbesOfHost.Host.Id)
+		if !loaded {
+			msg := fmt.Sprintf("host %s not found", req.ProbesOfHost.Host.Id)
+			logger.Error(msg)
+			return status.Error(codes.NotFound, msg)
+		}
+
+		// Store probes measured by the host in network topology.
+		for _, probe := range req.ProbesOfHost.Probes {
+			probedHost, loaded := v.resource.HostManager().Load(probe.Host.Id)
+			if !loaded {
+				host.Log.Warnf("probed host %s not found", probe.Host.Id)
+				continue
+			}
+
+			if err := v.networkTopology.Enqueue(host, networktopology.NewProbe(probedHost, probe.Rtt.AsDuration(), probe.CreatedAt.AsTime())); err != nil {
+				host.Log.Errorf("enqueue probe of host %s failed: %s", probedHost.ID, err.Error())
+				continue
+			}
+		}
+
+		// Find the hosts needs to be probed by the host.
+		var probedHosts []*commonv1.Host
+		for _, probedHost := range v.networkTopology.FindProbedHosts(host.ID) {
+			probedHosts = append(probedHosts, &commonv1.Host{
+				Id:             probedHost.ID,
+				Ip:             probedHost.IP,
+				Hostname:       probedHost.Hostname,
+				Port:           probedHost.Port,
+				DownloadPort:   probedHost.DownloadPort,
+				SecurityDomain: probedHost.Network.SecurityDomain,
+				Location:       probedHost.Network.Location,
+				Idc:            probedHost.Network.IDC,
+			})
+		}
+
+		host.Log.Infof("send %d probed hosts", len(probedHosts))
+		if err := stream.Send(&schedulerv1.SyncProbesResponse{
+			Hosts:         probedHosts,
+			ProbeInterval: durationpb.New(v.config.NetworkTopology.Probe.SyncInterval),
+		}); err != nil {
+			host.Log.Error(err)
+			return err
+		}
+	}
+}
+
+// SyncNetworkTopology sync network topology of the hosts from other schedulers.
+func (v *V1) SyncNetworkTopology(stream schedulerv1.Scheduler_SyncNetworkTopologyServer) error {
+	if v.networkTopology == nil {
+		return status.Error(codes.Unimplemented, "network topology is disabled")
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Infof("context was done")
+			return ctx.Err()
+		default:
+		}
+
+		req, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return stream.SendAndClose(new(emptypb.Empty))
+			}
+
+			logger.Errorf("receive network topology failed: %s", err.Error())
+			return err
+		}
+
+		switch request := req.GetRequest().(type) {
+		case *schedulerv1.SyncNetworkTopologyRequest_UpdateProbesOfHostsRequest:
+			for _, probesOfHost := range request.UpdateProbesOfHostsRequest.ProbesOfHosts {
+				host := v.loadOrConstructHost(probesOfHost.Host)
+				for _, probe := range probesOfHost.Probes {
+					probedHost := v.loadOrConstructHost(probe.Host)
+					if err := v.networkTopology.Enqueue(host, networktopology.NewProbe(probedHost, probe.Rtt.AsDuration(), probe.CreatedAt.AsTime())); err != nil {
+						host.Log.Errorf("enqueue probe of host %s failed: %s", probedHost.ID, err.Error())
+						continue
+					}
+				}
+			}
+		case *schedulerv1.SyncNetworkTopologyRequest_DeleteProbesOfHostsRequest:
+			for _, probesOfHost := range request.DeleteProbesOfHostsRequest.ProbesOfHosts {
+				logger.WithHostID(probesOfHost.Host.Id).Info("delete host from network topology")
+				v.networkTopology.DeleteHost(probesOfHost.Host.Id)
+			}
+		default:
+			msg := fmt.Sprintf("receive unknow request: %#v", request)
+			logger.Error(msg)
+			return status.Error(codes.FailedPrecondition, msg)
+		}
+	}
+}
+
+// loadOrConstructHost loads host from host manager, if the host is not found,
+// it constructs host by the host metadata.
+func (v *V1) loadOrConstructHost(rawHost *commonv1.Host) *resource.Host {
+	if host, loaded := v.resource.HostManager().Load(rawHost.Id); loaded {
+		return host
+	}
+
+	return resource.NewHost(rawHost.Id, rawHost.Ip, rawHost.Hostname, rawHost.Port, rawHost.DownloadPort, types.HostTypeNormal,
+		resource.WithNetwork(resource.Network{
+			SecurityDomain: rawHost.SecurityDomain,
+			Location:       rawHost.Location,
+			IDC:            rawHost.Idc,
+		}))
+}
+
 // triggerTask triggers the first download of the task.
 func (v *V1) triggerTask(ctx context.Context, req *schedulerv1.PeerTaskRequest, task *resource.Task, host *resource.Host, peer *resource.Peer, dynconfig config.DynconfigInterface) error {
 	// If task has available peer, peer does not need to be triggered.
@@ -691,7 +856,9 @@ func (v *V1) triggerTask(ctx context.Context, req *schedulerv1.PeerTaskRequest,
 
 	switch priority {
 	case commonv1.Priority_LEVEL6, commonv1.Priority_LEVEL0:
-		if v.config.SeedPeer.Enable && !task.IsSeedPeerFailed() {
+		// The seed peer downloads back-to-source for the peer, if the back-to-source
+		// quotas of application are exceeded, the seed peer is not triggered.
+		if v.config.SeedPeer.Enable && !task.IsSeedPeerFailed() && v.acquireBackToSourceQuota(ctx, peer) {
 			if len(req.UrlMeta.Range) > 0 {
 				if rg, err := http.ParseURLMetaRange(req.UrlMeta.Range, math.MaxInt64); err == nil {
 					go v.triggerSeedPeerTask(ctx, &rg, task)
@@ -724,6 +891,16 @@ func (v *V1) triggerTask(ctx context.Context, req *schedulerv1.PeerTaskRequest,
 	return nil
 }
 
+// acquireBackToSourceQuota acquires the back-to-source quotas of application for the peer
+// before triggering the seed peer, it returns false if the quotas are exceeded.
+func (v *V1) acquireBackToSourceQuota(ctx context.Context, peer *resource.Peer) bool {
+	if !v.config.BackToSourceQuota.Enable {
+		return true
+	}
+
+	return resource.AcquireBackToSourceQuota(ctx, v.resource.BackToSourceQuota(), peer)
+}
+
 // triggerSeedPeerTask starts to trigger seed peer task.
 func (v *V1) triggerSeedPeerTask(ctx context.Context, rg *http.Range, task *resource.Task) {
 	task.Log.Info("trigger seed peer")
@@ -745,6 +922,11 @@ func (v *V1) storeTask(ctx context.Context, req *schedulerv1.PeerTaskRequest, ty
 	filters := strings.Split(req.UrlMeta.Filter, idgen.URLFilterSeparator)
 
 	task, loaded := v.resource.TaskManager().Load(req.TaskId)
+	if !loaded && v.config.Replication.Enable {
+		// Take over the task from the replica if its owner died.
+		task, loaded = v.resource.Replicator().Takeover(ctx, req.TaskId)
+	}
+
 	if !loaded {
 		options := []resource.TaskOption{}
 		if d, err := digest.Parse(req.UrlMeta.Digest); err == nil {
@@ -1052,6 +1234,22 @@ func (v *V1) handlePieceFailure(ctx context.Context, peer *resource.Peer, piece
 	// host upload failed and UploadErrorCount needs to be increased.
 	parent.Host.UploadFailedCount.Inc()
 
+	// The piece downloaded from parent does not match its digest. A single child may report it falsely,
+	// so the data of parent is corrupted only when enough distinct children report it, then the parent
+	// is set failed and it will not be scheduled as the parent of other peers.
+	if common.IsPieceDigestMismatch(piece) {
+		parent.DigestMismatchChildren.Add(peer.ID)
+		reported := parent.DigestMismatchChildren.Len()
+		peer.Log.Warnf("piece %d downloaded from parent %s does not match digest, reported by %d children",
+			piece.GetPieceInfo().GetPieceNum(), parent.ID, reported)
+		if reported >= resource.PieceDigestMismatchThreshold {
+			peer.Log.Errorf("parent %s is corrupted", parent.ID)
+			if err := parent.FSM.Event(ctx, resource.PeerEventDownloadFailed); err != nil {
+				peer.Log.Errorf("peer fsm event failed: %s", err.Error())
+			}
+		}
+	}
+
 	// It’s not a case of back-to-source downloading failed,
 	// to help peer to reschedule the parent node.
 	code := piece.Code
@@ -1076,7 +1274,7 @@ func (v *V1) handlePieceFailure(ctx context.Context, peer *resource.Peer, piece
 		v.handleLegacySeedPeer(ctx, parent)
 
 		// Start trigger seed peer task.
-		if v.config.SeedPeer.Enable {
+		if v.config.SeedPeer.Enable && v.acquireBackToSourceQuota(ctx, peer) {
 			go v.triggerSeedPeerTask(ctx, peer.Range, parent.Task)
 		}
 	default:
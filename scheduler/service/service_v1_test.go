@@ -36,6 +36,8 @@ import (
 	"go.uber.org/atomic"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	commonv1 "d7y.io/api/pkg/apis/common/v1"
 	commonv2 "d7y.io/api/pkg/apis/common/v2"
@@ -54,6 +56,8 @@ import (
 	pkgtypes "d7y.io/dragonfly/v2/pkg/types"
 	"d7y.io/dragonfly/v2/scheduler/config"
 	configmocks "d7y.io/dragonfly/v2/scheduler/config/mocks"
+	"d7y.io/dragonfly/v2/scheduler/networktopology"
+	networktopologymocks "d7y.io/dragonfly/v2/scheduler/networktopology/mocks"
 	"d7y.io/dragonfly/v2/scheduler/resource"
 	"d7y.io/dragonfly/v2/scheduler/scheduling"
 	"d7y.io/dragonfly/v2/scheduler/scheduling/mocks"
@@ -69,6 +73,17 @@ var (
 		BackToSourceCount:      int(mockTaskBackToSourceLimit),
 	}
 
+	mockNetworkTopologyConfig = config.NetworkTopologyConfig{
+		Enable:          true,
+		SyncInterval:    30 * time.Second,
+		CollectInterval: 60 * time.Second,
+		Probe: config.ProbeConfig{
+			QueueLength:  5,
+			SyncInterval: 30 * time.Second,
+			SyncCount:    50,
+		},
+	}
+
 	mockRawHost = resource.Host{
 		ID:              mockHostID,
 		Type:            pkgtypes.HostTypeNormal,
@@ -232,7 +247,7 @@ func TestService_NewV1(t *testing.T) {
 			resource := resource.NewMockResource(ctl)
 			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
 			storage := storagemocks.NewMockStorage(ctl)
-			tc.expect(t, NewV1(&config.Config{Scheduler: mockSchedulerConfig}, resource, scheduling, dynconfig, storage))
+			tc.expect(t, NewV1(&config.Config{Scheduler: mockSchedulerConfig}, resource, scheduling, dynconfig, storage, nil))
 		})
 	}
 }
@@ -897,7 +912,7 @@ func TestServiceV1_RegisterPeerTask(t *testing.T) {
 			hostManager := resource.NewMockHostManager(ctl)
 			taskManager := resource.NewMockTaskManager(ctl)
 			peerManager := resource.NewMockPeerManager(ctl)
-			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduling, dynconfig, storage)
+			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduling, dynconfig, storage, nil)
 
 			mockHost := resource.NewHost(
 				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
@@ -1161,7 +1176,7 @@ func TestServiceV1_ReportPieceResult(t *testing.T) {
 			storage := storagemocks.NewMockStorage(ctl)
 			peerManager := resource.NewMockPeerManager(ctl)
 			stream := schedulerv1mocks.NewMockScheduler_ReportPieceResultServer(ctl)
-			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduling, dynconfig, storage)
+			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduling, dynconfig, storage, nil)
 
 			mockHost := resource.NewHost(
 				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
@@ -1343,7 +1358,7 @@ func TestServiceV1_ReportPeerResult(t *testing.T) {
 			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
 			storage := storagemocks.NewMockStorage(ctl)
 			peerManager := resource.NewMockPeerManager(ctl)
-			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduling, dynconfig, storage)
+			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduling, dynconfig, storage, nil)
 
 			mockHost := resource.NewHost(
 				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
@@ -1407,7 +1422,7 @@ func TestServiceV1_StatTask(t *testing.T) {
 			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
 			storage := storagemocks.NewMockStorage(ctl)
 			taskManager := resource.NewMockTaskManager(ctl)
-			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig, Metrics: config.MetricsConfig{EnableHost: true}}, res, scheduling, dynconfig, storage)
+			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig, Metrics: config.MetricsConfig{EnableHost: true}}, res, scheduling, dynconfig, storage, nil)
 			mockTask := resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength))
 
 			tc.mock(mockTask, taskManager, res.EXPECT(), taskManager.EXPECT())
@@ -1703,7 +1718,7 @@ func TestServiceV1_AnnounceTask(t *testing.T) {
 			hostManager := resource.NewMockHostManager(ctl)
 			taskManager := resource.NewMockTaskManager(ctl)
 			peerManager := resource.NewMockPeerManager(ctl)
-			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig, Metrics: config.MetricsConfig{EnableHost: true}}, res, scheduling, dynconfig, storage)
+			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig, Metrics: config.MetricsConfig{EnableHost: true}}, res, scheduling, dynconfig, storage, nil)
 			mockHost := resource.NewHost(
 				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
 				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
@@ -1906,7 +1921,7 @@ func TestServiceV1_LeaveTask(t *testing.T) {
 				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
 			mockTask := resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength))
 			peer := resource.NewPeer(mockSeedPeerID, mockTask, mockHost)
-			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig, Metrics: config.MetricsConfig{EnableHost: true}}, res, scheduling, dynconfig, storage)
+			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig, Metrics: config.MetricsConfig{EnableHost: true}}, res, scheduling, dynconfig, storage, nil)
 
 			tc.mock(peer, peerManager, scheduling.EXPECT(), res.EXPECT(), peerManager.EXPECT())
 			tc.expect(t, peer, svc.LeaveTask(context.Background(), &schedulerv1.PeerTarget{}))
@@ -2338,7 +2353,7 @@ func TestServiceV1_AnnounceHost(t *testing.T) {
 			host := resource.NewHost(
 				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
 				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
-			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig, Metrics: config.MetricsConfig{EnableHost: true}}, res, scheduling, dynconfig, storage)
+			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig, Metrics: config.MetricsConfig{EnableHost: true}}, res, scheduling, dynconfig, storage, nil)
 
 			tc.run(t, svc, tc.req, host, hostManager, res.EXPECT(), hostManager.EXPECT(), dynconfig.EXPECT())
 		})
@@ -2552,7 +2567,7 @@ func TestServiceV1_LeaveHost(t *testing.T) {
 				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
 			mockTask := resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength))
 			mockPeer := resource.NewPeer(mockSeedPeerID, mockTask, host)
-			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig, Metrics: config.MetricsConfig{EnableHost: true}}, res, scheduling, dynconfig, storage)
+			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig, Metrics: config.MetricsConfig{EnableHost: true}}, res, scheduling, dynconfig, storage, nil)
 
 			tc.mock(host, mockPeer, hostManager, scheduling.EXPECT(), res.EXPECT(), hostManager.EXPECT())
 			tc.expect(t, mockPeer, svc.LeaveHost(context.Background(), &schedulerv1.LeaveHostRequest{
@@ -2562,6 +2577,267 @@ func TestServiceV1_LeaveHost(t *testing.T) {
 	}
 }
 
+func TestServiceV1_SyncProbes(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(host *resource.Host, seedHost *resource.Host, hostManager resource.HostManager, stream schedulerv1.Scheduler_SyncProbesServer, ms *schedulerv1mocks.MockScheduler_SyncProbesServerMockRecorder, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, mn *networktopologymocks.MockNetworkTopologyMockRecorder)
+		expect func(t *testing.T, err error)
+	}{
+		{
+			name: "receive error",
+			mock: func(host *resource.Host, seedHost *resource.Host, hostManager resource.HostManager, stream schedulerv1.Scheduler_SyncProbesServer, ms *schedulerv1mocks.MockScheduler_SyncProbesServerMockRecorder, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, mn *networktopologymocks.MockNetworkTopologyMockRecorder) {
+				gomock.InOrder(
+					ms.Context().Return(context.Background()).Times(1),
+					ms.Recv().Return(nil, errors.New("foo")).Times(1),
+				)
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "foo")
+			},
+		},
+		{
+			name: "receive io.EOF",
+			mock: func(host *resource.Host, seedHost *resource.Host, hostManager resource.HostManager, stream schedulerv1.Scheduler_SyncProbesServer, ms *schedulerv1mocks.MockScheduler_SyncProbesServerMockRecorder, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, mn *networktopologymocks.MockNetworkTopologyMockRecorder) {
+				gomock.InOrder(
+					ms.Context().Return(context.Background()).Times(1),
+					ms.Recv().Return(nil, io.EOF).Times(1),
+				)
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+			},
+		},
+		{
+			name: "host not found",
+			mock: func(host *resource.Host, seedHost *resource.Host, hostManager resource.HostManager, stream schedulerv1.Scheduler_SyncProbesServer, ms *schedulerv1mocks.MockScheduler_SyncProbesServerMockRecorder, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, mn *networktopologymocks.MockNetworkTopologyMockRecorder) {
+				gomock.InOrder(
+					ms.Context().Return(context.Background()).Times(1),
+					ms.Recv().Return(&schedulerv1.SyncProbesRequest{
+						ProbesOfHost: &schedulerv1.ProbesOfHost{
+							Host: &commonv1.Host{Id: host.ID},
+						},
+					}, nil).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(host.ID)).Return(nil, false).Times(1),
+				)
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.Equal(status.Code(err), codes.NotFound)
+			},
+		},
+		{
+			name: "receive probes and send probed hosts",
+			mock: func(host *resource.Host, seedHost *resource.Host, hostManager resource.HostManager, stream schedulerv1.Scheduler_SyncProbesServer, ms *schedulerv1mocks.MockScheduler_SyncProbesServerMockRecorder, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, mn *networktopologymocks.MockNetworkTopologyMockRecorder) {
+				gomock.InOrder(
+					ms.Context().Return(context.Background()).Times(1),
+					ms.Recv().Return(&schedulerv1.SyncProbesRequest{
+						ProbesOfHost: &schedulerv1.ProbesOfHost{
+							Host: &commonv1.Host{Id: host.ID},
+							Probes: []*schedulerv1.Probe{
+								{
+									Host:      &commonv1.Host{Id: seedHost.ID},
+									Rtt:       durationpb.New(10 * time.Millisecond),
+									CreatedAt: timestamppb.New(time.Now()),
+								},
+								{
+									Host:      &commonv1.Host{Id: "foo"},
+									Rtt:       durationpb.New(10 * time.Millisecond),
+									CreatedAt: timestamppb.New(time.Now()),
+								},
+							},
+						},
+					}, nil).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(host.ID)).Return(host, true).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(seedHost.ID)).Return(seedHost, true).Times(1),
+					mn.Enqueue(gomock.Eq(host), gomock.Any()).DoAndReturn(func(srcHost *resource.Host, probe *networktopology.Probe) error {
+						assert := assert.New(t)
+						assert.Equal(probe.Host.ID, seedHost.ID)
+						assert.Equal(probe.RTT, 10*time.Millisecond)
+						return nil
+					}).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq("foo")).Return(nil, false).Times(1),
+					mn.FindProbedHosts(gomock.Eq(host.ID)).Return([]*resource.Host{seedHost}).Times(1),
+					ms.Send(gomock.Any()).DoAndReturn(func(resp *schedulerv1.SyncProbesResponse) error {
+						assert := assert.New(t)
+						assert.Equal(len(resp.Hosts), 1)
+						assert.Equal(resp.Hosts[0].Id, seedHost.ID)
+						assert.Equal(resp.ProbeInterval.AsDuration(), 30*time.Second)
+						return nil
+					}).Times(1),
+					ms.Recv().Return(nil, io.EOF).Times(1),
+				)
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+			},
+		},
+		{
+			name: "send error",
+			mock: func(host *resource.Host, seedHost *resource.Host, hostManager resource.HostManager, stream schedulerv1.Scheduler_SyncProbesServer, ms *schedulerv1mocks.MockScheduler_SyncProbesServerMockRecorder, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, mn *networktopologymocks.MockNetworkTopologyMockRecorder) {
+				gomock.InOrder(
+					ms.Context().Return(context.Background()).Times(1),
+					ms.Recv().Return(&schedulerv1.SyncProbesRequest{
+						ProbesOfHost: &schedulerv1.ProbesOfHost{
+							Host: &commonv1.Host{Id: host.ID},
+						},
+					}, nil).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(host.ID)).Return(host, true).Times(1),
+					mn.FindProbedHosts(gomock.Eq(host.ID)).Return([]*resource.Host{}).Times(1),
+					ms.Send(gomock.Any()).Return(errors.New("foo")).Times(1),
+				)
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "foo")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			scheduling := mocks.NewMockScheduling(ctl)
+			res := resource.NewMockResource(ctl)
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			storage := storagemocks.NewMockStorage(ctl)
+			networkTopology := networktopologymocks.NewMockNetworkTopology(ctl)
+			hostManager := resource.NewMockHostManager(ctl)
+			stream := schedulerv1mocks.NewMockScheduler_SyncProbesServer(ctl)
+			host := resource.NewHost(
+				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
+			seedHost := resource.NewHost(
+				mockRawSeedHost.ID, mockRawSeedHost.IP, mockRawSeedHost.Hostname,
+				mockRawSeedHost.Port, mockRawSeedHost.DownloadPort, mockRawSeedHost.Type)
+			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig, NetworkTopology: mockNetworkTopologyConfig}, res, scheduling, dynconfig, storage, networkTopology)
+
+			tc.mock(host, seedHost, hostManager, stream, stream.EXPECT(), res.EXPECT(), hostManager.EXPECT(), networkTopology.EXPECT())
+			tc.expect(t, svc.SyncProbes(stream))
+		})
+	}
+}
+
+func TestServiceV1_SyncNetworkTopology(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(host *resource.Host, seedHost *resource.Host, hostManager resource.HostManager, ms *schedulerv1mocks.MockScheduler_SyncNetworkTopologyServerMockRecorder, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, mn *networktopologymocks.MockNetworkTopologyMockRecorder)
+		expect func(t *testing.T, err error)
+	}{
+		{
+			name: "receive error",
+			mock: func(host *resource.Host, seedHost *resource.Host, hostManager resource.HostManager, ms *schedulerv1mocks.MockScheduler_SyncNetworkTopologyServerMockRecorder, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, mn *networktopologymocks.MockNetworkTopologyMockRecorder) {
+				gomock.InOrder(
+					ms.Context().Return(context.Background()).Times(1),
+					ms.Recv().Return(nil, errors.New("foo")).Times(1),
+				)
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "foo")
+			},
+		},
+		{
+			name: "update probes of hosts",
+			mock: func(host *resource.Host, seedHost *resource.Host, hostManager resource.HostManager, ms *schedulerv1mocks.MockScheduler_SyncNetworkTopologyServerMockRecorder, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, mn *networktopologymocks.MockNetworkTopologyMockRecorder) {
+				gomock.InOrder(
+					ms.Context().Return(context.Background()).Times(1),
+					ms.Recv().Return(&schedulerv1.SyncNetworkTopologyRequest{
+						Request: &schedulerv1.SyncNetworkTopologyRequest_UpdateProbesOfHostsRequest{
+							UpdateProbesOfHostsRequest: &schedulerv1.UpdateHostsRequest{
+								ProbesOfHosts: []*schedulerv1.ProbesOfHost{
+									{
+										Host: &commonv1.Host{Id: host.ID},
+										Probes: []*schedulerv1.Probe{
+											{
+												Host:      &commonv1.Host{Id: "foo", Ip: "127.0.0.2", Hostname: "foo", Idc: "bar"},
+												Rtt:       durationpb.New(10 * time.Millisecond),
+												CreatedAt: timestamppb.New(time.Now()),
+											},
+										},
+									},
+								},
+							},
+						},
+					}, nil).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(host.ID)).Return(host, true).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq("foo")).Return(nil, false).Times(1),
+					mn.Enqueue(gomock.Eq(host), gomock.Any()).DoAndReturn(func(srcHost *resource.Host, probe *networktopology.Probe) error {
+						assert := assert.New(t)
+						assert.Equal(probe.Host.ID, "foo")
+						assert.Equal(probe.Host.Network.IDC, "bar")
+						return nil
+					}).Times(1),
+					ms.Recv().Return(nil, io.EOF).Times(1),
+					ms.SendAndClose(gomock.Any()).Return(nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+			},
+		},
+		{
+			name: "delete probes of hosts",
+			mock: func(host *resource.Host, seedHost *resource.Host, hostManager resource.HostManager, ms *schedulerv1mocks.MockScheduler_SyncNetworkTopologyServerMockRecorder, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, mn *networktopologymocks.MockNetworkTopologyMockRecorder) {
+				gomock.InOrder(
+					ms.Context().Return(context.Background()).Times(1),
+					ms.Recv().Return(&schedulerv1.SyncNetworkTopologyRequest{
+						Request: &schedulerv1.SyncNetworkTopologyRequest_DeleteProbesOfHostsRequest{
+							DeleteProbesOfHostsRequest: &schedulerv1.DeleteHostsRequest{
+								ProbesOfHosts: []*schedulerv1.ProbesOfHost{
+									{Host: &commonv1.Host{Id: seedHost.ID}},
+								},
+							},
+						},
+					}, nil).Times(1),
+					mn.DeleteHost(gomock.Eq(seedHost.ID)).Times(1),
+					ms.Recv().Return(nil, io.EOF).Times(1),
+					ms.SendAndClose(gomock.Any()).Return(nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			scheduling := mocks.NewMockScheduling(ctl)
+			res := resource.NewMockResource(ctl)
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			storage := storagemocks.NewMockStorage(ctl)
+			networkTopology := networktopologymocks.NewMockNetworkTopology(ctl)
+			hostManager := resource.NewMockHostManager(ctl)
+			stream := schedulerv1mocks.NewMockScheduler_SyncNetworkTopologyServer(ctl)
+			host := resource.NewHost(
+				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
+			seedHost := resource.NewHost(
+				mockRawSeedHost.ID, mockRawSeedHost.IP, mockRawSeedHost.Hostname,
+				mockRawSeedHost.Port, mockRawSeedHost.DownloadPort, mockRawSeedHost.Type)
+			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig, NetworkTopology: mockNetworkTopologyConfig}, res, scheduling, dynconfig, storage, networkTopology)
+
+			tc.mock(host, seedHost, hostManager, stream.EXPECT(), res.EXPECT(), hostManager.EXPECT(), networkTopology.EXPECT())
+			tc.expect(t, svc.SyncNetworkTopology(stream))
+		})
+	}
+}
+
 func TestServiceV1_triggerTask(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -3012,7 +3288,7 @@ func TestServiceV1_triggerTask(t *testing.T) {
 			res := resource.NewMockResource(ctl)
 			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
 			storage := storagemocks.NewMockStorage(ctl)
-			svc := NewV1(tc.config, res, scheduling, dynconfig, storage)
+			svc := NewV1(tc.config, res, scheduling, dynconfig, storage, nil)
 
 			mockHost := resource.NewHost(
 				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
@@ -3029,6 +3305,85 @@ func TestServiceV1_triggerTask(t *testing.T) {
 	}
 }
 
+func TestServiceV1_triggerTaskWithBackToSourceQuota(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, svc *V1, mockTask *resource.Task, mockHost *resource.Host, mockPeer *resource.Peer, dynconfig config.DynconfigInterface, seedPeer resource.SeedPeer, quota resource.BackToSourceQuota, mr *resource.MockResourceMockRecorder, mc *resource.MockSeedPeerMockRecorder, mq *resource.MockBackToSourceQuotaMockRecorder)
+	}{
+		{
+			name: "back-to-source quota is acquired",
+			run: func(t *testing.T, svc *V1, mockTask *resource.Task, mockHost *resource.Host, mockPeer *resource.Peer, dynconfig config.DynconfigInterface, seedPeer resource.SeedPeer, quota resource.BackToSourceQuota, mr *resource.MockResourceMockRecorder, mc *resource.MockSeedPeerMockRecorder, mq *resource.MockBackToSourceQuotaMockRecorder) {
+				mockTask.FSM.SetState(resource.TaskStatePending)
+
+				var wg sync.WaitGroup
+				wg.Add(1)
+				defer wg.Wait()
+
+				gomock.InOrder(
+					mr.BackToSourceQuota().Return(quota).Times(1),
+					mq.Acquire(gomock.Any(), gomock.Eq(mockPeer)).Return(true).Times(1),
+					mr.SeedPeer().Return(seedPeer).Times(1),
+					mc.TriggerTask(gomock.Any(), gomock.Any(), gomock.Any()).Do(func(ctx context.Context, rg *nethttp.Range, task *resource.Task) { wg.Done() }).Return(mockPeer, &schedulerv1.PeerResult{}, nil).Times(1),
+				)
+
+				err := svc.triggerTask(context.Background(), &schedulerv1.PeerTaskRequest{
+					UrlMeta: &commonv1.UrlMeta{
+						Priority: commonv1.Priority_LEVEL6,
+					},
+				}, mockTask, mockHost, mockPeer, dynconfig)
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.False(mockPeer.NeedBackToSource.Load())
+			},
+		},
+		{
+			name: "back-to-source quota is exceeded",
+			run: func(t *testing.T, svc *V1, mockTask *resource.Task, mockHost *resource.Host, mockPeer *resource.Peer, dynconfig config.DynconfigInterface, seedPeer resource.SeedPeer, quota resource.BackToSourceQuota, mr *resource.MockResourceMockRecorder, mc *resource.MockSeedPeerMockRecorder, mq *resource.MockBackToSourceQuotaMockRecorder) {
+				mockTask.FSM.SetState(resource.TaskStatePending)
+
+				gomock.InOrder(
+					mr.BackToSourceQuota().Return(quota).Times(1),
+					mq.Acquire(gomock.Any(), gomock.Eq(mockPeer)).Return(false).Times(1),
+				)
+
+				err := svc.triggerTask(context.Background(), &schedulerv1.PeerTaskRequest{
+					UrlMeta: &commonv1.UrlMeta{
+						Priority: commonv1.Priority_LEVEL6,
+					},
+				}, mockTask, mockHost, mockPeer, dynconfig)
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(mockPeer.NeedBackToSource.Load())
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			scheduling := mocks.NewMockScheduling(ctl)
+			res := resource.NewMockResource(ctl)
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			storage := storagemocks.NewMockStorage(ctl)
+			svc := NewV1(&config.Config{
+				Scheduler:         mockSchedulerConfig,
+				SeedPeer:          config.SeedPeerConfig{Enable: true},
+				BackToSourceQuota: config.BackToSourceQuotaConfig{Enable: true},
+			}, res, scheduling, dynconfig, storage, nil)
+
+			mockHost := resource.NewHost(
+				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
+			mockTask := resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength))
+			mockPeer := resource.NewPeer(mockPeerID, mockTask, mockHost)
+			seedPeer := resource.NewMockSeedPeer(ctl)
+			quota := resource.NewMockBackToSourceQuota(ctl)
+			tc.run(t, svc, mockTask, mockHost, mockPeer, dynconfig, seedPeer, quota, res.EXPECT(), seedPeer.EXPECT(), quota.EXPECT())
+		})
+	}
+}
+
 func TestServiceV1_storeTask(t *testing.T) {
 	tests := []struct {
 		name string
@@ -3059,6 +3414,36 @@ func TestServiceV1_storeTask(t *testing.T) {
 				assert.EqualValues(task, mockTask)
 			},
 		},
+		{
+			name: "task is taken over from replica",
+			run: func(t *testing.T, svc *V1, taskManager resource.TaskManager, mr *resource.MockResourceMockRecorder, mt *resource.MockTaskManagerMockRecorder) {
+				svc.config.Replication.Enable = true
+				mockTask := resource.NewTask(mockTaskID, "", mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, nil, nil, mockTaskBackToSourceLimit)
+				replicator := resource.NewMockReplicator(gomock.NewController(t))
+
+				gomock.InOrder(
+					mr.TaskManager().Return(taskManager).Times(1),
+					mt.Load(gomock.Eq(mockTaskID)).Return(nil, false).Times(1),
+					mr.Replicator().Return(replicator).Times(1),
+					replicator.EXPECT().Takeover(gomock.Any(), gomock.Eq(mockTaskID)).Return(mockTask, true).Times(1),
+				)
+
+				task := svc.storeTask(context.Background(), &schedulerv1.PeerTaskRequest{
+					TaskId: mockTaskID,
+					Url:    mockTaskURL,
+					UrlMeta: &commonv1.UrlMeta{
+						Priority: commonv1.Priority_LEVEL0,
+						Filter:   strings.Join(mockTaskFilters, idgen.URLFilterSeparator),
+						Header:   mockTaskHeader,
+					},
+					PeerHost: mockPeerHost,
+				}, commonv2.TaskType_DFDAEMON)
+
+				assert := assert.New(t)
+				assert.Same(task, mockTask)
+				assert.Equal(task.URL, mockTaskURL)
+			},
+		},
 		{
 			name: "task does not exist",
 			run: func(t *testing.T, svc *V1, taskManager resource.TaskManager, mr *resource.MockResourceMockRecorder, mt *resource.MockTaskManagerMockRecorder) {
@@ -3116,7 +3501,7 @@ func TestServiceV1_storeTask(t *testing.T) {
 			res := resource.NewMockResource(ctl)
 			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
 			storage := storagemocks.NewMockStorage(ctl)
-			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduling, dynconfig, storage)
+			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduling, dynconfig, storage, nil)
 			taskManager := resource.NewMockTaskManager(ctl)
 			tc.run(t, svc, taskManager, res.EXPECT(), taskManager.EXPECT())
 		})
@@ -3195,7 +3580,7 @@ func TestServiceV1_storeHost(t *testing.T) {
 			res := resource.NewMockResource(ctl)
 			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
 			storage := storagemocks.NewMockStorage(ctl)
-			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduling, dynconfig, storage)
+			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduling, dynconfig, storage, nil)
 			hostManager := resource.NewMockHostManager(ctl)
 			mockHost := resource.NewHost(
 				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
@@ -3279,7 +3664,7 @@ func TestServiceV1_storePeer(t *testing.T) {
 			res := resource.NewMockResource(ctl)
 			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
 			storage := storagemocks.NewMockStorage(ctl)
-			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduling, dynconfig, storage)
+			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduling, dynconfig, storage, nil)
 			peerManager := resource.NewMockPeerManager(ctl)
 
 			tc.run(t, svc, peerManager, res.EXPECT(), peerManager.EXPECT())
@@ -3344,7 +3729,7 @@ func TestServiceV1_triggerSeedPeerTask(t *testing.T) {
 				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
 			task := resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength))
 			peer := resource.NewPeer(mockPeerID, task, mockHost)
-			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduling, dynconfig, storage)
+			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduling, dynconfig, storage, nil)
 
 			tc.mock(task, peer, seedPeer, res.EXPECT(), seedPeer.EXPECT())
 			svc.triggerSeedPeerTask(context.Background(), &mockPeerRange, task)
@@ -3425,7 +3810,7 @@ func TestServiceV1_handleBeginOfPiece(t *testing.T) {
 				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
 			mockTask := resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength))
 			peer := resource.NewPeer(mockPeerID, mockTask, mockHost)
-			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduling, dynconfig, storage)
+			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduling, dynconfig, storage, nil)
 
 			tc.mock(peer, scheduling.EXPECT())
 			svc.handleBeginOfPiece(context.Background(), peer)
@@ -3560,7 +3945,7 @@ func TestServiceV1_handlePieceSuccess(t *testing.T) {
 			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
 			storage := storagemocks.NewMockStorage(ctl)
 			peerManager := resource.NewMockPeerManager(ctl)
-			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig, Metrics: config.MetricsConfig{EnableHost: true}}, res, scheduling, dynconfig, storage)
+			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig, Metrics: config.MetricsConfig{EnableHost: true}}, res, scheduling, dynconfig, storage, nil)
 
 			tc.mock(tc.peer, peerManager, res.EXPECT(), peerManager.EXPECT())
 			svc.handlePieceSuccess(context.Background(), tc.peer, tc.piece)
@@ -3652,6 +4037,69 @@ func TestServiceV1_handlePieceFail(t *testing.T) {
 				assert.Equal(parent.Host.UploadFailedCount.Load(), int64(1))
 			},
 		},
+		{
+			name: "piece result reports digest mismatch by one child and parent is not failed",
+			config: &config.Config{
+				Scheduler: mockSchedulerConfig,
+				SeedPeer:  config.SeedPeerConfig{Enable: true},
+				Metrics:   config.MetricsConfig{EnableHost: true},
+			},
+			piece: &schedulerv1.PieceResult{
+				Code:            commonv1.Code_ClientPieceDownloadFail,
+				DstPid:          mockSeedPeerID,
+				ExtendAttribute: common.NewDigestMismatchExtendAttribute(),
+			},
+			run: func(t *testing.T, svc *V1, peer *resource.Peer, parent *resource.Peer, piece *schedulerv1.PieceResult, peerManager resource.PeerManager, seedPeer resource.SeedPeer, ms *mocks.MockSchedulingMockRecorder, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder, mc *resource.MockSeedPeerMockRecorder) {
+				peer.FSM.SetState(resource.PeerStateRunning)
+				parent.FSM.SetState(resource.PeerStateSucceeded)
+				blocklist := set.NewSafeSet[string]()
+				blocklist.Add(parent.ID)
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(parent.ID)).Return(parent, true).Times(1),
+					ms.ScheduleParentAndCandidateParents(gomock.Any(), gomock.Eq(peer), gomock.Eq(blocklist)).Return().Times(1),
+				)
+
+				svc.handlePieceFailure(context.Background(), peer, piece)
+				assert := assert.New(t)
+				assert.True(peer.FSM.Is(resource.PeerStateRunning))
+				assert.True(parent.FSM.Is(resource.PeerStateSucceeded))
+				assert.Equal(parent.DigestMismatchChildren.Len(), uint(1))
+				assert.Equal(parent.Host.UploadFailedCount.Load(), int64(1))
+			},
+		},
+		{
+			name: "piece result reports digest mismatch by distinct children and parent state set PeerEventDownloadFailed",
+			config: &config.Config{
+				Scheduler: mockSchedulerConfig,
+				SeedPeer:  config.SeedPeerConfig{Enable: true},
+				Metrics:   config.MetricsConfig{EnableHost: true},
+			},
+			piece: &schedulerv1.PieceResult{
+				Code:            commonv1.Code_ClientPieceDownloadFail,
+				DstPid:          mockSeedPeerID,
+				ExtendAttribute: common.NewDigestMismatchExtendAttribute(),
+			},
+			run: func(t *testing.T, svc *V1, peer *resource.Peer, parent *resource.Peer, piece *schedulerv1.PieceResult, peerManager resource.PeerManager, seedPeer resource.SeedPeer, ms *mocks.MockSchedulingMockRecorder, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder, mc *resource.MockSeedPeerMockRecorder) {
+				peer.FSM.SetState(resource.PeerStateRunning)
+				parent.FSM.SetState(resource.PeerStateSucceeded)
+				parent.DigestMismatchChildren.Add("foo")
+				blocklist := set.NewSafeSet[string]()
+				blocklist.Add(parent.ID)
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(parent.ID)).Return(parent, true).Times(1),
+					ms.ScheduleParentAndCandidateParents(gomock.Any(), gomock.Eq(peer), gomock.Eq(blocklist)).Return().Times(1),
+				)
+
+				svc.handlePieceFailure(context.Background(), peer, piece)
+				assert := assert.New(t)
+				assert.True(peer.FSM.Is(resource.PeerStateRunning))
+				assert.True(parent.FSM.Is(resource.PeerStateFailed))
+				assert.Equal(parent.DigestMismatchChildren.Len(), uint(2))
+				assert.Equal(parent.Host.UploadFailedCount.Load(), int64(1))
+			},
+		},
 		{
 			name: "piece result code is Code_ClientPieceNotFound and parent is not seed peer",
 			config: &config.Config{
@@ -3756,7 +4204,7 @@ func TestServiceV1_handlePieceFail(t *testing.T) {
 			peer := resource.NewPeer(mockPeerID, mockTask, mockHost)
 			parent := resource.NewPeer(mockSeedPeerID, mockTask, mockHost)
 			seedPeer := resource.NewMockSeedPeer(ctl)
-			svc := NewV1(tc.config, res, scheduling, dynconfig, storage)
+			svc := NewV1(tc.config, res, scheduling, dynconfig, storage, nil)
 
 			tc.run(t, svc, peer, parent, tc.piece, peerManager, seedPeer, scheduling.EXPECT(), res.EXPECT(), peerManager.EXPECT(), seedPeer.EXPECT())
 		})
@@ -3879,7 +4327,7 @@ func TestServiceV1_handlePeerSuccess(t *testing.T) {
 				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
 			mockTask := resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength))
 			peer := resource.NewPeer(mockPeerID, mockTask, mockHost)
-			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig, Metrics: config.MetricsConfig{EnableHost: true}}, res, scheduling, dynconfig, storage)
+			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig, Metrics: config.MetricsConfig{EnableHost: true}}, res, scheduling, dynconfig, storage, nil)
 
 			tc.mock(peer)
 			svc.handlePeerSuccess(context.Background(), peer)
@@ -3954,7 +4402,7 @@ func TestServiceV1_handlePeerFail(t *testing.T) {
 			res := resource.NewMockResource(ctl)
 			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
 			storage := storagemocks.NewMockStorage(ctl)
-			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig, Metrics: config.MetricsConfig{EnableHost: true}}, res, scheduling, dynconfig, storage)
+			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig, Metrics: config.MetricsConfig{EnableHost: true}}, res, scheduling, dynconfig, storage, nil)
 			mockHost := resource.NewHost(
 				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
 				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
@@ -4040,7 +4488,7 @@ func TestServiceV1_handleTaskSuccess(t *testing.T) {
 			res := resource.NewMockResource(ctl)
 			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
 			storage := storagemocks.NewMockStorage(ctl)
-			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig, Metrics: config.MetricsConfig{EnableHost: true}}, res, scheduling, dynconfig, storage)
+			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig, Metrics: config.MetricsConfig{EnableHost: true}}, res, scheduling, dynconfig, storage, nil)
 			task := resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength))
 
 			tc.mock(task)
@@ -4179,7 +4627,7 @@ func TestServiceV1_handleTaskFail(t *testing.T) {
 			res := resource.NewMockResource(ctl)
 			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
 			storage := storagemocks.NewMockStorage(ctl)
-			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig, Metrics: config.MetricsConfig{EnableHost: true}}, res, scheduling, dynconfig, storage)
+			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig, Metrics: config.MetricsConfig{EnableHost: true}}, res, scheduling, dynconfig, storage, nil)
 			task := resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength))
 
 			tc.mock(task)
@@ -34,6 +34,7 @@ import (
 	"d7y.io/dragonfly/v2/pkg/container/set"
 	"d7y.io/dragonfly/v2/pkg/digest"
 	"d7y.io/dragonfly/v2/pkg/net/http"
+	"d7y.io/dragonfly/v2/pkg/rpc/common"
 	"d7y.io/dragonfly/v2/pkg/types"
 	"d7y.io/dragonfly/v2/scheduler/config"
 	"d7y.io/dragonfly/v2/scheduler/metrics"
@@ -176,7 +177,10 @@ func (v *V2) AnnouncePeer(stream schedulerv2.Scheduler_AnnouncePeerServer) error
 			}
 		case *schedulerv2.AnnouncePeerRequest_SyncPiecesFailedRequest:
 			logger.Infof("receive AnnouncePeerRequest_SyncPiecesFailedRequest: %#v", announcePeerRequest.SyncPiecesFailedRequest)
-			v.handleSyncPiecesFailedRequest(ctx, announcePeerRequest.SyncPiecesFailedRequest)
+			if err := v.handleSyncPiecesFailedRequest(ctx, req.PeerId, announcePeerRequest.SyncPiecesFailedRequest); err != nil {
+				logger.Error(err)
+				return err
+			}
 		default:
 			msg := fmt.Sprintf("receive unknow request: %#v", announcePeerRequest)
 			logger.Error(msg)
@@ -375,8 +379,10 @@ func (v *V2) LeavePeer(ctx context.Context, req *schedulerv2.LeavePeerRequest) e
 	return nil
 }
 
-// TODO Implement function.
 // ExchangePeer exchanges peer information.
+//
+// TODO Implement function when ExchangePeerResponse carries the peers and their
+// finished pieces, it has no fields in d7y.io/api v1.8.6.
 func (v *V2) ExchangePeer(ctx context.Context, req *schedulerv2.ExchangePeerRequest) (*schedulerv2.ExchangePeerResponse, error) {
 	return nil, nil
 }
@@ -448,6 +454,9 @@ func (v *V2) StatTask(ctx context.Context, req *schedulerv2.StatTaskRequest) (*c
 func (v *V2) AnnounceHost(ctx context.Context, req *schedulerv2.AnnounceHostRequest) error {
 	logger.WithHostID(req.Host.Id).Infof("announce host request: %#v", req.Host)
 
+	// The upload bandwidth of host is announced in grpc metadata.
+	bandwidth, _ := common.NetworkBandwidthFromIncomingContext(ctx)
+
 	// Get scheduler cluster client config by manager.
 	var concurrentUploadLimit int32
 	if clientConfig, err := v.dynconfig.GetSchedulerClusterClientConfig(); err == nil {
@@ -507,6 +516,8 @@ func (v *V2) AnnounceHost(ctx context.Context, req *schedulerv2.AnnounceHostRequ
 				SecurityDomain:           req.Host.Network.SecurityDomain,
 				Location:                 req.Host.Network.Location,
 				IDC:                      req.Host.Network.Idc,
+				UploadRate:               bandwidth.UploadRate,
+				UploadBandwidth:          bandwidth.UploadBandwidth,
 			}))
 		}
 
@@ -597,6 +608,8 @@ func (v *V2) AnnounceHost(ctx context.Context, req *schedulerv2.AnnounceHostRequ
 			SecurityDomain:           req.Host.Network.SecurityDomain,
 			Location:                 req.Host.Network.Location,
 			IDC:                      req.Host.Network.Idc,
+			UploadRate:               bandwidth.UploadRate,
+			UploadBandwidth:          bandwidth.UploadBandwidth,
 		}
 	}
 
@@ -1079,9 +1092,29 @@ func (v *V2) handleDownloadPieceBackToSourceFailedRequest(ctx context.Context, p
 	return status.Error(codes.Internal, "download piece from source failed")
 }
 
-// TODO Implement function.
 // handleSyncPiecesFailedRequest handles SyncPiecesFailedRequest of AnnouncePeerRequest.
-func (v *V2) handleSyncPiecesFailedRequest(ctx context.Context, req *schedulerv2.SyncPiecesFailedRequest) {
+func (v *V2) handleSyncPiecesFailedRequest(ctx context.Context, peerID string, req *schedulerv2.SyncPiecesFailedRequest) error {
+	peer, loaded := v.resource.PeerManager().Load(peerID)
+	if !loaded {
+		return status.Errorf(codes.NotFound, "peer %s not found", peerID)
+	}
+
+	// Handle peer with sync pieces failed request, the parent is blocked
+	// and candidate parents are rescheduled.
+	peer.Log.Infof("sync pieces from parent %s failed: %s", req.ParentId, req.Description)
+	peer.UpdatedAt.Store(time.Now())
+	peer.BlockParents.Add(req.ParentId)
+	if parent, loaded := v.resource.PeerManager().Load(req.ParentId); loaded {
+		parent.Host.UploadFailedCount.Inc()
+	}
+
+	if err := v.scheduling.ScheduleCandidateParents(ctx, peer, peer.BlockParents); err != nil {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	// Handle task with sync pieces failed request.
+	peer.Task.UpdatedAt.Store(time.Now())
+	return nil
 }
 
 // handleResource handles resource included host, task, and peer.
@@ -1095,6 +1128,11 @@ func (v *V2) handleResource(ctx context.Context, stream schedulerv2.Scheduler_An
 
 	// Store new task or update task.
 	task, loaded := v.resource.TaskManager().Load(taskID)
+	if !loaded && v.config.Replication.Enable {
+		// Take over the task from the replica if its owner died.
+		task, loaded = v.resource.Replicator().Takeover(ctx, taskID)
+	}
+
 	if !loaded {
 		options := []resource.TaskOption{resource.WithPieceLength(download.PieceLength)}
 		if download.Digest != "" {
@@ -1131,16 +1169,35 @@ func (v *V2) handleResource(ctx context.Context, stream schedulerv2.Scheduler_An
 	return host, task, peer, nil
 }
 
+// acquireBackToSourceQuota acquires the back-to-source quotas of application for the peer
+// before triggering the seed peer, it returns false if the quotas are exceeded.
+func (v *V2) acquireBackToSourceQuota(ctx context.Context, peer *resource.Peer) bool {
+	if !v.config.BackToSourceQuota.Enable {
+		return true
+	}
+
+	return resource.AcquireBackToSourceQuota(ctx, v.resource.BackToSourceQuota(), peer)
+}
+
 // downloadTaskBySeedPeer downloads task by seed peer.
 func (v *V2) downloadTaskBySeedPeer(ctx context.Context, peer *resource.Peer) error {
 	// Trigger the first download task based on different priority levels,
 	// refer to https://github.com/dragonflyoss/api/blob/main/pkg/apis/common/v2/common.proto#L74.
 	priority := peer.CalculatePriority(v.dynconfig)
 	peer.Log.Infof("peer priority is %s", priority.String())
+
+	// The seed peer downloads back-to-source for the peer, if the back-to-source
+	// quotas of application are exceeded, the seed peer is not triggered.
+	seedPeerEnabled := v.config.SeedPeer.Enable && !peer.Task.IsSeedPeerFailed()
+	switch priority {
+	case commonv2.Priority_LEVEL6, commonv2.Priority_LEVEL0, commonv2.Priority_LEVEL5, commonv2.Priority_LEVEL4:
+		seedPeerEnabled = seedPeerEnabled && v.acquireBackToSourceQuota(ctx, peer)
+	}
+
 	switch priority {
 	case commonv2.Priority_LEVEL6, commonv2.Priority_LEVEL0:
 		// Super peer is first triggered to download back-to-source.
-		if v.config.SeedPeer.Enable && !peer.Task.IsSeedPeerFailed() {
+		if seedPeerEnabled {
 			go func(ctx context.Context, peer *resource.Peer, hostType types.HostType) {
 				if err := v.resource.SeedPeer().DownloadTask(context.Background(), peer.Task, hostType); err != nil {
 					peer.Log.Errorf("%s seed peer downloads task failed %s", hostType.Name(), err.Error())
@@ -1153,7 +1210,7 @@ func (v *V2) downloadTaskBySeedPeer(ctx context.Context, peer *resource.Peer) er
 		fallthrough
 	case commonv2.Priority_LEVEL5:
 		// Strong peer is first triggered to download back-to-source.
-		if v.config.SeedPeer.Enable && !peer.Task.IsSeedPeerFailed() {
+		if seedPeerEnabled {
 			go func(ctx context.Context, peer *resource.Peer, hostType types.HostType) {
 				if err := v.resource.SeedPeer().DownloadTask(context.Background(), peer.Task, hostType); err != nil {
 					peer.Log.Errorf("%s seed peer downloads task failed %s", hostType.Name(), err.Error())
@@ -1166,7 +1223,7 @@ func (v *V2) downloadTaskBySeedPeer(ctx context.Context, peer *resource.Peer) er
 		fallthrough
 	case commonv2.Priority_LEVEL4:
 		// Weak peer is first triggered to download back-to-source.
-		if v.config.SeedPeer.Enable && !peer.Task.IsSeedPeerFailed() {
+		if seedPeerEnabled {
 			go func(ctx context.Context, peer *resource.Peer, hostType types.HostType) {
 				if err := v.resource.SeedPeer().DownloadTask(context.Background(), peer.Task, hostType); err != nil {
 					peer.Log.Errorf("%s seed peer downloads task failed %s", hostType.Name(), err.Error())
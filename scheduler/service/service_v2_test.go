@@ -3148,6 +3148,123 @@ func TestServiceV2_handleDownloadPieceBackToSourceFailedRequest(t *testing.T) {
 	}
 }
 
+func TestServiceV2_handleSyncPiecesFailedRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *schedulerv2.SyncPiecesFailedRequest
+		run  func(t *testing.T, svc *V2, req *schedulerv2.SyncPiecesFailedRequest, peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder,
+			mp *resource.MockPeerManagerMockRecorder, ms *schedulingmocks.MockSchedulingMockRecorder)
+	}{
+		{
+			name: "peer can not be loaded",
+			req: &schedulerv2.SyncPiecesFailedRequest{
+				ParentId: mockSeedPeerID,
+			},
+			run: func(t *testing.T, svc *V2, req *schedulerv2.SyncPiecesFailedRequest, peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder,
+				mp *resource.MockPeerManagerMockRecorder, ms *schedulingmocks.MockSchedulingMockRecorder) {
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(peer.ID)).Return(nil, false).Times(1),
+				)
+
+				assert := assert.New(t)
+				assert.ErrorIs(svc.handleSyncPiecesFailedRequest(context.Background(), peer.ID, req), status.Errorf(codes.NotFound, "peer %s not found", peer.ID))
+			},
+		},
+		{
+			name: "schedule failed",
+			req: &schedulerv2.SyncPiecesFailedRequest{
+				ParentId: mockSeedPeerID,
+			},
+			run: func(t *testing.T, svc *V2, req *schedulerv2.SyncPiecesFailedRequest, peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder,
+				mp *resource.MockPeerManagerMockRecorder, ms *schedulingmocks.MockSchedulingMockRecorder) {
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(peer.ID)).Return(peer, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(req.ParentId)).Return(nil, false).Times(1),
+					ms.ScheduleCandidateParents(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("foo")).Times(1),
+				)
+
+				assert := assert.New(t)
+				assert.ErrorIs(svc.handleSyncPiecesFailedRequest(context.Background(), peer.ID, req), status.Error(codes.FailedPrecondition, "foo"))
+				assert.NotEqual(peer.UpdatedAt.Load(), 0)
+				assert.True(peer.BlockParents.Contains(req.ParentId))
+			},
+		},
+		{
+			name: "parent can not be loaded",
+			req: &schedulerv2.SyncPiecesFailedRequest{
+				ParentId: mockSeedPeerID,
+			},
+			run: func(t *testing.T, svc *V2, req *schedulerv2.SyncPiecesFailedRequest, peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder,
+				mp *resource.MockPeerManagerMockRecorder, ms *schedulingmocks.MockSchedulingMockRecorder) {
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(peer.ID)).Return(peer, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(req.ParentId)).Return(nil, false).Times(1),
+					ms.ScheduleCandidateParents(gomock.Any(), gomock.Eq(peer), gomock.Any()).Return(nil).Times(1),
+				)
+
+				assert := assert.New(t)
+				assert.NoError(svc.handleSyncPiecesFailedRequest(context.Background(), peer.ID, req))
+				assert.True(peer.BlockParents.Contains(req.ParentId))
+				assert.Equal(peer.Host.UploadFailedCount.Load(), int64(0))
+				assert.NotEqual(peer.Task.UpdatedAt.Load(), 0)
+			},
+		},
+		{
+			name: "parent can be loaded",
+			req: &schedulerv2.SyncPiecesFailedRequest{
+				ParentId:    mockSeedPeerID,
+				Description: "foo",
+			},
+			run: func(t *testing.T, svc *V2, req *schedulerv2.SyncPiecesFailedRequest, peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder,
+				mp *resource.MockPeerManagerMockRecorder, ms *schedulingmocks.MockSchedulingMockRecorder) {
+				parent := resource.NewPeer(mockSeedPeerID, peer.Task, resource.NewHost(
+					mockRawSeedHost.ID, mockRawSeedHost.IP, mockRawSeedHost.Hostname,
+					mockRawSeedHost.Port, mockRawSeedHost.DownloadPort, mockRawSeedHost.Type))
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(peer.ID)).Return(peer, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(req.ParentId)).Return(parent, true).Times(1),
+					ms.ScheduleCandidateParents(gomock.Any(), gomock.Eq(peer), gomock.Any()).Return(nil).Times(1),
+				)
+
+				assert := assert.New(t)
+				assert.NoError(svc.handleSyncPiecesFailedRequest(context.Background(), peer.ID, req))
+				assert.True(peer.BlockParents.Contains(req.ParentId))
+				assert.Equal(parent.Host.UploadFailedCount.Load(), int64(1))
+				assert.Equal(peer.Host.UploadFailedCount.Load(), int64(0))
+				assert.NotEqual(peer.Task.UpdatedAt.Load(), 0)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			scheduling := schedulingmocks.NewMockScheduling(ctl)
+			res := resource.NewMockResource(ctl)
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			storage := storagemocks.NewMockStorage(ctl)
+			peerManager := resource.NewMockPeerManager(ctl)
+
+			mockHost := resource.NewHost(
+				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
+			mockTask := resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength))
+			peer := resource.NewPeer(mockPeerID, mockTask, mockHost)
+			svc := NewV2(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduling, dynconfig, storage)
+
+			tc.run(t, svc, tc.req, peer, peerManager, res.EXPECT(), peerManager.EXPECT(), scheduling.EXPECT())
+		})
+	}
+}
+
 func TestServiceV2_handleResource(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -3633,3 +3750,82 @@ func TestServiceV2_downloadTaskBySeedPeer(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceV2_downloadTaskBySeedPeerWithBackToSourceQuota(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, svc *V2, peer *resource.Peer, seedPeerClient resource.SeedPeer, quota resource.BackToSourceQuota, mr *resource.MockResourceMockRecorder, ms *resource.MockSeedPeerMockRecorder, mq *resource.MockBackToSourceQuotaMockRecorder)
+	}{
+		{
+			name: "back-to-source quota is acquired",
+			run: func(t *testing.T, svc *V2, peer *resource.Peer, seedPeerClient resource.SeedPeer, quota resource.BackToSourceQuota, mr *resource.MockResourceMockRecorder, ms *resource.MockSeedPeerMockRecorder, mq *resource.MockBackToSourceQuotaMockRecorder) {
+				var wg sync.WaitGroup
+				wg.Add(1)
+				defer wg.Wait()
+
+				gomock.InOrder(
+					mr.BackToSourceQuota().Return(quota).Times(1),
+					mq.Acquire(gomock.Any(), gomock.Eq(peer)).Return(true).Times(1),
+					mr.SeedPeer().Return(seedPeerClient).Times(1),
+					ms.DownloadTask(gomock.All(), gomock.Any(), types.HostTypeSuperSeed).Do(func(context.Context, *resource.Task, types.HostType) { wg.Done() }).Return(nil).Times(1),
+				)
+
+				peer.Priority = commonv2.Priority_LEVEL6
+
+				assert := assert.New(t)
+				assert.NoError(svc.downloadTaskBySeedPeer(context.Background(), peer))
+				assert.False(peer.NeedBackToSource.Load())
+			},
+		},
+		{
+			name: "back-to-source quota is exceeded",
+			run: func(t *testing.T, svc *V2, peer *resource.Peer, seedPeerClient resource.SeedPeer, quota resource.BackToSourceQuota, mr *resource.MockResourceMockRecorder, ms *resource.MockSeedPeerMockRecorder, mq *resource.MockBackToSourceQuotaMockRecorder) {
+				gomock.InOrder(
+					mr.BackToSourceQuota().Return(quota).Times(1),
+					mq.Acquire(gomock.Any(), gomock.Eq(peer)).Return(false).Times(1),
+				)
+
+				peer.Priority = commonv2.Priority_LEVEL6
+
+				assert := assert.New(t)
+				assert.NoError(svc.downloadTaskBySeedPeer(context.Background(), peer))
+				assert.True(peer.NeedBackToSource.Load())
+			},
+		},
+		{
+			name: "priority is Priority_LEVEL3",
+			run: func(t *testing.T, svc *V2, peer *resource.Peer, seedPeerClient resource.SeedPeer, quota resource.BackToSourceQuota, mr *resource.MockResourceMockRecorder, ms *resource.MockSeedPeerMockRecorder, mq *resource.MockBackToSourceQuotaMockRecorder) {
+				peer.Priority = commonv2.Priority_LEVEL3
+
+				assert := assert.New(t)
+				assert.NoError(svc.downloadTaskBySeedPeer(context.Background(), peer))
+				assert.True(peer.NeedBackToSource.Load())
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			scheduling := schedulingmocks.NewMockScheduling(ctl)
+			res := resource.NewMockResource(ctl)
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			storage := storagemocks.NewMockStorage(ctl)
+			seedPeerClient := resource.NewMockSeedPeer(ctl)
+			quota := resource.NewMockBackToSourceQuota(ctl)
+
+			mockHost := resource.NewHost(
+				mockRawHost.ID, mockRawHost.IP, mockRawHost.Hostname,
+				mockRawHost.Port, mockRawHost.DownloadPort, mockRawHost.Type)
+			mockTask := resource.NewTask(mockTaskID, mockTaskURL, mockTaskTag, mockTaskApplication, commonv2.TaskType_DFDAEMON, mockTaskFilters, mockTaskHeader, mockTaskBackToSourceLimit, resource.WithDigest(mockTaskDigest), resource.WithPieceLength(mockTaskPieceLength))
+			peer := resource.NewPeer(mockPeerID, mockTask, mockHost)
+			svc := NewV2(&config.Config{
+				SeedPeer:          config.SeedPeerConfig{Enable: true},
+				BackToSourceQuota: config.BackToSourceQuotaConfig{Enable: true},
+			}, res, scheduling, dynconfig, storage)
+
+			tc.run(t, svc, peer, seedPeerClient, quota, res.EXPECT(), seedPeerClient.EXPECT(), quota.EXPECT())
+		})
+	}
+}
@@ -0,0 +1,161 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/gocarina/gocsv"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// downloadEncoder encodes the downloads to the writer.
+type downloadEncoder interface {
+	// Encode writes the download to the writer.
+	Encode(Download) error
+
+	// Close flushes the downloads which are not written.
+	Close() error
+}
+
+// newDownloadEncoder returns a new downloadEncoder interface by the format.
+func newDownloadEncoder(w io.Writer, format Format) (downloadEncoder, error) {
+	switch format {
+	case FormatCSV:
+		return &csvEncoder{w: w}, nil
+	case FormatJSONL:
+		return &jsonlEncoder{encoder: json.NewEncoder(w)}, nil
+	case FormatParquet:
+		pw, err := writer.NewParquetWriterFromWriter(w, new(parquetDownload), 1)
+		if err != nil {
+			return nil, err
+		}
+
+		return &parquetEncoder{pw: pw}, nil
+	default:
+		return nil, fmt.Errorf("invalid format: %s", format)
+	}
+}
+
+// csvEncoder encodes a download per csv row without headers.
+type csvEncoder struct {
+	w io.Writer
+}
+
+// Encode writes the download to the writer.
+func (e *csvEncoder) Encode(download Download) error {
+	return gocsv.MarshalWithoutHeaders([]Download{download}, e.w)
+}
+
+// Close flushes the downloads which are not written.
+func (e *csvEncoder) Close() error {
+	return nil
+}
+
+// jsonlEncoder encodes a download per json line.
+type jsonlEncoder struct {
+	encoder *json.Encoder
+}
+
+// Encode writes the download to the writer.
+func (e *jsonlEncoder) Encode(download Download) error {
+	return e.encoder.Encode(download)
+}
+
+// Close flushes the downloads which are not written.
+func (e *jsonlEncoder) Close() error {
+	return nil
+}
+
+// parquetEncoder encodes the downloads to a parquet file.
+type parquetEncoder struct {
+	pw *writer.ParquetWriter
+}
+
+// Encode writes the download to the writer.
+func (e *parquetEncoder) Encode(download Download) error {
+	record, err := newParquetDownload(download)
+	if err != nil {
+		return err
+	}
+
+	return e.pw.Write(record)
+}
+
+// Close flushes the downloads which are not written, and writes the footer of parquet file.
+func (e *parquetEncoder) Close() error {
+	return e.pw.WriteStop()
+}
+
+// parquetDownload is the parquet record of download. The ids of task and host are flattened
+// for filtering, and the nested error, task, host and parents are encoded in json.
+type parquetDownload struct {
+	ID          string `parquet:"name=id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Tag         string `parquet:"name=tag, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Application string `parquet:"name=application, type=BYTE_ARRAY, convertedtype=UTF8"`
+	State       string `parquet:"name=state, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Cost        int64  `parquet:"name=cost, type=INT64"`
+	TaskID      string `parquet:"name=task_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	HostID      string `parquet:"name=host_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Error       string `parquet:"name=error, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Task        string `parquet:"name=task, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Host        string `parquet:"name=host, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Parents     string `parquet:"name=parents, type=BYTE_ARRAY, convertedtype=UTF8"`
+	CreatedAt   int64  `parquet:"name=created_at, type=INT64"`
+	UpdatedAt   int64  `parquet:"name=updated_at, type=INT64"`
+}
+
+// newParquetDownload returns the parquet record of download.
+func newParquetDownload(download Download) (*parquetDownload, error) {
+	downloadError, err := json.Marshal(download.Error)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := json.Marshal(download.Task)
+	if err != nil {
+		return nil, err
+	}
+
+	host, err := json.Marshal(download.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	parents, err := json.Marshal(download.Parents)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parquetDownload{
+		ID:          download.ID,
+		Tag:         download.Tag,
+		Application: download.Application,
+		State:       download.State,
+		Cost:        download.Cost,
+		TaskID:      download.Task.ID,
+		HostID:      download.Host.ID,
+		Error:       string(downloadError),
+		Task:        string(task),
+		Host:        string(host),
+		Parents:     string(parents),
+		CreatedAt:   download.CreatedAt,
+		UpdatedAt:   download.UpdatedAt,
+	}, nil
+}
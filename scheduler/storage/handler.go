@@ -0,0 +1,95 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+)
+
+const (
+	// DownloadExportPath is the http path of exporting downloads.
+	DownloadExportPath = "/debug/storage/downloads"
+)
+
+// downloadExportHandler exports the downloads of storage over http.
+type downloadExportHandler struct {
+	storage Storage
+}
+
+// NewDownloadExportHandler returns a http handler which exports the downloads of storage.
+// The downloads are filtered by the query parameters task_id, host_id, start_time and
+// end_time in RFC3339, and encoded by the query parameter format, which is csv by default.
+func NewDownloadExportHandler(storage Storage) http.Handler {
+	return &downloadExportHandler{storage: storage}
+}
+
+// ServeHTTP streams the downloads matched by the query parameters.
+func (h *downloadExportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	format := FormatCSV
+	if query.Get("format") != "" {
+		format = Format(query.Get("format"))
+	}
+
+	var contentType string
+	switch format {
+	case FormatCSV:
+		contentType = "text/csv"
+	case FormatJSONL:
+		contentType = "application/x-ndjson"
+	case FormatParquet:
+		contentType = "application/vnd.apache.parquet"
+	default:
+		http.Error(w, fmt.Sprintf("invalid format: %s", format), http.StatusBadRequest)
+		return
+	}
+
+	filter := DownloadFilter{
+		TaskID: query.Get("task_id"),
+		HostID: query.Get("host_id"),
+	}
+
+	var err error
+	if query.Get("start_time") != "" {
+		if filter.StartTime, err = time.Parse(time.RFC3339, query.Get("start_time")); err != nil {
+			http.Error(w, fmt.Sprintf("invalid start_time: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if query.Get("end_time") != "" {
+		if filter.EndTime, err = time.Parse(time.RFC3339, query.Get("end_time")); err != nil {
+			http.Error(w, fmt.Sprintf("invalid end_time: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.%s", DownloadFilePrefix, format))
+	if err := h.storage.ExportDownload(w, format, filter); err != nil {
+		logger.Errorf("export downloads failed: %s", err.Error())
+	}
+}
@@ -0,0 +1,101 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/scheduler/config"
+)
+
+func TestDownloadExportHandler_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		expect func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "export downloads of task in jsonl",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s?format=jsonl&task_id=%s", DownloadExportPath, mockTask.ID),
+			expect: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert := assert.New(t)
+				assert.Equal(w.Code, http.StatusOK)
+				assert.Equal(w.Header().Get("Content-Type"), "application/x-ndjson")
+
+				var download Download
+				assert.NoError(json.Unmarshal(w.Body.Bytes(), &download))
+				assert.Equal(download.ID, mockDownload.ID)
+			},
+		},
+		{
+			name:   "format is invalid",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s?format=foo", DownloadExportPath),
+			expect: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert := assert.New(t)
+				assert.Equal(w.Code, http.StatusBadRequest)
+			},
+		},
+		{
+			name:   "start time is invalid",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s?start_time=foo", DownloadExportPath),
+			expect: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert := assert.New(t)
+				assert.Equal(w.Code, http.StatusBadRequest)
+			},
+		},
+		{
+			name:   "method is not allowed",
+			method: http.MethodPost,
+			url:    DownloadExportPath,
+			expect: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert := assert.New(t)
+				assert.Equal(w.Code, http.StatusMethodNotAllowed)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := New(os.TempDir(), config.DefaultStorageMaxSize, config.DefaultStorageMaxBackups, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := s.(*storage).createDownload(mockDownload); err != nil {
+				t.Fatal(err)
+			}
+
+			w := httptest.NewRecorder()
+			NewDownloadExportHandler(s).ServeHTTP(w, httptest.NewRequest(tc.method, tc.url, nil))
+			tc.expect(t, w)
+			if err := s.ClearDownload(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
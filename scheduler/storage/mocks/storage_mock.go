@@ -49,6 +49,20 @@ func (mr *MockStorageMockRecorder) ClearDownload() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearDownload", reflect.TypeOf((*MockStorage)(nil).ClearDownload))
 }
 
+// ClearNetworkTopology mocks base method.
+func (m *MockStorage) ClearNetworkTopology() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearNetworkTopology")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearNetworkTopology indicates an expected call of ClearNetworkTopology.
+func (mr *MockStorageMockRecorder) ClearNetworkTopology() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearNetworkTopology", reflect.TypeOf((*MockStorage)(nil).ClearNetworkTopology))
+}
+
 // CreateDownload mocks base method.
 func (m *MockStorage) CreateDownload(arg0 storage.Download) error {
 	m.ctrl.T.Helper()
@@ -63,6 +77,20 @@ func (mr *MockStorageMockRecorder) CreateDownload(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDownload", reflect.TypeOf((*MockStorage)(nil).CreateDownload), arg0)
 }
 
+// CreateNetworkTopology mocks base method.
+func (m *MockStorage) CreateNetworkTopology(arg0 storage.NetworkTopology) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNetworkTopology", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateNetworkTopology indicates an expected call of CreateNetworkTopology.
+func (mr *MockStorageMockRecorder) CreateNetworkTopology(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNetworkTopology", reflect.TypeOf((*MockStorage)(nil).CreateNetworkTopology), arg0)
+}
+
 // DownloadCount mocks base method.
 func (m *MockStorage) DownloadCount() int64 {
 	m.ctrl.T.Helper()
@@ -77,19 +105,62 @@ func (mr *MockStorageMockRecorder) DownloadCount() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadCount", reflect.TypeOf((*MockStorage)(nil).DownloadCount))
 }
 
+// ExportDownload mocks base method.
+func (m *MockStorage) ExportDownload(arg0 io.Writer, arg1 storage.Format, arg2 storage.DownloadFilter) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportDownload", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportDownload indicates an expected call of ExportDownload.
+func (mr *MockStorageMockRecorder) ExportDownload(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportDownload", reflect.TypeOf((*MockStorage)(nil).ExportDownload), arg0, arg1, arg2)
+}
+
 // ListDownload mocks base method.
-func (m *MockStorage) ListDownload() ([]storage.Download, error) {
+func (m *MockStorage) ListDownload(arg0 storage.DownloadFilter) ([]storage.Download, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListDownload")
+	ret := m.ctrl.Call(m, "ListDownload", arg0)
 	ret0, _ := ret[0].([]storage.Download)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListDownload indicates an expected call of ListDownload.
-func (mr *MockStorageMockRecorder) ListDownload() *gomock.Call {
+func (mr *MockStorageMockRecorder) ListDownload(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDownload", reflect.TypeOf((*MockStorage)(nil).ListDownload))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDownload", reflect.TypeOf((*MockStorage)(nil).ListDownload), arg0)
+}
+
+// ListNetworkTopology mocks base method.
+func (m *MockStorage) ListNetworkTopology() ([]storage.NetworkTopology, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNetworkTopology")
+	ret0, _ := ret[0].([]storage.NetworkTopology)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNetworkTopology indicates an expected call of ListNetworkTopology.
+func (mr *MockStorageMockRecorder) ListNetworkTopology() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNetworkTopology", reflect.TypeOf((*MockStorage)(nil).ListNetworkTopology))
+}
+
+// NetworkTopologyCount mocks base method.
+func (m *MockStorage) NetworkTopologyCount() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetworkTopologyCount")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// NetworkTopologyCount indicates an expected call of NetworkTopologyCount.
+func (mr *MockStorageMockRecorder) NetworkTopologyCount() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetworkTopologyCount", reflect.TypeOf((*MockStorage)(nil).NetworkTopologyCount))
 }
 
 // OpenDownload mocks base method.
@@ -106,3 +177,18 @@ func (mr *MockStorageMockRecorder) OpenDownload() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenDownload", reflect.TypeOf((*MockStorage)(nil).OpenDownload))
 }
+
+// OpenNetworkTopology mocks base method.
+func (m *MockStorage) OpenNetworkTopology() (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenNetworkTopology")
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenNetworkTopology indicates an expected call of OpenNetworkTopology.
+func (mr *MockStorageMockRecorder) OpenNetworkTopology() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenNetworkTopology", reflect.TypeOf((*MockStorage)(nil).OpenNetworkTopology))
+}
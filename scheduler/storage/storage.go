@@ -19,6 +19,7 @@
 package storage
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -41,8 +42,32 @@ const (
 	// DownloadFilePrefix is prefix of download file name.
 	DownloadFilePrefix = "download"
 
+	// NetworkTopologyFilePrefix is prefix of network topology file name.
+	NetworkTopologyFilePrefix = "networktopology"
+
 	// CSVFileExt is extension of file name.
 	CSVFileExt = "csv"
+
+	// JSONLFileExt is extension of json lines file name.
+	JSONLFileExt = "jsonl"
+
+	// ParquetFileExt is extension of parquet file name.
+	ParquetFileExt = "parquet"
+)
+
+// Format is the format of records.
+type Format string
+
+const (
+	// FormatCSV is the format of csv without headers.
+	FormatCSV Format = CSVFileExt
+
+	// FormatJSONL is the format of json lines, a download per line.
+	FormatJSONL Format = JSONLFileExt
+
+	// FormatParquet is the format of parquet, it is only used for exporting downloads,
+	// because the parquet file can not be appended.
+	FormatParquet Format = ParquetFileExt
 )
 
 const (
@@ -55,11 +80,11 @@ const (
 
 // Storage is the interface used for storage.
 type Storage interface {
-	// CreateDownload inserts the download into csv file.
+	// CreateDownload inserts the download into download file.
 	CreateDownload(Download) error
 
-	// ListDownload returns all downloads in csv file.
-	ListDownload() ([]Download, error)
+	// ListDownload returns the downloads matched by the filter in download files.
+	ListDownload(DownloadFilter) ([]Download, error)
 
 	// DownloadCount returns the count of downloads.
 	DownloadCount() int64
@@ -67,8 +92,63 @@ type Storage interface {
 	// OpenDownload opens download files for read, it returns io.ReadCloser of download files.
 	OpenDownload() (io.ReadCloser, error)
 
+	// ExportDownload writes the downloads matched by the filter to the writer in the format,
+	// the downloads are read from download files one by one.
+	ExportDownload(io.Writer, Format, DownloadFilter) error
+
 	// ClearDownload removes all download files.
 	ClearDownload() error
+
+	// CreateNetworkTopology inserts the network topology into network topology file.
+	CreateNetworkTopology(NetworkTopology) error
+
+	// ListNetworkTopology returns all network topologies in network topology files.
+	ListNetworkTopology() ([]NetworkTopology, error)
+
+	// NetworkTopologyCount returns the count of network topologies.
+	NetworkTopologyCount() int64
+
+	// OpenNetworkTopology opens network topology files for read, it returns io.ReadCloser of network topology files.
+	OpenNetworkTopology() (io.ReadCloser, error)
+
+	// ClearNetworkTopology removes all network topology files.
+	ClearNetworkTopology() error
+}
+
+// DownloadFilter is the filter of downloads, the zero value of field matches all downloads.
+type DownloadFilter struct {
+	// StartTime matches the downloads created at or after it.
+	StartTime time.Time
+
+	// EndTime matches the downloads created before it.
+	EndTime time.Time
+
+	// TaskID matches the downloads of the task.
+	TaskID string
+
+	// HostID matches the downloads of the host.
+	HostID string
+}
+
+// Match returns whether the download is matched by the filter.
+func (f DownloadFilter) Match(download Download) bool {
+	if !f.StartTime.IsZero() && download.CreatedAt < f.StartTime.UnixNano() {
+		return false
+	}
+
+	if !f.EndTime.IsZero() && download.CreatedAt >= f.EndTime.UnixNano() {
+		return false
+	}
+
+	if f.TaskID != "" && download.Task.ID != f.TaskID {
+		return false
+	}
+
+	if f.HostID != "" && download.Host.ID != f.HostID {
+		return false
+	}
+
+	return true
 }
 
 // storage provides storage function.
@@ -77,36 +157,64 @@ type storage struct {
 	maxSize    int64
 	maxBackups int
 	bufferSize int
+	format     Format
 	mu         *sync.RWMutex
 
 	downloadFilename string
 	downloadBuffer   []Download
 	downloadCount    int64
+
+	networkTopologyFilename string
+	networkTopologyBuffer   []NetworkTopology
+	networkTopologyCount    int64
+}
+
+// Option is a functional option for configuring the storage.
+type Option func(s *storage)
+
+// WithFormat sets the format of storage files, the csv format is used by default.
+func WithFormat(format Format) Option {
+	return func(s *storage) {
+		s.format = format
+	}
 }
 
 // New returns a new Storage instence.
-func New(baseDir string, maxSize, maxBackups, bufferSize int) (Storage, error) {
+func New(baseDir string, maxSize, maxBackups, bufferSize int, options ...Option) (Storage, error) {
 	s := &storage{
 		baseDir:    baseDir,
 		maxSize:    int64(maxSize * megabyte),
 		maxBackups: maxBackups,
 		bufferSize: bufferSize,
+		format:     FormatCSV,
 		mu:         &sync.RWMutex{},
 
-		downloadFilename: filepath.Join(baseDir, fmt.Sprintf("%s.%s", DownloadFilePrefix, CSVFileExt)),
-		downloadBuffer:   make([]Download, 0, bufferSize),
+		downloadBuffer:        make([]Download, 0, bufferSize),
+		networkTopologyBuffer: make([]NetworkTopology, 0, bufferSize),
 	}
 
-	file, err := os.OpenFile(s.downloadFilename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
-	if err != nil {
-		return nil, err
+	for _, opt := range options {
+		opt(s)
+	}
+
+	if s.format != FormatCSV && s.format != FormatJSONL {
+		return nil, fmt.Errorf("invalid format of storage files: %s", s.format)
+	}
+	s.downloadFilename = filepath.Join(baseDir, fmt.Sprintf("%s.%s", DownloadFilePrefix, s.format))
+	s.networkTopologyFilename = filepath.Join(baseDir, fmt.Sprintf("%s.%s", NetworkTopologyFilePrefix, s.format))
+
+	for _, filename := range []string{s.downloadFilename, s.networkTopologyFilename} {
+		file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return nil, err
+		}
+		file.Close()
 	}
-	file.Close()
 
 	return s, nil
 }
 
-// CreateDownload inserts the download into csv file.
+// CreateDownload inserts the download into download file.
 func (s *storage) CreateDownload(download Download) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -140,38 +248,16 @@ func (s *storage) CreateDownload(download Download) error {
 	return nil
 }
 
-// ListDownload returns all downloads in csv file.
-func (s *storage) ListDownload() ([]Download, error) {
+// ListDownload returns the downloads matched by the filter in download files.
+func (s *storage) ListDownload(filter DownloadFilter) ([]Download, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	fileInfos, err := s.downloadBackups()
-	if err != nil {
-		return nil, err
-	}
-
-	var readers []io.Reader
-	var readClosers []io.ReadCloser
-	defer func() {
-		for _, readCloser := range readClosers {
-			if err := readCloser.Close(); err != nil {
-				logger.Error(err)
-			}
-		}
-	}()
-
-	for _, fileInfo := range fileInfos {
-		file, err := os.Open(filepath.Join(s.baseDir, fileInfo.Name()))
-		if err != nil {
-			return nil, err
-		}
-
-		readers = append(readers, file)
-		readClosers = append(readClosers, file)
-	}
-
 	var downloads []Download
-	if err := gocsv.UnmarshalWithoutHeaders(io.MultiReader(readers...), &downloads); err != nil {
+	if err := s.rangeDownload(filter, func(download Download) error {
+		downloads = append(downloads, download)
+		return nil
+	}); err != nil {
 		return nil, err
 	}
 
@@ -188,22 +274,38 @@ func (s *storage) OpenDownload() (io.ReadCloser, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	fileInfos, err := s.downloadBackups()
+	return s.open(DownloadFilePrefix)
+}
+
+// ExportDownload writes the downloads matched by the filter to the writer in the format,
+// the downloads are read from download files one by one.
+// The download files are opened under the lock and streamed after the lock is released,
+// so a slow writer does not block CreateDownload.
+func (s *storage) ExportDownload(w io.Writer, format Format, filter DownloadFilter) error {
+	s.mu.RLock()
+	files, err := s.snapshot(DownloadFilePrefix)
+	s.mu.RUnlock()
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer closeFiles(files)
 
-	var readClosers []io.ReadCloser
-	for _, fileInfo := range fileInfos {
-		file, err := os.Open(filepath.Join(s.baseDir, fileInfo.Name()))
-		if err != nil {
-			return nil, err
+	encoder, err := newDownloadEncoder(w, format)
+	if err != nil {
+		return err
+	}
+
+	if err := rangeSnapshot(files, DownloadFilePrefix, s.format, func(download Download) error {
+		if !filter.Match(download) {
+			return nil
 		}
 
-		readClosers = append(readClosers, file)
+		return encoder.Encode(download)
+	}); err != nil {
+		return err
 	}
 
-	return pkgio.MultiReadCloser(readClosers...), nil
+	return encoder.Close()
 }
 
 // ClearDownload removes all downloads.
@@ -211,22 +313,81 @@ func (s *storage) ClearDownload() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	fileInfos, err := s.downloadBackups()
-	if err != nil {
-		return err
+	return s.clear(DownloadFilePrefix)
+}
+
+// CreateNetworkTopology inserts the network topology into network topology file.
+func (s *storage) CreateNetworkTopology(networkTopology NetworkTopology) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Write without buffer.
+	if s.bufferSize == 0 {
+		if err := s.createNetworkTopology(networkTopology); err != nil {
+			return err
+		}
+
+		// Update network topology count.
+		s.networkTopologyCount++
+		return nil
 	}
 
-	for _, fileInfo := range fileInfos {
-		filename := filepath.Join(s.baseDir, fileInfo.Name())
-		if err := os.Remove(filename); err != nil {
+	// Write network topologies to file.
+	if len(s.networkTopologyBuffer) >= s.bufferSize {
+		if err := s.createNetworkTopology(s.networkTopologyBuffer...); err != nil {
 			return err
 		}
+
+		// Update network topology count.
+		s.networkTopologyCount += int64(s.bufferSize)
+
+		// Keep allocated memory.
+		s.networkTopologyBuffer = s.networkTopologyBuffer[:0]
 	}
 
+	// Write network topologies to buffer.
+	s.networkTopologyBuffer = append(s.networkTopologyBuffer, networkTopology)
 	return nil
 }
 
-// createDownload inserts the downloads into csv file.
+// ListNetworkTopology returns all network topologies in network topology files.
+func (s *storage) ListNetworkTopology() ([]NetworkTopology, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var networkTopologies []NetworkTopology
+	if err := rangeFiles(s, NetworkTopologyFilePrefix, func(networkTopology NetworkTopology) error {
+		networkTopologies = append(networkTopologies, networkTopology)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return networkTopologies, nil
+}
+
+// NetworkTopologyCount returns the count of network topologies.
+func (s *storage) NetworkTopologyCount() int64 {
+	return s.networkTopologyCount
+}
+
+// OpenNetworkTopology opens network topology files for read, it returns io.ReadCloser of network topology files.
+func (s *storage) OpenNetworkTopology() (io.ReadCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.open(NetworkTopologyFilePrefix)
+}
+
+// ClearNetworkTopology removes all network topologies.
+func (s *storage) ClearNetworkTopology() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.clear(NetworkTopologyFilePrefix)
+}
+
+// createDownload inserts the downloads into download file.
 func (s *storage) createDownload(downloads ...Download) error {
 	file, err := s.openDownloadFile()
 	if err != nil {
@@ -234,27 +395,87 @@ func (s *storage) createDownload(downloads ...Download) error {
 	}
 	defer file.Close()
 
-	if err := gocsv.MarshalWithoutHeaders(downloads, file); err != nil {
+	return writeRecords(file, s.format, downloads)
+}
+
+// createNetworkTopology inserts the network topologies into network topology file.
+func (s *storage) createNetworkTopology(networkTopologies ...NetworkTopology) error {
+	file, err := s.openFile(s.networkTopologyFilename, NetworkTopologyFilePrefix)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return writeRecords(file, s.format, networkTopologies)
+}
+
+// rangeDownload calls fn for each download matched by the filter in download files.
+func (s *storage) rangeDownload(filter DownloadFilter, fn func(Download) error) error {
+	return rangeFiles(s, DownloadFilePrefix, func(download Download) error {
+		if !filter.Match(download) {
+			return nil
+		}
+
+		return fn(download)
+	})
+}
+
+// open opens the files with the prefix for read, it returns io.ReadCloser of the files.
+func (s *storage) open(prefix string) (io.ReadCloser, error) {
+	fileInfos, err := s.backups(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var readClosers []io.ReadCloser
+	for _, fileInfo := range fileInfos {
+		file, err := os.Open(filepath.Join(s.baseDir, fileInfo.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		readClosers = append(readClosers, file)
+	}
+
+	return pkgio.MultiReadCloser(readClosers...), nil
+}
+
+// clear removes the files with the prefix.
+func (s *storage) clear(prefix string) error {
+	fileInfos, err := s.backups(prefix)
+	if err != nil {
 		return err
 	}
 
+	for _, fileInfo := range fileInfos {
+		filename := filepath.Join(s.baseDir, fileInfo.Name())
+		if err := os.Remove(filename); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 // openDownloadFile opens the download file and removes download files that exceed the total size.
 func (s *storage) openDownloadFile() (*os.File, error) {
-	fileInfo, err := os.Stat(s.downloadFilename)
+	return s.openFile(s.downloadFilename, DownloadFilePrefix)
+}
+
+// openFile opens the file and removes the files with the prefix that exceed the total size.
+func (s *storage) openFile(filename, prefix string) (*os.File, error) {
+	fileInfo, err := os.Stat(filename)
 	if err != nil {
 		return nil, err
 	}
 
 	if s.maxSize <= fileInfo.Size() {
-		if err := os.Rename(s.downloadFilename, s.downloadBackupFilename()); err != nil {
+		if err := os.Rename(filename, s.backupFilename(prefix)); err != nil {
 			return nil, err
 		}
 	}
 
-	fileInfos, err := s.downloadBackups()
+	fileInfos, err := s.backups(prefix)
 	if err != nil {
 		return nil, err
 	}
@@ -266,7 +487,7 @@ func (s *storage) openDownloadFile() (*os.File, error) {
 		}
 	}
 
-	file, err := os.OpenFile(s.downloadFilename, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
 	if err != nil {
 		return nil, err
 	}
@@ -276,19 +497,29 @@ func (s *storage) openDownloadFile() (*os.File, error) {
 
 // downloadBackupFilename generates download file name of backup files.
 func (s *storage) downloadBackupFilename() string {
+	return s.backupFilename(DownloadFilePrefix)
+}
+
+// backupFilename generates file name of backup files with the prefix.
+func (s *storage) backupFilename(prefix string) string {
 	timestamp := time.Now().Format(backupTimeFormat)
-	return filepath.Join(s.baseDir, fmt.Sprintf("%s-%s.%s", DownloadFilePrefix, timestamp, CSVFileExt))
+	return filepath.Join(s.baseDir, fmt.Sprintf("%s-%s.%s", prefix, timestamp, s.format))
 }
 
 // downloadBackups returns download backup file information.
 func (s *storage) downloadBackups() ([]fs.FileInfo, error) {
+	return s.backups(DownloadFilePrefix)
+}
+
+// backups returns backup file information with the prefix.
+func (s *storage) backups(prefix string) ([]fs.FileInfo, error) {
 	fileInfos, err := ioutil.ReadDir(s.baseDir)
 	if err != nil {
 		return nil, err
 	}
 
 	var backups []fs.FileInfo
-	regexp := regexp.MustCompile(DownloadFilePrefix)
+	regexp := regexp.MustCompile(fmt.Sprintf(`^%s.*\.%s$`, prefix, s.format))
 	for _, fileInfo := range fileInfos {
 		if !fileInfo.IsDir() && regexp.MatchString(fileInfo.Name()) {
 			backups = append(backups, fileInfo)
@@ -296,7 +527,7 @@ func (s *storage) downloadBackups() ([]fs.FileInfo, error) {
 	}
 
 	if len(backups) <= 0 {
-		return nil, errors.New("download files backup does not exist")
+		return nil, fmt.Errorf("%s files backup does not exist", prefix)
 	}
 
 	sort.Slice(backups, func(i, j int) bool {
@@ -305,3 +536,131 @@ func (s *storage) downloadBackups() ([]fs.FileInfo, error) {
 
 	return backups, nil
 }
+
+// writeRecords writes the records to the writer in the format.
+func writeRecords[T any](w io.Writer, format Format, records []T) error {
+	switch format {
+	case FormatJSONL:
+		encoder := json.NewEncoder(w)
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	default:
+		return gocsv.MarshalWithoutHeaders(records, w)
+	}
+}
+
+// rangeFiles calls fn for each record in the files with the prefix,
+// the files are decoded one by one from the earliest.
+func rangeFiles[T any](s *storage, prefix string, fn func(T) error) error {
+	files, err := s.snapshot(prefix)
+	if err != nil {
+		return err
+	}
+	defer closeFiles(files)
+
+	return rangeSnapshot(files, prefix, s.format, fn)
+}
+
+// snapshotFile is the file opened for read, it only reads the size of
+// the file when it is opened.
+type snapshotFile struct {
+	*os.File
+	size int64
+}
+
+// snapshot opens the files with the prefix from the earliest. The opened files
+// are still readable after they are rotated or removed, and the records
+// written after the snapshot are not read, so the caller can read them
+// without holding the lock.
+func (s *storage) snapshot(prefix string) ([]snapshotFile, error) {
+	fileInfos, err := s.backups(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []snapshotFile
+	for _, fileInfo := range fileInfos {
+		file, err := os.Open(filepath.Join(s.baseDir, fileInfo.Name()))
+		if err != nil {
+			closeFiles(files)
+			return nil, err
+		}
+
+		files = append(files, snapshotFile{File: file, size: fileInfo.Size()})
+	}
+
+	return files, nil
+}
+
+// closeFiles closes the snapshot files.
+func closeFiles(files []snapshotFile) {
+	for _, file := range files {
+		if err := file.Close(); err != nil {
+			logger.Error(err)
+		}
+	}
+}
+
+// rangeSnapshot calls fn for each record in the snapshot files.
+func rangeSnapshot[T any](files []snapshotFile, prefix string, format Format, fn func(T) error) error {
+	var n int
+	for _, file := range files {
+		if err := rangeFile(io.LimitReader(file, file.size), format, func(record T) error {
+			n++
+			return fn(record)
+		}); err != nil {
+			return err
+		}
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%s files are empty", prefix)
+	}
+
+	return nil
+}
+
+// rangeFile calls fn for each record in the file.
+func rangeFile[T any](file io.Reader, format Format, fn func(T) error) error {
+	switch format {
+	case FormatJSONL:
+		decoder := json.NewDecoder(file)
+		for {
+			var record T
+			if err := decoder.Decode(&record); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+
+				return err
+			}
+
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+	default:
+		records := make(chan T)
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- gocsv.UnmarshalToChanWithoutHeaders(file, records)
+		}()
+
+		for record := range records {
+			if err := fn(record); err != nil {
+				// Drain the records to stop unmarshaling.
+				for range records {
+				}
+
+				return err
+			}
+		}
+
+		return <-errCh
+	}
+}
@@ -17,6 +17,8 @@
 package storage
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/fs"
 	"io/ioutil"
@@ -147,6 +149,32 @@ var (
 		CreatedAt: time.Now().UnixNano(),
 		UpdatedAt: time.Now().UnixNano(),
 	}
+
+	mockNetworkTopology = NetworkTopology{
+		Host: SrcHost{
+			ID:       mockHost.ID,
+			Type:     mockHost.Type,
+			Hostname: mockHost.Hostname,
+			IP:       mockHost.IP,
+			Port:     mockHost.Port,
+			Network:  mockHost.Network,
+		},
+		DestHost: DestHost{
+			ID:       "5",
+			Type:     "super",
+			Hostname: "foo",
+			IP:       "127.0.0.2",
+			Port:     8080,
+			Network:  mockHost.Network,
+			Probes: Probes{
+				AverageRTT: int64(10 * time.Millisecond),
+				Count:      5,
+				CreatedAt:  time.Now().UnixNano(),
+				UpdatedAt:  time.Now().UnixNano(),
+			},
+		},
+		CreatedAt: time.Now().UnixNano(),
+	}
 )
 
 func TestStorage_New(t *testing.T) {
@@ -288,7 +316,7 @@ func TestStorage_ListDownload(t *testing.T) {
 			mock:       func(t *testing.T, s Storage, baseDir string, download Download) {},
 			expect: func(t *testing.T, s Storage, baseDir string, download Download) {
 				assert := assert.New(t)
-				_, err := s.ListDownload()
+				_, err := s.ListDownload(DownloadFilter{})
 				assert.Error(err)
 			},
 		},
@@ -301,7 +329,7 @@ func TestStorage_ListDownload(t *testing.T) {
 			},
 			expect: func(t *testing.T, s Storage, baseDir string, download Download) {
 				assert := assert.New(t)
-				_, err := s.ListDownload()
+				_, err := s.ListDownload(DownloadFilter{})
 				assert.Error(err)
 				s.(*storage).baseDir = baseDir
 			},
@@ -319,7 +347,7 @@ func TestStorage_ListDownload(t *testing.T) {
 			},
 			expect: func(t *testing.T, s Storage, baseDir string, download Download) {
 				assert := assert.New(t)
-				_, err := s.ListDownload()
+				_, err := s.ListDownload(DownloadFilter{})
 				assert.Error(err)
 			},
 		},
@@ -335,13 +363,13 @@ func TestStorage_ListDownload(t *testing.T) {
 			},
 			expect: func(t *testing.T, s Storage, baseDir string, download Download) {
 				assert := assert.New(t)
-				_, err := s.ListDownload()
+				_, err := s.ListDownload(DownloadFilter{})
 				assert.Error(err)
 
 				if err := s.CreateDownload(download); err != nil {
 					t.Fatal(err)
 				}
-				downloads, err := s.ListDownload()
+				downloads, err := s.ListDownload(DownloadFilter{})
 				assert.NoError(err)
 				assert.Equal(len(downloads), 1)
 				assert.EqualValues(downloads[0], download)
@@ -373,13 +401,39 @@ func TestStorage_ListDownload(t *testing.T) {
 			},
 			expect: func(t *testing.T, s Storage, baseDir string, download Download) {
 				assert := assert.New(t)
-				downloads, err := s.ListDownload()
+				downloads, err := s.ListDownload(DownloadFilter{})
 				assert.NoError(err)
 				assert.Equal(len(downloads), 2)
 				assert.Equal(downloads[0].ID, "2")
 				assert.Equal(downloads[1].ID, "1")
 			},
 		},
+		{
+			name:       "list downloads by filter",
+			baseDir:    os.TempDir(),
+			bufferSize: 1,
+			download:   mockDownload,
+			mock: func(t *testing.T, s Storage, baseDir string, download Download) {
+				for _, taskID := range []string{"foo", download.Task.ID, "bar"} {
+					d := download
+					d.Task.ID = taskID
+					if err := s.CreateDownload(d); err != nil {
+						t.Fatal(err)
+					}
+				}
+			},
+			expect: func(t *testing.T, s Storage, baseDir string, download Download) {
+				assert := assert.New(t)
+				downloads, err := s.ListDownload(DownloadFilter{TaskID: download.Task.ID})
+				assert.NoError(err)
+				assert.Equal(len(downloads), 1)
+				assert.EqualValues(downloads[0], download)
+
+				downloads, err = s.ListDownload(DownloadFilter{HostID: "baz"})
+				assert.NoError(err)
+				assert.Equal(len(downloads), 0)
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -516,6 +570,209 @@ func TestStorage_OpenDownload(t *testing.T) {
 	}
 }
 
+func TestStorage_ExportDownload(t *testing.T) {
+	tests := []struct {
+		name          string
+		storageFormat Format
+		format        Format
+		expect        func(t *testing.T, data []byte, err error)
+	}{
+		{
+			name:          "export downloads of csv file in jsonl",
+			storageFormat: FormatCSV,
+			format:        FormatJSONL,
+			expect: func(t *testing.T, data []byte, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+
+				var download Download
+				assert.NoError(json.Unmarshal(data, &download))
+				assert.EqualValues(download, mockDownload)
+			},
+		},
+		{
+			name:          "export downloads of jsonl file in csv",
+			storageFormat: FormatJSONL,
+			format:        FormatCSV,
+			expect: func(t *testing.T, data []byte, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+
+				var downloads []Download
+				assert.NoError(gocsv.UnmarshalWithoutHeaders(bytes.NewReader(data), &downloads))
+				assert.Equal(len(downloads), 1)
+				assert.EqualValues(downloads[0], mockDownload)
+			},
+		},
+		{
+			name:          "export downloads in parquet",
+			storageFormat: FormatCSV,
+			format:        FormatParquet,
+			expect: func(t *testing.T, data []byte, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(bytes.HasPrefix(data, []byte("PAR1")))
+				assert.True(bytes.HasSuffix(data, []byte("PAR1")))
+			},
+		},
+		{
+			name:          "export downloads in invalid format",
+			storageFormat: FormatCSV,
+			format:        Format("foo"),
+			expect: func(t *testing.T, data []byte, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "invalid format: foo")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := New(os.TempDir(), config.DefaultStorageMaxSize, config.DefaultStorageMaxBackups, 0, WithFormat(tc.storageFormat))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := s.(*storage).createDownload(mockDownload); err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			err = s.ExportDownload(&buf, tc.format, DownloadFilter{})
+			tc.expect(t, buf.Bytes(), err)
+			if err := s.ClearDownload(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// createDownloadWriter creates a download on every write.
+type createDownloadWriter struct {
+	bytes.Buffer
+	storage Storage
+}
+
+func (w *createDownloadWriter) Write(p []byte) (int, error) {
+	if err := w.storage.CreateDownload(mockDownload); err != nil {
+		return 0, err
+	}
+
+	return w.Buffer.Write(p)
+}
+
+func TestStorage_ExportDownloadWithCreateDownload(t *testing.T) {
+	assert := assert.New(t)
+	s, err := New(os.TempDir(), config.DefaultStorageMaxSize, config.DefaultStorageMaxBackups, 0, WithFormat(FormatJSONL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.ClearDownload()
+
+	if err := s.(*storage).createDownload(mockDownload); err != nil {
+		t.Fatal(err)
+	}
+
+	// CreateDownload is not blocked by the export.
+	w := &createDownloadWriter{storage: s}
+	done := make(chan error)
+	go func() {
+		done <- s.ExportDownload(w, FormatJSONL, DownloadFilter{})
+	}()
+
+	select {
+	case err := <-done:
+		assert.NoError(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("export download is blocked")
+	}
+
+	var download Download
+	assert.NoError(json.Unmarshal(w.Bytes(), &download))
+	assert.EqualValues(download, mockDownload)
+}
+
+func TestDownload_CSVColumns(t *testing.T) {
+	assert := assert.New(t)
+	download := mockDownload
+	download.Host.Network.UploadRate = 100
+	download.Host.Network.UploadBandwidth = 200
+
+	// Host bandwidth is not written to csv records, so the columns
+	// parsed by the trainer are not shifted.
+	var withBandwidth, withoutBandwidth bytes.Buffer
+	assert.NoError(gocsv.MarshalWithoutHeaders([]Download{download}, &withBandwidth))
+	assert.NoError(gocsv.MarshalWithoutHeaders([]Download{mockDownload}, &withoutBandwidth))
+	assert.Equal(withoutBandwidth.String(), withBandwidth.String())
+
+	header, err := gocsv.MarshalString([]Download{})
+	assert.NoError(err)
+	assert.NotContains(header, "uploadRate")
+	assert.NotContains(header, "uploadBandwidth")
+
+	// Host bandwidth is carried by jsonl records.
+	b, err := json.Marshal(download)
+	assert.NoError(err)
+	var decoded Download
+	assert.NoError(json.Unmarshal(b, &decoded))
+	assert.EqualValues(100, decoded.Host.Network.UploadRate)
+	assert.EqualValues(200, decoded.Host.Network.UploadBandwidth)
+}
+
+func TestDownloadFilter_Match(t *testing.T) {
+	createdAt := time.Now()
+	tests := []struct {
+		name   string
+		filter DownloadFilter
+		expect bool
+	}{
+		{
+			name:   "empty filter",
+			filter: DownloadFilter{},
+			expect: true,
+		},
+		{
+			name:   "match time range",
+			filter: DownloadFilter{StartTime: createdAt, EndTime: createdAt.Add(time.Second)},
+			expect: true,
+		},
+		{
+			name:   "download is created before start time",
+			filter: DownloadFilter{StartTime: createdAt.Add(time.Second)},
+			expect: false,
+		},
+		{
+			name:   "download is created at end time",
+			filter: DownloadFilter{EndTime: createdAt},
+			expect: false,
+		},
+		{
+			name:   "match task and host",
+			filter: DownloadFilter{TaskID: mockTask.ID, HostID: mockHost.ID},
+			expect: true,
+		},
+		{
+			name:   "task does not match",
+			filter: DownloadFilter{TaskID: "foo"},
+			expect: false,
+		},
+		{
+			name:   "host does not match",
+			filter: DownloadFilter{HostID: "foo"},
+			expect: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			download := mockDownload
+			download.CreatedAt = createdAt.UnixNano()
+			assert.Equal(tc.filter.Match(download), tc.expect)
+		})
+	}
+}
+
 func TestStorage_ClearDownload(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -572,6 +829,196 @@ func TestStorage_ClearDownload(t *testing.T) {
 	}
 }
 
+func TestStorage_CreateNetworkTopology(t *testing.T) {
+	tests := []struct {
+		name       string
+		baseDir    string
+		bufferSize int
+		mock       func(s Storage)
+		expect     func(t *testing.T, s Storage, baseDir string)
+	}{
+		{
+			name:       "create network topology",
+			baseDir:    os.TempDir(),
+			bufferSize: 1,
+			mock:       func(s Storage) {},
+			expect: func(t *testing.T, s Storage, baseDir string) {
+				assert := assert.New(t)
+				assert.NoError(s.CreateNetworkTopology(NetworkTopology{}))
+				assert.Equal(s.NetworkTopologyCount(), int64(0))
+			},
+		},
+		{
+			name:       "create network topology without buffer",
+			baseDir:    os.TempDir(),
+			bufferSize: 0,
+			mock:       func(s Storage) {},
+			expect: func(t *testing.T, s Storage, baseDir string) {
+				assert := assert.New(t)
+				assert.NoError(s.CreateNetworkTopology(NetworkTopology{}))
+				assert.Equal(s.NetworkTopologyCount(), int64(1))
+			},
+		},
+		{
+			name:       "write network topology to file",
+			baseDir:    os.TempDir(),
+			bufferSize: 1,
+			mock:       func(s Storage) {},
+			expect: func(t *testing.T, s Storage, baseDir string) {
+				assert := assert.New(t)
+				assert.NoError(s.CreateNetworkTopology(NetworkTopology{}))
+				assert.NoError(s.CreateNetworkTopology(NetworkTopology{}))
+				assert.Equal(s.NetworkTopologyCount(), int64(1))
+			},
+		},
+		{
+			name:       "open file failed",
+			baseDir:    os.TempDir(),
+			bufferSize: 0,
+			mock: func(s Storage) {
+				s.(*storage).baseDir = "foo"
+			},
+			expect: func(t *testing.T, s Storage, baseDir string) {
+				assert := assert.New(t)
+				assert.Error(s.CreateNetworkTopology(NetworkTopology{}))
+				s.(*storage).baseDir = baseDir
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := New(tc.baseDir, config.DefaultStorageMaxSize, config.DefaultStorageMaxBackups, tc.bufferSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tc.mock(s)
+			tc.expect(t, s, tc.baseDir)
+			if err := s.ClearNetworkTopology(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestStorage_ListNetworkTopology(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseDir string
+		format  Format
+		mock    func(t *testing.T, s Storage)
+		expect  func(t *testing.T, s Storage)
+	}{
+		{
+			name:    "empty file given",
+			baseDir: os.TempDir(),
+			format:  FormatCSV,
+			mock:    func(t *testing.T, s Storage) {},
+			expect: func(t *testing.T, s Storage) {
+				assert := assert.New(t)
+				_, err := s.ListNetworkTopology()
+				assert.EqualError(err, "networktopology files are empty")
+			},
+		},
+		{
+			name:    "list network topologies of csv file",
+			baseDir: os.TempDir(),
+			format:  FormatCSV,
+			mock: func(t *testing.T, s Storage) {
+				if err := s.CreateNetworkTopology(mockNetworkTopology); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expect: func(t *testing.T, s Storage) {
+				assert := assert.New(t)
+				networkTopologies, err := s.ListNetworkTopology()
+				assert.NoError(err)
+				assert.Equal(len(networkTopologies), 1)
+				assert.EqualValues(networkTopologies[0], mockNetworkTopology)
+			},
+		},
+		{
+			name:    "list network topologies of jsonl file",
+			baseDir: os.TempDir(),
+			format:  FormatJSONL,
+			mock: func(t *testing.T, s Storage) {
+				if err := s.CreateNetworkTopology(mockNetworkTopology); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expect: func(t *testing.T, s Storage) {
+				assert := assert.New(t)
+				networkTopologies, err := s.ListNetworkTopology()
+				assert.NoError(err)
+				assert.Equal(len(networkTopologies), 1)
+				assert.EqualValues(networkTopologies[0], mockNetworkTopology)
+			},
+		},
+		{
+			name:    "list network topologies of backup files",
+			baseDir: os.TempDir(),
+			format:  FormatCSV,
+			mock: func(t *testing.T, s Storage) {
+				s.(*storage).maxSize = 1
+				for _, id := range []string{"foo", "bar"} {
+					networkTopology := mockNetworkTopology
+					networkTopology.Host.ID = id
+					if err := s.CreateNetworkTopology(networkTopology); err != nil {
+						t.Fatal(err)
+					}
+				}
+			},
+			expect: func(t *testing.T, s Storage) {
+				assert := assert.New(t)
+				backups, err := s.(*storage).backups(NetworkTopologyFilePrefix)
+				assert.NoError(err)
+				assert.Equal(len(backups), 2)
+
+				networkTopologies, err := s.ListNetworkTopology()
+				assert.NoError(err)
+				assert.Equal(len(networkTopologies), 2)
+				assert.Equal(networkTopologies[0].Host.ID, "foo")
+				assert.Equal(networkTopologies[1].Host.ID, "bar")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := New(tc.baseDir, config.DefaultStorageMaxSize, config.DefaultStorageMaxBackups, 0, WithFormat(tc.format))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tc.mock(t, s)
+			tc.expect(t, s)
+			if err := s.ClearNetworkTopology(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestStorage_ClearNetworkTopology(t *testing.T) {
+	assert := assert.New(t)
+	s, err := New(os.TempDir(), config.DefaultStorageMaxSize, config.DefaultStorageMaxBackups, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(s.CreateNetworkTopology(mockNetworkTopology))
+	assert.NoError(s.ClearNetworkTopology())
+
+	_, err = s.OpenNetworkTopology()
+	assert.EqualError(err, "networktopology files backup does not exist")
+
+	// Download files are not cleared with network topology files.
+	_, err = s.OpenDownload()
+	assert.NoError(err)
+	assert.NoError(s.ClearDownload())
+}
+
 func TestStorage_createDownload(t *testing.T) {
 	tests := []struct {
 		name    string
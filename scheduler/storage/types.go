@@ -25,177 +25,249 @@ import (
 // Task contains content for task.
 type Task struct {
 	// ID is task id.
-	ID string `csv:"id"`
+	ID string `csv:"id" json:"id"`
 
 	// URL is task download url.
-	URL string `csv:"url"`
+	URL string `csv:"url" json:"url"`
 
 	// Type is task type.
-	Type string `csv:"type"`
+	Type string `csv:"type" json:"type"`
 
 	// ContentLength is task total content length.
-	ContentLength int64 `csv:"contentLength"`
+	ContentLength int64 `csv:"contentLength" json:"contentLength"`
 
 	// TotalPieceCount is total piece count.
-	TotalPieceCount int32 `csv:"totalPieceCount"`
+	TotalPieceCount int32 `csv:"totalPieceCount" json:"totalPieceCount"`
 
 	// BackToSourceLimit is back-to-source limit.
-	BackToSourceLimit int32 `csv:"backToSourceLimit"`
+	BackToSourceLimit int32 `csv:"backToSourceLimit" json:"backToSourceLimit"`
 
 	// BackToSourcePeerCount is back-to-source peer count.
-	BackToSourcePeerCount int32 `csv:"backToSourcePeerCount"`
+	BackToSourcePeerCount int32 `csv:"backToSourcePeerCount" json:"backToSourcePeerCount"`
 
 	// State is the download state of the task.
-	State string `csv:"state"`
+	State string `csv:"state" json:"state"`
 
 	// CreatedAt is peer create nanosecond time.
-	CreatedAt int64 `csv:"createdAt"`
+	CreatedAt int64 `csv:"createdAt" json:"createdAt"`
 
 	// UpdatedAt is peer update nanosecond time.
-	UpdatedAt int64 `csv:"updatedAt"`
+	UpdatedAt int64 `csv:"updatedAt" json:"updatedAt"`
 }
 
 // Host contains content for host.
 type Host struct {
 	// ID is host id.
-	ID string `csv:"id"`
+	ID string `csv:"id" json:"id"`
 
 	// Type is host type.
-	Type string `csv:"type"`
+	Type string `csv:"type" json:"type"`
 
 	// Hostname is host name.
-	Hostname string `csv:"hostname"`
+	Hostname string `csv:"hostname" json:"hostname"`
 
 	// IP is host ip.
-	IP string `csv:"ip"`
+	IP string `csv:"ip" json:"ip"`
 
 	// Port is grpc service port.
-	Port int32 `csv:"port"`
+	Port int32 `csv:"port" json:"port"`
 
 	// DownloadPort is piece downloading port.
-	DownloadPort int32 `csv:"downloadPort"`
+	DownloadPort int32 `csv:"downloadPort" json:"downloadPort"`
 
 	// Host OS.
-	OS string `csv:"os"`
+	OS string `csv:"os" json:"os"`
 
 	// Host platform.
-	Platform string `csv:"platform"`
+	Platform string `csv:"platform" json:"platform"`
 
 	// Host platform family.
-	PlatformFamily string `csv:"platformFamily"`
+	PlatformFamily string `csv:"platformFamily" json:"platformFamily"`
 
 	// Host platform version.
-	PlatformVersion string `csv:"platformVersion"`
+	PlatformVersion string `csv:"platformVersion" json:"platformVersion"`
 
 	// Host kernel version.
-	KernelVersion string `csv:"kernelVersion"`
+	KernelVersion string `csv:"kernelVersion" json:"kernelVersion"`
 
 	// ConcurrentUploadLimit is concurrent upload limit count.
-	ConcurrentUploadLimit int32 `csv:"concurrentUploadLimit"`
+	ConcurrentUploadLimit int32 `csv:"concurrentUploadLimit" json:"concurrentUploadLimit"`
 
 	// ConcurrentUploadCount is concurrent upload count.
-	ConcurrentUploadCount int32 `csv:"concurrentUploadCount"`
+	ConcurrentUploadCount int32 `csv:"concurrentUploadCount" json:"concurrentUploadCount"`
 
 	// UploadCount is total upload count.
-	UploadCount int64 `csv:"uploadCount"`
+	UploadCount int64 `csv:"uploadCount" json:"uploadCount"`
 
 	// UploadFailedCount is upload failed count.
-	UploadFailedCount int64 `csv:"uploadFailedCount"`
+	UploadFailedCount int64 `csv:"uploadFailedCount" json:"uploadFailedCount"`
 
 	// CPU Stat.
-	CPU resource.CPU `csv:"cpu"`
+	CPU resource.CPU `csv:"cpu" json:"cpu"`
 
 	// Memory Stat.
-	Memory resource.Memory `csv:"memory"`
+	Memory resource.Memory `csv:"memory" json:"memory"`
 
 	// Network Stat.
-	Network resource.Network `csv:"network"`
+	Network resource.Network `csv:"network" json:"network"`
 
 	// Disk Stat.
-	Disk resource.Disk `csv:"disk"`
+	Disk resource.Disk `csv:"disk" json:"disk"`
 
 	// Build information.
-	Build resource.Build `csv:"build"`
+	Build resource.Build `csv:"build" json:"build"`
 
 	// CreatedAt is peer create nanosecond time.
-	CreatedAt int64 `csv:"createdAt"`
+	CreatedAt int64 `csv:"createdAt" json:"createdAt"`
 
 	// UpdatedAt is peer update nanosecond time.
-	UpdatedAt int64 `csv:"updatedAt"`
+	UpdatedAt int64 `csv:"updatedAt" json:"updatedAt"`
 }
 
 // Parent contains content for parent.
 type Parent struct {
 	// ID is peer id.
-	ID string `csv:"id"`
+	ID string `csv:"id" json:"id"`
 
 	// Tag is peer tag.
-	Tag string `csv:"tag"`
+	Tag string `csv:"tag" json:"tag"`
 
 	// Application is peer application.
-	Application string `csv:"application"`
+	Application string `csv:"application" json:"application"`
 
 	// State is the download state of the peer.
-	State string `csv:"state"`
+	State string `csv:"state" json:"state"`
 
 	// Cost is the task download duration of nanosecond.
-	Cost int64 `csv:"cost"`
+	Cost int64 `csv:"cost" json:"cost"`
 
 	// UploadPieceCount is upload piece count.
-	UploadPieceCount int32 `csv:"uploadPieceCount"`
+	UploadPieceCount int32 `csv:"uploadPieceCount" json:"uploadPieceCount"`
 
 	// Host is peer host.
-	Host Host `csv:"host"`
+	Host Host `csv:"host" json:"host"`
 
 	// CreatedAt is peer create nanosecond time.
-	CreatedAt int64 `csv:"createdAt"`
+	CreatedAt int64 `csv:"createdAt" json:"createdAt"`
 
 	// UpdatedAt is peer update nanosecond time.
-	UpdatedAt int64 `csv:"updatedAt"`
+	UpdatedAt int64 `csv:"updatedAt" json:"updatedAt"`
 }
 
 // Error contains content for error.
 type Error struct {
 	time.Duration
 	// Code is the code of error.
-	Code string `csv:"code"`
+	Code string `csv:"code" json:"code"`
 
 	// Message is the message of error.
-	Message string `csv:"message"`
+	Message string `csv:"message" json:"message"`
 }
 
 // Download contains content for download.
 type Download struct {
 	// ID is peer id.
-	ID string `csv:"id"`
+	ID string `csv:"id" json:"id"`
 
 	// Tag is peer tag.
-	Tag string `csv:"tag"`
+	Tag string `csv:"tag" json:"tag"`
 
 	// Application is peer application.
-	Application string `csv:"application"`
+	Application string `csv:"application" json:"application"`
 
 	// State is the download state of the peer.
-	State string `csv:"state"`
+	State string `csv:"state" json:"state"`
 
 	// Error is the details of error.
-	Error Error `csv:"error"`
+	Error Error `csv:"error" json:"error"`
 
 	// Cost is the task download duration of nanosecond.
-	Cost int64 `csv:"cost"`
+	Cost int64 `csv:"cost" json:"cost"`
 
 	// Task is peer task.
-	Task Task `csv:"task"`
+	Task Task `csv:"task" json:"task"`
 
 	// Host is peer host.
-	Host Host `csv:"host"`
+	Host Host `csv:"host" json:"host"`
 
 	// Parents is peer parents.
-	Parents []Parent `csv:"parents" csv[]:"20"`
+	Parents []Parent `csv:"parents" csv[]:"20" json:"parents"`
 
 	// CreatedAt is peer create nanosecond time.
-	CreatedAt int64 `csv:"createdAt"`
+	CreatedAt int64 `csv:"createdAt" json:"createdAt"`
 
 	// UpdatedAt is peer update nanosecond time.
-	UpdatedAt int64 `csv:"updatedAt"`
+	UpdatedAt int64 `csv:"updatedAt" json:"updatedAt"`
+}
+
+// SrcHost contains content for source host of network topology.
+type SrcHost struct {
+	// ID is host id.
+	ID string `csv:"id" json:"id"`
+
+	// Type is host type.
+	Type string `csv:"type" json:"type"`
+
+	// Hostname is host name.
+	Hostname string `csv:"hostname" json:"hostname"`
+
+	// IP is host ip.
+	IP string `csv:"ip" json:"ip"`
+
+	// Port is grpc service port.
+	Port int32 `csv:"port" json:"port"`
+
+	// Network Stat.
+	Network resource.Network `csv:"network" json:"network"`
+}
+
+// DestHost contains content for destination host of network topology.
+type DestHost struct {
+	// ID is host id.
+	ID string `csv:"id" json:"id"`
+
+	// Type is host type.
+	Type string `csv:"type" json:"type"`
+
+	// Hostname is host name.
+	Hostname string `csv:"hostname" json:"hostname"`
+
+	// IP is host ip.
+	IP string `csv:"ip" json:"ip"`
+
+	// Port is grpc service port.
+	Port int32 `csv:"port" json:"port"`
+
+	// Network Stat.
+	Network resource.Network `csv:"network" json:"network"`
+
+	// Probes is the probes from source host to destination host.
+	Probes Probes `csv:"probes" json:"probes"`
+}
+
+// Probes contains content for probes.
+type Probes struct {
+	// AverageRTT is the moving average round-trip time of probes in nanosecond.
+	AverageRTT int64 `csv:"averageRTT" json:"averageRTT"`
+
+	// Count is the count of probes in the queue.
+	Count int32 `csv:"count" json:"count"`
+
+	// CreatedAt is probes create nanosecond time.
+	CreatedAt int64 `csv:"createdAt" json:"createdAt"`
+
+	// UpdatedAt is probes update nanosecond time.
+	UpdatedAt int64 `csv:"updatedAt" json:"updatedAt"`
+}
+
+// NetworkTopology contains content for the snapshot of probes between hosts.
+type NetworkTopology struct {
+	// Host is the source host.
+	Host SrcHost `csv:"host" json:"host"`
+
+	// DestHost is the destination host.
+	DestHost DestHost `csv:"destHost" json:"destHost"`
+
+	// CreatedAt is network topology create nanosecond time.
+	CreatedAt int64 `csv:"createdAt" json:"createdAt"`
 }